@@ -19,17 +19,19 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/vidfriends/backend/internal/auth"
 	"github.com/vidfriends/backend/internal/config"
 	"github.com/vidfriends/backend/internal/db"
 	"github.com/vidfriends/backend/internal/handlers"
 	"github.com/vidfriends/backend/internal/httpserver"
 	"github.com/vidfriends/backend/internal/middleware"
+	"github.com/vidfriends/backend/internal/repositories"
 )
 
 // Run bootstraps the VidFriends backend application.
 func Run(ctx context.Context, args []string) error {
 	if len(args) == 0 {
-		return errors.New("expected command: serve, migrate, or seed")
+		return errors.New("expected command: serve, migrate, seed, or unlock")
 	}
 
 	switch args[0] {
@@ -39,6 +41,8 @@ func Run(ctx context.Context, args []string) error {
 		return runMigrations(ctx, args[1:])
 	case "seed":
 		return runSeed(ctx, args[1:])
+	case "unlock":
+		return runUnlock(ctx, args[1:])
 	default:
 		return fmt.Errorf("unknown command %q", args[0])
 	}
@@ -286,6 +290,38 @@ func runSeed(ctx context.Context, args []string) error {
 	return nil
 }
 
+// runUnlock clears the failed login history of an account so its owner can sign in again before
+// the lockout expires.
+func runUnlock(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected email address of the account to unlock")
+	}
+
+	email := auth.NormalizeEmail(args[0])
+	if email == "" {
+		return errors.New("expected email address of the account to unlock")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	guard := auth.NewLoginGuard(repositories.NewPostgresLoginAttemptStore(pool), auth.DefaultLockoutPolicy())
+	if err := guard.Unlock(ctx, email); err != nil {
+		return fmt.Errorf("unlock %s: %w", email, err)
+	}
+
+	fmt.Printf("unlocked %s\n", email)
+	return nil
+}
+
 func applyMigrationWithRetry(ctx context.Context, conn *pgxpool.Conn, name string, contents string) error {
 	var attempt int
 	for attempt = 0; attempt < migrationMaxRetries; attempt++ {
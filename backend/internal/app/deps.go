@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log/slog"
 	"time"
@@ -9,7 +10,11 @@ import (
 	"github.com/vidfriends/backend/internal/auth"
 	"github.com/vidfriends/backend/internal/config"
 	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/email"
 	"github.com/vidfriends/backend/internal/handlers"
+	"github.com/vidfriends/backend/internal/invites"
+	"github.com/vidfriends/backend/internal/oidc"
+	"github.com/vidfriends/backend/internal/profiles"
 	"github.com/vidfriends/backend/internal/repositories"
 	"github.com/vidfriends/backend/internal/storage"
 	"github.com/vidfriends/backend/internal/videos"
@@ -22,6 +27,21 @@ func buildDependencies(ctx context.Context, pool db.Pool, cfg config.Config) (ha
 	sessionStore := repositories.NewPostgresSessionStore(pool)
 	videoRepo := repositories.NewPostgresVideoRepository(pool)
 
+	keyring, err := buildKeyring(cfg)
+	if err != nil {
+		return handlers.Dependencies{}, nil, fmt.Errorf("configure access token keys: %w", err)
+	}
+
+	passwords, err := buildPasswordHasher(cfg.PasswordHash)
+	if err != nil {
+		return handlers.Dependencies{}, nil, fmt.Errorf("configure password hashing: %w", err)
+	}
+
+	mailer, err := email.New(cfg.Mail)
+	if err != nil {
+		return handlers.Dependencies{}, nil, fmt.Errorf("configure mail delivery: %w", err)
+	}
+
 	objectStore, err := storage.NewS3Storage(ctx, cfg.ObjectStore)
 	if err != nil {
 		return handlers.Dependencies{}, nil, fmt.Errorf("configure object storage: %w", err)
@@ -33,12 +53,31 @@ func buildDependencies(ctx context.Context, pool db.Pool, cfg config.Config) (ha
 	}, slog.Default())
 
 	deps := handlers.Dependencies{
-		Users:         repositories.NewPostgresUserRepository(pool),
-		Sessions:      auth.NewManager(15*time.Minute, 24*time.Hour, sessionStore),
-		Friends:       repositories.NewPostgresFriendRepository(pool),
-		Videos:        videoRepo,
-		VideoMetadata: metadataProvider,
-		VideoAssets:   assetIngestor,
+		Users:             repositories.NewPostgresUserRepository(pool),
+		Sessions:          auth.NewManager(15*time.Minute, 24*time.Hour, sessionStore, keyring),
+		Tokens:            auth.NewUserTokens(repositories.NewPostgresUserTokenStore(pool)),
+		Mailer:            mailer,
+		TwoFactor:         auth.NewTwoFactor(repositories.NewPostgresTwoFactorStore(pool), keyring, "VidFriends"),
+		Passwords:         passwords,
+		APITokens:         auth.NewAPITokens(repositories.NewPostgresAPITokenStore(pool)),
+		Lockout:           auth.NewLoginGuard(repositories.NewPostgresLoginAttemptStore(pool), auth.DefaultLockoutPolicy()),
+		OIDCProviders:     buildOIDCProviders(cfg.OIDCProviders),
+		Identities:        repositories.NewPostgresIdentityRepository(pool),
+		States:            keyring,
+		Friends:           repositories.NewPostgresFriendRepository(pool),
+		Videos:            videoRepo,
+		VideoMetadata:     metadataProvider,
+		VideoAssets:       assetIngestor,
+		Assets:            objectStore,
+		Profiles:          repositories.NewPostgresProfileRepository(pool),
+		Avatars:           profiles.NewAvatars(objectStore),
+		Invites:           invites.NewManager(repositories.NewPostgresInviteStore(pool)),
+		Circles:           repositories.NewPostgresCircleRepository(pool),
+		FeedFilters:       repositories.NewPostgresFeedFilterRepository(pool),
+		Comments:          repositories.NewPostgresCommentRepository(pool),
+		Reactions:         repositories.NewPostgresReactionRepository(pool),
+		AppBaseURL:        cfg.AppBaseURL,
+		EmailVerification: handlers.EmailVerificationPolicy(cfg.EmailVerificationPolicy),
 	}
 
 	cleanup := func(shutdownCtx context.Context) error {
@@ -50,3 +89,56 @@ func buildDependencies(ctx context.Context, pool db.Pool, cfg config.Config) (ha
 
 	return deps, cleanup, nil
 }
+
+// buildOIDCProviders constructs a provider for each configured OpenID Connect issuer. Discovery
+// happens on first use so an unreachable provider does not prevent startup.
+func buildOIDCProviders(configs []config.OIDCProviderConfig) map[string]handlers.OIDCProvider {
+	providers := make(map[string]handlers.OIDCProvider, len(configs))
+	for _, cfg := range configs {
+		providers[cfg.Name] = oidc.NewProvider(cfg, nil)
+	}
+	return providers
+}
+
+// buildPasswordHasher applies the configured algorithm and parameters on top of the defaults.
+func buildPasswordHasher(cfg config.PasswordHashConfig) (*auth.PasswordHasher, error) {
+	hashCfg := auth.DefaultPasswordHashConfig()
+	if cfg.Algorithm != "" {
+		hashCfg.Algorithm = auth.PasswordAlgorithm(cfg.Algorithm)
+	}
+	if cfg.Argon2MemoryKiB > 0 {
+		hashCfg.Argon2.Memory = uint32(cfg.Argon2MemoryKiB)
+	}
+	if cfg.Argon2Iterations > 0 {
+		hashCfg.Argon2.Iterations = uint32(cfg.Argon2Iterations)
+	}
+	if cfg.Argon2Parallelism > 255 {
+		return nil, fmt.Errorf("argon2id parallelism must be at most 255, got %d", cfg.Argon2Parallelism)
+	}
+	if cfg.Argon2Parallelism > 0 {
+		hashCfg.Argon2.Parallelism = uint8(cfg.Argon2Parallelism)
+	}
+	if cfg.BcryptCost > 0 {
+		hashCfg.BcryptCost = cfg.BcryptCost
+	}
+	return auth.NewPasswordHasher(hashCfg)
+}
+
+// buildKeyring loads access token keys from files, falling back to SESSION_SECRET and finally to
+// an ephemeral secret suitable only for a single local instance.
+func buildKeyring(cfg config.Config) (*auth.Keyring, error) {
+	if cfg.AccessTokenKeys.SigningKeyFile != "" {
+		return auth.LoadKeyring(cfg.AccessTokenKeys.SigningKeyFile, cfg.AccessTokenKeys.VerificationKeyFiles)
+	}
+
+	secret := []byte(cfg.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate session secret: %w", err)
+		}
+		slog.Warn("no access token signing key configured; access tokens will not survive restarts or validate across instances")
+	}
+
+	return auth.NewKeyring(auth.Key{ID: "default", Secret: secret})
+}
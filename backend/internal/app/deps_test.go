@@ -49,6 +49,15 @@ func TestBuildDependencies(t *testing.T) {
 	if deps.Sessions == nil {
 		t.Fatal("expected session manager to be configured")
 	}
+	if deps.Passwords == nil {
+		t.Fatal("expected password hasher to be configured")
+	}
+	if deps.APITokens == nil {
+		t.Fatal("expected api tokens to be configured")
+	}
+	if deps.Lockout == nil {
+		t.Fatal("expected login lockout to be configured")
+	}
 	if deps.Friends == nil {
 		t.Fatal("expected friend repository to be configured")
 	}
@@ -61,4 +70,28 @@ func TestBuildDependencies(t *testing.T) {
 	if deps.VideoAssets == nil {
 		t.Fatal("expected video asset ingestor to be configured")
 	}
+	if deps.Assets == nil {
+		t.Fatal("expected asset storage to be configured")
+	}
+	if deps.Profiles == nil {
+		t.Fatal("expected profile repository to be configured")
+	}
+	if deps.Avatars == nil {
+		t.Fatal("expected avatar service to be configured")
+	}
+	if deps.Invites == nil {
+		t.Fatal("expected invite service to be configured")
+	}
+	if deps.Circles == nil {
+		t.Fatal("expected circle repository to be configured")
+	}
+	if deps.FeedFilters == nil {
+		t.Fatal("expected feed filter repository to be configured")
+	}
+	if deps.Comments == nil {
+		t.Fatal("expected comment repository to be configured")
+	}
+	if deps.Reactions == nil {
+		t.Fatal("expected reaction repository to be configured")
+	}
 }
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAccessToken indicates the access token is malformed, carries a bad signature, or has expired.
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+// AccessClaims are the JWT claims carried by every access token.
+type AccessClaims struct {
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type accessTokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+const (
+	accessTokenAlgorithm = "HS256"
+	// accessTokenType is the typ header of access tokens. Other tokens signed with the keyring
+	// use a different typ so they can never be accepted as access tokens.
+	accessTokenType = "JWT"
+)
+
+// signAccessToken encodes the claims as an HS256 JWT signed with the keyring's signing key.
+func signAccessToken(keys *Keyring, claims AccessClaims) (string, error) {
+	return signToken(keys, accessTokenType, claims)
+}
+
+// parseAccessToken verifies the token against the keyring and checks its expiry.
+func parseAccessToken(keys *Keyring, token string, now time.Time) (AccessClaims, error) {
+	var claims AccessClaims
+	if err := parseToken(keys, accessTokenType, token, &claims); err != nil {
+		return AccessClaims{}, ErrInvalidAccessToken
+	}
+
+	if claims.Subject == "" || claims.SessionID == "" || !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return AccessClaims{}, ErrInvalidAccessToken
+	}
+
+	return claims, nil
+}
+
+// signToken encodes claims as an HS256 JWT with the given typ header.
+func signToken(keys *Keyring, typ string, claims any) (string, error) {
+	header, err := json.Marshal(accessTokenHeader{Algorithm: accessTokenAlgorithm, Type: typ, KeyID: keys.signing.ID})
+	if err != nil {
+		return "", fmt.Errorf("encode token header: %w", err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("encode token claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(accessTokenSignature(keys.signing.Secret, signingInput)), nil
+}
+
+// parseToken verifies the signature and typ header of token and decodes its claims into into.
+// Callers are responsible for checking expiry and required claims.
+func parseToken(keys *Keyring, typ, token string, into any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidAccessToken
+	}
+
+	var header accessTokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return ErrInvalidAccessToken
+	}
+	if header.Algorithm != accessTokenAlgorithm || header.Type != typ {
+		return ErrInvalidAccessToken
+	}
+
+	secret, ok := keys.verificationKey(header.KeyID)
+	if !ok {
+		return ErrInvalidAccessToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidAccessToken
+	}
+
+	if !hmac.Equal(signature, accessTokenSignature(secret, parts[0]+"."+parts[1])) {
+		return ErrInvalidAccessToken
+	}
+
+	if err := decodeSegment(parts[1], into); err != nil {
+		return ErrInvalidAccessToken
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, into any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, into)
+}
+
+func accessTokenSignature(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewInMemoryAPITokenStore returns an APITokenStore backed by an in-memory map.
+func NewInMemoryAPITokenStore() *InMemoryAPITokenStore {
+	return &InMemoryAPITokenStore{tokens: make(map[string]APIToken)}
+}
+
+// InMemoryAPITokenStore implements APITokenStore for tests and local development.
+type InMemoryAPITokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]APIToken
+}
+
+// Create persists the token.
+func (s *InMemoryAPITokenStore) Create(_ context.Context, token APIToken) error {
+	s.mu.Lock()
+	s.tokens[token.ID] = token
+	s.mu.Unlock()
+	return nil
+}
+
+// FindByHash returns the token with the hash.
+func (s *InMemoryAPITokenStore) FindByHash(_ context.Context, hash string) (APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range s.tokens {
+		if token.Hash == hash {
+			return token, nil
+		}
+	}
+	return APIToken{}, ErrAPITokenNotFound
+}
+
+// Find returns one of the user's tokens.
+func (s *InMemoryAPITokenStore) Find(_ context.Context, userID, id string) (APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok || token.UserID != userID {
+		return APIToken{}, ErrAPITokenNotFound
+	}
+	return token, nil
+}
+
+// ListForUser returns the user's tokens, newest first.
+func (s *InMemoryAPITokenStore) ListForUser(_ context.Context, userID string) ([]APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tokens []APIToken
+	for _, token := range s.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+// Rename changes the label of one of the user's tokens.
+func (s *InMemoryAPITokenStore) Rename(_ context.Context, userID, id, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok || token.UserID != userID {
+		return ErrAPITokenNotFound
+	}
+	token.Name = name
+	s.tokens[id] = token
+	return nil
+}
+
+// Delete removes one of the user's tokens.
+func (s *InMemoryAPITokenStore) Delete(_ context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok || token.UserID != userID {
+		return ErrAPITokenNotFound
+	}
+	delete(s.tokens, id)
+	return nil
+}
+
+// DeleteForUser removes every token belonging to the user.
+func (s *InMemoryAPITokenStore) DeleteForUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.tokens {
+		if token.UserID == userID {
+			delete(s.tokens, id)
+		}
+	}
+	return nil
+}
+
+// TouchLastUsed records when the token was last used.
+func (s *InMemoryAPITokenStore) TouchLastUsed(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return ErrAPITokenNotFound
+	}
+	usedAt := at.UTC()
+	token.LastUsedAt = &usedAt
+	s.tokens[id] = token
+	return nil
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+)
+
+var (
+	// ErrAPITokenNotFound indicates the personal access token does not exist or belongs to
+	// another user.
+	ErrAPITokenNotFound = errors.New("api token not found")
+	// ErrInvalidAPIToken indicates a presented personal access token is unknown or expired.
+	ErrInvalidAPIToken = errors.New("invalid api token")
+	// ErrAPITokenLimit indicates the user already has the maximum number of tokens.
+	ErrAPITokenLimit = errors.New("api token limit reached")
+	// ErrInvalidAPITokenRequest indicates a token name, scope list, or expiry was rejected.
+	ErrInvalidAPITokenRequest = errors.New("invalid api token request")
+)
+
+// APITokenPrefix starts every personal access token so they are easy to recognise in logs and
+// secret scanners, and so the auth middleware can tell them apart from session access tokens.
+const APITokenPrefix = "vfp_"
+
+const (
+	// maxAPITokensPerUser bounds how many personal access tokens one account may hold.
+	maxAPITokensPerUser = 50
+	// maxAPITokenNameLength bounds the user supplied label of a token.
+	maxAPITokenNameLength = 100
+	// lastUsedResolution limits how often using a token writes its last-used time.
+	lastUsedResolution = time.Minute
+)
+
+// Scopes a personal access token can be granted. Session tokens may use every scope.
+const (
+	ScopeFeedRead     = "feed:read"
+	ScopeVideosWrite  = "videos:write"
+	ScopeFriendsRead  = "friends:read"
+	ScopeFriendsWrite = "friends:write"
+)
+
+// APITokenScopes lists every scope a personal access token may be granted.
+var APITokenScopes = []string{ScopeFeedRead, ScopeVideosWrite, ScopeFriendsRead, ScopeFriendsWrite}
+
+// APIToken is a long-lived personal access token. Only the SHA-256 hash of the secret is stored.
+type APIToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	Hash       string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// APITokenStore persists personal access tokens.
+type APITokenStore interface {
+	Create(ctx context.Context, token APIToken) error
+	// FindByHash returns ErrAPITokenNotFound when no token has the hash.
+	FindByHash(ctx context.Context, hash string) (APIToken, error)
+	// Find returns ErrAPITokenNotFound when the token does not exist or belongs to another user.
+	Find(ctx context.Context, userID, id string) (APIToken, error)
+	ListForUser(ctx context.Context, userID string) ([]APIToken, error)
+	// Rename returns ErrAPITokenNotFound when the token does not exist or belongs to another user.
+	Rename(ctx context.Context, userID, id, name string) error
+	// Delete returns ErrAPITokenNotFound when the token does not exist or belongs to another user.
+	Delete(ctx context.Context, userID, id string) error
+	DeleteForUser(ctx context.Context, userID string) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// APITokens issues, authenticates and revokes personal access tokens.
+type APITokens struct {
+	store APITokenStore
+
+	// NowFunc overrides the clock, primarily for tests.
+	NowFunc func() time.Time
+}
+
+// NewAPITokens constructs an APITokens service backed by the store.
+func NewAPITokens(store APITokenStore) *APITokens {
+	if store == nil {
+		panic("auth: api token store must not be nil")
+	}
+	return &APITokens{store: store}
+}
+
+// Create issues a token for the user and returns it together with the secret, which is only
+// available now. A nil expiresAt creates a token that lasts until it is revoked.
+func (t *APITokens) Create(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (APIToken, string, error) {
+	if userID == "" {
+		return APIToken{}, "", errors.New("user id must be provided")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPITokenNameLength {
+		return APIToken{}, "", fmt.Errorf("%w: name must be between 1 and %d characters", ErrInvalidAPITokenRequest, maxAPITokenNameLength)
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+
+	now := t.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return APIToken{}, "", fmt.Errorf("%w: expiry must be in the future", ErrInvalidAPITokenRequest)
+	}
+
+	existing, err := t.store.ListForUser(ctx, userID)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	if len(existing) >= maxAPITokensPerUser {
+		return APIToken{}, "", ErrAPITokenLimit
+	}
+
+	random, err := randomToken()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	secret := APITokenPrefix + random
+
+	token := APIToken{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Hash:      hashAPIToken(secret),
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if expiresAt != nil {
+		expiry := expiresAt.UTC()
+		token.ExpiresAt = &expiry
+	}
+
+	if err := t.store.Create(ctx, token); err != nil {
+		return APIToken{}, "", err
+	}
+	return token, secret, nil
+}
+
+// Authenticate resolves a personal access token to the user and scopes it grants and records
+// when it was last used.
+func (t *APITokens) Authenticate(ctx context.Context, secret string) (Principal, error) {
+	if !strings.HasPrefix(secret, APITokenPrefix) {
+		return Principal{}, ErrInvalidAPIToken
+	}
+
+	token, err := t.store.FindByHash(ctx, hashAPIToken(secret))
+	if err != nil {
+		if errors.Is(err, ErrAPITokenNotFound) {
+			return Principal{}, ErrInvalidAPIToken
+		}
+		return Principal{}, err
+	}
+
+	now := t.now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return Principal{}, ErrInvalidAPIToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if err := t.store.TouchLastUsed(ctx, token.ID, now); err != nil {
+			logging.FromContext(ctx).Error("failed to record api token use", "error", err, "tokenId", token.ID)
+		}
+	}
+
+	return Principal{UserID: token.UserID, TokenID: token.ID, Scopes: token.Scopes}, nil
+}
+
+// List returns the user's tokens, newest first.
+func (t *APITokens) List(ctx context.Context, userID string) ([]APIToken, error) {
+	return t.store.ListForUser(ctx, userID)
+}
+
+// Get returns one of the user's tokens.
+func (t *APITokens) Get(ctx context.Context, userID, id string) (APIToken, error) {
+	return t.store.Find(ctx, userID, id)
+}
+
+// Rename changes the label of one of the user's tokens and returns the updated token.
+func (t *APITokens) Rename(ctx context.Context, userID, id, name string) (APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPITokenNameLength {
+		return APIToken{}, fmt.Errorf("%w: name must be between 1 and %d characters", ErrInvalidAPITokenRequest, maxAPITokenNameLength)
+	}
+	if err := t.store.Rename(ctx, userID, id, name); err != nil {
+		return APIToken{}, err
+	}
+	return t.store.Find(ctx, userID, id)
+}
+
+// Revoke deletes one of the user's tokens.
+func (t *APITokens) Revoke(ctx context.Context, userID, id string) error {
+	return t.store.Delete(ctx, userID, id)
+}
+
+// RevokeAll deletes every token belonging to the user.
+func (t *APITokens) RevokeAll(ctx context.Context, userID string) error {
+	return t.store.DeleteForUser(ctx, userID)
+}
+
+func (t *APITokens) now() time.Time {
+	if t.NowFunc != nil {
+		return t.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+// normalizeScopes rejects unknown scopes and returns the rest sorted without duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !validScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPITokenRequest, scope)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		normalized = append(normalized, scope)
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPITokenRequest)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func validScope(scope string) bool {
+	for _, known := range APITokenScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
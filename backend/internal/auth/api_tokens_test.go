@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPITokensLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryAPITokenStore()
+	tokens := NewAPITokens(store)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tokens.NowFunc = func() time.Time { return now }
+
+	token, secret, err := tokens.Create(ctx, "user-1", " CI bot ", []string{"videos:write", "FEED:read", "videos:write"}, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(secret, APITokenPrefix) {
+		t.Fatalf("expected secret to start with %q, got %q", APITokenPrefix, secret)
+	}
+	if token.Name != "CI bot" || strings.Join(token.Scopes, ",") != "feed:read,videos:write" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+	if token.Hash == secret || strings.Contains(token.Hash, secret) {
+		t.Fatal("expected only a hash of the secret to be stored")
+	}
+
+	principal, err := tokens.Authenticate(ctx, secret)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if principal.UserID != "user-1" || principal.TokenID != token.ID || principal.SessionID != "" {
+		t.Fatalf("unexpected principal %+v", principal)
+	}
+	if !principal.HasScope(ScopeVideosWrite) || principal.HasScope(ScopeFriendsWrite) {
+		t.Fatalf("unexpected scopes %v", principal.Scopes)
+	}
+
+	stored, err := tokens.Get(ctx, "user-1", token.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(now) {
+		t.Fatalf("expected last use to be recorded, got %v", stored.LastUsedAt)
+	}
+
+	if _, err := tokens.Get(ctx, "user-2", token.ID); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("expected other users not to see the token, got %v", err)
+	}
+
+	if err := tokens.Revoke(ctx, "user-1", token.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := tokens.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("expected revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestAPITokensExpiry(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewAPITokens(NewInMemoryAPITokenStore())
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tokens.NowFunc = func() time.Time { return now }
+
+	past := now.Add(-time.Minute)
+	if _, _, err := tokens.Create(ctx, "user-1", "old", []string{ScopeFeedRead}, &past); !errors.Is(err, ErrInvalidAPITokenRequest) {
+		t.Fatalf("expected past expiry to be rejected, got %v", err)
+	}
+
+	expiresAt := now.Add(time.Hour)
+	_, secret, err := tokens.Create(ctx, "user-1", "short lived", []string{ScopeFeedRead}, &expiresAt)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := tokens.Authenticate(ctx, secret); err != nil {
+		t.Fatalf("expected token to work before expiry, got %v", err)
+	}
+
+	now = expiresAt
+	if _, err := tokens.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestAPITokensValidation(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewAPITokens(NewInMemoryAPITokenStore())
+
+	cases := map[string]struct {
+		name   string
+		scopes []string
+	}{
+		"missingName":  {"", []string{ScopeFeedRead}},
+		"longName":     {strings.Repeat("a", maxAPITokenNameLength+1), []string{ScopeFeedRead}},
+		"noScopes":     {"bot", nil},
+		"unknownScope": {"bot", []string{"admin"}},
+	}
+	for name, tc := range cases {
+		if _, _, err := tokens.Create(ctx, "user-1", tc.name, tc.scopes, nil); !errors.Is(err, ErrInvalidAPITokenRequest) {
+			t.Fatalf("%s: expected invalid request, got %v", name, err)
+		}
+	}
+
+	for i := 0; i < maxAPITokensPerUser; i++ {
+		if _, _, err := tokens.Create(ctx, "user-1", "bot", []string{ScopeFeedRead}, nil); err != nil {
+			t.Fatalf("create %d: %v", i, err)
+		}
+	}
+	if _, _, err := tokens.Create(ctx, "user-1", "bot", []string{ScopeFeedRead}, nil); !errors.Is(err, ErrAPITokenLimit) {
+		t.Fatalf("expected token limit, got %v", err)
+	}
+
+	if err := tokens.RevokeAll(ctx, "user-1"); err != nil {
+		t.Fatalf("revoke all: %v", err)
+	}
+	remaining, err := tokens.List(ctx, "user-1")
+	if err != nil || len(remaining) != 0 {
+		t.Fatalf("expected no tokens after revoke all, got %d, %v", len(remaining), err)
+	}
+
+	if _, err := tokens.Authenticate(ctx, "not-a-token"); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("expected unprefixed token to be rejected, got %v", err)
+	}
+}
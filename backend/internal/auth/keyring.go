@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// minKeyLength is the minimum HMAC secret size accepted for access token keys.
+const minKeyLength = 32
+
+// Key is an HMAC-SHA256 secret identified by the key ID embedded in access token headers.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring holds the key used to sign new access tokens plus every key still accepted for
+// verification. Rotating keys is a matter of distributing the new key as a verification key,
+// promoting it to signing key, and retiring the old key once the access token TTL has elapsed.
+type Keyring struct {
+	signing      Key
+	verification map[string][]byte
+}
+
+// NewKeyring builds a keyring that signs with signing and additionally accepts tokens signed
+// by any of the verification keys. The signing key is always accepted for verification.
+func NewKeyring(signing Key, verification ...Key) (*Keyring, error) {
+	if err := validateKey(signing); err != nil {
+		return nil, fmt.Errorf("signing key: %w", err)
+	}
+
+	ring := &Keyring{
+		signing:      signing,
+		verification: map[string][]byte{signing.ID: signing.Secret},
+	}
+
+	for _, key := range verification {
+		if err := validateKey(key); err != nil {
+			return nil, fmt.Errorf("verification key %q: %w", key.ID, err)
+		}
+		if existing, ok := ring.verification[key.ID]; ok && !bytes.Equal(existing, key.Secret) {
+			return nil, fmt.Errorf("verification key %q: conflicting secrets for key id", key.ID)
+		}
+		ring.verification[key.ID] = key.Secret
+	}
+
+	return ring, nil
+}
+
+// LoadKeyring reads the signing key and verification keys from files. Each file holds a raw
+// secret; the key ID is the file name without its extension.
+func LoadKeyring(signingFile string, verificationFiles []string) (*Keyring, error) {
+	signing, err := loadKeyFile(signingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := make([]Key, 0, len(verificationFiles))
+	for _, file := range verificationFiles {
+		key, err := loadKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+
+	return NewKeyring(signing, verification...)
+}
+
+// SigningKeyID reports the identifier of the key used for newly issued tokens.
+func (k *Keyring) SigningKeyID() string {
+	return k.signing.ID
+}
+
+// Sign encodes claims as a JWT signed with the signing key and tagged with the typ header. It is
+// meant for short-lived state handed to browsers, such as OIDC login cookies; typ must differ from
+// the access token typ so the result can never be used as an access token.
+func (k *Keyring) Sign(typ string, claims any) (string, error) {
+	if typ == "" || typ == accessTokenType {
+		return "", fmt.Errorf("token type %q is reserved", typ)
+	}
+	return signToken(k, typ, claims)
+}
+
+// Verify checks the signature and typ header of a token produced by Sign and decodes its claims
+// into into. Callers are responsible for checking expiry.
+func (k *Keyring) Verify(typ, token string, into any) error {
+	return parseToken(k, typ, token, into)
+}
+
+func (k *Keyring) verificationKey(id string) ([]byte, bool) {
+	secret, ok := k.verification[id]
+	return secret, ok
+}
+
+func loadKeyFile(path string) (Key, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("read key file %s: %w", path, err)
+	}
+
+	base := filepath.Base(path)
+	return Key{
+		ID:     strings.TrimSuffix(base, filepath.Ext(base)),
+		Secret: bytes.TrimSpace(contents),
+	}, nil
+}
+
+func validateKey(key Key) error {
+	if strings.TrimSpace(key.ID) == "" {
+		return errors.New("key id must not be empty")
+	}
+	if len(key.Secret) < minKeyLength {
+		return fmt.Errorf("secret must be at least %d bytes", minKeyLength)
+	}
+	return nil
+}
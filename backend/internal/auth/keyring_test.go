@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oldKey = Key{ID: "2024-01", Secret: []byte("old-secret-old-secret-old-secret!")}
+	newKey = Key{ID: "2024-02", Secret: []byte("new-secret-new-secret-new-secret!")}
+)
+
+func TestKeyringRotation(t *testing.T) {
+	before, err := NewKeyring(oldKey)
+	if err != nil {
+		t.Fatalf("keyring before rotation: %v", err)
+	}
+	tokens, err := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), before).Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	during, err := NewKeyring(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("keyring during rotation: %v", err)
+	}
+	manager := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), during)
+	if principal, err := manager.Authenticate(context.Background(), tokens.AccessToken); err != nil || principal.UserID != "user-1" {
+		t.Fatalf("expected token signed with retiring key to verify, got %q, %v", principal.UserID, err)
+	}
+
+	fresh, err := manager.Issue(context.Background(), "user-2", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue with new key: %v", err)
+	}
+	header, _, _ := strings.Cut(fresh.AccessToken, ".")
+	decoded, _ := base64.RawURLEncoding.DecodeString(header)
+	if !strings.Contains(string(decoded), `"kid":"2024-02"`) {
+		t.Fatalf("expected new tokens to be signed with the new key, header %s", decoded)
+	}
+
+	after, err := NewKeyring(newKey)
+	if err != nil {
+		t.Fatalf("keyring after rotation: %v", err)
+	}
+	retired := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), after)
+	if _, err := retired.Authenticate(context.Background(), tokens.AccessToken); err != ErrInvalidAccessToken {
+		t.Fatalf("expected token signed with retired key to be rejected, got %v", err)
+	}
+	if _, err := retired.Authenticate(context.Background(), fresh.AccessToken); err != nil {
+		t.Fatalf("expected token signed with current key to verify, got %v", err)
+	}
+}
+
+func TestKeyringRejectsUnsignedTokens(t *testing.T) {
+	keys, err := NewKeyring(newKey)
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT","kid":"2024-02"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","sid":"s","jti":"j","iat":1,"exp":9999999999}`))
+	if _, err := parseAccessToken(keys, header+"."+payload+".", time.Now()); err != ErrInvalidAccessToken {
+		t.Fatalf("expected alg none to be rejected, got %v", err)
+	}
+}
+
+func TestKeyringSignAndVerify(t *testing.T) {
+	keys, err := NewKeyring(newKey)
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+
+	if _, err := keys.Sign(accessTokenType, map[string]string{"sub": "user-1"}); err == nil {
+		t.Fatal("expected the access token type to be reserved")
+	}
+
+	token, err := keys.Sign("test-state", map[string]string{"value": "abc"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	var claims map[string]string
+	if err := keys.Verify("test-state", token, &claims); err != nil || claims["value"] != "abc" {
+		t.Fatalf("expected token to verify, got %v %v", claims, err)
+	}
+	if err := keys.Verify("other-state", token, &claims); err != ErrInvalidAccessToken {
+		t.Fatalf("expected typ mismatch to be rejected, got %v", err)
+	}
+	if _, err := parseAccessToken(keys, token, time.Now()); err != ErrInvalidAccessToken {
+		t.Fatalf("expected signed state to be rejected as an access token, got %v", err)
+	}
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	if _, err := NewKeyring(Key{ID: "short", Secret: []byte("too-short")}); err == nil {
+		t.Fatal("expected short signing key to be rejected")
+	}
+	if _, err := NewKeyring(Key{ID: "", Secret: newKey.Secret}); err == nil {
+		t.Fatal("expected empty key id to be rejected")
+	}
+	if _, err := NewKeyring(newKey, Key{ID: newKey.ID, Secret: oldKey.Secret}); err == nil {
+		t.Fatal("expected conflicting key ids to be rejected")
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	signingFile := filepath.Join(dir, "2024-02.key")
+	verificationFile := filepath.Join(dir, "2024-01.key")
+	if err := os.WriteFile(signingFile, append(newKey.Secret, '\n'), 0o600); err != nil {
+		t.Fatalf("write signing key: %v", err)
+	}
+	if err := os.WriteFile(verificationFile, oldKey.Secret, 0o600); err != nil {
+		t.Fatalf("write verification key: %v", err)
+	}
+
+	keys, err := LoadKeyring(signingFile, []string{verificationFile})
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	if keys.SigningKeyID() != "2024-02" {
+		t.Fatalf("expected signing key id 2024-02 got %s", keys.SigningKeyID())
+	}
+	if _, ok := keys.verificationKey("2024-01"); !ok {
+		t.Fatal("expected verification key 2024-01 to be loaded")
+	}
+
+	if _, err := LoadKeyring(filepath.Join(dir, "missing.key"), nil); err == nil {
+		t.Fatal("expected missing key file to fail")
+	}
+}
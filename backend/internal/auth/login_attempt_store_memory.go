@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NewInMemoryLoginAttemptStore returns a LoginAttemptStore backed by an in-memory map.
+func NewInMemoryLoginAttemptStore() *InMemoryLoginAttemptStore {
+	return &InMemoryLoginAttemptStore{attempts: make(map[string]LoginAttempts)}
+}
+
+// InMemoryLoginAttemptStore implements LoginAttemptStore for tests and local development.
+type InMemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]LoginAttempts
+}
+
+// Find returns the attempts recorded for email.
+func (s *InMemoryLoginAttemptStore) Find(_ context.Context, email string) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.attempts[email]
+	if !ok {
+		return LoginAttempts{Email: email}, nil
+	}
+	return attempts, nil
+}
+
+// RecordFailure counts a failed login.
+func (s *InMemoryLoginAttemptStore) RecordFailure(_ context.Context, email string, at, windowStart time.Time) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.attempts[email]
+	if !ok || !attempts.LastFailedAt.After(windowStart) {
+		attempts = LoginAttempts{Email: email, LockedUntil: attempts.LockedUntil}
+	}
+	attempts.FailedCount++
+	attempts.LastFailedAt = at.UTC()
+	s.attempts[email] = attempts
+	return attempts, nil
+}
+
+// Lock prevents logins for email until the given time.
+func (s *InMemoryLoginAttemptStore) Lock(_ context.Context, email string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts := s.attempts[email]
+	attempts.Email = email
+	lockedUntil := until.UTC()
+	attempts.LockedUntil = &lockedUntil
+	s.attempts[email] = attempts
+	return nil
+}
+
+// Reset clears the attempts recorded for email.
+func (s *InMemoryLoginAttemptStore) Reset(_ context.Context, email string) error {
+	s.mu.Lock()
+	delete(s.attempts, email)
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy tunes how failed logins against a single account are throttled. After
+// FreeAttempts failures each further attempt must wait an exponentially growing delay starting at
+// BaseDelay and capped at MaxDelay. Every LockAfter failures the account is locked for
+// LockDuration. Failures older than Window are forgotten.
+type LockoutPolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockDuration time.Duration
+	Window       time.Duration
+}
+
+// DefaultLockoutPolicy returns the policy used in production.
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		LockAfter:    10,
+		LockDuration: 15 * time.Minute,
+		Window:       time.Hour,
+	}
+}
+
+// LoginAttempts is the failed login history of one normalized e-mail address.
+type LoginAttempts struct {
+	Email        string
+	FailedCount  int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+// LoginAttemptStore persists failed login counters keyed by normalized e-mail address. Counters
+// are kept for addresses without an account too, so throttling does not reveal which exist.
+type LoginAttemptStore interface {
+	// Find returns the attempts for email, or a zero value with FailedCount 0 when there are none.
+	Find(ctx context.Context, email string) (LoginAttempts, error)
+	// RecordFailure atomically counts a failure at the given time and returns the updated record.
+	// The count restarts at one when the previous failure happened at or before windowStart.
+	RecordFailure(ctx context.Context, email string, at, windowStart time.Time) (LoginAttempts, error)
+	// Lock prevents logins for email until the given time.
+	Lock(ctx context.Context, email string, until time.Time) error
+	// Reset clears the failure count and any lock.
+	Reset(ctx context.Context, email string) error
+}
+
+// LoginGuard applies a LockoutPolicy to password logins.
+type LoginGuard struct {
+	store  LoginAttemptStore
+	policy LockoutPolicy
+
+	// NowFunc overrides the clock, primarily for tests.
+	NowFunc func() time.Time
+}
+
+// NewLoginGuard constructs a LoginGuard backed by the store.
+func NewLoginGuard(store LoginAttemptStore, policy LockoutPolicy) *LoginGuard {
+	if store == nil {
+		panic("auth: login attempt store must not be nil")
+	}
+	return &LoginGuard{store: store, policy: policy}
+}
+
+// NormalizeEmail returns the form of an e-mail address that login attempts are keyed by.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Allow reports whether a password may be checked for email now. It returns false while the
+// account is locked or the progressive delay since the last failure has not elapsed.
+func (g *LoginGuard) Allow(ctx context.Context, email string) (bool, error) {
+	attempts, err := g.store.Find(ctx, NormalizeEmail(email))
+	if err != nil {
+		return false, err
+	}
+
+	now := g.now()
+	if attempts.LockedUntil != nil && now.Before(*attempts.LockedUntil) {
+		return false, nil
+	}
+	if attempts.FailedCount == 0 || !now.Before(attempts.LastFailedAt.Add(g.policy.Window)) {
+		return true, nil
+	}
+	return !now.Before(attempts.LastFailedAt.Add(g.delay(attempts.FailedCount))), nil
+}
+
+// RecordFailure counts a failed login and returns the lock expiry when this failure locked the
+// account, so callers can notify the owner.
+func (g *LoginGuard) RecordFailure(ctx context.Context, email string) (*time.Time, error) {
+	email = NormalizeEmail(email)
+	now := g.now()
+
+	attempts, err := g.store.RecordFailure(ctx, email, now, now.Add(-g.policy.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	if g.policy.LockAfter <= 0 || attempts.FailedCount%g.policy.LockAfter != 0 {
+		return nil, nil
+	}
+
+	until := now.Add(g.policy.LockDuration)
+	if err := g.store.Lock(ctx, email, until); err != nil {
+		return nil, err
+	}
+	return &until, nil
+}
+
+// RecordSuccess clears the failure history after a successful login.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, NormalizeEmail(email))
+}
+
+// Unlock lifts a lockout, for example at an administrator's request.
+func (g *LoginGuard) Unlock(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, NormalizeEmail(email))
+}
+
+// delay returns how long after the latest of count failures the next attempt must wait.
+func (g *LoginGuard) delay(count int) time.Duration {
+	excess := count - g.policy.FreeAttempts
+	if excess <= 0 || g.policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := g.policy.BaseDelay
+	for i := 1; i < excess && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if g.policy.MaxDelay > 0 && delay > g.policy.MaxDelay {
+		delay = g.policy.MaxDelay
+	}
+	return delay
+}
+
+func (g *LoginGuard) now() time.Time {
+	if g.NowFunc != nil {
+		return g.NowFunc()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestLoginGuard() (*LoginGuard, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewLoginGuard(NewInMemoryLoginAttemptStore(), DefaultLockoutPolicy())
+	guard.NowFunc = func() time.Time { return now }
+	return guard, &now
+}
+
+func TestLoginGuardProgressiveDelay(t *testing.T) {
+	guard, now := newTestLoginGuard()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := guard.Allow(ctx, "User@Example.com"); !allowed {
+			t.Fatalf("expected attempt %d to be free", i+1)
+		}
+		if _, err := guard.RecordFailure(ctx, "user@example.com "); err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+	}
+
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected no delay after only the free attempts")
+	}
+	guard.RecordFailure(ctx, "user@example.com")
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); allowed {
+		t.Fatal("expected a delay once the free attempts are used up")
+	}
+
+	*now = now.Add(time.Second)
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected attempt after the delay to be allowed")
+	}
+	guard.RecordFailure(ctx, "user@example.com")
+
+	*now = now.Add(time.Second)
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); allowed {
+		t.Fatal("expected the delay to double")
+	}
+	*now = now.Add(time.Second)
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected attempt after the doubled delay to be allowed")
+	}
+
+	if err := guard.RecordSuccess(ctx, "USER@example.com"); err != nil {
+		t.Fatalf("record success: %v", err)
+	}
+	guard.RecordFailure(ctx, "user@example.com")
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected success to reset the failure count")
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	guard, now := newTestLoginGuard()
+	ctx := context.Background()
+	policy := DefaultLockoutPolicy()
+
+	var lockedUntil *time.Time
+	for i := 1; i <= policy.LockAfter; i++ {
+		until, err := guard.RecordFailure(ctx, "user@example.com")
+		if err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+		if i < policy.LockAfter && until != nil {
+			t.Fatalf("expected no lockout after %d failures", i)
+		}
+		lockedUntil = until
+	}
+	if lockedUntil == nil || !lockedUntil.Equal(now.Add(policy.LockDuration)) {
+		t.Fatalf("expected lockout until %v, got %v", now.Add(policy.LockDuration), lockedUntil)
+	}
+
+	*now = now.Add(policy.LockDuration - time.Second)
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); allowed {
+		t.Fatal("expected account to stay locked")
+	}
+
+	if err := guard.Unlock(ctx, "user@example.com"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected unlock to lift the lockout")
+	}
+}
+
+func TestLoginGuardForgetsOldFailures(t *testing.T) {
+	guard, now := newTestLoginGuard()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		guard.RecordFailure(ctx, "user@example.com")
+	}
+	*now = now.Add(DefaultLockoutPolicy().Window)
+
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected stale failures to be ignored")
+	}
+	guard.RecordFailure(ctx, "user@example.com")
+	if allowed, _ := guard.Allow(ctx, "user@example.com"); !allowed {
+		t.Fatal("expected the failure count to restart")
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrPasswordTooLong indicates the configured algorithm cannot hash a password that long.
+	ErrPasswordTooLong = errors.New("password too long for the configured hash algorithm")
+	// ErrUnsupportedPasswordHash indicates a stored hash is in a format the hasher cannot verify.
+	ErrUnsupportedPasswordHash = errors.New("unsupported password hash format")
+)
+
+// PasswordAlgorithm names a password hashing algorithm.
+type PasswordAlgorithm string
+
+const (
+	// PasswordArgon2id hashes passwords with Argon2id, the default for new hashes.
+	PasswordArgon2id PasswordAlgorithm = "argon2id"
+	// PasswordBcrypt hashes passwords with bcrypt, which only considers the first 72 bytes.
+	PasswordBcrypt PasswordAlgorithm = "bcrypt"
+)
+
+// bcryptMaxPasswordLength is the longest password bcrypt can hash without truncating it.
+const bcryptMaxPasswordLength = 72
+
+// Argon2Params tunes Argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHashConfig selects the algorithm and parameters used for new password hashes.
+type PasswordHashConfig struct {
+	Algorithm  PasswordAlgorithm
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+// DefaultPasswordHashConfig returns Argon2id with the parameters recommended by OWASP.
+func DefaultPasswordHashConfig() PasswordHashConfig {
+	return PasswordHashConfig{
+		Algorithm: PasswordArgon2id,
+		Argon2: Argon2Params{
+			Memory:      19 * 1024,
+			Iterations:  2,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		BcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+// PasswordHasher hashes passwords into PHC string format ("$argon2id$v=19$m=...,t=...,p=...$salt$hash")
+// and verifies hashes produced by any supported algorithm, including bcrypt hashes written before
+// Argon2id was introduced.
+type PasswordHasher struct {
+	cfg PasswordHashConfig
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// NewPasswordHasher validates cfg and returns a hasher that writes new hashes with it.
+func NewPasswordHasher(cfg PasswordHashConfig) (*PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case PasswordArgon2id:
+		p := cfg.Argon2
+		if p.Memory < 8*uint32(p.Parallelism) || p.Iterations == 0 || p.Parallelism == 0 {
+			return nil, fmt.Errorf("invalid argon2id parameters m=%d t=%d p=%d", p.Memory, p.Iterations, p.Parallelism)
+		}
+		if p.SaltLength < 8 || p.KeyLength < 16 {
+			return nil, fmt.Errorf("argon2id salt must be at least 8 bytes and key at least 16 bytes")
+		}
+	case PasswordBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cfg.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.Algorithm)
+	}
+	return &PasswordHasher{cfg: cfg}, nil
+}
+
+// DefaultPasswordHasher returns a hasher using DefaultPasswordHashConfig.
+func DefaultPasswordHasher() *PasswordHasher {
+	return &PasswordHasher{cfg: DefaultPasswordHashConfig()}
+}
+
+// Hash returns the encoded hash of password using the configured algorithm.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == PasswordBcrypt {
+		if len(password) > bcryptMaxPasswordLength {
+			return "", ErrPasswordTooLong
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("bcrypt: %w", err)
+		}
+		return string(hashed), nil
+	}
+
+	p := h.cfg.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches encoded. needsRehash is true for a matching password
+// whose hash uses a different algorithm or weaker parameters than the configured ones, so the
+// caller can store a fresh hash while it has the plaintext.
+func (h *PasswordHasher) Verify(password, encoded string) (ok, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return false, false, nil
+		}
+		want := h.cfg.Argon2
+		stale := h.cfg.Algorithm != PasswordArgon2id ||
+			params.Memory != want.Memory || params.Iterations != want.Iterations ||
+			params.Parallelism != want.Parallelism || uint32(len(key)) != want.KeyLength
+		return true, stale, nil
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrPasswordTooLong) {
+				return false, false, nil
+			}
+			return false, false, fmt.Errorf("%w: %v", ErrUnsupportedPasswordHash, err)
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, fmt.Errorf("%w: %v", ErrUnsupportedPasswordHash, err)
+		}
+		return true, h.cfg.Algorithm != PasswordBcrypt || cost != h.cfg.BcryptCost, nil
+	default:
+		return false, false, ErrUnsupportedPasswordHash
+	}
+}
+
+// CompareDummy spends about as long as verifying password against a real hash. Callers use it
+// when there is no account to check so response timing does not reveal whether one exists.
+func (h *PasswordHasher) CompareDummy(password string) {
+	h.dummyOnce.Do(func() {
+		h.dummy, _ = h.Hash("vidfriends-dummy-password")
+	})
+	_, _, _ = h.Verify(password, h.dummy)
+}
+
+// decodeArgon2id parses "$argon2id$v=19$m=<kib>,t=<iterations>,p=<parallelism>$<salt>$<key>".
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return Argon2Params{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return Argon2Params{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasherArgon2id(t *testing.T) {
+	hasher := DefaultPasswordHasher()
+	long := strings.Repeat("correct horse battery staple ", 6)
+
+	encoded, err := hasher.Hash(long)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Fatalf("unexpected encoding %q", encoded)
+	}
+
+	ok, rehash, err := hasher.Verify(long, encoded)
+	if err != nil || !ok || rehash {
+		t.Fatalf("expected current hash to verify without rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+
+	// bcrypt would have ignored everything past the first 72 bytes.
+	ok, _, err = hasher.Verify(long[:72], encoded)
+	if err != nil || ok {
+		t.Fatalf("expected truncated password to be rejected, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestPasswordHasherUpgradesOutdatedHashes(t *testing.T) {
+	hasher := DefaultPasswordHasher()
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	ok, rehash, err := hasher.Verify("password123", string(legacy))
+	if err != nil || !ok || !rehash {
+		t.Fatalf("expected bcrypt hash to verify and need rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+	if ok, _, _ := hasher.Verify("wrongpass", string(legacy)); ok {
+		t.Fatal("expected wrong password to be rejected")
+	}
+
+	cfg := DefaultPasswordHashConfig()
+	cfg.Argon2.Iterations = 1
+	weaker, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("new hasher: %v", err)
+	}
+	encoded, err := weaker.Hash("password123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if ok, rehash, err := hasher.Verify("password123", encoded); err != nil || !ok || !rehash {
+		t.Fatalf("expected weaker argon2id hash to need rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+}
+
+func TestPasswordHasherBcrypt(t *testing.T) {
+	cfg := DefaultPasswordHashConfig()
+	cfg.Algorithm = PasswordBcrypt
+	cfg.BcryptCost = bcrypt.MinCost
+	hasher, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("new hasher: %v", err)
+	}
+
+	if _, err := hasher.Hash(strings.Repeat("a", 73)); !errors.Is(err, ErrPasswordTooLong) {
+		t.Fatalf("expected long password to be rejected, got %v", err)
+	}
+
+	encoded, err := hasher.Hash("password123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if ok, rehash, err := hasher.Verify("password123", encoded); err != nil || !ok || rehash {
+		t.Fatalf("expected bcrypt hash to verify without rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+}
+
+func TestPasswordHasherRejectsInvalidInput(t *testing.T) {
+	if _, err := NewPasswordHasher(PasswordHashConfig{Algorithm: "md5"}); err == nil {
+		t.Fatal("expected unknown algorithm to be rejected")
+	}
+
+	hasher := DefaultPasswordHasher()
+	for _, encoded := range []string{"", "plaintext", "$argon2id$v=19$m=1,t=1$salt$key", "$argon2i$v=19$m=8,t=1,p=1$c2FsdA$a2V5"} {
+		if _, _, err := hasher.Verify("password123", encoded); !errors.Is(err, ErrUnsupportedPasswordHash) {
+			t.Fatalf("expected %q to be unsupported, got %v", encoded, err)
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
 	"github.com/vidfriends/backend/internal/models"
 )
 
@@ -15,6 +18,8 @@ var (
 	ErrSessionNotFound = errors.New("session not found")
 	// ErrRefreshTokenExpired indicates the refresh token has expired and cannot be used.
 	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	// ErrRefreshTokenReused indicates an already rotated refresh token was presented again.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
 )
 
 // SessionStore persists issued refresh tokens so they can survive process restarts.
@@ -22,13 +27,59 @@ type SessionStore interface {
 	Save(ctx context.Context, session Session) error
 	Find(ctx context.Context, refreshToken string) (Session, error)
 	Delete(ctx context.Context, refreshToken string) error
+	// MarkRotated flags a refresh token as exchanged. It returns ErrRefreshTokenReused when the
+	// token had already been rotated so concurrent refreshes cannot both succeed.
+	MarkRotated(ctx context.Context, refreshToken string, at time.Time) error
+	// DeleteFamily removes every refresh token issued for the session.
+	DeleteFamily(ctx context.Context, sessionID string) error
+	// ListForUser returns the current, not yet rotated, refresh token of each of the user's sessions.
+	ListForUser(ctx context.Context, userID string) ([]Session, error)
+	// DeleteForUser removes every refresh token issued to the user.
+	DeleteForUser(ctx context.Context, userID string) error
 }
 
-// Session represents a refresh token issued to a user.
+// Session represents a refresh token issued to a user. ID identifies the login session and is
+// preserved when the refresh token is rotated, so all refresh tokens sharing an ID form one
+// token family; access tokens reference it through the sid claim. Rotated tokens are kept
+// until they expire so that replaying them can be detected.
 type Session struct {
-	RefreshToken string
-	UserID       string
-	ExpiresAt    time.Time
+	ID              string
+	RefreshToken    string
+	UserID          string
+	ExpiresAt       time.Time
+	RotatedAt       *time.Time
+	CreatedAt       time.Time
+	LastRefreshedAt *time.Time
+	UserAgent       string
+	ClientIP        string
+}
+
+// ClientInfo describes the client a session was issued to or last refreshed from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Principal identifies the user and session an access token was issued for. Requests made with
+// a personal access token have a TokenID and the token's Scopes instead of a SessionID.
+type Principal struct {
+	UserID    string
+	SessionID string
+	TokenID   string
+	Scopes    []string
+}
+
+// HasScope reports whether the credential may be used for scope. Session tokens carry every scope.
+func (p Principal) HasScope(scope string) bool {
+	if p.TokenID == "" {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // Manager manages the lifecycle of issued session tokens backed by a persistent store.
@@ -37,28 +88,51 @@ type Manager struct {
 	refreshTTL time.Duration
 
 	store SessionStore
+	keys  *Keyring
 }
 
 // NewManager constructs a Manager that issues access and refresh tokens with the provided TTLs.
-func NewManager(accessTTL, refreshTTL time.Duration, store SessionStore) *Manager {
+// Access tokens are signed with the keyring so any instance holding the same keys can verify them.
+func NewManager(accessTTL, refreshTTL time.Duration, store SessionStore, keys *Keyring) *Manager {
 	if store == nil {
 		panic("auth: session store must not be nil")
 	}
+	if keys == nil {
+		panic("auth: keyring must not be nil")
+	}
 	return &Manager{
 		accessTTL:  accessTTL,
 		refreshTTL: refreshTTL,
 		store:      store,
+		keys:       keys,
 	}
 }
 
-// Issue creates a new pair of access and refresh tokens for the provided user identifier.
-func (m *Manager) Issue(ctx context.Context, userID string) (models.SessionTokens, error) {
+// Issue starts a new session for the client and returns its access and refresh tokens.
+func (m *Manager) Issue(ctx context.Context, userID string, client ClientInfo) (models.SessionTokens, error) {
 	if userID == "" {
 		return models.SessionTokens{}, errors.New("user id must be provided")
 	}
 
+	return m.issue(ctx, Session{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+		UserAgent: client.UserAgent,
+		ClientIP:  client.IPAddress,
+	})
+}
+
+// issue mints a token pair for the session and persists its new refresh token.
+func (m *Manager) issue(ctx context.Context, session Session) (models.SessionTokens, error) {
 	now := time.Now().UTC()
-	accessToken, err := randomToken()
+	accessToken, err := signAccessToken(m.keys, AccessClaims{
+		Subject:   session.UserID,
+		SessionID: session.ID,
+		ID:        uuid.NewString(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.accessTTL).Unix(),
+	})
 	if err != nil {
 		return models.SessionTokens{}, err
 	}
@@ -75,19 +149,20 @@ func (m *Manager) Issue(ctx context.Context, userID string) (models.SessionToken
 		RefreshExpiresAt: now.Add(m.refreshTTL),
 	}
 
-	if err := m.store.Save(ctx, Session{
-		RefreshToken: refreshToken,
-		UserID:       userID,
-		ExpiresAt:    tokens.RefreshExpiresAt,
-	}); err != nil {
+	session.RefreshToken = refreshToken
+	session.ExpiresAt = tokens.RefreshExpiresAt
+	session.RotatedAt = nil
+	if err := m.store.Save(ctx, session); err != nil {
 		return models.SessionTokens{}, err
 	}
 
 	return tokens, nil
 }
 
-// Refresh exchanges a refresh token for a new session token pair.
-func (m *Manager) Refresh(ctx context.Context, refreshToken string) (models.SessionTokens, error) {
+// Refresh exchanges a refresh token for a new session token pair. Presenting a refresh token
+// that was already exchanged revokes its whole family, since either the legitimate client or an
+// attacker holds a stolen copy and we cannot tell which.
+func (m *Manager) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (models.SessionTokens, error) {
 	if refreshToken == "" {
 		return models.SessionTokens{}, ErrSessionNotFound
 	}
@@ -97,24 +172,114 @@ func (m *Manager) Refresh(ctx context.Context, refreshToken string) (models.Sess
 		return models.SessionTokens{}, err
 	}
 
-	if time.Now().UTC().After(session.ExpiresAt) {
+	if session.RotatedAt != nil {
+		m.revokeReusedFamily(ctx, session)
+		return models.SessionTokens{}, ErrRefreshTokenReused
+	}
+
+	now := time.Now().UTC()
+	if now.After(session.ExpiresAt) {
 		_ = m.store.Delete(ctx, refreshToken)
 		return models.SessionTokens{}, ErrRefreshTokenExpired
 	}
 
-	if err := m.store.Delete(ctx, refreshToken); err != nil {
+	if err := m.store.MarkRotated(ctx, refreshToken, now); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			m.revokeReusedFamily(ctx, session)
+		}
 		return models.SessionTokens{}, err
 	}
 
-	return m.Issue(ctx, session.UserID)
+	return m.issue(ctx, Session{
+		ID:              session.ID,
+		UserID:          session.UserID,
+		CreatedAt:       session.CreatedAt,
+		LastRefreshedAt: &now,
+		UserAgent:       client.UserAgent,
+		ClientIP:        client.IPAddress,
+	})
+}
+
+func (m *Manager) revokeReusedFamily(ctx context.Context, session Session) {
+	logger := logging.FromContext(ctx)
+	logger.Warn("refresh token reuse detected; revoking session family",
+		"event", "security.refresh_token_reuse",
+		"userId", session.UserID,
+		"sessionId", session.ID,
+	)
+	if err := m.store.DeleteFamily(ctx, session.ID); err != nil {
+		logger.Error("failed to revoke reused session family", "error", err, "userId", session.UserID, "sessionId", session.ID)
+	}
 }
 
-// Revoke removes the provided refresh token from the active session store.
+// Authenticate verifies an access token and returns the user and session it was issued for.
+func (m *Manager) Authenticate(_ context.Context, accessToken string) (Principal, error) {
+	if accessToken == "" {
+		return Principal{}, ErrInvalidAccessToken
+	}
+
+	claims, err := m.ParseAccessToken(accessToken)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{UserID: claims.Subject, SessionID: claims.SessionID}, nil
+}
+
+// ParseAccessToken verifies an access token and returns all of its claims.
+func (m *Manager) ParseAccessToken(accessToken string) (AccessClaims, error) {
+	return parseAccessToken(m.keys, accessToken, time.Now().UTC())
+}
+
+// Revoke ends the session the refresh token belongs to, including every token in its family.
 func (m *Manager) Revoke(ctx context.Context, refreshToken string) {
 	if refreshToken == "" {
 		return
 	}
-	_ = m.store.Delete(ctx, refreshToken)
+	session, err := m.store.Find(ctx, refreshToken)
+	if err != nil {
+		_ = m.store.Delete(ctx, refreshToken)
+		return
+	}
+	_ = m.store.DeleteFamily(ctx, session.ID)
+}
+
+// Sessions lists the user's active sessions. Expired sessions are omitted.
+func (m *Manager) Sessions(ctx context.Context, userID string) ([]Session, error) {
+	sessions, err := m.store.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	active := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		if now.Before(session.ExpiresAt) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// RevokeSession ends one of the user's sessions. It returns ErrSessionNotFound when the session
+// does not exist or belongs to another user.
+func (m *Manager) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	sessions, err := m.store.ListForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return m.store.DeleteFamily(ctx, sessionID)
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeAll ends every session belonging to the user.
+func (m *Manager) RevokeAll(ctx context.Context, userID string) error {
+	return m.store.DeleteForUser(ctx, userID)
 }
 
 func randomToken() (string, error) {
@@ -6,11 +6,20 @@ import (
 	"time"
 )
 
+func testKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	keys, err := NewKeyring(Key{ID: "test", Secret: []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+	return keys
+}
+
 func TestManagerIssueAndRefresh(t *testing.T) {
 	store := NewInMemorySessionStore()
-	manager := NewManager(time.Minute, time.Hour, store)
+	manager := NewManager(time.Minute, time.Hour, store, testKeyring(t))
 
-	tokens, err := manager.Issue(context.Background(), "user-1")
+	tokens, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
 	if err != nil {
 		t.Fatalf("issue: %v", err)
 	}
@@ -18,49 +27,272 @@ func TestManagerIssueAndRefresh(t *testing.T) {
 		t.Fatalf("expected non-empty tokens: %+v", tokens)
 	}
 
-	refreshed, err := manager.Refresh(context.Background(), tokens.RefreshToken)
+	refreshed, err := manager.Refresh(context.Background(), tokens.RefreshToken, ClientInfo{})
 	if err != nil {
 		t.Fatalf("refresh: %v", err)
 	}
 	if refreshed.RefreshToken == tokens.RefreshToken {
 		t.Fatal("expected new refresh token")
 	}
-	if store.Has(tokens.RefreshToken) {
-		t.Fatal("old token should have been removed")
+	rotated, err := store.Find(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("expected rotated token to be retained for reuse detection: %v", err)
+	}
+	if rotated.RotatedAt == nil {
+		t.Fatal("old token should have been marked rotated")
+	}
+
+	original, err := manager.ParseAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("parse original access token: %v", err)
+	}
+	next, err := manager.ParseAccessToken(refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("parse refreshed access token: %v", err)
+	}
+	if original.SessionID != next.SessionID {
+		t.Fatalf("expected session id to survive refresh, got %s and %s", original.SessionID, next.SessionID)
+	}
+	if original.ID == next.ID {
+		t.Fatal("expected a fresh token id after refresh")
 	}
 }
 
 func TestManagerIssueValidation(t *testing.T) {
-	manager := NewManager(time.Minute, time.Hour, NewInMemorySessionStore())
-	if _, err := manager.Issue(context.Background(), ""); err == nil {
+	manager := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), testKeyring(t))
+	if _, err := manager.Issue(context.Background(), "", ClientInfo{}); err == nil {
 		t.Fatal("expected error for empty user id")
 	}
 }
 
 func TestManagerRefreshFailures(t *testing.T) {
-	manager := NewManager(time.Minute, time.Millisecond, NewInMemorySessionStore())
+	manager := NewManager(time.Minute, time.Millisecond, NewInMemorySessionStore(), testKeyring(t))
 
-	if _, err := manager.Refresh(context.Background(), ""); err != ErrSessionNotFound {
+	if _, err := manager.Refresh(context.Background(), "", ClientInfo{}); err != ErrSessionNotFound {
 		t.Fatalf("expected session not found got %v", err)
 	}
 
-	tokens, err := manager.Issue(context.Background(), "user-1")
+	tokens, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
 	if err != nil {
 		t.Fatalf("issue: %v", err)
 	}
 
 	time.Sleep(2 * time.Millisecond)
 
-	if _, err := manager.Refresh(context.Background(), tokens.RefreshToken); err != ErrRefreshTokenExpired {
+	if _, err := manager.Refresh(context.Background(), tokens.RefreshToken, ClientInfo{}); err != ErrRefreshTokenExpired {
 		t.Fatalf("expected refresh expired got %v", err)
 	}
 
-	tokens, err = manager.Issue(context.Background(), "user-1")
+	tokens, err = manager.Issue(context.Background(), "user-1", ClientInfo{})
 	if err != nil {
 		t.Fatalf("issue: %v", err)
 	}
 	manager.Revoke(context.Background(), tokens.RefreshToken)
-	if _, err := manager.Refresh(context.Background(), tokens.RefreshToken); err != ErrSessionNotFound {
+	if _, err := manager.Refresh(context.Background(), tokens.RefreshToken, ClientInfo{}); err != ErrSessionNotFound {
 		t.Fatalf("expected session not found after revoke got %v", err)
 	}
 }
+
+func TestManagerAuthenticate(t *testing.T) {
+	manager := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), testKeyring(t))
+
+	tokens, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	principal, err := manager.Authenticate(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if principal.UserID != "user-1" {
+		t.Fatalf("expected user-1 got %q", principal.UserID)
+	}
+
+	claims, err := manager.ParseAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	if principal.SessionID != claims.SessionID {
+		t.Fatalf("expected principal session %q got %q", claims.SessionID, principal.SessionID)
+	}
+	if claims.SessionID == "" || claims.ID == "" || claims.IssuedAt == 0 || claims.ExpiresAt <= claims.IssuedAt {
+		t.Fatalf("expected populated claims, got %+v", claims)
+	}
+
+	otherKeys, err := NewKeyring(Key{ID: "test", Secret: []byte("fedcba9876543210fedcba9876543210")})
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+	other := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), otherKeys)
+	if _, err := other.Authenticate(context.Background(), tokens.AccessToken); err != ErrInvalidAccessToken {
+		t.Fatalf("expected invalid token for foreign secret got %v", err)
+	}
+
+	tampered := tokens.AccessToken[:len(tokens.AccessToken)-2] + "xx"
+	for _, token := range []string{"", "not-a-token", tampered, tokens.RefreshToken} {
+		if _, err := manager.Authenticate(context.Background(), token); err != ErrInvalidAccessToken {
+			t.Fatalf("expected invalid token for %q got %v", token, err)
+		}
+	}
+}
+
+func TestManagerAuthenticateExpired(t *testing.T) {
+	manager := NewManager(-time.Second, time.Hour, NewInMemorySessionStore(), testKeyring(t))
+
+	tokens, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if _, err := manager.Authenticate(context.Background(), tokens.AccessToken); err != ErrInvalidAccessToken {
+		t.Fatalf("expected expired token to be rejected got %v", err)
+	}
+}
+
+func TestManagerRefreshReuseRevokesFamily(t *testing.T) {
+	store := NewInMemorySessionStore()
+	manager := NewManager(time.Minute, time.Hour, store, testKeyring(t))
+
+	stolen, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	other, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue second session: %v", err)
+	}
+
+	legitimate, err := manager.Refresh(context.Background(), stolen.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if _, err := manager.Refresh(context.Background(), stolen.RefreshToken, ClientInfo{}); err != ErrRefreshTokenReused {
+		t.Fatalf("expected reuse to be detected got %v", err)
+	}
+
+	if store.Has(stolen.RefreshToken) || store.Has(legitimate.RefreshToken) {
+		t.Fatal("expected every token in the reused family to be revoked")
+	}
+	if _, err := manager.Refresh(context.Background(), legitimate.RefreshToken, ClientInfo{}); err != ErrSessionNotFound {
+		t.Fatalf("expected descendant token to be revoked got %v", err)
+	}
+
+	if !store.Has(other.RefreshToken) {
+		t.Fatal("expected unrelated session to survive family revocation")
+	}
+}
+
+func TestManagerRevokeEndsFamily(t *testing.T) {
+	store := NewInMemorySessionStore()
+	manager := NewManager(time.Minute, time.Hour, store, testKeyring(t))
+
+	tokens, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	refreshed, err := manager.Refresh(context.Background(), tokens.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	manager.Revoke(context.Background(), refreshed.RefreshToken)
+
+	if store.Has(tokens.RefreshToken) || store.Has(refreshed.RefreshToken) {
+		t.Fatal("expected revoke to remove the whole family")
+	}
+}
+
+func TestManagerSessions(t *testing.T) {
+	manager := NewManager(time.Minute, time.Hour, NewInMemorySessionStore(), testKeyring(t))
+	laptop := ClientInfo{UserAgent: "Firefox", IPAddress: "203.0.113.10"}
+	phone := ClientInfo{UserAgent: "VidFriends iOS", IPAddress: "198.51.100.7"}
+
+	first, err := manager.Issue(context.Background(), "user-1", laptop)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if _, err := manager.Issue(context.Background(), "user-1", phone); err != nil {
+		t.Fatalf("issue second session: %v", err)
+	}
+	if _, err := manager.Issue(context.Background(), "user-2", laptop); err != nil {
+		t.Fatalf("issue other user: %v", err)
+	}
+
+	refreshed, err := manager.Refresh(context.Background(), first.RefreshToken, ClientInfo{UserAgent: "Firefox", IPAddress: "203.0.113.99"})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	claims, err := manager.ParseAccessToken(refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+
+	sessions, err := manager.Sessions(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected one entry per session, got %d", len(sessions))
+	}
+
+	current := sessions[0]
+	if current.ID != claims.SessionID {
+		t.Fatalf("expected most recently refreshed session first, got %+v", sessions)
+	}
+	if current.LastRefreshedAt == nil || current.CreatedAt.IsZero() || current.CreatedAt.After(*current.LastRefreshedAt) {
+		t.Fatalf("expected creation and refresh times, got %+v", current)
+	}
+	if current.ClientIP != "203.0.113.99" || current.UserAgent != "Firefox" {
+		t.Fatalf("expected client details from latest refresh, got %+v", current)
+	}
+	if sessions[1].LastRefreshedAt != nil || sessions[1].UserAgent != "VidFriends iOS" {
+		t.Fatalf("unexpected second session %+v", sessions[1])
+	}
+}
+
+func TestManagerRevokeSession(t *testing.T) {
+	store := NewInMemorySessionStore()
+	manager := NewManager(time.Minute, time.Hour, store, testKeyring(t))
+
+	mine, err := manager.Issue(context.Background(), "user-1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	theirs, err := manager.Issue(context.Background(), "user-2", ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue other user: %v", err)
+	}
+	theirClaims, err := manager.ParseAccessToken(theirs.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+
+	if err := manager.RevokeSession(context.Background(), "user-1", theirClaims.SessionID); err != ErrSessionNotFound {
+		t.Fatalf("expected other user's session to be hidden, got %v", err)
+	}
+	if !store.Has(theirs.RefreshToken) {
+		t.Fatal("expected other user's session to survive")
+	}
+
+	if err := manager.RevokeSession(context.Background(), "user-2", theirClaims.SessionID); err != nil {
+		t.Fatalf("revoke session: %v", err)
+	}
+	if store.Has(theirs.RefreshToken) {
+		t.Fatal("expected session to be revoked")
+	}
+
+	if _, err := manager.Issue(context.Background(), "user-1", ClientInfo{}); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if err := manager.RevokeAll(context.Background(), "user-1"); err != nil {
+		t.Fatalf("revoke all: %v", err)
+	}
+	if store.Has(mine.RefreshToken) {
+		t.Fatal("expected every session to be revoked")
+	}
+	if sessions, _ := manager.Sessions(context.Background(), "user-1"); len(sessions) != 0 {
+		t.Fatalf("expected no sessions, got %d", len(sessions))
+	}
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 )
 
 // NewInMemorySessionStore returns a SessionStore backed by an in-memory map.
@@ -43,6 +45,72 @@ func (s *InMemorySessionStore) Delete(_ context.Context, refreshToken string) er
 	return nil
 }
 
+// MarkRotated flags the refresh token as exchanged.
+func (s *InMemorySessionStore) MarkRotated(_ context.Context, refreshToken string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[refreshToken]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if session.RotatedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	rotatedAt := at.UTC()
+	session.RotatedAt = &rotatedAt
+	s.sessions[refreshToken] = session
+	return nil
+}
+
+// DeleteFamily removes every refresh token issued for the session.
+func (s *InMemorySessionStore) DeleteFamily(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, session := range s.sessions {
+		if session.ID == sessionID {
+			delete(s.sessions, token)
+		}
+	}
+	return nil
+}
+
+// ListForUser returns the current refresh token of each of the user's sessions, most recently
+// used first.
+func (s *InMemorySessionStore) ListForUser(_ context.Context, userID string) ([]Session, error) {
+	s.mu.RLock()
+	sessions := make([]Session, 0)
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RotatedAt == nil {
+			sessions = append(sessions, session)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return lastActive(sessions[i]).After(lastActive(sessions[j]))
+	})
+	return sessions, nil
+}
+
+// DeleteForUser removes every refresh token issued to the user.
+func (s *InMemorySessionStore) DeleteForUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, token)
+		}
+	}
+	return nil
+}
+
+func lastActive(session Session) time.Time {
+	if session.LastRefreshedAt != nil {
+		return *session.LastRefreshedAt
+	}
+	return session.CreatedAt
+}
+
 // Has reports whether a refresh token exists. Useful for tests.
 func (s *InMemorySessionStore) Has(refreshToken string) bool {
 	s.mu.RLock()
@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTwoFactorNotEnrolled indicates the user has not started or finished TOTP enrollment.
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	// ErrTwoFactorAlreadyEnabled indicates enrollment was attempted while 2FA is already active.
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrInvalidTwoFactorCode indicates the TOTP or recovery code was wrong, expired, or reused.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge indicates the login challenge token is malformed or expired.
+	ErrInvalidChallenge = errors.New("invalid login challenge")
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes are issued at a time.
+	recoveryCodeCount = 10
+	// recoveryCodeLength is the number of characters in a recovery code, excluding separators.
+	recoveryCodeLength = 12
+	// challengeTTL bounds how long a user has to enter their code after the password step.
+	challengeTTL = 5 * time.Minute
+	// challengeTokenType is the typ header of login challenge tokens.
+	challengeTokenType = "vidfriends-2fa-challenge"
+)
+
+// recoveryCodeAlphabet omits characters that are easily confused when read aloud or typed.
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// TwoFactorEnrollment is the TOTP state stored for a user. EnabledAt stays nil until the user
+// confirms enrollment with a first code. LastUsedStep records the most recent accepted time
+// step so a code cannot be replayed.
+type TwoFactorEnrollment struct {
+	UserID       string
+	Secret       string
+	EnabledAt    *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// TwoFactorStore persists TOTP enrollments and hashed recovery codes.
+type TwoFactorStore interface {
+	// Find returns ErrTwoFactorNotEnrolled when the user has no enrollment.
+	Find(ctx context.Context, userID string) (TwoFactorEnrollment, error)
+	// SavePending stores a new, not yet enabled, enrollment replacing any pending one.
+	SavePending(ctx context.Context, enrollment TwoFactorEnrollment) error
+	// Enable activates the enrollment and replaces the user's recovery codes.
+	Enable(ctx context.Context, userID string, at time.Time, step int64, recoveryCodeHashes []string) error
+	// RecordStep advances LastUsedStep and returns ErrInvalidTwoFactorCode when step was
+	// already used, so concurrent logins cannot share a code.
+	RecordStep(ctx context.Context, userID string, step int64) error
+	// ReplaceRecoveryCodes discards existing recovery codes and stores new ones.
+	ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error
+	// UseRecoveryCode marks a recovery code as used and returns ErrInvalidTwoFactorCode when
+	// it is unknown or already used.
+	UseRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error
+	// Delete removes the enrollment and recovery codes.
+	Delete(ctx context.Context, userID string) error
+}
+
+// TOTPEnrollment is returned when a user starts enrolling an authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// LoginChallenge is handed to clients after a correct password when 2FA is enabled. It must be
+// exchanged together with a TOTP or recovery code for session tokens.
+type LoginChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type challengeClaims struct {
+	Subject   string `json:"sub"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TwoFactor manages TOTP enrollment, verification, recovery codes and login challenges.
+type TwoFactor struct {
+	store  TwoFactorStore
+	keys   *Keyring
+	issuer string
+
+	// NowFunc overrides the clock, primarily for tests.
+	NowFunc func() time.Time
+}
+
+// NewTwoFactor constructs a TwoFactor service. Challenge tokens are signed with keys; issuer is
+// the account label shown in authenticator apps.
+func NewTwoFactor(store TwoFactorStore, keys *Keyring, issuer string) *TwoFactor {
+	if store == nil {
+		panic("auth: two-factor store must not be nil")
+	}
+	if keys == nil {
+		panic("auth: keyring must not be nil")
+	}
+	return &TwoFactor{store: store, keys: keys, issuer: issuer}
+}
+
+// Enabled reports whether the user must pass a second factor at login.
+func (t *TwoFactor) Enabled(ctx context.Context, userID string) (bool, error) {
+	enrollment, err := t.store.Find(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return enrollment.EnabledAt != nil, nil
+}
+
+// BeginEnrollment generates a new secret for the user. 2FA is not enforced until the user
+// confirms the enrollment with a code from their authenticator app.
+func (t *TwoFactor) BeginEnrollment(ctx context.Context, userID, account string) (TOTPEnrollment, error) {
+	enabled, err := t.Enabled(ctx, userID)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if enabled {
+		return TOTPEnrollment{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	if err := t.store.SavePending(ctx, TwoFactorEnrollment{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: t.now(),
+	}); err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	return TOTPEnrollment{Secret: secret, URI: TOTPURI(t.issuer, account, secret)}, nil
+}
+
+// ConfirmEnrollment enables 2FA once the user proves their app produces valid codes and returns
+// the plaintext recovery codes. They are only shown this once.
+func (t *TwoFactor) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	enrollment, err := t.store.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.EnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	now := t.now()
+	step, ok := matchTOTP(enrollment.Secret, normalizeCode(code), now)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.store.Enable(ctx, userID, now, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code, or failing that a recovery code, for a user with 2FA enabled. Each
+// TOTP time step and each recovery code can only be used once.
+func (t *TwoFactor) Verify(ctx context.Context, userID, code string) error {
+	enrollment, err := t.store.Find(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnrolled) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	if enrollment.EnabledAt == nil {
+		return ErrInvalidTwoFactorCode
+	}
+
+	code = normalizeCode(code)
+	now := t.now()
+	if step, ok := matchTOTP(enrollment.Secret, code, now); ok {
+		if step <= enrollment.LastUsedStep {
+			return ErrInvalidTwoFactorCode
+		}
+		return t.store.RecordStep(ctx, userID, step)
+	}
+
+	if len(code) != recoveryCodeLength {
+		return ErrInvalidTwoFactorCode
+	}
+	return t.store.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), now)
+}
+
+// Disable turns 2FA off after verifying a current code.
+func (t *TwoFactor) Disable(ctx context.Context, userID, code string) error {
+	if err := t.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return t.store.Delete(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after verifying a current code.
+func (t *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if err := t.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.store.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// IssueChallenge returns a short-lived token proving the user passed the password step.
+func (t *TwoFactor) IssueChallenge(userID string) (LoginChallenge, error) {
+	now := t.now()
+	expiresAt := now.Add(challengeTTL)
+	token, err := signToken(t.keys, challengeTokenType, challengeClaims{
+		Subject:   userID,
+		ID:        uuid.NewString(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return LoginChallenge{}, err
+	}
+	return LoginChallenge{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// CompleteChallenge verifies the challenge token and the second factor and returns the user ID.
+func (t *TwoFactor) CompleteChallenge(ctx context.Context, challenge, code string) (string, error) {
+	var claims challengeClaims
+	if err := parseToken(t.keys, challengeTokenType, challenge, &claims); err != nil {
+		return "", ErrInvalidChallenge
+	}
+	if claims.Subject == "" || !t.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return "", ErrInvalidChallenge
+	}
+
+	if err := t.Verify(ctx, claims.Subject, code); err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+func (t *TwoFactor) now() time.Time {
+	if t.NowFunc != nil {
+		return t.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+// normalizeCode strips the spaces and dashes users commonly type and lowercases the result.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	buf := make([]byte, recoveryCodeLength)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := make([]byte, recoveryCodeLength)
+		for j, b := range buf {
+			raw[j] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+		}
+		code := string(raw)
+		codes = append(codes, code[:4]+"-"+code[4:8]+"-"+code[8:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
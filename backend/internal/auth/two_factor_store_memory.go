@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NewInMemoryTwoFactorStore returns a TwoFactorStore backed by in-memory maps.
+func NewInMemoryTwoFactorStore() *InMemoryTwoFactorStore {
+	return &InMemoryTwoFactorStore{
+		enrollments:   make(map[string]TwoFactorEnrollment),
+		recoveryCodes: make(map[string]map[string]*time.Time),
+	}
+}
+
+// InMemoryTwoFactorStore implements TwoFactorStore for tests and local development.
+type InMemoryTwoFactorStore struct {
+	mu            sync.Mutex
+	enrollments   map[string]TwoFactorEnrollment
+	recoveryCodes map[string]map[string]*time.Time
+}
+
+// Find returns the user's enrollment.
+func (s *InMemoryTwoFactorStore) Find(_ context.Context, userID string) (TwoFactorEnrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enrollment, ok := s.enrollments[userID]
+	if !ok {
+		return TwoFactorEnrollment{}, ErrTwoFactorNotEnrolled
+	}
+	return enrollment, nil
+}
+
+// SavePending stores a not yet enabled enrollment.
+func (s *InMemoryTwoFactorStore) SavePending(_ context.Context, enrollment TwoFactorEnrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.enrollments[enrollment.UserID]; ok && existing.EnabledAt != nil {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	enrollment.EnabledAt = nil
+	s.enrollments[enrollment.UserID] = enrollment
+	return nil
+}
+
+// Enable activates the enrollment and stores the recovery code hashes.
+func (s *InMemoryTwoFactorStore) Enable(_ context.Context, userID string, at time.Time, step int64, recoveryCodeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enrollment, ok := s.enrollments[userID]
+	if !ok {
+		return ErrTwoFactorNotEnrolled
+	}
+	if enrollment.EnabledAt != nil {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	enabledAt := at.UTC()
+	enrollment.EnabledAt = &enabledAt
+	enrollment.LastUsedStep = step
+	s.enrollments[userID] = enrollment
+	s.replaceRecoveryCodes(userID, recoveryCodeHashes)
+	return nil
+}
+
+// RecordStep advances the last used time step.
+func (s *InMemoryTwoFactorStore) RecordStep(_ context.Context, userID string, step int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enrollment, ok := s.enrollments[userID]
+	if !ok || enrollment.LastUsedStep >= step {
+		return ErrInvalidTwoFactorCode
+	}
+	enrollment.LastUsedStep = step
+	s.enrollments[userID] = enrollment
+	return nil
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes.
+func (s *InMemoryTwoFactorStore) ReplaceRecoveryCodes(_ context.Context, userID string, hashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.enrollments[userID]; !ok {
+		return ErrTwoFactorNotEnrolled
+	}
+	s.replaceRecoveryCodes(userID, hashes)
+	return nil
+}
+
+// UseRecoveryCode marks the recovery code as used.
+func (s *InMemoryTwoFactorStore) UseRecoveryCode(_ context.Context, userID, hash string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usedAt, ok := s.recoveryCodes[userID][hash]
+	if !ok || usedAt != nil {
+		return ErrInvalidTwoFactorCode
+	}
+	now := at.UTC()
+	s.recoveryCodes[userID][hash] = &now
+	return nil
+}
+
+// Delete removes the user's enrollment and recovery codes.
+func (s *InMemoryTwoFactorStore) Delete(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.enrollments, userID)
+	delete(s.recoveryCodes, userID)
+	return nil
+}
+
+func (s *InMemoryTwoFactorStore) replaceRecoveryCodes(userID string, hashes []string) {
+	codes := make(map[string]*time.Time, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = nil
+	}
+	s.recoveryCodes[userID] = codes
+}
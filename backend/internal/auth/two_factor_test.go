@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1 secret "12345678901234567890" truncated to six digits.
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range vectors {
+		got, err := TOTPCode(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("totp code: %v", err)
+		}
+		if got != want {
+			t.Fatalf("at %d expected %s got %s", unix, want, got)
+		}
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("VidFriends", "alice@example.com", "SECRET")
+	if !strings.HasPrefix(uri, "otpauth://totp/VidFriends:alice@example.com?") {
+		t.Fatalf("unexpected uri %q", uri)
+	}
+	for _, part := range []string{"secret=SECRET", "issuer=VidFriends", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Fatalf("expected %q in %q", part, uri)
+		}
+	}
+}
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestTwoFactor(t *testing.T) (*TwoFactor, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	service := NewTwoFactor(NewInMemoryTwoFactorStore(), testKeyring(t), "VidFriends")
+	service.NowFunc = clock.Now
+	return service, clock
+}
+
+func enrollTwoFactor(t *testing.T, service *TwoFactor, clock *fakeClock) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := service.BeginEnrollment(ctx, "user-1", "alice@example.com")
+	if err != nil {
+		t.Fatalf("begin enrollment: %v", err)
+	}
+	code, err := TOTPCode(enrollment.Secret, clock.now)
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	recoveryCodes, err := service.ConfirmEnrollment(ctx, "user-1", code)
+	if err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	return enrollment.Secret, recoveryCodes
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	service, clock := newTestTwoFactor(t)
+	ctx := context.Background()
+
+	enrollment, err := service.BeginEnrollment(ctx, "user-1", "alice@example.com")
+	if err != nil {
+		t.Fatalf("begin enrollment: %v", err)
+	}
+	if enabled, _ := service.Enabled(ctx, "user-1"); enabled {
+		t.Fatal("expected 2FA to stay disabled until confirmed")
+	}
+
+	if _, err := service.ConfirmEnrollment(ctx, "user-1", "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected wrong code to be rejected, got %v", err)
+	}
+
+	code, _ := TOTPCode(enrollment.Secret, clock.now)
+	recoveryCodes, err := service.ConfirmEnrollment(ctx, "user-1", code)
+	if err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes got %d", recoveryCodeCount, len(recoveryCodes))
+	}
+	if enabled, _ := service.Enabled(ctx, "user-1"); !enabled {
+		t.Fatal("expected 2FA to be enabled")
+	}
+
+	if _, err := service.BeginEnrollment(ctx, "user-1", "alice@example.com"); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Fatalf("expected re-enrollment to be rejected, got %v", err)
+	}
+}
+
+func TestTwoFactorVerifyRejectsReplay(t *testing.T) {
+	service, clock := newTestTwoFactor(t)
+	ctx := context.Background()
+	secret, _ := enrollTwoFactor(t, service, clock)
+
+	code, _ := TOTPCode(secret, clock.now)
+	if err := service.Verify(ctx, "user-1", code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected enrollment code to be spent, got %v", err)
+	}
+
+	clock.now = clock.now.Add(totpPeriod)
+	code, _ = TOTPCode(secret, clock.now)
+	if err := service.Verify(ctx, "user-1", code); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := service.Verify(ctx, "user-1", code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected replayed code to be rejected, got %v", err)
+	}
+
+	clock.now = clock.now.Add(10 * totpPeriod)
+	if err := service.Verify(ctx, "user-1", code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected stale code to be rejected, got %v", err)
+	}
+}
+
+func TestTwoFactorRecoveryCodesAreSingleUse(t *testing.T) {
+	service, clock := newTestTwoFactor(t)
+	ctx := context.Background()
+	secret, recoveryCodes := enrollTwoFactor(t, service, clock)
+
+	if err := service.Verify(ctx, "user-1", strings.ToUpper(recoveryCodes[0])); err != nil {
+		t.Fatalf("verify recovery code: %v", err)
+	}
+	if err := service.Verify(ctx, "user-1", recoveryCodes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected recovery code to be single use, got %v", err)
+	}
+
+	clock.now = clock.now.Add(totpPeriod)
+	code, _ := TOTPCode(secret, clock.now)
+	regenerated, err := service.RegenerateRecoveryCodes(ctx, "user-1", code)
+	if err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if err := service.Verify(ctx, "user-1", recoveryCodes[1]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected old recovery codes to be discarded, got %v", err)
+	}
+	if err := service.Disable(ctx, "user-1", regenerated[0]); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if enabled, _ := service.Enabled(ctx, "user-1"); enabled {
+		t.Fatal("expected 2FA to be disabled")
+	}
+}
+
+func TestTwoFactorChallenge(t *testing.T) {
+	service, clock := newTestTwoFactor(t)
+	ctx := context.Background()
+	secret, _ := enrollTwoFactor(t, service, clock)
+
+	challenge, err := service.IssueChallenge("user-1")
+	if err != nil {
+		t.Fatalf("issue challenge: %v", err)
+	}
+	if _, err := parseAccessToken(service.keys, challenge.Token, clock.now); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Fatalf("expected challenge to be unusable as an access token, got %v", err)
+	}
+
+	clock.now = clock.now.Add(totpPeriod)
+	code, _ := TOTPCode(secret, clock.now)
+	userID, err := service.CompleteChallenge(ctx, challenge.Token, code)
+	if err != nil {
+		t.Fatalf("complete challenge: %v", err)
+	}
+	if userID != "user-1" {
+		t.Fatalf("expected user-1 got %q", userID)
+	}
+
+	clock.now = clock.now.Add(challengeTTL)
+	if _, err := service.CompleteChallenge(ctx, challenge.Token, code); !errors.Is(err, ErrInvalidChallenge) {
+		t.Fatalf("expected expired challenge to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NewInMemoryUserTokenStore returns a UserTokenStore backed by an in-memory map.
+func NewInMemoryUserTokenStore() *InMemoryUserTokenStore {
+	return &InMemoryUserTokenStore{tokens: make(map[string]UserToken)}
+}
+
+// InMemoryUserTokenStore implements UserTokenStore for tests and local development.
+type InMemoryUserTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]UserToken
+}
+
+// Save persists the provided token record.
+func (s *InMemoryUserTokenStore) Save(_ context.Context, token UserToken) error {
+	s.mu.Lock()
+	s.tokens[token.Hash] = token
+	s.mu.Unlock()
+	return nil
+}
+
+// Consume marks the token as redeemed if it is still valid for the purpose.
+func (s *InMemoryUserTokenStore) Consume(_ context.Context, hash string, purpose TokenPurpose, at time.Time) (UserToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[hash]
+	if !ok || token.Purpose != purpose || token.ConsumedAt != nil || !at.Before(token.ExpiresAt) {
+		return UserToken{}, ErrUserTokenInvalid
+	}
+	consumedAt := at.UTC()
+	token.ConsumedAt = &consumedAt
+	s.tokens[hash] = token
+	return token, nil
+}
+
+// DeleteForUser removes the user's tokens for the purpose.
+func (s *InMemoryUserTokenStore) DeleteForUser(_ context.Context, userID string, purpose TokenPurpose) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.tokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// Len reports how many tokens are stored. Useful for tests.
+func (s *InMemoryUserTokenStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tokens)
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrUserTokenInvalid indicates a single-use token is unknown, expired, or already redeemed.
+var ErrUserTokenInvalid = errors.New("user token invalid")
+
+// TokenPurpose scopes a single-use token to the flow it was issued for.
+type TokenPurpose string
+
+const (
+	// PurposePasswordReset marks tokens e-mailed to users who forgot their password.
+	PurposePasswordReset TokenPurpose = "password_reset"
+	// PurposeEmailVerification marks tokens proving the user controls their e-mail address.
+	PurposeEmailVerification TokenPurpose = "email_verification"
+	// PurposeEmailChange marks tokens e-mailed to a new address the user wants to switch to. The
+	// payload holds the new address.
+	PurposeEmailChange TokenPurpose = "email_change"
+)
+
+// UserToken is a single-use token e-mailed to a user. Only the SHA-256 hash of the token is
+// stored so a leaked table cannot be replayed.
+type UserToken struct {
+	Hash       string
+	UserID     string
+	Purpose    TokenPurpose
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time
+	CreatedAt  time.Time
+	// Payload carries flow specific data, such as the pending address of an e-mail change.
+	Payload string
+}
+
+// UserTokenStore persists hashed single-use tokens.
+type UserTokenStore interface {
+	Save(ctx context.Context, token UserToken) error
+	// Consume atomically marks the token as redeemed and returns it. It returns
+	// ErrUserTokenInvalid when the token is unknown, expired, already consumed, or was issued
+	// for a different purpose.
+	Consume(ctx context.Context, hash string, purpose TokenPurpose, at time.Time) (UserToken, error)
+	// DeleteForUser removes the user's outstanding tokens for the purpose.
+	DeleteForUser(ctx context.Context, userID string, purpose TokenPurpose) error
+}
+
+// UserTokens issues and redeems single-use tokens for account recovery flows.
+type UserTokens struct {
+	store UserTokenStore
+	now   func() time.Time
+}
+
+// NewUserTokens constructs a UserTokens service backed by the store.
+func NewUserTokens(store UserTokenStore) *UserTokens {
+	if store == nil {
+		panic("auth: user token store must not be nil")
+	}
+	return &UserTokens{store: store, now: func() time.Time { return time.Now().UTC() }}
+}
+
+// Issue creates a token for the user that expires after ttl. Earlier tokens issued for the same
+// purpose stop working so only the most recent e-mail can be used.
+func (t *UserTokens) Issue(ctx context.Context, userID string, purpose TokenPurpose, ttl time.Duration) (string, error) {
+	return t.IssueWithPayload(ctx, userID, purpose, ttl, "")
+}
+
+// IssueWithPayload behaves like Issue and stores payload alongside the token so Redeem can return
+// it.
+func (t *UserTokens) IssueWithPayload(ctx context.Context, userID string, purpose TokenPurpose, ttl time.Duration, payload string) (string, error) {
+	if userID == "" {
+		return "", errors.New("user id must be provided")
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := t.store.DeleteForUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	now := t.now()
+	if err := t.store.Save(ctx, UserToken{
+		Hash:      hashUserToken(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		Payload:   payload,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Consume redeems the token and returns the user it was issued to.
+func (t *UserTokens) Consume(ctx context.Context, token string, purpose TokenPurpose) (string, error) {
+	consumed, err := t.Redeem(ctx, token, purpose)
+	if err != nil {
+		return "", err
+	}
+	return consumed.UserID, nil
+}
+
+// Redeem consumes the token and returns its record, including the payload it was issued with.
+func (t *UserTokens) Redeem(ctx context.Context, token string, purpose TokenPurpose) (UserToken, error) {
+	if token == "" {
+		return UserToken{}, ErrUserTokenInvalid
+	}
+
+	consumed, err := t.store.Consume(ctx, hashUserToken(token), purpose, t.now())
+	if err != nil {
+		return UserToken{}, err
+	}
+
+	_ = t.store.DeleteForUser(ctx, consumed.UserID, purpose)
+	return consumed, nil
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUserTokensIssueAndConsume(t *testing.T) {
+	store := NewInMemoryUserTokenStore()
+	tokens := NewUserTokens(store)
+
+	token, err := tokens.Issue(context.Background(), "user-1", PurposePasswordReset, time.Hour)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if _, ok := store.tokens[token]; ok {
+		t.Fatal("expected only the token hash to be stored")
+	}
+
+	if _, err := tokens.Consume(context.Background(), token, "email_verification"); err != ErrUserTokenInvalid {
+		t.Fatalf("expected token to be scoped to its purpose, got %v", err)
+	}
+
+	userID, err := tokens.Consume(context.Background(), token, PurposePasswordReset)
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if userID != "user-1" {
+		t.Fatalf("expected user-1 got %q", userID)
+	}
+
+	if _, err := tokens.Consume(context.Background(), token, PurposePasswordReset); err != ErrUserTokenInvalid {
+		t.Fatalf("expected token to be single use, got %v", err)
+	}
+}
+
+func TestUserTokensReissueInvalidatesPrevious(t *testing.T) {
+	tokens := NewUserTokens(NewInMemoryUserTokenStore())
+
+	first, err := tokens.Issue(context.Background(), "user-1", PurposePasswordReset, time.Hour)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	second, err := tokens.Issue(context.Background(), "user-1", PurposePasswordReset, time.Hour)
+	if err != nil {
+		t.Fatalf("issue again: %v", err)
+	}
+
+	if _, err := tokens.Consume(context.Background(), first, PurposePasswordReset); err != ErrUserTokenInvalid {
+		t.Fatalf("expected superseded token to be rejected, got %v", err)
+	}
+	if _, err := tokens.Consume(context.Background(), second, PurposePasswordReset); err != nil {
+		t.Fatalf("consume latest token: %v", err)
+	}
+}
+
+func TestUserTokensExpire(t *testing.T) {
+	tokens := NewUserTokens(NewInMemoryUserTokenStore())
+
+	token, err := tokens.Issue(context.Background(), "user-1", PurposePasswordReset, time.Hour)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	tokens.now = func() time.Time { return time.Now().UTC().Add(2 * time.Hour) }
+	if _, err := tokens.Consume(context.Background(), token, PurposePasswordReset); err != ErrUserTokenInvalid {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+	if _, err := tokens.Consume(context.Background(), "", PurposePasswordReset); err != ErrUserTokenInvalid {
+		t.Fatalf("expected empty token to be rejected, got %v", err)
+	}
+}
+
+func TestUserTokensRedeemReturnsPayload(t *testing.T) {
+	tokens := NewUserTokens(NewInMemoryUserTokenStore())
+
+	token, err := tokens.IssueWithPayload(context.Background(), "user-1", PurposeEmailChange, time.Hour, "new@example.com")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	redeemed, err := tokens.Redeem(context.Background(), token, PurposeEmailChange)
+	if err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	if redeemed.UserID != "user-1" || redeemed.Payload != "new@example.com" {
+		t.Fatalf("unexpected token %+v", redeemed)
+	}
+
+	if _, err := tokens.Redeem(context.Background(), token, PurposeEmailChange); err != ErrUserTokenInvalid {
+		t.Fatalf("expected token to be single use, got %v", err)
+	}
+}
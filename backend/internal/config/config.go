@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,7 +18,59 @@ type Config struct {
 	YTDLPPath        string
 	YTDLPTimeout     time.Duration
 	MetadataCacheTTL time.Duration
+	SessionSecret    string
+	AccessTokenKeys  AccessTokenKeyConfig
 	ObjectStore      ObjectStoreConfig
+	Mail             MailConfig
+	// AppBaseURL is the public URL of the web app, used to build links in e-mails.
+	AppBaseURL string
+	// EmailVerificationPolicy is "flag" to let unverified users act while marking their
+	// requests, or "restrict" to block them from sharing videos and sending invites.
+	EmailVerificationPolicy string
+	// OIDCProviders lists the external identity providers users may sign in with.
+	OIDCProviders []OIDCProviderConfig
+	PasswordHash  PasswordHashConfig
+}
+
+// PasswordHashConfig selects how new password hashes are written. Algorithm is "argon2id" or
+// "bcrypt"; existing hashes in the other format keep working and are upgraded at login.
+type PasswordHashConfig struct {
+	Algorithm         string
+	Argon2MemoryKiB   int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+}
+
+// OIDCProviderConfig describes an OpenID Connect identity provider. Name appears in the login
+// URLs, IssuerURL is used for discovery, and RedirectURL must point at the provider's callback
+// route and be registered with the provider.
+type OIDCProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// MailConfig selects how transactional e-mail is delivered. Driver is "smtp", "file" (write
+// messages to OutboxDir) or "log".
+type MailConfig struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string
+}
+
+// AccessTokenKeyConfig locates the HMAC keys used to sign and verify access tokens. When no
+// signing key file is configured the backend falls back to SESSION_SECRET.
+type AccessTokenKeyConfig struct {
+	SigningKeyFile       string
+	VerificationKeyFiles []string
 }
 
 // ObjectStoreConfig captures configuration for the S3/MinIO compatible storage
@@ -40,17 +94,104 @@ func Load() (Config, error) {
 		YTDLPPath:        getString("VIDFRIENDS_YTDLP_PATH", "yt-dlp"),
 		YTDLPTimeout:     getDuration("VIDFRIENDS_YTDLP_TIMEOUT", 30*time.Second),
 		MetadataCacheTTL: getDuration("VIDFRIENDS_METADATA_CACHE_TTL", 15*time.Minute),
+		SessionSecret:    os.Getenv("SESSION_SECRET"),
+		AccessTokenKeys: AccessTokenKeyConfig{
+			SigningKeyFile:       os.Getenv("VIDFRIENDS_ACCESS_TOKEN_SIGNING_KEY_FILE"),
+			VerificationKeyFiles: getList("VIDFRIENDS_ACCESS_TOKEN_VERIFICATION_KEY_FILES"),
+		},
 		ObjectStore: ObjectStoreConfig{
 			Endpoint:      getString("VIDFRIENDS_S3_ENDPOINT", "http://localhost:9000"),
 			Bucket:        getString("VIDFRIENDS_S3_BUCKET", "vidfriends"),
 			Region:        getString("VIDFRIENDS_S3_REGION", "us-east-1"),
 			PublicBaseURL: getString("VIDFRIENDS_S3_PUBLIC_BASE_URL", "http://localhost:9000/vidfriends"),
 		},
+		Mail: MailConfig{
+			Driver:       getString("VIDFRIENDS_MAIL_DRIVER", "log"),
+			From:         getString("VIDFRIENDS_MAIL_FROM", "VidFriends <no-reply@vidfriends.local>"),
+			SMTPHost:     os.Getenv("VIDFRIENDS_SMTP_HOST"),
+			SMTPPort:     getInt("VIDFRIENDS_SMTP_PORT", 587),
+			SMTPUsername: os.Getenv("VIDFRIENDS_SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("VIDFRIENDS_SMTP_PASSWORD"),
+			OutboxDir:    getString("VIDFRIENDS_MAIL_OUTBOX_DIR", "tmp/outbox"),
+		},
+		AppBaseURL:              getString("VIDFRIENDS_APP_BASE_URL", "http://localhost:5173"),
+		EmailVerificationPolicy: strings.ToLower(getString("VIDFRIENDS_EMAIL_VERIFICATION_POLICY", "flag")),
+		PasswordHash: PasswordHashConfig{
+			Algorithm:         strings.ToLower(getString("VIDFRIENDS_PASSWORD_HASH_ALGORITHM", "argon2id")),
+			Argon2MemoryKiB:   getInt("VIDFRIENDS_ARGON2_MEMORY_KIB", 19*1024),
+			Argon2Iterations:  getInt("VIDFRIENDS_ARGON2_ITERATIONS", 2),
+			Argon2Parallelism: getInt("VIDFRIENDS_ARGON2_PARALLELISM", 1),
+			BcryptCost:        getInt("VIDFRIENDS_BCRYPT_COST", 10),
+		},
+	}
+
+	switch cfg.EmailVerificationPolicy {
+	case "flag", "restrict":
+	default:
+		return Config{}, fmt.Errorf("VIDFRIENDS_EMAIL_VERIFICATION_POLICY must be \"flag\" or \"restrict\", got %q", cfg.EmailVerificationPolicy)
+	}
+
+	switch cfg.PasswordHash.Algorithm {
+	case "argon2id", "bcrypt":
+	default:
+		return Config{}, fmt.Errorf("VIDFRIENDS_PASSWORD_HASH_ALGORITHM must be \"argon2id\" or \"bcrypt\", got %q", cfg.PasswordHash.Algorithm)
 	}
 
+	providers, err := loadOIDCProviders()
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.OIDCProviders = providers
+
 	return cfg, nil
 }
 
+// loadOIDCProviders reads VIDFRIENDS_OIDC_PROVIDERS, a comma separated list of provider names,
+// and the VIDFRIENDS_OIDC_<NAME>_* settings of each provider.
+func loadOIDCProviders() ([]OIDCProviderConfig, error) {
+	var providers []OIDCProviderConfig
+	for _, name := range getList("VIDFRIENDS_OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		if !validProviderName(name) {
+			return nil, fmt.Errorf("VIDFRIENDS_OIDC_PROVIDERS: provider name %q must contain only letters, digits and underscores", name)
+		}
+
+		prefix := "VIDFRIENDS_OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       getList(prefix + "SCOPES"),
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+
+		for key, value := range map[string]string{"ISSUER_URL": provider.IssuerURL, "CLIENT_ID": provider.ClientID, "REDIRECT_URL": provider.RedirectURL} {
+			if value == "" {
+				return nil, fmt.Errorf("%s%s is required for OIDC provider %q", prefix, key, name)
+			}
+		}
+
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+func validProviderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
 func getString(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -58,6 +199,16 @@ func getString(key, fallback string) string {
 	return fallback
 }
 
+func getList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getInt(key string, fallback int) int {
 	value := os.Getenv(key)
 	if value == "" {
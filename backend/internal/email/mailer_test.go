@@ -0,0 +1,104 @@
+package email
+
+import (
+	"context"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/config"
+)
+
+func TestFileMailerWritesOutbox(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := NewFileMailer(dir, "VidFriends <no-reply@vidfriends.local>")
+	if err != nil {
+		t.Fatalf("new file mailer: %v", err)
+	}
+
+	msg := Message{To: "user@example.com", Subject: "Reset your password", Body: "line one\nline two"}
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	if len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".eml" {
+		t.Fatalf("expected one .eml file, got %v", entries)
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	for _, want := range []string{"To: user@example.com\r\n", "Subject: Reset your password\r\n", "\r\n\r\nline one\r\nline two"} {
+		if !strings.Contains(string(contents), want) {
+			t.Fatalf("expected message to contain %q, got %q", want, contents)
+		}
+	}
+}
+
+func TestMailerRejectsHeaderInjection(t *testing.T) {
+	mailer := NewLogMailer("no-reply@vidfriends.local")
+
+	err := mailer.Send(context.Background(), Message{To: "user@example.com\r\nBcc: victim@example.com", Subject: "hi"})
+	if err == nil {
+		t.Fatal("expected header injection to be rejected")
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	mailer, err := NewSMTPMailer(config.MailConfig{
+		From:         "no-reply@vidfriends.local",
+		SMTPHost:     "smtp.example.com",
+		SMTPPort:     587,
+		SMTPUsername: "mailer",
+		SMTPPassword: "secret",
+	})
+	if err != nil {
+		t.Fatalf("new smtp mailer: %v", err)
+	}
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	mailer.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if a == nil {
+			t.Fatal("expected credentials to be used")
+		}
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		return nil
+	}
+
+	if err := mailer.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Hi"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" || gotFrom != "no-reply@vidfriends.local" || len(gotTo) != 1 || gotTo[0] != "user@example.com" {
+		t.Fatalf("unexpected envelope %s %s %v", gotAddr, gotFrom, gotTo)
+	}
+	if !strings.Contains(string(gotMsg), "From: no-reply@vidfriends.local\r\n") {
+		t.Fatalf("unexpected message %q", gotMsg)
+	}
+}
+
+func TestNewSelectsDriver(t *testing.T) {
+	if _, err := New(config.MailConfig{Driver: "carrier-pigeon"}); err == nil {
+		t.Fatal("expected unknown driver to be rejected")
+	}
+	if _, err := New(config.MailConfig{Driver: "smtp", From: "no-reply@vidfriends.local"}); err == nil {
+		t.Fatal("expected smtp driver without host to be rejected")
+	}
+
+	mailer, err := New(config.MailConfig{})
+	if err != nil {
+		t.Fatalf("default driver: %v", err)
+	}
+	if _, ok := mailer.(*LogMailer); !ok {
+		t.Fatalf("expected log mailer by default, got %T", mailer)
+	}
+}
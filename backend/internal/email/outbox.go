@@ -0,0 +1,74 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+)
+
+// FileMailer writes each message as an .eml file into an outbox directory instead of sending
+// it. It is intended for local development and tests.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates the outbox directory if necessary and returns a mailer writing to it.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("file mailer: outbox directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("file mailer: create outbox: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send stores the message in the outbox.
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), uuid.NewString())
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, render(m.from, msg, now), 0o600); err != nil {
+		return fmt.Errorf("file mailer: write message: %w", err)
+	}
+	return nil
+}
+
+// LogMailer logs messages, including their bodies, instead of sending them. It is the default
+// for local development so links such as password resets can be copied from the server log.
+type LogMailer struct {
+	from string
+}
+
+// NewLogMailer returns a mailer that writes messages to the request logger.
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+// Send logs the message.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("outgoing email",
+		slog.String("from", m.from),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
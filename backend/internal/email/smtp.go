@@ -0,0 +1,59 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vidfriends/backend/internal/config"
+)
+
+// SMTPMailer delivers messages through an SMTP relay. Connections are upgraded with STARTTLS
+// when the server offers it.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer configures a mailer that relays through cfg.SMTPHost.
+func NewSMTPMailer(cfg config.MailConfig) (*SMTPMailer, error) {
+	if strings.TrimSpace(cfg.SMTPHost) == "" {
+		return nil, fmt.Errorf("smtp mailer: host is required")
+	}
+	if strings.TrimSpace(cfg.From) == "" {
+		return nil, fmt.Errorf("smtp mailer: from address is required")
+	}
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		from: cfg.From,
+		auth: auth,
+		send: smtp.SendMail,
+	}, nil
+}
+
+// Send relays the message to the configured SMTP server.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := m.send(m.addr, m.auth, m.from, []string{msg.To}, render(m.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
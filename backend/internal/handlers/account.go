@@ -0,0 +1,525 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/profiles"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// emailChangeTTL bounds how long the confirmation link sent to a new e-mail address stays usable.
+const emailChangeTTL = 24 * time.Hour
+
+// AccountHandler lets signed-in users change their password and e-mail address and delete their
+// account.
+type AccountHandler struct {
+	Users    UserStore
+	Sessions SessionManager
+	Tokens   UserTokenManager
+	Mailer   Mailer
+	// Passwords is optional; when nil, passwords are hashed with auth.DefaultPasswordHashConfig.
+	Passwords PasswordHasher
+	// APITokens is optional; when set, changing the password also revokes the user's personal
+	// access tokens.
+	APITokens APITokenService
+	Videos    VideoStore
+	// Ingestor is optional; when nil, deleting an account does not stop pending video downloads.
+	Ingestor VideoAssetIngestor
+	// Assets is optional; when nil, deleting an account leaves the stored video files in place.
+	Assets      AssetRemover
+	AppBaseURL  string
+	NowFunc     func() time.Time
+	RateLimiter RateLimiter
+}
+
+// ChangePassword handles POST /api/v1/auth/password requests. The current password must be
+// supplied, and every other session and personal access token of the user is ended once the new
+// password is stored.
+func (h AccountHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AccountHandler.ChangePassword")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "account:password") {
+		logger.Warn("rate limit exceeded", "scope", "account:password")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many password change attempts"})
+		return
+	}
+
+	if h.Users == nil || h.Sessions == nil {
+		logger.Error("password change dependencies unavailable", "hasUsers", h.Users != nil, "hasSessions", h.Sessions != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "account services unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid password change payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		logger.Warn("password change missing fields", "userId", userID)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "current and new password are required"})
+		return
+	}
+
+	if len(req.NewPassword) < minPasswordLength || len(req.NewPassword) > maxPasswordLength {
+		logger.Warn("password change password length invalid", "userId", userID, "length", len(req.NewPassword))
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": passwordLengthError})
+		return
+	}
+
+	user, ok := h.confirmPassword(ctx, w, userID, req.CurrentPassword, "password change")
+	if !ok {
+		return
+	}
+
+	hashed, err := h.passwords().Hash(req.NewPassword)
+	if err != nil {
+		if errors.Is(err, auth.ErrPasswordTooLong) {
+			logger.Warn("password change password too long for hash algorithm", "length", len(req.NewPassword))
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "password is too long"})
+			return
+		}
+		logger.Error("password change failed to hash password", "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to secure password"})
+		return
+	}
+
+	user.Password = hashed
+	user.UpdatedAt = h.now()
+	if err := h.Users.Update(ctx, user); err != nil {
+		logger.Error("password change failed to update user", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to change password"})
+		return
+	}
+
+	if err := h.revokeOtherSessions(ctx, user.ID); err != nil {
+		logger.Error("password change failed to revoke other sessions", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "password updated but other sessions could not be ended"})
+		return
+	}
+	if h.APITokens != nil {
+		if err := h.APITokens.RevokeAll(ctx, user.ID); err != nil {
+			logger.Error("password change failed to revoke api tokens", "error", err, "userId", user.ID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "password updated but personal access tokens could not be revoked"})
+			return
+		}
+		logger.Info("all api tokens revoked", "event", "security.api_tokens_revoked", "userId", user.ID, "reason", "password_changed")
+	}
+
+	logger.Info("password changed", "event", "security.password_changed", "userId", user.ID)
+	h.sendNotice(ctx, user.Email, "Your VidFriends password was changed",
+		"The password for your VidFriends account was just changed, your other devices were signed out and your personal access tokens were revoked.\n\n"+
+			"If you did not do this, reset your password right away:\n\n"+strings.TrimRight(h.AppBaseURL, "/")+"/forgot-password\n")
+
+	respondJSON(ctx, w, http.StatusOK, map[string]string{"status": "Your password has been changed. Your other sessions have been signed out and your personal access tokens revoked."})
+}
+
+// ChangeEmail handles POST /api/v1/auth/email requests. The address is not changed until the
+// user follows the confirmation link e-mailed to the new address.
+func (h AccountHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AccountHandler.ChangeEmail")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "account:email") {
+		logger.Warn("rate limit exceeded", "scope", "account:email")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many email change attempts"})
+		return
+	}
+
+	if h.Users == nil || h.Tokens == nil || h.Mailer == nil {
+		logger.Error("email change dependencies unavailable", "hasUsers", h.Users != nil, "hasTokens", h.Tokens != nil, "hasMailer", h.Mailer != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "account services unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req changeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid email change payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	req.NewEmail = strings.TrimSpace(strings.ToLower(req.NewEmail))
+	if req.NewEmail == "" || req.Password == "" {
+		logger.Warn("email change missing fields", "userId", userID)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "new email and password are required"})
+		return
+	}
+
+	if _, err := mail.ParseAddress(req.NewEmail); err != nil {
+		logger.Warn("email change invalid email", "email", req.NewEmail, "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid email address"})
+		return
+	}
+
+	user, ok := h.confirmPassword(ctx, w, userID, req.Password, "email change")
+	if !ok {
+		return
+	}
+
+	if req.NewEmail == user.Email {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "new email matches the current email"})
+		return
+	}
+
+	if _, err := h.Users.FindByEmail(ctx, req.NewEmail); err == nil {
+		logger.Warn("email change address already in use", "userId", user.ID)
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "email already in use"})
+		return
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		logger.Error("email change lookup failed", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to change email"})
+		return
+	}
+
+	token, err := h.Tokens.IssueWithPayload(ctx, user.ID, auth.PurposeEmailChange, emailChangeTTL, req.NewEmail)
+	if err != nil {
+		logger.Error("email change token issue failed", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to change email"})
+		return
+	}
+
+	link := strings.TrimRight(h.AppBaseURL, "/") + "/confirm-email-change?token=" + url.QueryEscape(token)
+	msg := email.Message{
+		To:      req.NewEmail,
+		Subject: "Confirm your new VidFriends email address",
+		Body: fmt.Sprintf("Someone asked to use this address for their VidFriends account.\n\n"+
+			"Open the link below within %d hours to confirm the change:\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this e-mail.\n", int(emailChangeTTL.Hours()), link),
+	}
+	if err := h.Mailer.Send(ctx, msg); err != nil {
+		logger.Error("email change confirmation failed to send", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to send confirmation email"})
+		return
+	}
+
+	logger.Info("email change requested", "event", "security.email_change_requested", "userId", user.ID)
+	h.sendNotice(ctx, user.Email, "Your VidFriends email address is being changed",
+		fmt.Sprintf("Someone signed in to your VidFriends account asked to change its email address to %s. "+
+			"The change takes effect once the new address is confirmed.\n\n"+
+			"If you did not do this, reset your password right away:\n\n%s/forgot-password\n", req.NewEmail, strings.TrimRight(h.AppBaseURL, "/")))
+
+	respondJSON(ctx, w, http.StatusAccepted, map[string]string{"status": "Check your new email address for a confirmation link."})
+}
+
+// ConfirmEmailChange handles POST /api/v1/auth/email/confirm requests. The token from the
+// confirmation e-mail switches the account to the new address and marks it verified.
+func (h AccountHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AccountHandler.ConfirmEmailChange")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "account:email-confirm") {
+		logger.Warn("rate limit exceeded", "scope", "account:email-confirm")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many confirmation attempts"})
+		return
+	}
+
+	if h.Users == nil || h.Tokens == nil {
+		logger.Error("email change dependencies unavailable", "hasUsers", h.Users != nil, "hasTokens", h.Tokens != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "account services unavailable"})
+		return
+	}
+
+	var req confirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid email change confirmation payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" || len(req.Token) > 512 {
+		logger.Warn("email change token missing or too long", "length", len(req.Token))
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired confirmation token"})
+		return
+	}
+
+	token, err := h.Tokens.Redeem(ctx, req.Token, auth.PurposeEmailChange)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserTokenInvalid) {
+			logger.Warn("email change token rejected")
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired confirmation token"})
+			return
+		}
+		logger.Error("email change token lookup failed", "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to change email"})
+		return
+	}
+
+	if token.Payload == "" {
+		logger.Warn("email change token carries no address", "userId", token.UserID)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired confirmation token"})
+		return
+	}
+
+	user, err := h.Users.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			logger.Warn("email change user missing", "userId", token.UserID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired confirmation token"})
+			return
+		}
+		logger.Error("email change user lookup failed", "error", err, "userId", token.UserID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to change email"})
+		return
+	}
+
+	previous := user.Email
+	now := h.now()
+	user.Email = token.Payload
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	if err := h.Users.Update(ctx, user); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			logger.Warn("email change address taken before confirmation", "userId", user.ID)
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "email already in use"})
+			return
+		}
+		logger.Error("email change failed to update user", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to change email"})
+		return
+	}
+
+	logger.Info("email changed", "event", "security.email_changed", "userId", user.ID, "previousEmail", previous, "email", user.Email)
+	respondJSON(ctx, w, http.StatusOK, map[string]string{"status": "Your email address has been changed."})
+}
+
+// DeleteAccount handles DELETE /api/v1/auth/account requests. The stored files of the user's
+// shared videos are removed first; deleting the user then removes every row that references it
+// through ON DELETE CASCADE foreign keys.
+func (h AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AccountHandler.DeleteAccount")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "account:delete") {
+		logger.Warn("rate limit exceeded", "scope", "account:delete")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many account deletion attempts"})
+		return
+	}
+
+	if h.Users == nil || h.Videos == nil {
+		logger.Error("account deletion dependencies unavailable", "hasUsers", h.Users != nil, "hasVideos", h.Videos != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "account services unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req deleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid account deletion payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	if req.Password == "" {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "password is required"})
+		return
+	}
+
+	user, ok := h.confirmPassword(ctx, w, userID, req.Password, "account deletion")
+	if !ok {
+		return
+	}
+
+	if err := h.deleteStoredAssets(ctx, user.ID); err != nil {
+		logger.Error("account deletion failed to remove stored videos", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to delete account"})
+		return
+	}
+
+	if err := h.Users.Delete(ctx, user.ID); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		logger.Error("account deletion failed", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to delete account"})
+		return
+	}
+
+	logger.Info("account deleted", "event", "security.account_deleted", "userId", user.ID)
+	h.sendNotice(ctx, user.Email, "Your VidFriends account was deleted",
+		"Your VidFriends account and the videos you shared have been deleted. We're sorry to see you go.\n")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// confirmPassword loads the user and checks password against their stored hash. It writes the
+// response and returns false when the user cannot be loaded or the password is wrong.
+func (h AccountHandler) confirmPassword(ctx context.Context, w http.ResponseWriter, userID, password, action string) (models.User, bool) {
+	logger := logging.FromContext(ctx)
+
+	user, err := h.Users.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error(action+" user lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load account"})
+		return models.User{}, false
+	}
+
+	matches, _, err := h.passwords().Verify(password, user.Password)
+	if err != nil && !errors.Is(err, auth.ErrUnsupportedPasswordHash) {
+		logger.Error(action+" password verification failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to verify password"})
+		return models.User{}, false
+	}
+	if !matches {
+		logger.Warn(action+" rejected", "event", "security.password_confirmation_failed", "userId", userID)
+		respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "current password is incorrect"})
+		return models.User{}, false
+	}
+
+	return user, true
+}
+
+// revokeOtherSessions ends every session of the user except the one making the request.
+func (h AccountHandler) revokeOtherSessions(ctx context.Context, userID string) error {
+	sessions, err := h.Sessions.Sessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	current := logging.SessionIDFromContext(ctx)
+	for _, session := range sessions {
+		if session.ID == current {
+			continue
+		}
+		if err := h.Sessions.RevokeSession(ctx, userID, session.ID); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteStoredAssets stops the pending downloads of every video the user shared and removes their
+// stored files and the user's avatars. Each download is cancelled before its files are removed so
+// that it cannot upload files after them.
+func (h AccountHandler) deleteStoredAssets(ctx context.Context, userID string) error {
+	if h.Assets == nil && h.Ingestor == nil {
+		logging.FromContext(ctx).Warn("asset storage unavailable; stored videos were not removed", "userId", userID)
+		return nil
+	}
+
+	shares, err := h.Videos.ListByOwner(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list shared videos: %w", err)
+	}
+	for _, share := range shares {
+		if h.Ingestor != nil {
+			if err := h.Ingestor.Cancel(ctx, share.ID); err != nil {
+				return fmt.Errorf("cancel asset ingestion of share %s: %w", share.ID, err)
+			}
+		}
+		if h.Assets != nil {
+			if err := h.Assets.DeletePrefix(ctx, share.ID+"/"); err != nil {
+				return fmt.Errorf("delete assets of share %s: %w", share.ID, err)
+			}
+		}
+	}
+	if h.Assets == nil {
+		logging.FromContext(ctx).Warn("asset storage unavailable; stored videos were not removed", "userId", userID)
+		return nil
+	}
+	if err := h.Assets.DeletePrefix(ctx, profiles.AvatarPrefix(userID)); err != nil {
+		return fmt.Errorf("delete avatars: %w", err)
+	}
+	return nil
+}
+
+// sendNotice e-mails the account owner about a security relevant change. Failures are logged.
+func (h AccountHandler) sendNotice(ctx context.Context, to, subject, body string) {
+	if h.Mailer == nil {
+		return
+	}
+	if err := h.Mailer.Send(ctx, email.Message{To: to, Subject: subject, Body: body}); err != nil {
+		logging.FromContext(ctx).Error("account notice email failed", "error", err, "subject", subject)
+	}
+}
+
+func (h AccountHandler) passwords() PasswordHasher {
+	if h.Passwords != nil {
+		return h.Passwords
+	}
+	return defaultPasswordHasher
+}
+
+func (h AccountHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type changeEmailRequest struct {
+	NewEmail string `json:"newEmail"`
+	Password string `json:"password"`
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+type recordingAssetRemover struct {
+	prefixes []string
+	err      error
+}
+
+func (r *recordingAssetRemover) DeletePrefix(_ context.Context, prefix string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.prefixes = append(r.prefixes, prefix)
+	return nil
+}
+
+// pendingIngestor stands in for download jobs that keep writing to storage until they are
+// cancelled. It records the shares whose files were removed while their job could still run.
+type pendingIngestor struct {
+	pending  map[string]bool
+	files    *recordingAssetRemover
+	orphaned []string
+}
+
+func (p *pendingIngestor) Enqueue(_ context.Context, share models.VideoShare) error {
+	p.pending[share.ID] = true
+	return nil
+}
+
+func (p *pendingIngestor) Cancel(_ context.Context, shareID string) error {
+	if p.pending[shareID] && slices.Contains(p.files.prefixes, shareID+"/") {
+		p.orphaned = append(p.orphaned, shareID)
+	}
+	delete(p.pending, shareID)
+	return nil
+}
+
+type accountFixture struct {
+	mux       *http.ServeMux
+	users     *inMemoryUserStore
+	sessions  *auth.Manager
+	mailer    *recordingMailer
+	videos    *videoStoreStub
+	assets    *recordingAssetRemover
+	ingestor  *pendingIngestor
+	apiTokens *auth.APITokens
+}
+
+func newAccountFixture(t *testing.T) accountFixture {
+	t.Helper()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	f := accountFixture{
+		mux:       http.NewServeMux(),
+		users:     newInMemoryUserStore(),
+		sessions:  newSessionManager(),
+		mailer:    &recordingMailer{},
+		videos:    &videoStoreStub{},
+		assets:    &recordingAssetRemover{},
+		apiTokens: auth.NewAPITokens(auth.NewInMemoryAPITokenStore()),
+	}
+	f.ingestor = &pendingIngestor{pending: make(map[string]bool), files: f.assets}
+	f.users.users["user@example.com"] = models.User{ID: "user-1", Email: "user@example.com", Password: string(hashed)}
+
+	RegisterRoutes(f.mux, Dependencies{
+		Users:       f.users,
+		Sessions:    f.sessions,
+		Tokens:      auth.NewUserTokens(auth.NewInMemoryUserTokenStore()),
+		Mailer:      f.mailer,
+		Videos:      f.videos,
+		VideoAssets: f.ingestor,
+		APITokens:   f.apiTokens,
+		Assets:      f.assets,
+		AppBaseURL:  "https://vidfriends.example",
+	})
+	return f
+}
+
+func (f accountFixture) do(t *testing.T, method, path, accessToken string, payload any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	rec := httptest.NewRecorder()
+	f.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAccountHandlerChangePassword(t *testing.T) {
+	f := newAccountFixture(t)
+
+	current, err := f.sessions.Issue(context.Background(), "user-1", auth.ClientInfo{UserAgent: "laptop"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	other, err := f.sessions.Issue(context.Background(), "user-1", auth.ClientInfo{UserAgent: "phone"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	_, secret, err := f.apiTokens.Create(context.Background(), "user-1", "ci", []string{auth.ScopeFeedRead}, nil)
+	if err != nil {
+		t.Fatalf("create api token: %v", err)
+	}
+	_, otherUserSecret, err := f.apiTokens.Create(context.Background(), "user-2", "ci", []string{auth.ScopeFeedRead}, nil)
+	if err != nil {
+		t.Fatalf("create api token: %v", err)
+	}
+
+	rec := f.do(t, http.MethodPost, "/api/v1/auth/password", current.AccessToken, changePasswordRequest{CurrentPassword: "wrongpass", NewPassword: "newpassword"})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected wrong current password to be rejected, got %d", rec.Code)
+	}
+
+	rec = f.do(t, http.MethodPost, "/api/v1/auth/password", current.AccessToken, changePasswordRequest{CurrentPassword: "password123", NewPassword: "short"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected short password to be rejected, got %d", rec.Code)
+	}
+
+	rec = f.do(t, http.MethodPost, "/api/v1/auth/password", current.AccessToken, changePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if ok, _, err := defaultPasswordHasher.Verify("newpassword", f.users.users["user@example.com"].Password); err != nil || !ok {
+		t.Fatalf("expected new password to be stored, ok=%v err=%v", ok, err)
+	}
+
+	if _, err := f.sessions.Refresh(context.Background(), other.RefreshToken, auth.ClientInfo{}); err == nil {
+		t.Fatal("expected other session to be revoked")
+	}
+	if _, err := f.sessions.Refresh(context.Background(), current.RefreshToken, auth.ClientInfo{}); err != nil {
+		t.Fatalf("expected current session to survive: %v", err)
+	}
+	if _, err := f.apiTokens.Authenticate(context.Background(), secret); !errors.Is(err, auth.ErrInvalidAPIToken) {
+		t.Fatalf("expected personal access tokens to be revoked, got %v", err)
+	}
+	if _, err := f.apiTokens.Authenticate(context.Background(), otherUserSecret); err != nil {
+		t.Fatalf("expected other users' tokens to keep working: %v", err)
+	}
+
+	if len(f.mailer.messages) != 1 || f.mailer.messages[0].To != "user@example.com" {
+		t.Fatalf("expected a password change notice, got %+v", f.mailer.messages)
+	}
+}
+
+func TestAccountHandlerChangeEmail(t *testing.T) {
+	f := newAccountFixture(t)
+	f.users.users["taken@example.com"] = models.User{ID: "user-2", Email: "taken@example.com"}
+
+	tokens, err := f.sessions.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		request changeEmailRequest
+		want    int
+	}{
+		{"wrongPassword", changeEmailRequest{NewEmail: "new@example.com", Password: "wrongpass"}, http.StatusForbidden},
+		{"invalidEmail", changeEmailRequest{NewEmail: "not-an-email", Password: "password123"}, http.StatusBadRequest},
+		{"sameEmail", changeEmailRequest{NewEmail: "User@Example.com", Password: "password123"}, http.StatusBadRequest},
+		{"taken", changeEmailRequest{NewEmail: "taken@example.com", Password: "password123"}, http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := f.do(t, http.MethodPost, "/api/v1/auth/email", tokens.AccessToken, tc.request); rec.Code != tc.want {
+				t.Fatalf("expected %d got %d", tc.want, rec.Code)
+			}
+		})
+	}
+	if len(f.mailer.messages) != 0 {
+		t.Fatalf("expected rejected requests to send no mail, got %+v", f.mailer.messages)
+	}
+
+	rec := f.do(t, http.MethodPost, "/api/v1/auth/email", tokens.AccessToken, changeEmailRequest{NewEmail: "New@Example.com", Password: "password123"})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := f.users.users["user@example.com"]; !ok {
+		t.Fatal("expected the address to stay unchanged until confirmed")
+	}
+	if len(f.mailer.messages) != 2 || f.mailer.messages[0].To != "new@example.com" || f.mailer.messages[1].To != "user@example.com" {
+		t.Fatalf("expected a confirmation to the new address and a notice to the old one, got %+v", f.mailer.messages)
+	}
+
+	token := tokenFromLink(t, f.mailer.messages[0].Body)
+	rec = f.do(t, http.MethodPost, "/api/v1/auth/email/confirm", "", confirmEmailChangeRequest{Token: token})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	user, ok := f.users.users["new@example.com"]
+	if !ok || user.ID != "user-1" || user.EmailVerifiedAt == nil {
+		t.Fatalf("expected account to move to the verified new address, got %+v", f.users.users)
+	}
+
+	if rec := f.do(t, http.MethodPost, "/api/v1/auth/email/confirm", "", confirmEmailChangeRequest{Token: token}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected used token to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestAccountHandlerConfirmEmailChangeConflict(t *testing.T) {
+	f := newAccountFixture(t)
+
+	tokens, err := f.sessions.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if rec := f.do(t, http.MethodPost, "/api/v1/auth/email", tokens.AccessToken, changeEmailRequest{NewEmail: "new@example.com", Password: "password123"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 got %d", rec.Code)
+	}
+	f.users.users["new@example.com"] = models.User{ID: "user-2", Email: "new@example.com"}
+
+	rec := f.do(t, http.MethodPost, "/api/v1/auth/email/confirm", "", confirmEmailChangeRequest{Token: tokenFromLink(t, f.mailer.messages[0].Body)})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when the address was taken meanwhile, got %d", rec.Code)
+	}
+	if f.users.users["user@example.com"].ID != "user-1" {
+		t.Fatal("expected the account to keep its address")
+	}
+}
+
+func TestAccountHandlerDeleteAccount(t *testing.T) {
+	f := newAccountFixture(t)
+	f.videos.owned = []models.VideoShare{
+		{ID: "share-1", OwnerID: "user-1"},
+		{ID: "share-2", OwnerID: "user-1"},
+		{ID: "share-3", OwnerID: "user-2"},
+	}
+
+	tokens, err := f.sessions.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if rec := f.do(t, http.MethodDelete, "/api/v1/auth/account", tokens.AccessToken, deleteAccountRequest{Password: "wrongpass"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected wrong password to be rejected, got %d", rec.Code)
+	}
+
+	f.assets.err = errors.New("bucket unavailable")
+	if rec := f.do(t, http.MethodDelete, "/api/v1/auth/account", tokens.AccessToken, deleteAccountRequest{Password: "password123"}); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected storage failure to abort deletion, got %d", rec.Code)
+	}
+	if _, ok := f.users.users["user@example.com"]; !ok {
+		t.Fatal("expected account to remain when its videos could not be removed")
+	}
+
+	f.assets.err = nil
+	rec := f.do(t, http.MethodDelete, "/api/v1/auth/account", tokens.AccessToken, deleteAccountRequest{Password: "password123"})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if _, ok := f.users.users["user@example.com"]; ok {
+		t.Fatal("expected account to be deleted")
+	}
+	if len(f.assets.prefixes) != 3 || f.assets.prefixes[0] != "share-1/" || f.assets.prefixes[1] != "share-2/" || f.assets.prefixes[2] != "avatars/user-1/" {
+		t.Fatalf("expected only the user's assets to be removed, got %v", f.assets.prefixes)
+	}
+}
+
+func TestAccountHandlerDeleteAccountCancelsPendingIngestion(t *testing.T) {
+	f := newAccountFixture(t)
+	f.videos.owned = []models.VideoShare{
+		{ID: "share-1", OwnerID: "user-1", AssetStatus: models.AssetStatusPending},
+		{ID: "share-2", OwnerID: "user-1", AssetStatus: models.AssetStatusReady},
+	}
+	f.ingestor.pending["share-1"] = true
+	f.ingestor.pending["share-9"] = true
+
+	tokens, err := f.sessions.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	rec := f.do(t, http.MethodDelete, "/api/v1/auth/account", tokens.AccessToken, deleteAccountRequest{Password: "password123"})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if f.ingestor.pending["share-1"] {
+		t.Fatal("expected the pending download of the user's share to be cancelled")
+	}
+	if len(f.ingestor.orphaned) != 0 {
+		t.Fatalf("expected downloads to be cancelled before their files were removed, got %v", f.ingestor.orphaned)
+	}
+	if !f.ingestor.pending["share-9"] {
+		t.Fatal("expected other users' downloads to keep running")
+	}
+}
+
+// Accounts created through an external provider have a password nobody knows. The documented way
+// to delete them, or to change their e-mail address, is to set a password through a reset first.
+func TestAccountHandlerDeleteAccountCreatedByExternalSignIn(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	user, err := OIDCHandler{Users: f.users}.createUser(ctx, "oidc@example.com")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tokens, err := f.sessions.Issue(ctx, user.ID, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if rec := f.do(t, http.MethodDelete, "/api/v1/auth/account", tokens.AccessToken, deleteAccountRequest{Password: "password123"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected the unknown password to be required, got %d", rec.Code)
+	}
+
+	if rec := f.do(t, http.MethodPost, "/api/v1/auth/password-reset", "", passwordResetRequest{Email: user.Email}); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected reset to be requested, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(f.mailer.messages) != 1 || f.mailer.messages[0].To != user.Email {
+		t.Fatalf("expected a reset e-mail to the account, got %+v", f.mailer.messages)
+	}
+	_, link, found := strings.Cut(f.mailer.messages[0].Body, "/reset-password?token=")
+	if !found {
+		t.Fatalf("expected a reset link, got %q", f.mailer.messages[0].Body)
+	}
+	token, err := url.QueryUnescape(strings.Fields(link)[0])
+	if err != nil {
+		t.Fatalf("unescape token: %v", err)
+	}
+	if rec := f.do(t, http.MethodPost, "/api/v1/auth/password-reset/confirm", "", confirmPasswordResetRequest{Token: token, Password: "chosen-password"}); rec.Code != http.StatusOK {
+		t.Fatalf("expected reset to be confirmed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The reset signs the user out everywhere, so they sign in again before deleting.
+	tokens, err = f.sessions.Issue(ctx, user.ID, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if rec := f.do(t, http.MethodDelete, "/api/v1/auth/account", tokens.AccessToken, deleteAccountRequest{Password: "chosen-password"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := f.users.users[user.Email]; ok {
+		t.Fatal("expected account to be deleted")
+	}
+}
+
+func TestAccountHandlerValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		userID  string
+		want    int
+	}{
+		{"passwordMethod", AccountHandler{}.ChangePassword, http.MethodGet, "user-1", http.StatusMethodNotAllowed},
+		{"passwordMissingDeps", AccountHandler{}.ChangePassword, http.MethodPost, "user-1", http.StatusInternalServerError},
+		{"passwordUnauthenticated", AccountHandler{Users: newInMemoryUserStore(), Sessions: newSessionManager()}.ChangePassword, http.MethodPost, "", http.StatusUnauthorized},
+		{"emailMissingMailer", AccountHandler{Users: newInMemoryUserStore(), Tokens: auth.NewUserTokens(auth.NewInMemoryUserTokenStore())}.ChangeEmail, http.MethodPost, "user-1", http.StatusInternalServerError},
+		{"confirmMethod", AccountHandler{}.ConfirmEmailChange, http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"deleteMethod", AccountHandler{}.DeleteAccount, http.MethodPost, "user-1", http.StatusMethodNotAllowed},
+		{"deleteMissingVideos", AccountHandler{Users: newInMemoryUserStore()}.DeleteAccount, http.MethodDelete, "user-1", http.StatusInternalServerError},
+		{"rateLimited", AccountHandler{RateLimiter: stubRateLimiter{allow: false}}.DeleteAccount, http.MethodDelete, "user-1", http.StatusTooManyRequests},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", bytes.NewReader([]byte(`{}`)))
+			if tc.userID != "" {
+				req = withUser(req, tc.userID)
+			}
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
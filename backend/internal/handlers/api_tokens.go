@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/middleware"
+)
+
+// APITokenHandler implements the personal access token management endpoints.
+type APITokenHandler struct {
+	Tokens      APITokenService
+	RateLimiter RateLimiter
+}
+
+// List handles GET /api/v1/auth/tokens requests.
+func (h APITokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "APITokenHandler.List")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Tokens == nil {
+		logger.Error("api token service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "api token service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.Tokens.List(ctx, userID)
+	if err != nil {
+		logger.Error("list api tokens failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load api tokens"})
+		return
+	}
+
+	resp := listAPITokensResponse{Tokens: make([]apiTokenResponse, 0, len(tokens))}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, newAPITokenResponse(token))
+	}
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Create handles POST /api/v1/auth/tokens requests. The token secret is only returned here.
+func (h APITokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "APITokenHandler.Create")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:tokens") {
+		logger.Warn("rate limit exceeded", "scope", "auth:tokens")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many token requests"})
+		return
+	}
+
+	if h.Tokens == nil {
+		logger.Error("api token service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "api token service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req createAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid create api token payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	token, secret, err := h.Tokens.Create(ctx, userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidAPITokenRequest):
+			logger.Warn("create api token rejected", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, auth.ErrAPITokenLimit):
+			logger.Warn("api token limit reached", "userId", userID)
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "api token limit reached; revoke an unused token first"})
+		default:
+			logger.Error("create api token failed", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to create api token"})
+		}
+		return
+	}
+
+	logger.Info("api token created", "event", "security.api_token_created", "userId", userID, "tokenId", token.ID, "scopes", token.Scopes)
+	respondJSON(ctx, w, http.StatusCreated, createAPITokenResponse{Token: newAPITokenResponse(token), Secret: secret})
+}
+
+// Get handles GET /api/v1/auth/tokens/{id} requests.
+func (h APITokenHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "APITokenHandler.Get")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Tokens == nil {
+		logger.Error("api token service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "api token service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	tokenID, ok := apiTokenID(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.Tokens.Get(ctx, userID, tokenID)
+	if err != nil {
+		respondAPITokenError(ctx, w, err, "unable to load api token")
+		return
+	}
+	respondJSON(ctx, w, http.StatusOK, newAPITokenResponse(token))
+}
+
+// Update handles PATCH /api/v1/auth/tokens/{id} requests. Only the name can change; create a new
+// token to change scopes or expiry.
+func (h APITokenHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "APITokenHandler.Update")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPatch {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Tokens == nil {
+		logger.Error("api token service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "api token service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	tokenID, ok := apiTokenID(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	var req updateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid update api token payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	token, err := h.Tokens.Rename(ctx, userID, tokenID, req.Name)
+	if err != nil {
+		respondAPITokenError(ctx, w, err, "unable to update api token")
+		return
+	}
+	respondJSON(ctx, w, http.StatusOK, newAPITokenResponse(token))
+}
+
+// Delete handles DELETE /api/v1/auth/tokens/{id} requests.
+func (h APITokenHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "APITokenHandler.Delete")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Tokens == nil {
+		logger.Error("api token service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "api token service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	tokenID, ok := apiTokenID(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Tokens.Revoke(ctx, userID, tokenID); err != nil {
+		respondAPITokenError(ctx, w, err, "unable to revoke api token")
+		return
+	}
+
+	logger.Info("api token revoked", "event", "security.api_token_revoked", "userId", userID, "tokenId", tokenID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAll handles POST /api/v1/auth/tokens/revoke-all requests.
+func (h APITokenHandler) RevokeAll(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "APITokenHandler.RevokeAll")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Tokens == nil {
+		logger.Error("api token service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "api token service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	if err := h.Tokens.RevokeAll(ctx, userID); err != nil {
+		logger.Error("revoke all api tokens failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to revoke api tokens"})
+		return
+	}
+
+	logger.Info("all api tokens revoked", "event", "security.api_tokens_revoked", "userId", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiTokenID reads the {id} path value. Malformed IDs cannot match a token and are reported as
+// not found.
+func apiTokenID(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	tokenID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(tokenID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "api token not found"})
+		return "", false
+	}
+	return tokenID, true
+}
+
+func respondAPITokenError(ctx context.Context, w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrAPITokenNotFound):
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "api token not found"})
+	case errors.Is(err, auth.ErrInvalidAPITokenRequest):
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		logging.FromContext(ctx).Error(message, "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": message})
+	}
+}
+
+// bearerAuthenticator accepts personal access tokens alongside session access tokens so both
+// pass through middleware.RequireAuth.
+type bearerAuthenticator struct {
+	sessions  SessionManager
+	apiTokens APITokenService
+}
+
+// newBearerAuthenticator returns nil when sessions is nil so RequireAuth reports the missing
+// dependency.
+func newBearerAuthenticator(sessions SessionManager, apiTokens APITokenService) middleware.Authenticator {
+	if sessions == nil {
+		return nil
+	}
+	return bearerAuthenticator{sessions: sessions, apiTokens: apiTokens}
+}
+
+// Authenticate dispatches on the token prefix.
+func (a bearerAuthenticator) Authenticate(ctx context.Context, token string) (auth.Principal, error) {
+	if strings.HasPrefix(token, auth.APITokenPrefix) {
+		if a.apiTokens == nil {
+			return auth.Principal{}, auth.ErrInvalidAPIToken
+		}
+		return a.apiTokens.Authenticate(ctx, token)
+	}
+	return a.sessions.Authenticate(ctx, token)
+}
+
+func newAPITokenResponse(token auth.APIToken) apiTokenResponse {
+	return apiTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+type createAPITokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is optional; tokens without it last until revoked.
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type updateAPITokenRequest struct {
+	Name string `json:"name"`
+}
+
+type apiTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type createAPITokenResponse struct {
+	Token  apiTokenResponse `json:"token"`
+	Secret string           `json:"secret"`
+}
+
+type listAPITokensResponse struct {
+	Tokens []apiTokenResponse `json:"tokens"`
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/auth"
+)
+
+func newAPITokenMux(tokens *auth.APITokens) *http.ServeMux {
+	h := APITokenHandler{Tokens: tokens}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/auth/tokens", h.List)
+	mux.HandleFunc("POST /api/v1/auth/tokens", h.Create)
+	mux.HandleFunc("POST /api/v1/auth/tokens/revoke-all", h.RevokeAll)
+	mux.HandleFunc("GET /api/v1/auth/tokens/{id}", h.Get)
+	mux.HandleFunc("PATCH /api/v1/auth/tokens/{id}", h.Update)
+	mux.HandleFunc("DELETE /api/v1/auth/tokens/{id}", h.Delete)
+	return mux
+}
+
+func serveAPITokenRequest(t *testing.T, mux *http.ServeMux, method, path string, payload any, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+	}
+	req := withUser(httptest.NewRequest(method, path, bytes.NewReader(body)), userID)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPITokenHandlerLifecycle(t *testing.T) {
+	tokens := auth.NewAPITokens(auth.NewInMemoryAPITokenStore())
+	mux := newAPITokenMux(tokens)
+
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	rec := serveAPITokenRequest(t, mux, http.MethodPost, "/api/v1/auth/tokens", createAPITokenRequest{
+		Name:      "CI bot",
+		Scopes:    []string{auth.ScopeVideosWrite, auth.ScopeFeedRead},
+		ExpiresAt: &expiresAt,
+	}, "user-1")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var created createAPITokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, auth.APITokenPrefix) || created.Token.Name != "CI bot" || created.Token.ExpiresAt == nil {
+		t.Fatalf("unexpected create response %+v", created)
+	}
+
+	if _, err := tokens.Authenticate(context.Background(), created.Secret); err != nil {
+		t.Fatalf("expected secret to authenticate: %v", err)
+	}
+
+	rec = serveAPITokenRequest(t, mux, http.MethodGet, "/api/v1/auth/tokens", nil, "user-1")
+	var listed listAPITokensResponse
+	if err := json.NewDecoder(rec.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if rec.Code != http.StatusOK || len(listed.Tokens) != 1 || listed.Tokens[0].LastUsedAt == nil {
+		t.Fatalf("unexpected list %d %+v", rec.Code, listed)
+	}
+	if strings.Contains(rec.Body.String(), created.Secret) {
+		t.Fatal("expected list not to include the secret")
+	}
+
+	path := "/api/v1/auth/tokens/" + created.Token.ID
+	if rec := serveAPITokenRequest(t, mux, http.MethodGet, path, nil, "user-2"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other users to get 404, got %d", rec.Code)
+	}
+
+	rec = serveAPITokenRequest(t, mux, http.MethodPatch, path, updateAPITokenRequest{Name: "Deploy bot"}, "user-1")
+	var updated apiTokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&updated); err != nil {
+		t.Fatalf("decode update: %v", err)
+	}
+	if rec.Code != http.StatusOK || updated.Name != "Deploy bot" {
+		t.Fatalf("unexpected update %d %+v", rec.Code, updated)
+	}
+
+	if rec := serveAPITokenRequest(t, mux, http.MethodDelete, path, nil, "user-1"); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+	if _, err := tokens.Authenticate(context.Background(), created.Secret); err == nil {
+		t.Fatal("expected revoked token to be rejected")
+	}
+	if rec := serveAPITokenRequest(t, mux, http.MethodDelete, path, nil, "user-1"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for deleted token, got %d", rec.Code)
+	}
+}
+
+func TestAPITokenHandlerValidationAndRevokeAll(t *testing.T) {
+	tokens := auth.NewAPITokens(auth.NewInMemoryAPITokenStore())
+	mux := newAPITokenMux(tokens)
+
+	past := time.Now().UTC().Add(-time.Hour)
+	invalid := []createAPITokenRequest{
+		{Name: "", Scopes: []string{auth.ScopeFeedRead}},
+		{Name: "bot", Scopes: []string{"admin"}},
+		{Name: "bot"},
+		{Name: "bot", Scopes: []string{auth.ScopeFeedRead}, ExpiresAt: &past},
+	}
+	for _, req := range invalid {
+		if rec := serveAPITokenRequest(t, mux, http.MethodPost, "/api/v1/auth/tokens", req, "user-1"); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %+v, got %d", req, rec.Code)
+		}
+	}
+
+	if rec := serveAPITokenRequest(t, mux, http.MethodGet, "/api/v1/auth/tokens/not-a-uuid", nil, "user-1"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for malformed id, got %d", rec.Code)
+	}
+
+	var secrets []string
+	for i := 0; i < 2; i++ {
+		_, secret, err := tokens.Create(context.Background(), "user-1", "bot", []string{auth.ScopeFeedRead}, nil)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	if rec := serveAPITokenRequest(t, mux, http.MethodPost, "/api/v1/auth/tokens/revoke-all", nil, "user-1"); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+	for _, secret := range secrets {
+		if _, err := tokens.Authenticate(context.Background(), secret); err == nil {
+			t.Fatal("expected every token to be revoked")
+		}
+	}
+}
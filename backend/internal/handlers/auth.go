@@ -4,26 +4,60 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/mail"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/invites"
 	"github.com/vidfriends/backend/internal/logging"
 	"github.com/vidfriends/backend/internal/models"
 	"github.com/vidfriends/backend/internal/repositories"
 )
 
+// passwordResetTTL bounds how long a password reset link stays usable.
+const passwordResetTTL = time.Hour
+
+// Password length limits. The upper bound only guards against oversized request bodies; Argon2id
+// hashes the whole password, unlike bcrypt which ignores everything past 72 bytes.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 256
+)
+
+// passwordLengthError is returned to clients whose password is outside the allowed length.
+var passwordLengthError = fmt.Sprintf("password must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+
+// defaultPasswordHasher is used by handlers that were not given a PasswordHasher.
+var defaultPasswordHasher = auth.DefaultPasswordHasher()
+
 // AuthHandler implements user authentication endpoints.
 type AuthHandler struct {
-	Users       UserStore
-	Sessions    SessionManager
+	Users    UserStore
+	Sessions SessionManager
+	Tokens   UserTokenManager
+	Mailer   Mailer
+	// TwoFactor is optional; when nil, logins never require a second factor.
+	TwoFactor TwoFactorService
+	// Passwords is optional; when nil, passwords are hashed with auth.DefaultPasswordHashConfig.
+	Passwords PasswordHasher
+	// Lockout is optional; when set, repeated failed logins for an account are throttled.
+	Lockout     LoginLockout
+	AppBaseURL  string
 	NowFunc     func() time.Time
 	RateLimiter RateLimiter
+	// ResendLimiter throttles verification e-mail resends per user.
+	ResendLimiter RateLimiter
+	// Invites is optional; when set, signing up with an invite code befriends the inviter
+	// through Friends.
+	Invites InviteService
+	Friends FriendStore
 }
 
 // Login handles POST /api/v1/auth/login requests.
@@ -71,33 +105,58 @@ func (h AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Password) < 8 || len(req.Password) > 72 {
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
 		logger.Warn("login password length invalid", "email", req.Email, "length", len(req.Password))
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "password must be between 8 and 72 characters"})
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": passwordLengthError})
 		return
 	}
 
+	if h.Lockout != nil {
+		allowed, err := h.Lockout.Allow(ctx, req.Email)
+		if err != nil {
+			logger.Error("login lockout check failed", "error", err, "email", req.Email)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to sign in"})
+			return
+		}
+		if !allowed {
+			h.passwords().CompareDummy(req.Password)
+			logger.Warn("login throttled", "event", "security.login_throttled", "email", req.Email)
+			respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
+			return
+		}
+	}
+
 	user, err := h.Users.FindByEmail(ctx, req.Email)
 	if err != nil {
 		logger.Warn("login user lookup failed", "email", req.Email, "error", err)
+		h.passwords().CompareDummy(req.Password)
+		h.recordLoginFailure(ctx, req.Email, nil)
 		respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	matched, needsRehash, err := h.passwords().Verify(req.Password, user.Password)
+	if err != nil {
+		logger.Error("login password hash unreadable", "error", err, "userId", user.ID)
+	}
+	if !matched {
 		logger.Warn("login password mismatch", "userId", user.ID)
+		h.recordLoginFailure(ctx, req.Email, &user)
 		respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
 		return
 	}
 
-	tokens, err := h.Sessions.Issue(ctx, user.ID)
-	if err != nil {
-		logger.Error("failed to issue session", "error", err, "userId", user.ID)
-		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
-		return
+	if needsRehash {
+		h.upgradePasswordHash(ctx, user, req.Password)
 	}
 
-	respondJSON(ctx, w, http.StatusOK, authResponse{Tokens: tokens})
+	if h.Lockout != nil {
+		if err := h.Lockout.RecordSuccess(ctx, req.Email); err != nil {
+			logger.Error("login failures could not be cleared", "error", err, "userId", user.ID)
+		}
+	}
+
+	completeSignIn(w, r, h.Sessions, h.TwoFactor, user.ID)
 }
 
 // SignUp handles POST /api/v1/auth/signup requests.
@@ -145,10 +204,27 @@ func (h AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Password) < 8 || len(req.Password) > 72 {
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
 		logger.Warn("signup password length invalid", "email", req.Email, "length", len(req.Password))
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "password must be between 8 and 72 characters"})
-		return
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": passwordLengthError})
+		return
+	}
+
+	req.InviteCode = strings.TrimSpace(req.InviteCode)
+	if req.InviteCode != "" {
+		if h.Invites == nil {
+			logger.Warn("signup invite ignored; invite service unavailable", "email", req.Email)
+			req.InviteCode = ""
+		} else if _, err := h.Invites.Lookup(ctx, req.InviteCode); err != nil {
+			if errors.Is(err, invites.ErrInvalidCode) {
+				logger.Warn("signup invite code rejected", "email", req.Email)
+				respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invite code is invalid or has expired"})
+				return
+			}
+			logger.Error("signup invite lookup failed", "error", err, "email", req.Email)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to check invite code"})
+			return
+		}
 	}
 
 	if _, err := h.Users.FindByEmail(ctx, req.Email); err == nil {
@@ -161,8 +237,13 @@ func (h AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashed, err := h.passwords().Hash(req.Password)
 	if err != nil {
+		if errors.Is(err, auth.ErrPasswordTooLong) {
+			logger.Warn("signup password too long for hash algorithm", "email", req.Email, "length", len(req.Password))
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "password is too long"})
+			return
+		}
 		logger.Error("signup failed to hash password", "error", err)
 		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to secure password"})
 		return
@@ -172,7 +253,7 @@ func (h AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 	user := models.User{
 		ID:        uuid.NewString(),
 		Email:     req.Email,
-		Password:  string(hashed),
+		Password:  hashed,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -188,7 +269,19 @@ func (h AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.Sessions.Issue(ctx, user.ID)
+	if req.InviteCode != "" {
+		h.acceptInvite(ctx, req.InviteCode, user)
+	}
+
+	if h.Tokens != nil && h.Mailer != nil {
+		if err := h.sendVerification(ctx, user); err != nil {
+			logger.Error("signup failed to send verification email", "error", err, "userId", user.ID)
+		}
+	} else {
+		logger.Warn("signup verification email skipped; mail delivery unavailable", "userId", user.ID)
+	}
+
+	tokens, err := h.Sessions.Issue(ctx, user.ID, clientInfo(r))
 	if err != nil {
 		logger.Error("signup failed to issue session", "error", err, "userId", user.ID)
 		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
@@ -243,10 +336,10 @@ func (h AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.Sessions.Refresh(ctx, req.RefreshToken)
+	tokens, err := h.Sessions.Refresh(ctx, req.RefreshToken, clientInfo(r))
 	if err != nil {
 		status := http.StatusUnauthorized
-		if errors.Is(err, auth.ErrRefreshTokenExpired) || errors.Is(err, auth.ErrSessionNotFound) {
+		if errors.Is(err, auth.ErrRefreshTokenExpired) || errors.Is(err, auth.ErrSessionNotFound) || errors.Is(err, auth.ErrRefreshTokenReused) {
 			status = http.StatusUnauthorized
 		} else {
 			status = http.StatusInternalServerError
@@ -278,8 +371,8 @@ func (h AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if h.Users == nil {
-		logger.Error("user store unavailable")
+	if h.Users == nil || h.Tokens == nil || h.Mailer == nil {
+		logger.Error("password reset dependencies unavailable", "hasUsers", h.Users != nil, "hasTokens", h.Tokens != nil, "hasMailer", h.Mailer != nil)
 		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
 		return
 	}
@@ -304,12 +397,15 @@ func (h AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if _, err := h.Users.FindByEmail(ctx, req.Email); err != nil {
+	user, err := h.Users.FindByEmail(ctx, req.Email)
+	if err != nil {
 		if !errors.Is(err, repositories.ErrNotFound) {
 			logger.Error("password reset lookup failed", "error", err, "email", req.Email)
 			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to process password reset"})
 			return
 		}
+	} else {
+		h.sendPasswordReset(ctx, user)
 	}
 
 	respondJSON(ctx, w, http.StatusAccepted, map[string]string{
@@ -317,6 +413,146 @@ func (h AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request
 	})
 }
 
+// sendPasswordReset e-mails a reset link to the user. Failures are logged rather than returned so
+// the response does not reveal whether the account exists.
+func (h AuthHandler) sendPasswordReset(ctx context.Context, user models.User) {
+	logger := logging.FromContext(ctx)
+
+	token, err := h.Tokens.Issue(ctx, user.ID, auth.PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		logger.Error("password reset token issue failed", "error", err, "userId", user.ID)
+		return
+	}
+
+	link := strings.TrimRight(h.AppBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+	msg := email.Message{
+		To:      user.Email,
+		Subject: "Reset your VidFriends password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your VidFriends account.\n\n"+
+			"Open this link within %d minutes to choose a new password:\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this e-mail.\n", int(passwordResetTTL.Minutes()), link),
+	}
+
+	if err := h.Mailer.Send(ctx, msg); err != nil {
+		logger.Error("password reset email failed", "error", err, "userId", user.ID)
+		return
+	}
+
+	logger.Info("password reset email sent", "userId", user.ID)
+}
+
+// ConfirmPasswordReset handles POST /api/v1/auth/password-reset/confirm requests. A valid token
+// sets the new password and signs the user out everywhere.
+func (h AuthHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.ConfirmPasswordReset")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:password-reset-confirm") {
+		logger.Warn("rate limit exceeded", "scope", "auth:password-reset-confirm")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many password reset attempts"})
+		return
+	}
+
+	if h.Users == nil || h.Tokens == nil || h.Sessions == nil {
+		logger.Error("password reset dependencies unavailable", "hasUsers", h.Users != nil, "hasTokens", h.Tokens != nil, "hasSessions", h.Sessions != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+		return
+	}
+
+	var req confirmPasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid password reset confirmation payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" || req.Password == "" {
+		logger.Warn("password reset confirmation missing fields")
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "token and password are required"})
+		return
+	}
+
+	if len(req.Token) > 512 {
+		logger.Warn("password reset token too long", "length", len(req.Token))
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired reset token"})
+		return
+	}
+
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		logger.Warn("password reset password length invalid", "length", len(req.Password))
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": passwordLengthError})
+		return
+	}
+
+	userID, err := h.Tokens.Consume(ctx, req.Token, auth.PurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserTokenInvalid) {
+			logger.Warn("password reset token rejected")
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired reset token"})
+			return
+		}
+		logger.Error("password reset token lookup failed", "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to reset password"})
+		return
+	}
+
+	user, err := h.Users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			logger.Warn("password reset user missing", "userId", userID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired reset token"})
+			return
+		}
+		logger.Error("password reset user lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to reset password"})
+		return
+	}
+
+	hashed, err := h.passwords().Hash(req.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrPasswordTooLong) {
+			logger.Warn("password reset password too long for hash algorithm", "length", len(req.Password))
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "password is too long"})
+			return
+		}
+		logger.Error("password reset failed to hash password", "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to secure password"})
+		return
+	}
+
+	user.Password = hashed
+	user.UpdatedAt = h.now()
+	if err := h.Users.Update(ctx, user); err != nil {
+		logger.Error("password reset failed to update user", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to reset password"})
+		return
+	}
+
+	if err := h.Sessions.RevokeAll(ctx, user.ID); err != nil {
+		logger.Error("password reset failed to revoke sessions", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "password updated but existing sessions could not be ended"})
+		return
+	}
+
+	if h.Lockout != nil {
+		if err := h.Lockout.RecordSuccess(ctx, user.Email); err != nil {
+			logger.Error("password reset failed to clear login lockout", "error", err, "userId", user.ID)
+		}
+	}
+
+	logger.Info("password reset completed", "userId", user.ID)
+	respondJSON(ctx, w, http.StatusOK, map[string]string{"status": "Your password has been reset. Sign in with your new password."})
+}
+
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -325,6 +561,8 @@ type loginRequest struct {
 type signUpRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// InviteCode is optional; see AuthHandler.Invites.
+	InviteCode string `json:"inviteCode"`
 }
 
 type refreshRequest struct {
@@ -335,10 +573,42 @@ type passwordResetRequest struct {
 	Email string `json:"email"`
 }
 
+type confirmPasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
 type authResponse struct {
 	Tokens models.SessionTokens `json:"tokens"`
 }
 
+// upgradePasswordHash stores a fresh hash of the password the user just signed in with. Failures
+// are logged and the old hash stays usable, so the login still succeeds.
+func (h AuthHandler) upgradePasswordHash(ctx context.Context, user models.User, password string) {
+	logger := logging.FromContext(ctx)
+
+	hashed, err := h.passwords().Hash(password)
+	if err != nil {
+		logger.Error("password rehash failed", "error", err, "userId", user.ID)
+		return
+	}
+
+	user.Password = hashed
+	user.UpdatedAt = h.now()
+	if err := h.Users.Update(ctx, user); err != nil {
+		logger.Error("password rehash could not be stored", "error", err, "userId", user.ID)
+		return
+	}
+	logger.Info("password hash upgraded", "userId", user.ID)
+}
+
+func (h AuthHandler) passwords() PasswordHasher {
+	if h.Passwords != nil {
+		return h.Passwords
+	}
+	return defaultPasswordHasher
+}
+
 func (h AuthHandler) now() time.Time {
 	if h.NowFunc != nil {
 		return h.NowFunc()
@@ -14,6 +14,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/logging"
 	"github.com/vidfriends/backend/internal/models"
 	"github.com/vidfriends/backend/internal/repositories"
 )
@@ -42,6 +44,39 @@ func (s *inMemoryUserStore) FindByEmail(_ context.Context, email string) (models
 	return user, nil
 }
 
+func (s *inMemoryUserStore) FindByID(_ context.Context, id string) (models.User, error) {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return models.User{}, repositories.ErrNotFound
+}
+
+func (s *inMemoryUserStore) Update(_ context.Context, user models.User) error {
+	if existing, ok := s.users[user.Email]; ok && existing.ID != user.ID {
+		return repositories.ErrConflict
+	}
+	for email, existing := range s.users {
+		if existing.ID == user.ID {
+			delete(s.users, email)
+			s.users[user.Email] = user
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (s *inMemoryUserStore) Delete(_ context.Context, id string) error {
+	for email, existing := range s.users {
+		if existing.ID == id {
+			delete(s.users, email)
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
 type failingUserStore struct {
 	createErr error
 	findErr   error
@@ -55,8 +90,42 @@ func (s failingUserStore) FindByEmail(context.Context, string) (models.User, err
 	return models.User{}, s.findErr
 }
 
+func (s failingUserStore) FindByID(context.Context, string) (models.User, error) {
+	return models.User{}, s.findErr
+}
+
+func (s failingUserStore) Update(context.Context, models.User) error {
+	return s.createErr
+}
+
+func (s failingUserStore) Delete(context.Context, string) error {
+	return s.createErr
+}
+
+type recordingMailer struct {
+	messages []email.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg email.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
 func newSessionManager() *auth.Manager {
-	return auth.NewManager(time.Minute, time.Hour, auth.NewInMemorySessionStore())
+	keys, err := auth.NewKeyring(auth.Key{ID: "test", Secret: []byte("handler-test-secret-handler-test")})
+	if err != nil {
+		panic(err)
+	}
+	return auth.NewManager(time.Minute, time.Hour, auth.NewInMemorySessionStore(), keys)
+}
+
+// withUser simulates middleware.RequireAuth having authenticated the request as userID.
+func withUser(req *http.Request, userID string) *http.Request {
+	return req.WithContext(logging.WithUserID(req.Context(), userID))
 }
 
 type stubRateLimiter struct {
@@ -74,7 +143,7 @@ type stubSessionManager struct {
 	refreshedWith string
 }
 
-func (s *stubSessionManager) Issue(_ context.Context, userID string) (models.SessionTokens, error) {
+func (s *stubSessionManager) Issue(_ context.Context, userID string, _ auth.ClientInfo) (models.SessionTokens, error) {
 	s.issuedFor = userID
 	if s.issueErr != nil {
 		return models.SessionTokens{}, s.issueErr
@@ -82,7 +151,23 @@ func (s *stubSessionManager) Issue(_ context.Context, userID string) (models.Ses
 	return s.issueTokens, nil
 }
 
-func (s *stubSessionManager) Refresh(_ context.Context, refreshToken string) (models.SessionTokens, error) {
+func (s *stubSessionManager) Authenticate(context.Context, string) (auth.Principal, error) {
+	return auth.Principal{}, auth.ErrInvalidAccessToken
+}
+
+func (s *stubSessionManager) Sessions(context.Context, string) ([]auth.Session, error) {
+	return nil, nil
+}
+
+func (s *stubSessionManager) RevokeSession(context.Context, string, string) error {
+	return auth.ErrSessionNotFound
+}
+
+func (s *stubSessionManager) RevokeAll(context.Context, string) error {
+	return nil
+}
+
+func (s *stubSessionManager) Refresh(_ context.Context, refreshToken string, _ auth.ClientInfo) (models.SessionTokens, error) {
 	s.refreshedWith = refreshToken
 	if s.refreshErr != nil {
 		return models.SessionTokens{}, s.refreshErr
@@ -123,7 +208,7 @@ func TestAuthHandlerSignUp(t *testing.T) {
 		t.Fatalf("expected user to be stored: %v", err)
 	}
 
-	if bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("supersafe")) != nil {
+	if ok, _, err := defaultPasswordHasher.Verify("supersafe", stored.Password); err != nil || !ok {
 		t.Fatal("stored password is not hashed")
 	}
 }
@@ -143,7 +228,7 @@ func TestAuthHandlerSignUpValidationErrors(t *testing.T) {
 		{"missingFields", []byte(`{"email":"","password":""}`), http.StatusBadRequest},
 		{"invalidEmail", []byte(`{"email":"bad","password":"password"}`), http.StatusBadRequest},
 		{"shortPassword", []byte(`{"email":"user@example.com","password":"short"}`), http.StatusBadRequest},
-		{"longPassword", []byte(`{"email":"user@example.com","password":"` + strings.Repeat("a", 100) + `"}`), http.StatusBadRequest},
+		{"longPassword", []byte(`{"email":"user@example.com","password":"` + strings.Repeat("a", maxPasswordLength+1) + `"}`), http.StatusBadRequest},
 	}
 
 	for _, tc := range cases {
@@ -242,6 +327,56 @@ func TestAuthHandlerLogin(t *testing.T) {
 	}
 }
 
+func TestAuthHandlerLoginUpgradesBcryptHash(t *testing.T) {
+	store := newInMemoryUserStore()
+	handler := AuthHandler{Users: store, Sessions: newSessionManager()}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	store.users["legacy@example.com"] = models.User{ID: "user-1", Email: "legacy@example.com", Password: string(hashed)}
+
+	for i := 0; i < 2; i++ {
+		body, _ := json.Marshal(loginRequest{Email: "legacy@example.com", Password: "password123"})
+		rec := httptest.NewRecorder()
+		handler.Login(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("login %d: expected status %d got %d", i+1, http.StatusOK, rec.Code)
+		}
+		if stored := store.users["legacy@example.com"].Password; !strings.HasPrefix(stored, "$argon2id$") {
+			t.Fatalf("login %d: expected hash to be upgraded to argon2id, got %q", i+1, stored)
+		}
+	}
+}
+
+func TestAuthHandlerLongPasswords(t *testing.T) {
+	store := newInMemoryUserStore()
+	handler := AuthHandler{Users: store, Sessions: newSessionManager()}
+	password := strings.Repeat("long passphrase ", 8)
+
+	body, _ := json.Marshal(signUpRequest{Email: "long@example.com", Password: password})
+	rec := httptest.NewRecorder()
+	handler.SignUp(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", bytes.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected signup with a %d character password to succeed, got %d", len(password), rec.Code)
+	}
+
+	body, _ = json.Marshal(loginRequest{Email: "long@example.com", Password: password[:72]})
+	rec = httptest.NewRecorder()
+	handler.Login(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected truncated password to be rejected, got %d", rec.Code)
+	}
+
+	body, _ = json.Marshal(loginRequest{Email: "long@example.com", Password: password})
+	rec = httptest.NewRecorder()
+	handler.Login(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected full password to sign in, got %d", rec.Code)
+	}
+}
+
 func TestAuthHandlerLoginFailures(t *testing.T) {
 	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 
@@ -325,7 +460,7 @@ func TestAuthHandlerLoginRateLimited(t *testing.T) {
 
 func TestAuthHandlerRefresh(t *testing.T) {
 	manager := newSessionManager()
-	tokens, err := manager.Issue(context.Background(), "user-123")
+	tokens, err := manager.Issue(context.Background(), "user-123", auth.ClientInfo{})
 	if err != nil {
 		t.Fatalf("issue tokens: %v", err)
 	}
@@ -358,7 +493,7 @@ func TestAuthHandlerRefresh(t *testing.T) {
 
 func TestAuthHandlerRefreshFailures(t *testing.T) {
 	manager := newSessionManager()
-	tokens, _ := manager.Issue(context.Background(), "user-123")
+	tokens, _ := manager.Issue(context.Background(), "user-123", auth.ClientInfo{})
 
 	cases := []struct {
 		name       string
@@ -402,13 +537,27 @@ func TestAuthHandlerRefreshFailures(t *testing.T) {
 	if rec.Code != http.StatusUnauthorized {
 		t.Fatalf("expected unauthorized got %d", rec.Code)
 	}
+
+	handler = AuthHandler{Sessions: &stubSessionManager{refreshErr: auth.ErrRefreshTokenReused}}
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+	handler.Refresh(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized for reused token got %d", rec.Code)
+	}
 }
 
 func TestAuthHandlerRequestPasswordReset(t *testing.T) {
 	store := newInMemoryUserStore()
-	store.users["user@example.com"] = models.User{Email: "user@example.com"}
+	store.users["user@example.com"] = models.User{ID: "user-1", Email: "user@example.com"}
+	mailer := &recordingMailer{}
 
-	handler := AuthHandler{Users: store}
+	handler := AuthHandler{
+		Users:      store,
+		Tokens:     auth.NewUserTokens(auth.NewInMemoryUserTokenStore()),
+		Mailer:     mailer,
+		AppBaseURL: "https://vidfriends.example/",
+	}
 
 	body, err := json.Marshal(passwordResetRequest{Email: "user@example.com"})
 	if err != nil {
@@ -433,6 +582,13 @@ func TestAuthHandlerRequestPasswordReset(t *testing.T) {
 		t.Fatal("expected a status message in response")
 	}
 
+	if len(mailer.messages) != 1 {
+		t.Fatalf("expected one reset email, got %d", len(mailer.messages))
+	}
+	if msg := mailer.messages[0]; msg.To != "user@example.com" || !strings.Contains(msg.Body, "https://vidfriends.example/reset-password?token=") {
+		t.Fatalf("unexpected reset email %+v", msg)
+	}
+
 	body, err = json.Marshal(passwordResetRequest{Email: "missing@example.com"})
 	if err != nil {
 		t.Fatalf("marshal: %v", err)
@@ -446,10 +602,26 @@ func TestAuthHandlerRequestPasswordReset(t *testing.T) {
 	if rec.Code != http.StatusAccepted {
 		t.Fatalf("expected status %d for unknown email got %d", http.StatusAccepted, rec.Code)
 	}
+	if len(mailer.messages) != 1 {
+		t.Fatalf("expected no email for unknown account, got %d", len(mailer.messages))
+	}
+
+	mailer.err = errors.New("smtp unavailable")
+	body, _ = json.Marshal(passwordResetRequest{Email: "user@example.com"})
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/password-reset", bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+
+	handler.RequestPasswordReset(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected mail failures to stay hidden, got %d", rec.Code)
+	}
 }
 
 func TestAuthHandlerRequestPasswordResetFailures(t *testing.T) {
 	store := newInMemoryUserStore()
+	tokens := auth.NewUserTokens(auth.NewInMemoryUserTokenStore())
+	mailer := &recordingMailer{}
 
 	cases := []struct {
 		name       string
@@ -458,11 +630,12 @@ func TestAuthHandlerRequestPasswordResetFailures(t *testing.T) {
 		body       []byte
 		wantStatus int
 	}{
-		{"methodNotAllowed", AuthHandler{Users: store}, http.MethodGet, nil, http.StatusMethodNotAllowed},
+		{"methodNotAllowed", AuthHandler{Users: store, Tokens: tokens, Mailer: mailer}, http.MethodGet, nil, http.StatusMethodNotAllowed},
 		{"missingStore", AuthHandler{}, http.MethodPost, nil, http.StatusInternalServerError},
-		{"invalidJSON", AuthHandler{Users: store}, http.MethodPost, []byte("{"), http.StatusBadRequest},
-		{"emptyEmail", AuthHandler{Users: store}, http.MethodPost, []byte(`{"email":""}`), http.StatusBadRequest},
-		{"invalidEmail", AuthHandler{Users: store}, http.MethodPost, []byte(`{"email":"bad"}`), http.StatusBadRequest},
+		{"missingMailer", AuthHandler{Users: store, Tokens: tokens}, http.MethodPost, nil, http.StatusInternalServerError},
+		{"invalidJSON", AuthHandler{Users: store, Tokens: tokens, Mailer: mailer}, http.MethodPost, []byte("{"), http.StatusBadRequest},
+		{"emptyEmail", AuthHandler{Users: store, Tokens: tokens, Mailer: mailer}, http.MethodPost, []byte(`{"email":""}`), http.StatusBadRequest},
+		{"invalidEmail", AuthHandler{Users: store, Tokens: tokens, Mailer: mailer}, http.MethodPost, []byte(`{"email":"bad"}`), http.StatusBadRequest},
 	}
 
 	for _, tc := range cases {
@@ -478,7 +651,7 @@ func TestAuthHandlerRequestPasswordResetFailures(t *testing.T) {
 		})
 	}
 
-	handler := AuthHandler{Users: failingUserStore{findErr: errors.New("query failed")}}
+	handler := AuthHandler{Users: failingUserStore{findErr: errors.New("query failed")}, Tokens: tokens, Mailer: mailer}
 	body, _ := json.Marshal(passwordResetRequest{Email: "user@example.com"})
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password-reset", bytes.NewReader(body))
 	rec := httptest.NewRecorder()
@@ -489,3 +662,94 @@ func TestAuthHandlerRequestPasswordResetFailures(t *testing.T) {
 		t.Fatalf("expected internal error got %d", rec.Code)
 	}
 }
+
+func TestAuthHandlerConfirmPasswordReset(t *testing.T) {
+	store := newInMemoryUserStore()
+	oldHash, err := bcrypt.GenerateFromPassword([]byte("forgotten1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	store.users["user@example.com"] = models.User{ID: "user-1", Email: "user@example.com", Password: string(oldHash)}
+
+	sessions := newSessionManager()
+	existing, err := sessions.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	tokens := auth.NewUserTokens(auth.NewInMemoryUserTokenStore())
+	token, err := tokens.Issue(context.Background(), "user-1", auth.PurposePasswordReset, time.Hour)
+	if err != nil {
+		t.Fatalf("issue reset token: %v", err)
+	}
+
+	handler := AuthHandler{Users: store, Sessions: sessions, Tokens: tokens}
+
+	confirm := func(payload confirmPasswordResetRequest) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password-reset/confirm", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ConfirmPasswordReset(rec, req)
+		return rec
+	}
+
+	if rec := confirm(confirmPasswordResetRequest{Token: token, Password: "short"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected weak password to be rejected, got %d", rec.Code)
+	}
+
+	if rec := confirm(confirmPasswordResetRequest{Token: token, Password: "brand-new-pass"}); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+
+	updated := store.users["user@example.com"]
+	if ok, _, err := defaultPasswordHasher.Verify("brand-new-pass", updated.Password); err != nil || !ok {
+		t.Fatalf("expected new password to be stored: %v", err)
+	}
+
+	if _, err := sessions.Refresh(context.Background(), existing.RefreshToken, auth.ClientInfo{}); err != auth.ErrSessionNotFound {
+		t.Fatalf("expected existing sessions to be revoked, got %v", err)
+	}
+
+	if rec := confirm(confirmPasswordResetRequest{Token: token, Password: "another-pass"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected reused token to be rejected, got %d", rec.Code)
+	}
+	if rec := confirm(confirmPasswordResetRequest{Token: "forged", Password: "another-pass"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown token to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestAuthHandlerConfirmPasswordResetFailures(t *testing.T) {
+	store := newInMemoryUserStore()
+	tokens := auth.NewUserTokens(auth.NewInMemoryUserTokenStore())
+	sessions := newSessionManager()
+
+	cases := []struct {
+		name       string
+		handler    AuthHandler
+		method     string
+		body       []byte
+		wantStatus int
+	}{
+		{"methodNotAllowed", AuthHandler{Users: store, Tokens: tokens, Sessions: sessions}, http.MethodGet, nil, http.StatusMethodNotAllowed},
+		{"rateLimited", AuthHandler{Users: store, Tokens: tokens, Sessions: sessions, RateLimiter: stubRateLimiter{allow: false}}, http.MethodPost, nil, http.StatusTooManyRequests},
+		{"missingTokens", AuthHandler{Users: store, Sessions: sessions}, http.MethodPost, nil, http.StatusInternalServerError},
+		{"invalidJSON", AuthHandler{Users: store, Tokens: tokens, Sessions: sessions}, http.MethodPost, []byte("{"), http.StatusBadRequest},
+		{"missingToken", AuthHandler{Users: store, Tokens: tokens, Sessions: sessions}, http.MethodPost, []byte(`{"password":"long-enough"}`), http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/v1/auth/password-reset/confirm", bytes.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+
+			tc.handler.ConfirmPasswordReset(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d", tc.wantStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+const (
+	// maxCircles caps how many circles one user may own.
+	maxCircles = 50
+	// maxCircleMembers caps the number of friends in one circle.
+	maxCircleMembers = 500
+	// maxCircleNameLength is the longest circle name accepted, in characters.
+	maxCircleNameLength = 50
+)
+
+// CircleHandler implements the endpoints for managing friend circles, the named groups of friends
+// a video can be shared with.
+type CircleHandler struct {
+	Circles CircleStore
+	// Profiles is optional; when nil, circle members are identified by ID only.
+	Profiles ProfileSummaries
+	NowFunc  func() time.Time
+}
+
+// List handles GET /api/v1/circles requests for the caller's circles.
+func (h CircleHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CircleHandler.List")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Circles == nil {
+		logger.Error("circle service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "circle service unavailable"})
+		return
+	}
+
+	ownerID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	circles, err := h.Circles.ListForOwner(ctx, ownerID)
+	if err != nil {
+		logger.Error("list circles failed", "error", err, "userId", ownerID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load circles"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, listCirclesResponse{Circles: h.circleViews(ctx, circles)})
+}
+
+// Create handles POST /api/v1/circles requests.
+func (h CircleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CircleHandler.Create")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Circles == nil {
+		logger.Error("circle service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "circle service unavailable"})
+		return
+	}
+
+	ownerID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req createCircleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid circle payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	name, err := circleName(req.Name)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	members, err := circleMembers(ownerID, req.MemberIDs)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	existing, err := h.Circles.ListForOwner(ctx, ownerID)
+	if err != nil {
+		logger.Error("count circles failed", "error", err, "userId", ownerID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to create circle"})
+		return
+	}
+	if len(existing) >= maxCircles {
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "circle limit reached; delete a circle first"})
+		return
+	}
+
+	now := h.now()
+	circle := models.Circle{
+		ID:        uuid.NewString(),
+		OwnerID:   ownerID,
+		Name:      name,
+		MemberIDs: members,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.Circles.Create(ctx, circle); err != nil {
+		h.respondWriteError(ctx, w, err, circle)
+		return
+	}
+
+	logger.Info("circle created", "userId", ownerID, "circleId", circle.ID, "members", len(members))
+	respondJSON(ctx, w, http.StatusCreated, h.circleViews(ctx, []models.Circle{circle})[0])
+}
+
+// Get handles GET /api/v1/circles/{id} requests.
+func (h CircleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CircleHandler.Get")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Circles == nil {
+		logger.Error("circle service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "circle service unavailable"})
+		return
+	}
+
+	ownerID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	circle, ok := h.findCircle(ctx, w, r, ownerID)
+	if !ok {
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, h.circleViews(ctx, []models.Circle{circle})[0])
+}
+
+// Update handles PATCH /api/v1/circles/{id} requests. Omitted fields are left unchanged;
+// memberIds replaces the whole member list.
+func (h CircleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CircleHandler.Update")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPatch {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Circles == nil {
+		logger.Error("circle service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "circle service unavailable"})
+		return
+	}
+
+	ownerID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req updateCircleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid circle payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	circle, ok := h.findCircle(ctx, w, r, ownerID)
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		name, err := circleName(*req.Name)
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		circle.Name = name
+	}
+	if req.MemberIDs != nil {
+		members, err := circleMembers(ownerID, *req.MemberIDs)
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		circle.MemberIDs = members
+	}
+	circle.UpdatedAt = h.now()
+
+	if err := h.Circles.Update(ctx, circle); err != nil {
+		h.respondWriteError(ctx, w, err, circle)
+		return
+	}
+
+	logger.Info("circle updated", "userId", ownerID, "circleId", circle.ID, "members", len(circle.MemberIDs))
+	respondJSON(ctx, w, http.StatusOK, h.circleViews(ctx, []models.Circle{circle})[0])
+}
+
+// Delete handles DELETE /api/v1/circles/{id} requests. Videos shared only with the circle are no
+// longer shown to its members.
+func (h CircleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CircleHandler.Delete")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Circles == nil {
+		logger.Error("circle service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "circle service unavailable"})
+		return
+	}
+
+	ownerID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	circleID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(circleID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "circle not found"})
+		return
+	}
+
+	if err := h.Circles.Delete(ctx, ownerID, circleID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "circle not found"})
+			return
+		}
+		logger.Error("delete circle failed", "error", err, "userId", ownerID, "circleId", circleID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to delete circle"})
+		return
+	}
+
+	logger.Info("circle deleted", "userId", ownerID, "circleId", circleID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findCircle loads the caller's circle named by the id path value, writing a 404 when it does not
+// exist or belongs to someone else.
+func (h CircleHandler) findCircle(ctx context.Context, w http.ResponseWriter, r *http.Request, ownerID string) (models.Circle, bool) {
+	circleID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(circleID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "circle not found"})
+		return models.Circle{}, false
+	}
+
+	circle, err := h.Circles.FindByID(ctx, ownerID, circleID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "circle not found"})
+			return models.Circle{}, false
+		}
+		logging.FromContext(ctx).Error("load circle failed", "error", err, "userId", ownerID, "circleId", circleID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load circle"})
+		return models.Circle{}, false
+	}
+	return circle, true
+}
+
+func (h CircleHandler) respondWriteError(ctx context.Context, w http.ResponseWriter, err error, circle models.Circle) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFriends):
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "circle members must be your friends"})
+	case errors.Is(err, repositories.ErrConflict):
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "you already have a circle with this name"})
+	case errors.Is(err, repositories.ErrNotFound):
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "circle not found"})
+	default:
+		logging.FromContext(ctx).Error("store circle failed", "error", err, "userId", circle.OwnerID, "circleId", circle.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to save circle"})
+	}
+}
+
+func (h CircleHandler) circleViews(ctx context.Context, circles []models.Circle) []circleView {
+	var memberIDs []string
+	for _, circle := range circles {
+		memberIDs = append(memberIDs, circle.MemberIDs...)
+	}
+	summaries := profileSummaries(ctx, h.Profiles, memberIDs)
+
+	views := make([]circleView, 0, len(circles))
+	for _, circle := range circles {
+		members := make([]models.ProfileSummary, 0, len(circle.MemberIDs))
+		for _, id := range circle.MemberIDs {
+			members = append(members, summaries[id])
+		}
+		views = append(views, circleView{
+			ID:        circle.ID,
+			Name:      circle.Name,
+			Members:   members,
+			CreatedAt: circle.CreatedAt,
+			UpdatedAt: circle.UpdatedAt,
+		})
+	}
+	return views
+}
+
+func (h CircleHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+// circleName trims and validates a circle name.
+func circleName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", errors.New("name is required")
+	case utf8.RuneCountInString(name) > maxCircleNameLength:
+		return "", fmt.Errorf("name must be at most %d characters", maxCircleNameLength)
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "", errors.New("name must not contain control characters")
+	}
+	return name, nil
+}
+
+// circleMembers validates the member IDs of one of ownerID's circles.
+func circleMembers(ownerID string, ids []string) ([]string, error) {
+	members, err := uniqueIDs("memberIds", ids, maxCircleMembers)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range members {
+		if id == ownerID {
+			return nil, errors.New("you cannot add yourself to a circle")
+		}
+	}
+	return members, nil
+}
+
+// uniqueIDs trims, validates and de-duplicates a list of UUIDs from the request field, keeping
+// the first occurrence of each.
+func uniqueIDs(field string, ids []string, limit int) ([]string, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("%s must contain valid ids", field)
+		}
+		id = parsed.String()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	if len(unique) > limit {
+		return nil, fmt.Errorf("%s must contain at most %d ids", field, limit)
+	}
+	return unique, nil
+}
+
+type createCircleRequest struct {
+	Name      string   `json:"name"`
+	MemberIDs []string `json:"memberIds"`
+}
+
+type updateCircleRequest struct {
+	Name      *string   `json:"name"`
+	MemberIDs *[]string `json:"memberIds"`
+}
+
+type circleView struct {
+	ID        string                  `json:"id"`
+	Name      string                  `json:"name"`
+	Members   []models.ProfileSummary `json:"members"`
+	CreatedAt time.Time               `json:"createdAt"`
+	UpdatedAt time.Time               `json:"updatedAt"`
+}
+
+type listCirclesResponse struct {
+	Circles []circleView `json:"circles"`
+}
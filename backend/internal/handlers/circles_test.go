@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+const (
+	thirdUUID    = "33333333-3333-3333-3333-333333333333"
+	strangerUUID = "44444444-4444-4444-4444-444444444444"
+)
+
+// inMemoryCircleStore treats every user in friends as an accepted friend of every owner.
+type inMemoryCircleStore struct {
+	circles map[string]models.Circle
+	friends map[string]bool
+}
+
+func newInMemoryCircleStore() *inMemoryCircleStore {
+	return &inMemoryCircleStore{
+		circles: make(map[string]models.Circle),
+		friends: map[string]bool{receiverUUID: true, thirdUUID: true},
+	}
+}
+
+func (s *inMemoryCircleStore) Create(_ context.Context, circle models.Circle) error {
+	if err := s.check(circle); err != nil {
+		return err
+	}
+	s.circles[circle.ID] = circle
+	return nil
+}
+
+func (s *inMemoryCircleStore) ListForOwner(_ context.Context, ownerID string) ([]models.Circle, error) {
+	var circles []models.Circle
+	for _, circle := range s.circles {
+		if circle.OwnerID == ownerID {
+			circles = append(circles, circle)
+		}
+	}
+	return circles, nil
+}
+
+func (s *inMemoryCircleStore) FindByID(_ context.Context, ownerID, circleID string) (models.Circle, error) {
+	circle, ok := s.circles[circleID]
+	if !ok || circle.OwnerID != ownerID {
+		return models.Circle{}, repositories.ErrNotFound
+	}
+	return circle, nil
+}
+
+func (s *inMemoryCircleStore) Update(_ context.Context, circle models.Circle) error {
+	existing, ok := s.circles[circle.ID]
+	if !ok || existing.OwnerID != circle.OwnerID {
+		return repositories.ErrNotFound
+	}
+	if err := s.check(circle); err != nil {
+		return err
+	}
+	s.circles[circle.ID] = circle
+	return nil
+}
+
+func (s *inMemoryCircleStore) Delete(_ context.Context, ownerID, circleID string) error {
+	circle, ok := s.circles[circleID]
+	if !ok || circle.OwnerID != ownerID {
+		return repositories.ErrNotFound
+	}
+	delete(s.circles, circleID)
+	return nil
+}
+
+func (s *inMemoryCircleStore) check(circle models.Circle) error {
+	for _, id := range circle.MemberIDs {
+		if !s.friends[id] {
+			return repositories.ErrNotFriends
+		}
+	}
+	for _, other := range s.circles {
+		if other.ID != circle.ID && other.OwnerID == circle.OwnerID && strings.EqualFold(other.Name, circle.Name) {
+			return repositories.ErrConflict
+		}
+	}
+	return nil
+}
+
+func circleRequest(t *testing.T, handler http.HandlerFunc, method, id string, payload any, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, "/api/v1/circles/"+id, &body)
+	req.SetPathValue("id", id)
+	if userID != "" {
+		req = withUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func decodeCircle(t *testing.T, rec *httptest.ResponseRecorder, wantStatus int) circleView {
+	t.Helper()
+	if rec.Code != wantStatus {
+		t.Fatalf("expected status %d got %d: %s", wantStatus, rec.Code, rec.Body.String())
+	}
+	var view circleView
+	if err := json.NewDecoder(rec.Body).Decode(&view); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return view
+}
+
+func TestCircleHandlerLifecycle(t *testing.T) {
+	profiles := newInMemoryProfileStore()
+	profiles.profiles[receiverUUID] = models.Profile{UserID: receiverUUID, Handle: "bob"}
+	handler := CircleHandler{Circles: newInMemoryCircleStore(), Profiles: profiles}
+
+	created := decodeCircle(t, postJSON(t, handler.Create, "/api/v1/circles", createCircleRequest{
+		Name:      "  Close friends ",
+		MemberIDs: []string{receiverUUID, strings.ToUpper(receiverUUID), thirdUUID},
+	}, requesterUUID), http.StatusCreated)
+	if created.Name != "Close friends" || len(created.Members) != 2 {
+		t.Fatalf("unexpected circle: %+v", created)
+	}
+	if created.Members[0].Handle != "bob" || created.Members[1].UserID != thirdUUID {
+		t.Fatalf("expected member summaries, got %+v", created.Members)
+	}
+
+	if rec := circleRequest(t, handler.Get, http.MethodGet, created.ID, nil, receiverUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other users' circles to be hidden, got %d", rec.Code)
+	}
+	if got := decodeCircle(t, circleRequest(t, handler.Get, http.MethodGet, created.ID, nil, requesterUUID), http.StatusOK); got.ID != created.ID {
+		t.Fatalf("unexpected circle: %+v", got)
+	}
+
+	renamed := "Family"
+	updated := decodeCircle(t, circleRequest(t, handler.Update, http.MethodPatch, created.ID, updateCircleRequest{Name: &renamed}, requesterUUID), http.StatusOK)
+	if updated.Name != "Family" || len(updated.Members) != 2 {
+		t.Fatalf("expected rename to keep members, got %+v", updated)
+	}
+	members := []string{thirdUUID}
+	updated = decodeCircle(t, circleRequest(t, handler.Update, http.MethodPatch, created.ID, updateCircleRequest{MemberIDs: &members}, requesterUUID), http.StatusOK)
+	if updated.Name != "Family" || len(updated.Members) != 1 || updated.Members[0].UserID != thirdUUID {
+		t.Fatalf("expected members to be replaced, got %+v", updated)
+	}
+	members = []string{strangerUUID}
+	if rec := circleRequest(t, handler.Update, http.MethodPatch, created.ID, updateCircleRequest{MemberIDs: &members}, requesterUUID); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected non-friends to be rejected, got %d", rec.Code)
+	}
+
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/circles", nil), requesterUUID)
+	rec := httptest.NewRecorder()
+	handler.List(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+	var list listCirclesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(list.Circles) != 1 || list.Circles[0].ID != created.ID {
+		t.Fatalf("unexpected circles: %+v", list.Circles)
+	}
+
+	if rec := circleRequest(t, handler.Delete, http.MethodDelete, created.ID, nil, receiverUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other users to be unable to delete, got %d", rec.Code)
+	}
+	if rec := circleRequest(t, handler.Delete, http.MethodDelete, created.ID, nil, requesterUUID); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+	if rec := circleRequest(t, handler.Get, http.MethodGet, created.ID, nil, requesterUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected deleted circle to be gone, got %d", rec.Code)
+	}
+}
+
+func TestCircleHandlerCreateFailures(t *testing.T) {
+	cases := []struct {
+		name       string
+		handler    func(CircleHandler) CircleHandler
+		payload    any
+		userID     string
+		wantStatus int
+	}{
+		{"unauthenticated", nil, createCircleRequest{Name: "Family"}, "", http.StatusUnauthorized},
+		{"invalidBody", nil, "not an object", requesterUUID, http.StatusBadRequest},
+		{"missingName", nil, createCircleRequest{Name: "  "}, requesterUUID, http.StatusBadRequest},
+		{"longName", nil, createCircleRequest{Name: strings.Repeat("a", maxCircleNameLength+1)}, requesterUUID, http.StatusBadRequest},
+		{"controlCharacters", nil, createCircleRequest{Name: "Fam\nily"}, requesterUUID, http.StatusBadRequest},
+		{"malformedMember", nil, createCircleRequest{Name: "Family", MemberIDs: []string{"bob"}}, requesterUUID, http.StatusBadRequest},
+		{"selfMember", nil, createCircleRequest{Name: "Family", MemberIDs: []string{requesterUUID}}, requesterUUID, http.StatusBadRequest},
+		{"notFriend", nil, createCircleRequest{Name: "Family", MemberIDs: []string{strangerUUID}}, requesterUUID, http.StatusBadRequest},
+		{"duplicateName", nil, createCircleRequest{Name: "WORK"}, requesterUUID, http.StatusConflict},
+		{"missingStore", func(h CircleHandler) CircleHandler {
+			h.Circles = nil
+			return h
+		}, createCircleRequest{Name: "Family"}, requesterUUID, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newInMemoryCircleStore()
+			store.circles["work"] = models.Circle{ID: "work", OwnerID: requesterUUID, Name: "Work"}
+			handler := CircleHandler{Circles: store}
+			if tc.handler != nil {
+				handler = tc.handler(handler)
+			}
+			rec := postJSON(t, handler.Create, "/api/v1/circles", tc.payload, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCircleHandlerCreateLimit(t *testing.T) {
+	store := newInMemoryCircleStore()
+	for i := 0; i < maxCircles; i++ {
+		id := strings.Repeat("c", i+1)
+		store.circles[id] = models.Circle{ID: id, OwnerID: requesterUUID, Name: id}
+	}
+	handler := CircleHandler{Circles: store}
+
+	rec := postJSON(t, handler.Create, "/api/v1/circles", createCircleRequest{Name: "One too many"}, requesterUUID)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d got %d", http.StatusConflict, rec.Code)
+	}
+}
@@ -0,0 +1,635 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+const (
+	// maxCommentLength is the most characters a comment body may hold.
+	maxCommentLength = 2000
+	// maxCommentOffsetMs bounds comment offsets on videos whose length is unknown.
+	maxCommentOffsetMs = int64(24 * time.Hour / time.Millisecond)
+	// Bucket sizes accepted by the markers endpoint through the bucketMs query parameter.
+	defaultMarkerBucketMs = int64(10 * time.Second / time.Millisecond)
+	minMarkerBucketMs     = int64(time.Second / time.Millisecond)
+	maxMarkerBucketMs     = int64(time.Hour / time.Millisecond)
+)
+
+// CommentHandler implements the endpoints for commenting on video shares. Only users who can see a
+// share, including its owner, may read or write its comments.
+type CommentHandler struct {
+	Comments CommentStore
+	Videos   VideoStore
+	// Profiles is optional; when nil, authors are identified by ID only.
+	Profiles ProfileSummaries
+	NowFunc  func() time.Time
+}
+
+// List handles GET /api/v1/videos/{id}/comments requests for the comments starting threads on a
+// share, oldest first. With order=offset it lists the comments anchored to a moment in the video
+// in timeline order instead, optionally between fromMs and toMs.
+func (h CommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CommentHandler.List")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Comments == nil || h.Videos == nil {
+		logger.Error("comment service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "comment service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var byOffset bool
+	switch order := strings.TrimSpace(query.Get("order")); order {
+	case "", "created":
+	case "offset":
+		byOffset = true
+	default:
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "order must be created or offset"})
+		return
+	}
+
+	fromMs, toMs := int64(0), int64(math.MaxInt64)
+	for _, param := range []struct {
+		name  string
+		value *int64
+	}{{"fromMs", &fromMs}, {"toMs", &toMs}} {
+		name := param.name
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		if !byOffset {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": name + " requires order=offset"})
+			return
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": name + " must be a non-negative number of milliseconds"})
+			return
+		}
+		*param.value = parsed
+	}
+	if fromMs >= toMs {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "fromMs must be before toMs"})
+		return
+	}
+
+	limit, after, ok := commentPage(ctx, w, r, byOffset)
+	if !ok {
+		return
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	var comments []models.Comment
+	var err error
+	if byOffset {
+		comments, err = h.Comments.ListByOffset(ctx, share.ID, fromMs, toMs, after, limit+1)
+	} else {
+		comments, err = h.Comments.ListThreads(ctx, share.ID, after, limit+1)
+	}
+	if err != nil {
+		logger.Error("list comments failed", "error", err, "shareId", share.ID, "byOffset", byOffset)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load comments"})
+		return
+	}
+	respondJSON(ctx, w, http.StatusOK, h.listResponse(ctx, comments, limit, byOffset))
+}
+
+// Markers handles GET /api/v1/videos/{id}/comments/markers requests, which count the live comments
+// anchored to each bucketMs-long stretch of the video for drawing markers on a player's timeline.
+// Stretches without comments are left out.
+func (h CommentHandler) Markers(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CommentHandler.Markers")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Comments == nil || h.Videos == nil {
+		logger.Error("comment service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "comment service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	bucketMs := defaultMarkerBucketMs
+	if raw := strings.TrimSpace(r.URL.Query().Get("bucketMs")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < minMarkerBucketMs || parsed > maxMarkerBucketMs {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("bucketMs must be between %d and %d", minMarkerBucketMs, maxMarkerBucketMs)})
+			return
+		}
+		bucketMs = parsed
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	markers, err := h.Comments.CountByOffset(ctx, share.ID, bucketMs)
+	if err != nil {
+		logger.Error("count comments by offset failed", "error", err, "shareId", share.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load comment markers"})
+		return
+	}
+
+	resp := commentMarkersResponse{BucketMs: bucketMs, DurationMs: share.DurationMs, Markers: make([]commentMarker, 0, len(markers))}
+	for _, marker := range markers {
+		resp.Markers = append(resp.Markers, commentMarker{OffsetMs: marker.OffsetMs, Count: marker.Count})
+	}
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Replies handles GET /api/v1/videos/{id}/comments/{commentId}/replies requests for the replies to
+// a comment, oldest first.
+func (h CommentHandler) Replies(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CommentHandler.Replies")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Comments == nil || h.Videos == nil {
+		logger.Error("comment service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "comment service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	limit, after, ok := commentPage(ctx, w, r, false)
+	if !ok {
+		return
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	parent, ok := h.findComment(ctx, w, r, share.ID)
+	if !ok {
+		return
+	}
+
+	replies, err := h.Comments.ListReplies(ctx, share.ID, parent.ID, after, limit+1)
+	if err != nil {
+		logger.Error("list replies failed", "error", err, "shareId", share.ID, "commentId", parent.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load replies"})
+		return
+	}
+	respondJSON(ctx, w, http.StatusOK, h.listResponse(ctx, replies, limit, false))
+}
+
+// Create handles POST /api/v1/videos/{id}/comments requests. A parentId makes the comment a reply;
+// replies can only be made to live comments that start a thread. Comments starting a thread may
+// point at a moment in the video with offsetMs, which must lie within the video when its length
+// is known.
+func (h CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CommentHandler.Create")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Comments == nil || h.Videos == nil {
+		logger.Error("comment service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "comment service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req createCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid create comment payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	body, err := commentBody(req.Body)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	parentID := strings.TrimSpace(req.ParentID)
+	if req.OffsetMs != nil {
+		switch offset := *req.OffsetMs; {
+		case parentID != "":
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "replies cannot have an offset"})
+			return
+		case offset < 0 || (share.DurationMs > 0 && offset > share.DurationMs):
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "offsetMs must be within the video"})
+			return
+		case offset > maxCommentOffsetMs:
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("offsetMs must be at most %d", maxCommentOffsetMs)})
+			return
+		}
+	}
+	if parentID != "" {
+		if _, err := uuid.Parse(parentID); err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "parentId must name a comment on this share"})
+			return
+		}
+		parent, err := h.Comments.FindByID(ctx, share.ID, parentID)
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "parentId must name a comment on this share"})
+			return
+		case err != nil:
+			logger.Error("failed to load parent comment", "error", err, "shareId", share.ID, "commentId", parentID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to create comment"})
+			return
+		case parent.ParentID != "":
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "replies cannot be replied to"})
+			return
+		case parent.DeletedAt != nil:
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "cannot reply to a deleted comment"})
+			return
+		}
+	}
+
+	comment := models.Comment{
+		ID:        uuid.NewString(),
+		ShareID:   share.ID,
+		AuthorID:  userID,
+		ParentID:  parentID,
+		Body:      body,
+		OffsetMs:  req.OffsetMs,
+		CreatedAt: h.now(),
+	}
+	if err := h.Comments.Create(ctx, comment); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			// The share or the parent went away since they were loaded.
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+			return
+		}
+		logger.Error("create comment failed", "error", err, "shareId", share.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to create comment"})
+		return
+	}
+
+	logger.Info("comment created", "commentId", comment.ID, "shareId", share.ID, "userId", userID, "reply", parentID != "", "anchored", comment.OffsetMs != nil)
+	respondJSON(ctx, w, http.StatusCreated, h.commentView(ctx, comment))
+}
+
+// Update handles PATCH /api/v1/videos/{id}/comments/{commentId} requests. Only the author may edit
+// a comment, and deleted comments cannot be edited.
+func (h CommentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CommentHandler.Update")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPatch {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Comments == nil || h.Videos == nil {
+		logger.Error("comment service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "comment service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req updateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid update comment payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	body, err := commentBody(req.Body)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	comment, ok := h.findComment(ctx, w, r, share.ID)
+	if !ok {
+		return
+	}
+	if comment.AuthorID != userID {
+		respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "only the author can edit this comment"})
+		return
+	}
+	if comment.DeletedAt != nil {
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "comment was deleted"})
+		return
+	}
+
+	editedAt := h.now()
+	comment.Body = body
+	comment.EditedAt = &editedAt
+	if err := h.Comments.Update(ctx, comment); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "comment was deleted"})
+			return
+		}
+		logger.Error("update comment failed", "error", err, "commentId", comment.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to update comment"})
+		return
+	}
+
+	logger.Info("comment updated", "commentId", comment.ID, "shareId", share.ID, "userId", userID)
+	respondJSON(ctx, w, http.StatusOK, h.commentView(ctx, comment))
+}
+
+// Delete handles DELETE /api/v1/videos/{id}/comments/{commentId} requests. The author and the
+// share's owner may delete a comment. Deleted comments stay in their thread as tombstones so the
+// replies to them keep their place.
+func (h CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "CommentHandler.Delete")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Comments == nil || h.Videos == nil {
+		logger.Error("comment service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "comment service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	comment, ok := h.findComment(ctx, w, r, share.ID)
+	if !ok {
+		return
+	}
+	if comment.AuthorID != userID && share.OwnerID != userID {
+		respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "only the author or the share owner can delete this comment"})
+		return
+	}
+
+	if err := h.Comments.Delete(ctx, share.ID, comment.ID, h.now()); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "comment not found"})
+			return
+		}
+		logger.Error("delete comment failed", "error", err, "commentId", comment.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to delete comment"})
+		return
+	}
+
+	logger.Info("comment deleted", "commentId", comment.ID, "shareId", share.ID, "userId", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findShare loads the share named by the id path value, responding 404 Not Found when the caller
+// cannot see it.
+func (h CommentHandler) findShare(ctx context.Context, w http.ResponseWriter, r *http.Request, userID string) (models.VideoShare, bool) {
+	return VideoHandler{Videos: h.Videos}.findShare(ctx, w, r, userID)
+}
+
+// findComment loads the comment named by the commentId path value, tombstones included.
+func (h CommentHandler) findComment(ctx context.Context, w http.ResponseWriter, r *http.Request, shareID string) (models.Comment, bool) {
+	commentID := strings.TrimSpace(r.PathValue("commentId"))
+	if _, err := uuid.Parse(commentID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "comment not found"})
+		return models.Comment{}, false
+	}
+
+	comment, err := h.Comments.FindByID(ctx, shareID, commentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "comment not found"})
+			return models.Comment{}, false
+		}
+		logging.FromContext(ctx).Error("failed to load comment", "error", err, "commentId", commentID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to load comment"})
+		return models.Comment{}, false
+	}
+	return comment, true
+}
+
+// listResponse trims a page fetched with limit+1 rows to limit and sets the cursor for the next one.
+func (h CommentHandler) listResponse(ctx context.Context, comments []models.Comment, limit int, byOffset bool) listCommentsResponse {
+	var resp listCommentsResponse
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+		if byOffset && last.OffsetMs != nil {
+			resp.NextCursor = encodeCursor(last.CreatedAt, strconv.FormatInt(*last.OffsetMs, 10)+"/"+last.ID)
+		}
+	}
+
+	ids := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		if comment.AuthorID != "" {
+			ids = append(ids, comment.AuthorID)
+		}
+	}
+	summaries := profileSummaries(ctx, h.Profiles, ids)
+
+	resp.Comments = make([]commentView, 0, len(comments))
+	for _, comment := range comments {
+		resp.Comments = append(resp.Comments, newCommentView(comment, summaries[comment.AuthorID]))
+	}
+	return resp
+}
+
+func (h CommentHandler) commentView(ctx context.Context, comment models.Comment) commentView {
+	if comment.AuthorID == "" {
+		return newCommentView(comment, models.ProfileSummary{})
+	}
+	author := profileSummaries(ctx, h.Profiles, []string{comment.AuthorID})[comment.AuthorID]
+	return newCommentView(comment, author)
+}
+
+func (h CommentHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// commentPage reads the limit and cursor query parameters of the comment list endpoints. Cursors for
+// comments in offset order carry the offset in front of the comment's ID.
+func commentPage(ctx context.Context, w http.ResponseWriter, r *http.Request, byOffset bool) (int, *models.CommentCursor, bool) {
+	limit, err := pageSize(r)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return 0, nil, false
+	}
+
+	cursor := strings.TrimSpace(r.URL.Query().Get("cursor"))
+	if cursor == "" {
+		return limit, nil, true
+	}
+	createdAt, commentID, err := decodeCursor(cursor)
+	var offsetMs int64
+	if err == nil && byOffset {
+		offset, id, ok := strings.Cut(commentID, "/")
+		offsetMs, err = strconv.ParseInt(offset, 10, 64)
+		if !ok {
+			err = errInvalidCursor
+		}
+		commentID = id
+	}
+	if err == nil {
+		_, err = uuid.Parse(commentID)
+	}
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": errInvalidCursor.Error()})
+		return 0, nil, false
+	}
+	return limit, &models.CommentCursor{OffsetMs: offsetMs, CreatedAt: createdAt, CommentID: commentID}, true
+}
+
+// commentBody trims and validates a comment body. Line breaks are allowed.
+func commentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	switch {
+	case body == "":
+		return "", errors.New("body is required")
+	case utf8.RuneCountInString(body) > maxCommentLength:
+		return "", fmt.Errorf("body must be at most %d characters", maxCommentLength)
+	case strings.IndexFunc(body, func(r rune) bool { return r != '\n' && unicode.IsControl(r) }) >= 0:
+		return "", errors.New("body must not contain control characters other than line breaks")
+	}
+	return body, nil
+}
+
+type createCommentRequest struct {
+	Body     string `json:"body"`
+	ParentID string `json:"parentId"`
+	OffsetMs *int64 `json:"offsetMs"`
+}
+
+type updateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type listCommentsResponse struct {
+	Comments   []commentView `json:"comments"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// commentView is a comment together with its author's profile. Deleted comments keep their place
+// in the thread but lose their body.
+type commentView struct {
+	ID         string                `json:"id"`
+	ParentID   string                `json:"parentId,omitempty"`
+	Author     models.ProfileSummary `json:"author"`
+	Body       string                `json:"body,omitempty"`
+	OffsetMs   *int64                `json:"offsetMs,omitempty"`
+	CreatedAt  time.Time             `json:"createdAt"`
+	EditedAt   *time.Time            `json:"editedAt,omitempty"`
+	Deleted    bool                  `json:"deleted,omitempty"`
+	ReplyCount int                   `json:"replyCount"`
+}
+
+func newCommentView(comment models.Comment, author models.ProfileSummary) commentView {
+	return commentView{
+		ID:         comment.ID,
+		ParentID:   comment.ParentID,
+		Author:     author,
+		Body:       comment.Body,
+		OffsetMs:   comment.OffsetMs,
+		CreatedAt:  comment.CreatedAt,
+		EditedAt:   comment.EditedAt,
+		Deleted:    comment.DeletedAt != nil,
+		ReplyCount: comment.ReplyCount,
+	}
+}
+
+type commentMarkersResponse struct {
+	BucketMs int64 `json:"bucketMs"`
+	// DurationMs is the video's length, or zero when it is unknown.
+	DurationMs int64           `json:"durationMs"`
+	Markers    []commentMarker `json:"markers"`
+}
+
+type commentMarker struct {
+	OffsetMs int64 `json:"offsetMs"`
+	Count    int   `json:"count"`
+}
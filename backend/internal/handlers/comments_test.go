@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// failingCommentStore fails every write with err.
+type failingCommentStore struct {
+	CommentStore
+	err error
+}
+
+func (s failingCommentStore) Create(context.Context, models.Comment) error { return s.err }
+
+// uuidProfileStore fails like the Postgres store when asked for an ID that is not a UUID.
+type uuidProfileStore struct {
+	*inMemoryProfileStore
+}
+
+func (s uuidProfileStore) Summaries(ctx context.Context, userIDs []string) (map[string]models.ProfileSummary, error) {
+	for _, id := range userIDs {
+		if id == "" {
+			return nil, errors.New("invalid UUID")
+		}
+	}
+	return s.inMemoryProfileStore.Summaries(ctx, userIDs)
+}
+
+func commentRequest(t *testing.T, handler http.HandlerFunc, method, path, commentID string, payload any, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, "/api/v1/videos/"+path, &body)
+	req.SetPathValue("id", strings.SplitN(path, "/", 2)[0])
+	if commentID != "" {
+		req.SetPathValue("commentId", commentID)
+	}
+	if userID != "" {
+		req = withUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func decodeComment(t *testing.T, rec *httptest.ResponseRecorder, wantStatus int) commentView {
+	t.Helper()
+	if rec.Code != wantStatus {
+		t.Fatalf("expected status %d got %d: %s", wantStatus, rec.Code, rec.Body.String())
+	}
+	var view commentView
+	if err := json.NewDecoder(rec.Body).Decode(&view); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return view
+}
+
+func newCommentHandler(now *time.Time) CommentHandler {
+	profiles := newInMemoryProfileStore()
+	profiles.profiles[receiverUUID] = models.Profile{UserID: receiverUUID, Handle: "bob"}
+	return CommentHandler{
+		Comments: repositories.NewInMemoryCommentRepository(),
+		Videos:   newSharedVideoStore(),
+		Profiles: profiles,
+		NowFunc:  func() time.Time { return *now },
+	}
+}
+
+func TestCommentHandlerThreadsAndReplies(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	handler := newCommentHandler(&now)
+
+	post := func(payload createCommentRequest, userID string) commentView {
+		t.Helper()
+		now = now.Add(time.Minute)
+		return decodeComment(t, commentRequest(t, handler.Create, http.MethodPost, shareUUID+"/comments", "", payload, userID), http.StatusCreated)
+	}
+	first := post(createCommentRequest{Body: "  What a goal!\nAgain!  "}, receiverUUID)
+	if first.Body != "What a goal!\nAgain!" || first.Author.Handle != "bob" || first.ParentID != "" {
+		t.Fatalf("unexpected comment: %+v", first)
+	}
+	second := post(createCommentRequest{Body: "Thanks for watching"}, requesterUUID)
+	reply := post(createCommentRequest{Body: "Agreed", ParentID: first.ID}, requesterUUID)
+	if reply.ParentID != first.ID {
+		t.Fatalf("expected reply to %s, got %+v", first.ID, reply)
+	}
+
+	list := func(path, commentID string) listCommentsResponse {
+		t.Helper()
+		endpoint := handler.List
+		if commentID != "" {
+			endpoint = handler.Replies
+		}
+		rec := commentRequest(t, endpoint, http.MethodGet, path, commentID, nil, receiverUUID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp listCommentsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+
+	page := list(shareUUID+"/comments?limit=1", "")
+	if len(page.Comments) != 1 || page.Comments[0].ID != first.ID || page.Comments[0].ReplyCount != 1 || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page = list(shareUUID+"/comments?limit=1&cursor="+page.NextCursor, "")
+	if len(page.Comments) != 1 || page.Comments[0].ID != second.ID || page.NextCursor != "" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	replies := list(shareUUID+"/comments/"+first.ID+"/replies", first.ID)
+	if len(replies.Comments) != 1 || replies.Comments[0].ID != reply.ID {
+		t.Fatalf("unexpected replies: %+v", replies)
+	}
+
+	rec := commentRequest(t, handler.Create, http.MethodPost, shareUUID+"/comments", "", createCommentRequest{Body: "Nested", ParentID: reply.ID}, receiverUUID)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected reply to a reply to be rejected, got %d", rec.Code)
+	}
+	rec = commentRequest(t, handler.List, http.MethodGet, shareUUID+"/comments?cursor=bogus", "", nil, receiverUUID)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid cursor to be rejected, got %d", rec.Code)
+	}
+	rec = commentRequest(t, handler.Replies, http.MethodGet, shareUUID+"/comments/"+hiddenShareUUID+"/replies", hiddenShareUUID, nil, receiverUUID)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown comment to be rejected, got %d", rec.Code)
+	}
+	rec = commentRequest(t, handler.List, http.MethodGet, hiddenShareUUID+"/comments", "", nil, strangerUUID)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected share the caller cannot see to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestCommentHandlerEditAndDelete(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	handler := newCommentHandler(&now)
+	path := shareUUID + "/comments"
+
+	comment := decodeComment(t, commentRequest(t, handler.Create, http.MethodPost, path, "", createCommentRequest{Body: "First!"}, receiverUUID), http.StatusCreated)
+	commentPath := path + "/" + comment.ID
+
+	now = now.Add(time.Minute)
+	edited := decodeComment(t, commentRequest(t, handler.Update, http.MethodPatch, commentPath, comment.ID, updateCommentRequest{Body: "Second!"}, receiverUUID), http.StatusOK)
+	if edited.Body != "Second!" || edited.EditedAt == nil || !edited.EditedAt.Equal(now) {
+		t.Fatalf("unexpected edited comment: %+v", edited)
+	}
+	if rec := commentRequest(t, handler.Update, http.MethodPatch, commentPath, comment.ID, updateCommentRequest{Body: "Mine now"}, requesterUUID); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected only the author to edit, got %d", rec.Code)
+	}
+	if rec := commentRequest(t, handler.Update, http.MethodPatch, commentPath, comment.ID, updateCommentRequest{Body: " "}, receiverUUID); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty body to be rejected, got %d", rec.Code)
+	}
+
+	// The share owner may delete other people's comments.
+	if rec := commentRequest(t, handler.Delete, http.MethodDelete, commentPath, comment.ID, nil, requesterUUID); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+	if rec := commentRequest(t, handler.Delete, http.MethodDelete, commentPath, comment.ID, nil, receiverUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected second delete to be rejected, got %d", rec.Code)
+	}
+	if rec := commentRequest(t, handler.Update, http.MethodPatch, commentPath, comment.ID, updateCommentRequest{Body: "Back"}, receiverUUID); rec.Code != http.StatusConflict {
+		t.Fatalf("expected deleted comment to stay deleted, got %d", rec.Code)
+	}
+	if rec := commentRequest(t, handler.Create, http.MethodPost, path, "", createCommentRequest{Body: "Reply", ParentID: comment.ID}, requesterUUID); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected reply to a deleted comment to be rejected, got %d", rec.Code)
+	}
+
+	rec := commentRequest(t, handler.List, http.MethodGet, path, "", nil, receiverUUID)
+	var resp listCommentsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Comments) != 1 || !resp.Comments[0].Deleted || resp.Comments[0].Body != "" {
+		t.Fatalf("expected a tombstone, got %+v", resp.Comments)
+	}
+
+	// Only the author and the share owner may delete.
+	other := decodeComment(t, commentRequest(t, handler.Create, http.MethodPost, path, "", createCommentRequest{Body: "Owner here"}, requesterUUID), http.StatusCreated)
+	if rec := commentRequest(t, handler.Delete, http.MethodDelete, path+"/"+other.ID, other.ID, nil, receiverUUID); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d got %d", http.StatusForbidden, rec.Code)
+	}
+}
+
+func TestCommentHandlerDeletedAuthor(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	handler := newCommentHandler(&now)
+	handler.Profiles = uuidProfileStore{handler.Profiles.(*inMemoryProfileStore)}
+
+	// Deleting an account leaves its comments behind as tombstones without an author.
+	deletedAt := now.Add(time.Hour)
+	orphan := models.Comment{ID: "00000000-0000-0000-0000-0000000000c1", ShareID: shareUUID, CreatedAt: now, DeletedAt: &deletedAt}
+	if err := handler.Comments.Create(context.Background(), orphan); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	now = now.Add(time.Minute)
+	decodeComment(t, commentRequest(t, handler.Create, http.MethodPost, shareUUID+"/comments", "", createCommentRequest{Body: "Still here"}, receiverUUID), http.StatusCreated)
+
+	rec := commentRequest(t, handler.List, http.MethodGet, shareUUID+"/comments", "", nil, receiverUUID)
+	var resp listCommentsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Comments) != 2 || resp.Comments[0].Author != (models.ProfileSummary{}) || !resp.Comments[0].Deleted {
+		t.Fatalf("expected a tombstone without an author, got %+v", resp.Comments)
+	}
+	if resp.Comments[1].Author.Handle != "bob" {
+		t.Fatalf("expected the other authors to keep their profiles, got %+v", resp.Comments[1].Author)
+	}
+
+	if rec := commentRequest(t, handler.Delete, http.MethodDelete, shareUUID+"/comments/"+orphan.ID, orphan.ID, nil, receiverUUID); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected nobody but the share owner to delete it, got %d", rec.Code)
+	}
+}
+
+func TestCommentHandlerCreateFailures(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	valid := createCommentRequest{Body: "Nice"}
+
+	cases := []struct {
+		name       string
+		handler    CommentHandler
+		shareID    string
+		payload    any
+		userID     string
+		wantStatus int
+	}{
+		{"unauthenticated", newCommentHandler(&now), shareUUID, valid, "", http.StatusUnauthorized},
+		{"invalidBody", newCommentHandler(&now), shareUUID, "not an object", receiverUUID, http.StatusBadRequest},
+		{"emptyBody", newCommentHandler(&now), shareUUID, createCommentRequest{Body: "  "}, receiverUUID, http.StatusBadRequest},
+		{"bodyTooLong", newCommentHandler(&now), shareUUID, createCommentRequest{Body: strings.Repeat("a", maxCommentLength+1)}, receiverUUID, http.StatusBadRequest},
+		{"controlCharacters", newCommentHandler(&now), shareUUID, createCommentRequest{Body: "a\x00b"}, receiverUUID, http.StatusBadRequest},
+		{"malformedParent", newCommentHandler(&now), shareUUID, createCommentRequest{Body: "Nice", ParentID: "first"}, receiverUUID, http.StatusBadRequest},
+		{"unknownParent", newCommentHandler(&now), shareUUID, createCommentRequest{Body: "Nice", ParentID: hiddenShareUUID}, receiverUUID, http.StatusBadRequest},
+		{"unknownShare", newCommentHandler(&now), hiddenShareUUID, valid, receiverUUID, http.StatusNotFound},
+		{"malformedShare", newCommentHandler(&now), "clip", valid, receiverUUID, http.StatusNotFound},
+		{"storeError", CommentHandler{Comments: failingCommentStore{err: errors.New("db down")}, Videos: newSharedVideoStore()}, shareUUID, valid, receiverUUID, http.StatusInternalServerError},
+		{"missingStore", CommentHandler{Videos: newSharedVideoStore()}, shareUUID, valid, receiverUUID, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := commentRequest(t, tc.handler.Create, http.MethodPost, tc.shareID+"/comments", "", tc.payload, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCommentHandlerOffsets(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	handler := newCommentHandler(&now)
+	videos := handler.Videos.(*videoStoreStub)
+	share := videos.visible[shareUUID]
+	share.DurationMs = 222000
+	videos.visible[shareUUID] = share
+	path := shareUUID + "/comments"
+
+	post := func(offsetMs int64) commentView {
+		t.Helper()
+		now = now.Add(time.Minute)
+		view := decodeComment(t, commentRequest(t, handler.Create, http.MethodPost, path, "", createCommentRequest{Body: "look here", OffsetMs: &offsetMs}, receiverUUID), http.StatusCreated)
+		if view.OffsetMs == nil || *view.OffsetMs != offsetMs {
+			t.Fatalf("expected offset %d, got %+v", offsetMs, view)
+		}
+		return view
+	}
+	late := post(60000)
+	early := post(5000)
+	later := post(61000)
+	decodeComment(t, commentRequest(t, handler.Create, http.MethodPost, path, "", createCommentRequest{Body: "whole video"}, receiverUUID), http.StatusCreated)
+
+	negative, pastTheEnd, inside := int64(-1), int64(222001), int64(1000)
+	for name, payload := range map[string]createCommentRequest{
+		"negative":    {Body: "x", OffsetMs: &negative},
+		"pastTheEnd":  {Body: "x", OffsetMs: &pastTheEnd},
+		"replyOffset": {Body: "x", OffsetMs: &inside, ParentID: late.ID},
+	} {
+		if rec := commentRequest(t, handler.Create, http.MethodPost, path, "", payload, receiverUUID); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status %d got %d", name, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	list := func(query string) listCommentsResponse {
+		t.Helper()
+		rec := commentRequest(t, handler.List, http.MethodGet, path+query, "", nil, receiverUUID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp listCommentsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+	ids := func(views []commentView) []string {
+		var ids []string
+		for _, view := range views {
+			ids = append(ids, view.ID)
+		}
+		return ids
+	}
+
+	page := list("?order=offset&limit=2")
+	if got := ids(page.Comments); len(got) != 2 || got[0] != early.ID || got[1] != late.ID || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page = list("?order=offset&limit=2&cursor=" + page.NextCursor)
+	if got := ids(page.Comments); len(got) != 1 || got[0] != later.ID || page.NextCursor != "" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+	if got := ids(list("?order=offset&fromMs=60000&toMs=61000").Comments); len(got) != 1 || got[0] != late.ID {
+		t.Fatalf("expected range to apply, got %v", got)
+	}
+	if got := list("").Comments; len(got) != 4 {
+		t.Fatalf("expected creation order to include every thread, got %+v", got)
+	}
+
+	markers := func(query string) commentMarkersResponse {
+		t.Helper()
+		rec := commentRequest(t, handler.Markers, http.MethodGet, path+"/markers"+query, "", nil, receiverUUID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp commentMarkersResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+	got := markers("")
+	want := []commentMarker{{OffsetMs: 0, Count: 1}, {OffsetMs: 60000, Count: 2}}
+	if got.BucketMs != 10000 || got.DurationMs != 222000 || len(got.Markers) != 2 || got.Markers[0] != want[0] || got.Markers[1] != want[1] {
+		t.Fatalf("unexpected markers: %+v", got)
+	}
+
+	if rec := commentRequest(t, handler.Delete, http.MethodDelete, path+"/"+early.ID, early.ID, nil, receiverUUID); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+	if got := markers("?bucketMs=1000").Markers; len(got) != 2 || got[0].OffsetMs != 60000 || got[1].OffsetMs != 61000 {
+		t.Fatalf("expected deleted comments to be left out, got %+v", got)
+	}
+
+	for _, query := range []string{"?order=newest", "?fromMs=1000", "?order=offset&fromMs=-1", "?order=offset&fromMs=5&toMs=5", "?order=offset&cursor=" + encodeCursor(now, late.ID)} {
+		if rec := commentRequest(t, handler.List, http.MethodGet, path+query, "", nil, receiverUUID); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status %d got %d", query, http.StatusBadRequest, rec.Code)
+		}
+	}
+	if rec := commentRequest(t, handler.Markers, http.MethodGet, path+"/markers?bucketMs=10", "", nil, receiverUUID); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected tiny bucket to be rejected, got %d", rec.Code)
+	}
+}
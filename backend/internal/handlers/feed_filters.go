@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// maxMuteDuration is the furthest in the future a timed mute may end.
+const maxMuteDuration = 365 * 24 * time.Hour
+
+// FeedFilterHandler implements the endpoints for muting friends and hiding shares, which keep
+// them out of the caller's feed without ending any friendship.
+type FeedFilterHandler struct {
+	Filters FeedFilterStore
+	// Profiles is optional; when nil, muted users are identified by ID only.
+	Profiles ProfileSummaries
+	NowFunc  func() time.Time
+}
+
+// ListMutes handles GET /api/v1/mutes requests for the caller's mutes that are in effect.
+func (h FeedFilterHandler) ListMutes(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FeedFilterHandler.ListMutes")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Filters == nil {
+		logger.Error("feed filter service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "feed filter service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	mutes, err := h.Filters.ListMutes(ctx, userID, h.now())
+	if err != nil {
+		logger.Error("list mutes failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load mutes"})
+		return
+	}
+
+	ids := make([]string, 0, len(mutes))
+	for _, mute := range mutes {
+		ids = append(ids, mute.MutedID)
+	}
+	summaries := profileSummaries(ctx, h.Profiles, ids)
+
+	resp := listMutesResponse{Mutes: make([]muteView, 0, len(mutes))}
+	for _, mute := range mutes {
+		resp.Mutes = append(resp.Mutes, muteView{User: summaries[mute.MutedID], Until: mute.Until, CreatedAt: mute.CreatedAt})
+	}
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Mute handles PUT /api/v1/mutes/{id} requests. Without an until time the friend stays muted
+// until the mute is undone; muting again replaces the previous mute.
+func (h FeedFilterHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FeedFilterHandler.Mute")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPut {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Filters == nil {
+		logger.Error("feed filter service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "feed filter service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	mutedID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(mutedID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "friend not found"})
+		return
+	}
+	if mutedID == userID {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "cannot mute yourself"})
+		return
+	}
+
+	// The body is optional; an empty one mutes indefinitely.
+	var req muteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Warn("invalid mute payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	now := h.now()
+	if req.Until != nil {
+		until := req.Until.UTC()
+		if !until.After(now) || until.Sub(now) > maxMuteDuration {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "until must be in the future and within a year"})
+			return
+		}
+		req.Until = &until
+	}
+
+	mute := models.FriendMute{UserID: userID, MutedID: mutedID, Until: req.Until, CreatedAt: now}
+	if err := h.Filters.Mute(ctx, mute); err != nil {
+		if errors.Is(err, repositories.ErrNotFriends) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "friend not found"})
+			return
+		}
+		logger.Error("mute failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to mute friend"})
+		return
+	}
+
+	logger.Info("friend muted", "userId", userID, "mutedId", mutedID, "timed", mute.Until != nil)
+	summary := profileSummaries(ctx, h.Profiles, []string{mutedID})[mutedID]
+	respondJSON(ctx, w, http.StatusOK, muteView{User: summary, Until: mute.Until, CreatedAt: mute.CreatedAt})
+}
+
+// Unmute handles DELETE /api/v1/mutes/{id} requests.
+func (h FeedFilterHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FeedFilterHandler.Unmute")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Filters == nil {
+		logger.Error("feed filter service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "feed filter service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	mutedID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(mutedID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "mute not found"})
+		return
+	}
+
+	if err := h.Filters.Unmute(ctx, userID, mutedID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "mute not found"})
+			return
+		}
+		logger.Error("unmute failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to unmute friend"})
+		return
+	}
+
+	logger.Info("friend unmuted", "userId", userID, "mutedId", mutedID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListHidden handles GET /api/v1/hidden-shares requests.
+func (h FeedFilterHandler) ListHidden(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FeedFilterHandler.ListHidden")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Filters == nil {
+		logger.Error("feed filter service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "feed filter service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	hidden, err := h.Filters.ListHidden(ctx, userID)
+	if err != nil {
+		logger.Error("list hidden shares failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load hidden shares"})
+		return
+	}
+
+	resp := listHiddenSharesResponse{Shares: make([]hiddenShareView, 0, len(hidden))}
+	for _, share := range hidden {
+		resp.Shares = append(resp.Shares, hiddenShareView{
+			ShareID:  share.ShareID,
+			OwnerID:  share.OwnerID,
+			URL:      share.URL,
+			Title:    share.Title,
+			HiddenAt: share.HiddenAt,
+		})
+	}
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Hide handles PUT /api/v1/hidden-shares/{id} requests for shares the caller can see.
+func (h FeedFilterHandler) Hide(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FeedFilterHandler.Hide")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPut {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Filters == nil {
+		logger.Error("feed filter service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "feed filter service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	shareID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(shareID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+		return
+	}
+
+	if err := h.Filters.HideShare(ctx, userID, shareID, h.now()); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+			return
+		}
+		logger.Error("hide share failed", "error", err, "userId", userID, "shareId", shareID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to hide share"})
+		return
+	}
+
+	logger.Info("share hidden", "userId", userID, "shareId", shareID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unhide handles DELETE /api/v1/hidden-shares/{id} requests.
+func (h FeedFilterHandler) Unhide(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FeedFilterHandler.Unhide")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Filters == nil {
+		logger.Error("feed filter service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "feed filter service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	shareID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(shareID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "hidden share not found"})
+		return
+	}
+
+	if err := h.Filters.UnhideShare(ctx, userID, shareID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "hidden share not found"})
+			return
+		}
+		logger.Error("unhide share failed", "error", err, "userId", userID, "shareId", shareID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to unhide share"})
+		return
+	}
+
+	logger.Info("share unhidden", "userId", userID, "shareId", shareID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h FeedFilterHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+type muteRequest struct {
+	Until *time.Time `json:"until"`
+}
+
+type muteView struct {
+	User      models.ProfileSummary `json:"user"`
+	Until     *time.Time            `json:"until,omitempty"`
+	CreatedAt time.Time             `json:"createdAt"`
+}
+
+type listMutesResponse struct {
+	Mutes []muteView `json:"mutes"`
+}
+
+type hiddenShareView struct {
+	ShareID  string    `json:"shareId"`
+	OwnerID  string    `json:"ownerId"`
+	URL      string    `json:"url"`
+	Title    string    `json:"title"`
+	HiddenAt time.Time `json:"hiddenAt"`
+}
+
+type listHiddenSharesResponse struct {
+	Shares []hiddenShareView `json:"shares"`
+}
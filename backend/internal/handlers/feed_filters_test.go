@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+const hiddenShareUUID = "99999999-9999-9999-9999-999999999999"
+
+// inMemoryFeedFilterStore treats every user in friends as an accepted friend of every user and
+// every share in shares as visible to everyone.
+type inMemoryFeedFilterStore struct {
+	mutes   map[[2]string]models.FriendMute
+	hidden  map[[2]string]models.HiddenShare
+	friends map[string]bool
+	shares  map[string]models.VideoShare
+	err     error
+}
+
+func newInMemoryFeedFilterStore() *inMemoryFeedFilterStore {
+	return &inMemoryFeedFilterStore{
+		mutes:   make(map[[2]string]models.FriendMute),
+		hidden:  make(map[[2]string]models.HiddenShare),
+		friends: map[string]bool{receiverUUID: true, thirdUUID: true},
+		shares:  map[string]models.VideoShare{hiddenShareUUID: {ID: hiddenShareUUID, OwnerID: receiverUUID, URL: "https://example.com/v", Title: "Clip"}},
+	}
+}
+
+func (s *inMemoryFeedFilterStore) Mute(_ context.Context, mute models.FriendMute) error {
+	if s.err != nil {
+		return s.err
+	}
+	if !s.friends[mute.MutedID] {
+		return repositories.ErrNotFriends
+	}
+	s.mutes[[2]string{mute.UserID, mute.MutedID}] = mute
+	return nil
+}
+
+func (s *inMemoryFeedFilterStore) ListMutes(_ context.Context, userID string, at time.Time) ([]models.FriendMute, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var mutes []models.FriendMute
+	for key, mute := range s.mutes {
+		if key[0] == userID && (mute.Until == nil || mute.Until.After(at)) {
+			mutes = append(mutes, mute)
+		}
+	}
+	return mutes, nil
+}
+
+func (s *inMemoryFeedFilterStore) Unmute(_ context.Context, userID, mutedID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	key := [2]string{userID, mutedID}
+	if _, ok := s.mutes[key]; !ok {
+		return repositories.ErrNotFound
+	}
+	delete(s.mutes, key)
+	return nil
+}
+
+func (s *inMemoryFeedFilterStore) HideShare(_ context.Context, userID, shareID string, at time.Time) error {
+	if s.err != nil {
+		return s.err
+	}
+	share, ok := s.shares[shareID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	key := [2]string{userID, shareID}
+	if _, ok := s.hidden[key]; !ok {
+		s.hidden[key] = models.HiddenShare{ShareID: share.ID, OwnerID: share.OwnerID, URL: share.URL, Title: share.Title, HiddenAt: at}
+	}
+	return nil
+}
+
+func (s *inMemoryFeedFilterStore) ListHidden(_ context.Context, userID string) ([]models.HiddenShare, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var hidden []models.HiddenShare
+	for key, share := range s.hidden {
+		if key[0] == userID {
+			hidden = append(hidden, share)
+		}
+	}
+	return hidden, nil
+}
+
+func (s *inMemoryFeedFilterStore) UnhideShare(_ context.Context, userID, shareID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	key := [2]string{userID, shareID}
+	if _, ok := s.hidden[key]; !ok {
+		return repositories.ErrNotFound
+	}
+	delete(s.hidden, key)
+	return nil
+}
+
+func feedFilterRequest(t *testing.T, handler http.HandlerFunc, method, id string, payload any, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, "/api/v1/mutes/"+id, &body)
+	req.SetPathValue("id", id)
+	if userID != "" {
+		req = withUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestFeedFilterHandlerMutes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	profiles := newInMemoryProfileStore()
+	profiles.profiles[receiverUUID] = models.Profile{UserID: receiverUUID, Handle: "bob"}
+	handler := FeedFilterHandler{Filters: newInMemoryFeedFilterStore(), Profiles: profiles, NowFunc: func() time.Time { return now }}
+
+	rec := feedFilterRequest(t, handler.Mute, http.MethodPut, receiverUUID, nil, requesterUUID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var view muteView
+	if err := json.NewDecoder(rec.Body).Decode(&view); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if view.User.Handle != "bob" || view.Until != nil {
+		t.Fatalf("expected indefinite mute of bob, got %+v", view)
+	}
+
+	until := now.Add(time.Hour)
+	rec = feedFilterRequest(t, handler.Mute, http.MethodPut, thirdUUID, muteRequest{Until: &until}, requesterUUID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	listMutes := func() []muteView {
+		t.Helper()
+		req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/mutes", nil), requesterUUID)
+		rec := httptest.NewRecorder()
+		handler.ListMutes(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+		}
+		var resp listMutesResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp.Mutes
+	}
+	if got := listMutes(); len(got) != 2 {
+		t.Fatalf("expected two mutes, got %+v", got)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if got := listMutes(); len(got) != 1 || got[0].User.UserID != receiverUUID {
+		t.Fatalf("expected expired mute to be left out, got %+v", got)
+	}
+
+	if rec := feedFilterRequest(t, handler.Unmute, http.MethodDelete, receiverUUID, nil, requesterUUID); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+	if got := listMutes(); len(got) != 0 {
+		t.Fatalf("expected no mutes, got %+v", got)
+	}
+	if rec := feedFilterRequest(t, handler.Unmute, http.MethodDelete, receiverUUID, nil, requesterUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestFeedFilterHandlerMuteFailures(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	farFuture := now.Add(maxMuteDuration + time.Hour)
+
+	cases := []struct {
+		name       string
+		store      FeedFilterStore
+		id         string
+		payload    any
+		userID     string
+		wantStatus int
+	}{
+		{"unauthenticated", newInMemoryFeedFilterStore(), receiverUUID, nil, "", http.StatusUnauthorized},
+		{"malformedID", newInMemoryFeedFilterStore(), "bob", nil, requesterUUID, http.StatusNotFound},
+		{"self", newInMemoryFeedFilterStore(), requesterUUID, nil, requesterUUID, http.StatusBadRequest},
+		{"notFriend", newInMemoryFeedFilterStore(), strangerUUID, nil, requesterUUID, http.StatusNotFound},
+		{"invalidBody", newInMemoryFeedFilterStore(), receiverUUID, "not an object", requesterUUID, http.StatusBadRequest},
+		{"pastUntil", newInMemoryFeedFilterStore(), receiverUUID, muteRequest{Until: &past}, requesterUUID, http.StatusBadRequest},
+		{"untilTooFar", newInMemoryFeedFilterStore(), receiverUUID, muteRequest{Until: &farFuture}, requesterUUID, http.StatusBadRequest},
+		{"storeError", &inMemoryFeedFilterStore{err: errors.New("db down")}, receiverUUID, nil, requesterUUID, http.StatusInternalServerError},
+		{"missingStore", nil, receiverUUID, nil, requesterUUID, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := FeedFilterHandler{Filters: tc.store, NowFunc: func() time.Time { return now }}
+			rec := feedFilterRequest(t, handler.Mute, http.MethodPut, tc.id, tc.payload, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFeedFilterHandlerHiddenShares(t *testing.T) {
+	handler := FeedFilterHandler{Filters: newInMemoryFeedFilterStore()}
+
+	for i := 0; i < 2; i++ {
+		if rec := feedFilterRequest(t, handler.Hide, http.MethodPut, hiddenShareUUID, nil, requesterUUID); rec.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := feedFilterRequest(t, handler.Hide, http.MethodPut, strangerUUID, nil, requesterUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown share to be rejected, got %d", rec.Code)
+	}
+	if rec := feedFilterRequest(t, handler.Hide, http.MethodPut, "clip", nil, requesterUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected malformed id to be rejected, got %d", rec.Code)
+	}
+
+	listHidden := func() []hiddenShareView {
+		t.Helper()
+		req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/hidden-shares", nil), requesterUUID)
+		rec := httptest.NewRecorder()
+		handler.ListHidden(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+		}
+		var resp listHiddenSharesResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp.Shares
+	}
+	if got := listHidden(); len(got) != 1 || got[0].ShareID != hiddenShareUUID || got[0].Title != "Clip" {
+		t.Fatalf("unexpected hidden shares: %+v", got)
+	}
+
+	if rec := feedFilterRequest(t, handler.Unhide, http.MethodDelete, hiddenShareUUID, nil, requesterUUID); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+	if got := listHidden(); len(got) != 0 {
+		t.Fatalf("expected no hidden shares, got %+v", got)
+	}
+	if rec := feedFilterRequest(t, handler.Unhide, http.MethodDelete, hiddenShareUUID, nil, requesterUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d got %d", http.StatusNotFound, rec.Code)
+	}
+	if rec := feedFilterRequest(t, handler.Hide, http.MethodPut, hiddenShareUUID, nil, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
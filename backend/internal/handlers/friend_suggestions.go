@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// Mutual handles GET /api/v1/friends/mutual/{id} requests for the friends the caller has in
+// common with another user. Users who blocked the caller, or were blocked by them, are reported
+// as not found.
+func (h FriendHandler) Mutual(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.Mutual")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Friends == nil {
+		logger.Error("friend service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "friend service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	otherID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(otherID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	}
+	if otherID == userID {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "cannot compare friends with yourself"})
+		return
+	}
+
+	if h.Users != nil {
+		if _, err := h.Users.FindByID(ctx, otherID); err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+				return
+			}
+			logger.Error("mutual friends user lookup failed", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to list mutual friends"})
+			return
+		}
+	}
+
+	existing, err := h.Friends.FindBetween(ctx, userID, otherID)
+	switch {
+	case err == nil && existing.Status == friendStatusBlocked:
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	case err != nil && !errors.Is(err, repositories.ErrNotFound):
+		logger.Error("mutual friends request lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to list mutual friends"})
+		return
+	}
+
+	ids, err := h.Friends.ListMutual(ctx, userID, otherID)
+	if err != nil {
+		logger.Error("failed to list mutual friends", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to list mutual friends"})
+		return
+	}
+
+	summaries := profileSummaries(ctx, h.Profiles, ids)
+	resp := mutualFriendsResponse{Users: make([]models.ProfileSummary, 0, len(ids)), Count: len(ids)}
+	for _, id := range ids {
+		resp.Users = append(resp.Users, summaries[id])
+	}
+
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Suggestions handles GET /api/v1/friends/suggestions requests for friends of the caller's
+// friends, ranked by how many friends they have in common with the caller. The limit query
+// parameter caps the number of suggestions.
+func (h FriendHandler) Suggestions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.Suggestions")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Friends == nil {
+		logger.Error("friend service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "friend service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	limit, err := pageSize(r)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	suggestions, err := h.Friends.ListSuggestions(ctx, userID, limit)
+	if err != nil {
+		logger.Error("failed to list friend suggestions", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to list friend suggestions"})
+		return
+	}
+
+	ids := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		ids = append(ids, suggestion.UserID)
+	}
+	summaries := profileSummaries(ctx, h.Profiles, ids)
+
+	resp := friendSuggestionsResponse{Suggestions: make([]friendSuggestionEntry, 0, len(suggestions))}
+	for _, suggestion := range suggestions {
+		resp.Suggestions = append(resp.Suggestions, friendSuggestionEntry{
+			User:        summaries[suggestion.UserID],
+			MutualCount: suggestion.MutualCount,
+		})
+	}
+
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// DismissSuggestion handles DELETE /api/v1/friends/suggestions/{id} requests, which stop the user
+// from being suggested to the caller again.
+func (h FriendHandler) DismissSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.DismissSuggestion")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Friends == nil {
+		logger.Error("friend service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "friend service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	candidateID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(candidateID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	}
+	if candidateID == userID {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "cannot dismiss yourself"})
+		return
+	}
+
+	if err := h.Friends.DismissSuggestion(ctx, userID, candidateID, h.now()); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+			return
+		}
+		logger.Error("failed to dismiss friend suggestion", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to dismiss suggestion"})
+		return
+	}
+
+	logger.Info("friend suggestion dismissed", "userId", userID, "candidateId", candidateID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type mutualFriendsResponse struct {
+	Users []models.ProfileSummary `json:"users"`
+	Count int                     `json:"count"`
+}
+
+type friendSuggestionsResponse struct {
+	Suggestions []friendSuggestionEntry `json:"suggestions"`
+}
+
+// friendSuggestionEntry is a suggested user and the number of friends they share with the caller.
+type friendSuggestionEntry struct {
+	User        models.ProfileSummary `json:"user"`
+	MutualCount int                   `json:"mutualCount"`
+}
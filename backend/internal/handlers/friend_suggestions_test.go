@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+func addFriendRequest(store *inMemoryFriendStore, requester, receiver, status string) {
+	id := uuid.NewString()
+	store.requests[id] = models.FriendRequest{ID: id, Requester: requester, Receiver: receiver, Status: status, ActorID: requester}
+}
+
+func friendRequestWithID(t *testing.T, handler http.HandlerFunc, method, path, id, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if id != "" {
+		req.SetPathValue("id", id)
+	}
+	if userID != "" {
+		req = withUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestFriendHandlerMutual(t *testing.T) {
+	const blockedUUID = "55555555-5555-5555-5555-555555555555"
+
+	store := newInMemoryFriendStore()
+	addFriendRequest(store, requesterUUID, receiverUUID, friendStatusAccepted)
+	addFriendRequest(store, thirdUUID, requesterUUID, friendStatusAccepted)
+	addFriendRequest(store, strangerUUID, receiverUUID, friendStatusAccepted)
+	addFriendRequest(store, thirdUUID, strangerUUID, friendStatusAccepted)
+	addFriendRequest(store, blockedUUID, receiverUUID, friendStatusAccepted)
+	addFriendRequest(store, requesterUUID, blockedUUID, friendStatusBlocked)
+	profiles := newInMemoryProfileStore()
+	profiles.profiles[receiverUUID] = models.Profile{UserID: receiverUUID, Handle: "bob"}
+	handler := FriendHandler{Friends: store, Profiles: profiles}
+
+	rec := friendRequestWithID(t, handler.Mutual, http.MethodGet, "/api/v1/friends/mutual/"+strangerUUID, strangerUUID, requesterUUID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp mutualFriendsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Count != 2 || len(resp.Users) != 2 || resp.Users[0].Handle != "bob" || resp.Users[1].UserID != thirdUUID {
+		t.Fatalf("unexpected mutual friends: %+v", resp)
+	}
+
+	cases := []struct {
+		name       string
+		handler    FriendHandler
+		id         string
+		userID     string
+		wantStatus int
+	}{
+		{"blocked", handler, blockedUUID, requesterUUID, http.StatusNotFound},
+		{"malformedID", handler, "bob", requesterUUID, http.StatusNotFound},
+		{"self", handler, requesterUUID, requesterUUID, http.StatusBadRequest},
+		{"unknownUser", FriendHandler{Friends: store, Users: newInMemoryUserStore()}, strangerUUID, requesterUUID, http.StatusNotFound},
+		{"unauthenticated", handler, strangerUUID, "", http.StatusUnauthorized},
+		{"storeError", FriendHandler{Friends: &stubFriendStore{listErr: errors.New("db down")}}, strangerUUID, requesterUUID, http.StatusInternalServerError},
+		{"missingStore", FriendHandler{}, strangerUUID, requesterUUID, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := friendRequestWithID(t, tc.handler.Mutual, http.MethodGet, "/api/v1/friends/mutual/"+tc.id, tc.id, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFriendHandlerSuggestions(t *testing.T) {
+	const (
+		pendingUUID   = "55555555-5555-5555-5555-555555555555"
+		blockedUUID   = "66666666-6666-6666-6666-666666666666"
+		lonelyUUID    = "77777777-7777-7777-7777-777777777777"
+		dismissedUUID = "88888888-8888-8888-8888-888888888888"
+	)
+
+	// The caller is friends with receiverUUID and thirdUUID. strangerUUID is a friend of both,
+	// the others of receiverUUID only.
+	store := newInMemoryFriendStore()
+	addFriendRequest(store, requesterUUID, receiverUUID, friendStatusAccepted)
+	addFriendRequest(store, thirdUUID, requesterUUID, friendStatusAccepted)
+	for _, candidate := range []string{strangerUUID, pendingUUID, blockedUUID, lonelyUUID, dismissedUUID} {
+		addFriendRequest(store, receiverUUID, candidate, friendStatusAccepted)
+	}
+	addFriendRequest(store, thirdUUID, strangerUUID, friendStatusAccepted)
+	addFriendRequest(store, pendingUUID, requesterUUID, friendStatusPending)
+	addFriendRequest(store, blockedUUID, requesterUUID, friendStatusBlocked)
+	handler := FriendHandler{Friends: store}
+
+	suggestions := func(query string) []friendSuggestionEntry {
+		t.Helper()
+		req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/friends/suggestions"+query, nil), requesterUUID)
+		rec := httptest.NewRecorder()
+		handler.Suggestions(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp friendSuggestionsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp.Suggestions
+	}
+
+	got := suggestions("")
+	if len(got) != 3 || got[0].User.UserID != strangerUUID || got[0].MutualCount != 2 ||
+		got[1].User.UserID != lonelyUUID || got[2].User.UserID != dismissedUUID || got[2].MutualCount != 1 {
+		t.Fatalf("unexpected suggestions: %+v", got)
+	}
+	if got := suggestions("?limit=1"); len(got) != 1 || got[0].User.UserID != strangerUUID {
+		t.Fatalf("expected limit to apply, got %+v", got)
+	}
+
+	rec := friendRequestWithID(t, handler.DismissSuggestion, http.MethodDelete, "/api/v1/friends/suggestions/"+dismissedUUID, dismissedUUID, requesterUUID)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+	if got := suggestions(""); len(got) != 2 || got[1].User.UserID != lonelyUUID {
+		t.Fatalf("expected dismissed user to be left out, got %+v", got)
+	}
+
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/friends/suggestions?limit=0", nil), requesterUUID)
+	rec = httptest.NewRecorder()
+	handler.Suggestions(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid limit to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestFriendHandlerDismissSuggestionFailures(t *testing.T) {
+	cases := []struct {
+		name       string
+		handler    FriendHandler
+		id         string
+		userID     string
+		wantStatus int
+	}{
+		{"malformedID", FriendHandler{Friends: &stubFriendStore{}}, "bob", requesterUUID, http.StatusNotFound},
+		{"self", FriendHandler{Friends: &stubFriendStore{}}, requesterUUID, requesterUUID, http.StatusBadRequest},
+		{"unknownUser", FriendHandler{Friends: &stubFriendStore{updateErr: repositories.ErrNotFound}}, receiverUUID, requesterUUID, http.StatusNotFound},
+		{"storeError", FriendHandler{Friends: &stubFriendStore{updateErr: errors.New("db down")}}, receiverUUID, requesterUUID, http.StatusInternalServerError},
+		{"unauthenticated", FriendHandler{Friends: &stubFriendStore{}}, receiverUUID, "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := friendRequestWithID(t, tc.handler.DismissSuggestion, http.MethodDelete, "/api/v1/friends/suggestions/"+tc.id, tc.id, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,22 +13,79 @@ import (
 
 	"github.com/vidfriends/backend/internal/logging"
 	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/profiles"
 	"github.com/vidfriends/backend/internal/repositories"
 )
 
 const (
-	friendStatusPending  = "pending"
-	friendStatusAccepted = "accepted"
-	friendStatusBlocked  = "blocked"
+	friendStatusPending   = "pending"
+	friendStatusAccepted  = "accepted"
+	friendStatusDeclined  = "declined"
+	friendStatusCancelled = "cancelled"
+	friendStatusRemoved   = "removed"
+	friendStatusBlocked   = "blocked"
 )
 
+// friendActor names the user who may apply a friendAction.
+type friendActor int
+
+const (
+	friendActorReceiver friendActor = iota
+	friendActorRequester
+	friendActorEither
+	// friendActorBlocker is whichever user blocked the other.
+	friendActorBlocker
+)
+
+// friendAction is a change users may make to a friend request through Respond.
+type friendAction struct {
+	from []string
+	to   string
+	by   friendActor
+}
+
+var friendActions = map[string]friendAction{
+	"accept":   {from: []string{friendStatusPending}, to: friendStatusAccepted, by: friendActorReceiver},
+	"decline":  {from: []string{friendStatusPending}, to: friendStatusDeclined, by: friendActorReceiver},
+	"cancel":   {from: []string{friendStatusPending}, to: friendStatusCancelled, by: friendActorRequester},
+	"unfriend": {from: []string{friendStatusAccepted}, to: friendStatusRemoved, by: friendActorEither},
+	"block": {
+		from: []string{friendStatusPending, friendStatusAccepted, friendStatusDeclined, friendStatusCancelled, friendStatusRemoved},
+		to:   friendStatusBlocked,
+		by:   friendActorEither,
+	},
+	"unblock": {from: []string{friendStatusBlocked}, to: friendStatusRemoved, by: friendActorBlocker},
+}
+
+// permits reports whether the user may apply the action to the request.
+func (a friendAction) permits(request models.FriendRequest, userID string) bool {
+	switch a.by {
+	case friendActorReceiver:
+		return request.Receiver == userID
+	case friendActorRequester:
+		return request.Requester == userID
+	case friendActorBlocker:
+		return request.ActorID == userID
+	default:
+		return request.Requester == userID || request.Receiver == userID
+	}
+}
+
 // FriendHandler provides friend invite and listing endpoints.
 type FriendHandler struct {
 	Friends     FriendStore
 	NowFunc     func() time.Time
 	RateLimiter RateLimiter
+	// Users resolves invites and searches by e-mail address.
+	Users UserStore
+	// Profiles is optional; when nil, listed users are identified by ID only and handles can
+	// neither be searched nor invited.
+	Profiles ProfileStore
 }
 
+// userSearchLimit bounds the number of handle matches returned by a search.
+const userSearchLimit = 20
+
 // Invite handles POST /api/v1/friends/invite.
 func (h FriendHandler) Invite(w http.ResponseWriter, r *http.Request) {
 	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.Invite")
@@ -52,6 +111,11 @@ func (h FriendHandler) Invite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	requesterID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
 	var req inviteFriendRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Warn("invalid invite payload", "error", err)
@@ -59,24 +123,33 @@ func (h FriendHandler) Invite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req.RequesterID = strings.TrimSpace(req.RequesterID)
 	req.ReceiverID = strings.TrimSpace(req.ReceiverID)
+	req.Receiver = strings.TrimSpace(req.Receiver)
 
-	if req.RequesterID == "" || req.ReceiverID == "" {
-		logger.Warn("invite missing participants", "requesterId", req.RequesterID, "receiverId", req.ReceiverID)
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "requesterId and receiverId are required"})
+	if req.ReceiverID == "" && req.Receiver == "" {
+		logger.Warn("invite missing receiver", "requesterId", requesterID)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "receiver or receiverId is required"})
 		return
 	}
 
-	if req.RequesterID == req.ReceiverID {
-		logger.Warn("invite attempted self", "userId", req.RequesterID)
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "cannot invite yourself"})
-		return
+	if req.ReceiverID == "" {
+		receiverID, err := h.resolveUser(ctx, req.Receiver)
+		if err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				logger.Warn("friend invite target missing", "requesterId", requesterID)
+				respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+				return
+			}
+			logger.Error("resolve friend invite target failed", "error", err, "requesterId", requesterID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create friend request"})
+			return
+		}
+		req.ReceiverID = receiverID
 	}
 
-	if _, err := uuid.Parse(req.RequesterID); err != nil {
-		logger.Warn("invite invalid requester id", "requesterId", req.RequesterID, "error", err)
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid requesterId"})
+	if requesterID == req.ReceiverID {
+		logger.Warn("invite attempted self", "userId", requesterID)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "cannot invite yourself"})
 		return
 	}
 
@@ -89,22 +162,23 @@ func (h FriendHandler) Invite(w http.ResponseWriter, r *http.Request) {
 	now := h.now()
 	friendReq := models.FriendRequest{
 		ID:        uuid.NewString(),
-		Requester: req.RequesterID,
+		Requester: requesterID,
 		Receiver:  req.ReceiverID,
 		Status:    friendStatusPending,
 		CreatedAt: now,
+		ActorID:   requesterID,
+		UpdatedAt: now,
 	}
 
 	if err := h.Friends.CreateRequest(ctx, friendReq); err != nil {
 		switch {
 		case errors.Is(err, repositories.ErrConflict):
-			logger.Warn("friend request already exists", "requesterId", req.RequesterID, "receiverId", req.ReceiverID)
-			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "friend request already exists"})
+			h.reinvite(ctx, w, requesterID, req.ReceiverID, now)
 		case errors.Is(err, repositories.ErrNotFound):
-			logger.Warn("friend invite target missing", "requesterId", req.RequesterID, "receiverId", req.ReceiverID)
+			logger.Warn("friend invite target missing", "requesterId", requesterID, "receiverId", req.ReceiverID)
 			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
 		default:
-			logger.Error("failed to create friend request", "error", err, "requesterId", req.RequesterID, "receiverId", req.ReceiverID)
+			logger.Error("failed to create friend request", "error", err, "requesterId", requesterID, "receiverId", req.ReceiverID)
 			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create friend request"})
 		}
 		return
@@ -113,6 +187,80 @@ func (h FriendHandler) Invite(w http.ResponseWriter, r *http.Request) {
 	respondJSON(ctx, w, http.StatusCreated, friendRequestResponse{Request: friendReq})
 }
 
+// Search handles GET /api/v1/users/search?q= requests. A query containing an e-mail address only
+// matches that exact address so accounts cannot be enumerated by e-mail; anything else is treated
+// as the start of a handle.
+func (h FriendHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.Search")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "users:search") {
+		logger.Warn("rate limit exceeded", "scope", "users:search")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many user searches"})
+		return
+	}
+
+	if h.Users == nil || h.Profiles == nil {
+		logger.Error("user search dependencies unavailable", "hasUsers", h.Users != nil, "hasProfiles", h.Profiles != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "user search unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "q is required"})
+		return
+	}
+
+	var matches []models.ProfileSummary
+	if isEmailQuery(query) {
+		user, err := h.Users.FindByEmail(ctx, strings.ToLower(query))
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			logger.Error("user search by email failed", "error", err)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to search users"})
+			return
+		}
+		if err == nil {
+			matches = append(matches, profileSummaries(ctx, h.Profiles, []string{user.ID})[user.ID])
+		}
+	} else {
+		prefix, err := profiles.NormalizeHandlePrefix(query)
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		// Ask for one extra match in case the caller's own profile is among them.
+		matches, err = h.Profiles.SearchHandles(ctx, prefix, userSearchLimit+1)
+		if err != nil {
+			logger.Error("user search by handle failed", "error", err)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to search users"})
+			return
+		}
+	}
+
+	users := make([]models.ProfileSummary, 0, len(matches))
+	for _, match := range matches {
+		if match.UserID != userID && len(users) < userSearchLimit {
+			users = append(users, match)
+		}
+	}
+
+	respondJSON(ctx, w, http.StatusOK, searchUsersResponse{Users: users})
+}
+
 // List handles GET /api/v1/friends requests.
 func (h FriendHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.List")
@@ -132,10 +280,8 @@ func (h FriendHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := strings.TrimSpace(r.URL.Query().Get("user"))
-	if userID == "" {
-		logger.Warn("list friends missing user id")
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "user query parameter is required"})
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
 		return
 	}
 
@@ -146,10 +292,124 @@ func (h FriendHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(ctx, w, http.StatusOK, listFriendsResponse{Requests: requests})
+	ids := make([]string, 0, 2*len(requests))
+	for _, request := range requests {
+		ids = append(ids, request.Requester, request.Receiver)
+	}
+	summaries := profileSummaries(ctx, h.Profiles, ids)
+
+	entries := make([]friendEntry, 0, len(requests))
+	for _, request := range requests {
+		entries = append(entries, friendEntry{
+			FriendRequest:    request,
+			RequesterProfile: summaries[request.Requester],
+			ReceiverProfile:  summaries[request.Receiver],
+		})
+	}
+
+	respondJSON(ctx, w, http.StatusOK, listFriendsResponse{Requests: entries})
 }
 
-// Respond handles POST /api/v1/friends/respond requests to accept or block invites.
+// View handles GET /api/v1/friends/{view} requests for one page of the caller's friends
+// ("accepted"), pending requests they received ("incoming") or sent ("outgoing"), or the users
+// they blocked ("blocked"), together with the size of every view. Pages are ordered newest first
+// and continue from the nextCursor of the previous page.
+func (h FriendHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.View")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Friends == nil {
+		logger.Error("friend service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "friend service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	view := models.FriendView(strings.ToLower(r.PathValue("view")))
+	switch view {
+	case models.FriendViewAccepted, models.FriendViewIncoming, models.FriendViewOutgoing, models.FriendViewBlocked:
+	default:
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "view must be accepted, incoming, outgoing or blocked"})
+		return
+	}
+
+	limit, err := pageSize(r)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var after *models.FriendCursor
+	if cursor := strings.TrimSpace(r.URL.Query().Get("cursor")); cursor != "" {
+		since, requestID, err := decodeCursor(cursor)
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		after = &models.FriendCursor{Since: since, RequestID: requestID}
+	}
+
+	// Ask for one extra entry to learn whether another page follows.
+	entries, err := h.Friends.ListView(ctx, userID, view, after, limit+1)
+	if err != nil {
+		logger.Error("failed to list friend view", "error", err, "userId", userID, "view", view)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to list friends"})
+		return
+	}
+
+	counts, err := h.Friends.CountViews(ctx, userID)
+	if err != nil {
+		logger.Error("failed to count friend views", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to list friends"})
+		return
+	}
+
+	resp := friendViewResponse{
+		Entries: make([]friendViewEntry, 0, min(len(entries), limit)),
+		Counts: friendCountsView{
+			Accepted: counts.Accepted,
+			Incoming: counts.Incoming,
+			Outgoing: counts.Outgoing,
+			Blocked:  counts.Blocked,
+		},
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		resp.NextCursor = encodeCursor(last.Since, last.RequestID)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.UserID)
+	}
+	summaries := profileSummaries(ctx, h.Profiles, ids)
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, friendViewEntry{
+			RequestID: entry.RequestID,
+			User:      summaries[entry.UserID],
+			Since:     entry.Since,
+		})
+	}
+
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Respond handles POST /api/v1/friends/respond requests that move a friend request along its
+// lifecycle. Only the receiver may accept or decline, only the requester may cancel, either user
+// may unfriend or block, and only the user who blocked may unblock.
 func (h FriendHandler) Respond(w http.ResponseWriter, r *http.Request) {
 	ctx, span := logging.StartSpan(r.Context(), "FriendHandler.Respond")
 	defer span.End()
@@ -168,6 +428,11 @@ func (h FriendHandler) Respond(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
 	var req respondFriendRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Warn("invalid respond payload", "error", err)
@@ -176,42 +441,163 @@ func (h FriendHandler) Respond(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req.RequestID = strings.TrimSpace(req.RequestID)
-	action := strings.ToLower(strings.TrimSpace(req.Action))
-	if req.RequestID == "" || action == "" {
-		logger.Warn("respond missing fields", "requestId", req.RequestID, "action", action)
+	actionName := strings.ToLower(strings.TrimSpace(req.Action))
+	if req.RequestID == "" || actionName == "" {
+		logger.Warn("respond missing fields", "requestId", req.RequestID, "action", actionName)
 		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "requestId and action are required"})
 		return
 	}
 
-	var status string
-	switch action {
-	case "accept":
-		status = friendStatusAccepted
-	case "block":
-		status = friendStatusBlocked
-	default:
-		logger.Warn("invalid respond action", "action", action)
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "action must be accept or block"})
+	action, ok := friendActions[actionName]
+	if !ok {
+		logger.Warn("invalid respond action", "action", actionName)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "action must be accept, decline, cancel, unfriend, block or unblock"})
 		return
 	}
 
-	if err := h.Friends.UpdateStatus(ctx, req.RequestID, status); err != nil {
+	request, err := h.Friends.FindByID(ctx, req.RequestID)
+	if err == nil && request.Requester != userID && request.Receiver != userID {
+		// Requests between other users are reported as missing so their IDs reveal nothing.
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
 		if errors.Is(err, repositories.ErrNotFound) {
-			logger.Warn("friend request not found", "requestId", req.RequestID)
+			logger.Warn("friend request not found", "requestId", req.RequestID, "userId", userID)
 			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "friend request not found"})
 			return
 		}
-		logger.Error("failed to update friend request", "error", err, "requestId", req.RequestID, "status", status)
+		logger.Error("failed to load friend request", "error", err, "requestId", req.RequestID, "userId", userID)
 		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to update friend request"})
 		return
 	}
 
+	if !slices.Contains(action.from, request.Status) {
+		logger.Warn("friend request action not applicable", "requestId", req.RequestID, "action", actionName, "status", request.Status)
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "cannot " + actionName + " a " + request.Status + " friend request"})
+		return
+	}
+
+	if !action.permits(request, userID) {
+		logger.Warn("friend request action forbidden", "requestId", req.RequestID, "action", actionName, "userId", userID)
+		respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "you cannot " + actionName + " this friend request"})
+		return
+	}
+
+	updated, err := h.Friends.Transition(ctx, models.FriendTransition{
+		RequestID: request.ID,
+		From:      request.Status,
+		To:        action.to,
+		ActorID:   userID,
+		At:        h.now(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			logger.Warn("friend request not found", "requestId", req.RequestID)
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "friend request not found"})
+		case errors.Is(err, repositories.ErrConflict):
+			logger.Warn("friend request changed concurrently", "requestId", req.RequestID, "action", actionName)
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "friend request was changed; reload and try again"})
+		default:
+			logger.Error("failed to update friend request", "error", err, "requestId", req.RequestID, "status", action.to, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to update friend request"})
+		}
+		return
+	}
+
+	logger.Info("friend request updated", "requestId", updated.ID, "action", actionName, "status", updated.Status, "userId", userID)
 	respondJSON(ctx, w, http.StatusOK, map[string]string{
-		"requestId": req.RequestID,
-		"status":    status,
+		"requestId": updated.ID,
+		"status":    updated.Status,
 	})
 }
 
+// reinvite answers an invite between two users who already share a friend request. Declined,
+// cancelled and removed requests are reopened as a new pending request from requesterID; a user
+// who was blocked cannot invite the user who blocked them.
+func (h FriendHandler) reinvite(ctx context.Context, w http.ResponseWriter, requesterID, receiverID string, now time.Time) {
+	logger := logging.FromContext(ctx)
+
+	existing, err := h.Friends.FindBetween(ctx, requesterID, receiverID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			logger.Warn("friend invite target missing", "requesterId", requesterID, "receiverId", receiverID)
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+			return
+		}
+		logger.Error("failed to load existing friend request", "error", err, "requesterId", requesterID, "receiverId", receiverID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create friend request"})
+		return
+	}
+
+	switch existing.Status {
+	case friendStatusBlocked:
+		if existing.ActorID == requesterID {
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "you have blocked this user; unblock them first"})
+			return
+		}
+		logger.Warn("friend invite from blocked user", "requesterId", requesterID, "receiverId", receiverID)
+		respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "cannot send a friend request to this user"})
+	case friendStatusDeclined, friendStatusCancelled, friendStatusRemoved:
+		reopened, err := h.Friends.Transition(ctx, models.FriendTransition{
+			RequestID: existing.ID,
+			From:      existing.Status,
+			To:        friendStatusPending,
+			ActorID:   requesterID,
+			At:        now,
+			Requester: requesterID,
+			Receiver:  receiverID,
+		})
+		if err != nil {
+			if errors.Is(err, repositories.ErrConflict) || errors.Is(err, repositories.ErrNotFound) {
+				logger.Warn("friend request changed concurrently", "requestId", existing.ID)
+				respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "friend request already exists"})
+				return
+			}
+			logger.Error("failed to reopen friend request", "error", err, "requestId", existing.ID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create friend request"})
+			return
+		}
+		respondJSON(ctx, w, http.StatusCreated, friendRequestResponse{Request: reopened})
+	default:
+		logger.Warn("friend request already exists", "requesterId", requesterID, "receiverId", receiverID)
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "friend request already exists"})
+	}
+}
+
+// resolveUser returns the ID of the user with the e-mail address or handle. Unknown users and
+// malformed handles yield repositories.ErrNotFound.
+func (h FriendHandler) resolveUser(ctx context.Context, receiver string) (string, error) {
+	if isEmailQuery(receiver) {
+		if h.Users == nil {
+			return "", errors.New("user store unavailable")
+		}
+		user, err := h.Users.FindByEmail(ctx, strings.ToLower(receiver))
+		if err != nil {
+			return "", err
+		}
+		return user.ID, nil
+	}
+
+	if h.Profiles == nil {
+		return "", errors.New("profile store unavailable")
+	}
+	handle, err := profiles.NormalizeHandle(receiver)
+	if err != nil {
+		return "", repositories.ErrNotFound
+	}
+	profile, err := h.Profiles.FindByHandle(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	return profile.UserID, nil
+}
+
+// isEmailQuery reports whether s names an e-mail address rather than an "@handle".
+func isEmailQuery(s string) bool {
+	return strings.Contains(s, "@") && !strings.HasPrefix(s, "@")
+}
+
 func (h FriendHandler) now() time.Time {
 	if h.NowFunc != nil {
 		return h.NowFunc()
@@ -220,8 +606,9 @@ func (h FriendHandler) now() time.Time {
 }
 
 type inviteFriendRequest struct {
-	RequesterID string `json:"requesterId"`
-	ReceiverID  string `json:"receiverId"`
+	ReceiverID string `json:"receiverId"`
+	// Receiver is an e-mail address or handle, used when ReceiverID is empty.
+	Receiver string `json:"receiver"`
 }
 
 type respondFriendRequest struct {
@@ -233,6 +620,37 @@ type friendRequestResponse struct {
 	Request models.FriendRequest `json:"request"`
 }
 
+type searchUsersResponse struct {
+	Users []models.ProfileSummary `json:"users"`
+}
+
 type listFriendsResponse struct {
-	Requests []models.FriendRequest `json:"requests"`
+	Requests []friendEntry `json:"requests"`
+}
+
+// friendEntry is a friend request together with the profiles of both users.
+type friendEntry struct {
+	models.FriendRequest
+	RequesterProfile models.ProfileSummary
+	ReceiverProfile  models.ProfileSummary
+}
+
+type friendViewResponse struct {
+	Entries    []friendViewEntry `json:"entries"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+	Counts     friendCountsView  `json:"counts"`
+}
+
+// friendViewEntry is the other user of a friend view entry and when the entry began.
+type friendViewEntry struct {
+	RequestID string                `json:"requestId"`
+	User      models.ProfileSummary `json:"user"`
+	Since     time.Time             `json:"since"`
+}
+
+type friendCountsView struct {
+	Accepted int `json:"accepted"`
+	Incoming int `json:"incoming"`
+	Outgoing int `json:"outgoing"`
+	Blocked  int `json:"blocked"`
 }
@@ -3,10 +3,14 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sort"
 	"testing"
 	"time"
 
@@ -27,6 +31,8 @@ func (s friendStubRateLimiter) Allow(string) bool { return s.allow }
 
 type inMemoryFriendStore struct {
 	requests map[string]models.FriendRequest
+	// dismissed holds the {user, candidate} pairs passed to DismissSuggestion.
+	dismissed map[[2]string]bool
 }
 
 func newInMemoryFriendStore() *inMemoryFriendStore {
@@ -35,7 +41,8 @@ func newInMemoryFriendStore() *inMemoryFriendStore {
 
 func (s *inMemoryFriendStore) CreateRequest(_ context.Context, request models.FriendRequest) error {
 	for _, existing := range s.requests {
-		if existing.Requester == request.Requester && existing.Receiver == request.Receiver {
+		if (existing.Requester == request.Requester && existing.Receiver == request.Receiver) ||
+			(existing.Requester == request.Receiver && existing.Receiver == request.Requester) {
 			return repositories.ErrConflict
 		}
 	}
@@ -53,18 +60,178 @@ func (s *inMemoryFriendStore) ListForUser(_ context.Context, userID string) ([]m
 	return out, nil
 }
 
-func (s *inMemoryFriendStore) UpdateStatus(_ context.Context, requestID, status string) error {
+func (s *inMemoryFriendStore) ListView(_ context.Context, userID string, view models.FriendView, after *models.FriendCursor, limit int) ([]models.FriendConnection, error) {
+	var out []models.FriendConnection
+	for _, request := range s.requests {
+		entry, ok := friendViewEntryFor(request, userID, view)
+		if !ok {
+			continue
+		}
+		if after != nil && !entry.Since.Before(after.Since) && (!entry.Since.Equal(after.Since) || entry.RequestID >= after.RequestID) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Since.Equal(out[j].Since) {
+			return out[i].Since.After(out[j].Since)
+		}
+		return out[i].RequestID > out[j].RequestID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *inMemoryFriendStore) CountViews(_ context.Context, userID string) (models.FriendCounts, error) {
+	var counts models.FriendCounts
+	for _, request := range s.requests {
+		for view, count := range map[models.FriendView]*int{
+			models.FriendViewAccepted: &counts.Accepted,
+			models.FriendViewIncoming: &counts.Incoming,
+			models.FriendViewOutgoing: &counts.Outgoing,
+			models.FriendViewBlocked:  &counts.Blocked,
+		} {
+			if _, ok := friendViewEntryFor(request, userID, view); ok {
+				*count++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// friendViewEntryFor mirrors the view filters of PostgresFriendRepository.ListView.
+func friendViewEntryFor(request models.FriendRequest, userID string, view models.FriendView) (models.FriendConnection, bool) {
+	other := request.Requester
+	if request.Requester == userID {
+		other = request.Receiver
+	} else if request.Receiver != userID {
+		return models.FriendConnection{}, false
+	}
+
+	entry := models.FriendConnection{RequestID: request.ID, UserID: other, Since: request.CreatedAt}
+	switch view {
+	case models.FriendViewAccepted:
+		if request.RespondedAt != nil {
+			entry.Since = *request.RespondedAt
+		}
+		return entry, request.Status == friendStatusAccepted
+	case models.FriendViewIncoming:
+		return entry, request.Status == friendStatusPending && request.Receiver == userID
+	case models.FriendViewOutgoing:
+		return entry, request.Status == friendStatusPending && request.Requester == userID
+	case models.FriendViewBlocked:
+		entry.Since = request.UpdatedAt
+		return entry, request.Status == friendStatusBlocked && request.ActorID == userID
+	}
+	return models.FriendConnection{}, false
+}
+
+func (s *inMemoryFriendStore) FindByID(_ context.Context, requestID string) (models.FriendRequest, error) {
 	request, ok := s.requests[requestID]
 	if !ok {
-		return repositories.ErrNotFound
+		return models.FriendRequest{}, repositories.ErrNotFound
 	}
-	request.Status = status
-	respondedAt := time.Now().UTC()
-	request.RespondedAt = &respondedAt
-	s.requests[requestID] = request
+	return request, nil
+}
+
+func (s *inMemoryFriendStore) FindBetween(_ context.Context, userID, otherID string) (models.FriendRequest, error) {
+	for _, request := range s.requests {
+		if (request.Requester == userID && request.Receiver == otherID) || (request.Requester == otherID && request.Receiver == userID) {
+			return request, nil
+		}
+	}
+	return models.FriendRequest{}, repositories.ErrNotFound
+}
+
+func (s *inMemoryFriendStore) Transition(_ context.Context, t models.FriendTransition) (models.FriendRequest, error) {
+	request, ok := s.requests[t.RequestID]
+	if !ok {
+		return models.FriendRequest{}, repositories.ErrNotFound
+	}
+	if request.Status != t.From {
+		return models.FriendRequest{}, repositories.ErrConflict
+	}
+	if t.Requester != "" {
+		request.Requester, request.Receiver = t.Requester, t.Receiver
+		request.CreatedAt = t.At
+		request.RespondedAt = nil
+	} else if request.Status == friendStatusPending {
+		at := t.At
+		request.RespondedAt = &at
+	}
+	request.Status = t.To
+	request.ActorID = t.ActorID
+	request.UpdatedAt = t.At
+	s.requests[t.RequestID] = request
+	return request, nil
+}
+
+func (s *inMemoryFriendStore) ListMutual(_ context.Context, userID, otherID string) ([]string, error) {
+	theirs := s.friendsOf(otherID)
+	var mutual []string
+	for id := range s.friendsOf(userID) {
+		if theirs[id] {
+			mutual = append(mutual, id)
+		}
+	}
+	sort.Strings(mutual)
+	return mutual, nil
+}
+
+func (s *inMemoryFriendStore) ListSuggestions(ctx context.Context, userID string, limit int) ([]models.FriendSuggestion, error) {
+	counts := make(map[string]int)
+	for friendID := range s.friendsOf(userID) {
+		for candidateID := range s.friendsOf(friendID) {
+			if candidateID == userID || s.dismissed[[2]string{userID, candidateID}] {
+				continue
+			}
+			if _, err := s.FindBetween(ctx, userID, candidateID); err == nil {
+				continue
+			}
+			counts[candidateID]++
+		}
+	}
+
+	var suggestions []models.FriendSuggestion
+	for id, count := range counts {
+		suggestions = append(suggestions, models.FriendSuggestion{UserID: id, MutualCount: count})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].MutualCount != suggestions[j].MutualCount {
+			return suggestions[i].MutualCount > suggestions[j].MutualCount
+		}
+		return suggestions[i].UserID < suggestions[j].UserID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+func (s *inMemoryFriendStore) DismissSuggestion(_ context.Context, userID, candidateID string, _ time.Time) error {
+	if s.dismissed == nil {
+		s.dismissed = make(map[[2]string]bool)
+	}
+	s.dismissed[[2]string{userID, candidateID}] = true
 	return nil
 }
 
+func (s *inMemoryFriendStore) friendsOf(userID string) map[string]bool {
+	friends := make(map[string]bool)
+	for _, request := range s.requests {
+		switch {
+		case request.Status != friendStatusAccepted:
+		case request.Requester == userID:
+			friends[request.Receiver] = true
+		case request.Receiver == userID:
+			friends[request.Requester] = true
+		}
+	}
+	return friends
+}
+
 type stubFriendStore struct {
 	createErr error
 	listErr   error
@@ -82,7 +249,50 @@ func (s *stubFriendStore) ListForUser(context.Context, string) ([]models.FriendR
 	return []models.FriendRequest{{ID: "req-1"}}, nil
 }
 
-func (s *stubFriendStore) UpdateStatus(context.Context, string, string) error {
+func (s *stubFriendStore) ListView(context.Context, string, models.FriendView, *models.FriendCursor, int) ([]models.FriendConnection, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	return []models.FriendConnection{{RequestID: "req-1", UserID: "user-2"}}, nil
+}
+
+func (s *stubFriendStore) CountViews(context.Context, string) (models.FriendCounts, error) {
+	if s.listErr != nil {
+		return models.FriendCounts{}, s.listErr
+	}
+	return models.FriendCounts{Accepted: 1}, nil
+}
+
+func (s *stubFriendStore) FindByID(_ context.Context, requestID string) (models.FriendRequest, error) {
+	return models.FriendRequest{ID: requestID, Requester: "user-1", Receiver: "user-2", Status: friendStatusPending}, nil
+}
+
+func (s *stubFriendStore) FindBetween(_ context.Context, userID, otherID string) (models.FriendRequest, error) {
+	return models.FriendRequest{ID: "req-1", Requester: userID, Receiver: otherID, Status: friendStatusPending}, nil
+}
+
+func (s *stubFriendStore) Transition(_ context.Context, t models.FriendTransition) (models.FriendRequest, error) {
+	if s.updateErr != nil {
+		return models.FriendRequest{}, s.updateErr
+	}
+	return models.FriendRequest{ID: t.RequestID, Status: t.To}, nil
+}
+
+func (s *stubFriendStore) ListMutual(context.Context, string, string) ([]string, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	return []string{"user-3"}, nil
+}
+
+func (s *stubFriendStore) ListSuggestions(context.Context, string, int) ([]models.FriendSuggestion, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	return []models.FriendSuggestion{{UserID: "user-3", MutualCount: 1}}, nil
+}
+
+func (s *stubFriendStore) DismissSuggestion(context.Context, string, string, time.Time) error {
 	return s.updateErr
 }
 
@@ -91,12 +301,12 @@ func TestFriendHandlerInvite(t *testing.T) {
 	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
 	handler := FriendHandler{Friends: store, NowFunc: func() time.Time { return now }}
 
-	body, err := json.Marshal(inviteFriendRequest{RequesterID: requesterUUID, ReceiverID: receiverUUID})
+	body, err := json.Marshal(inviteFriendRequest{ReceiverID: receiverUUID})
 	if err != nil {
 		t.Fatalf("marshal request: %v", err)
 	}
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader(body))
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader(body)), requesterUUID)
 	rec := httptest.NewRecorder()
 
 	handler.Invite(rec, req)
@@ -121,33 +331,61 @@ func TestFriendHandlerInvite(t *testing.T) {
 	if _, ok := store.requests[resp.Request.ID]; !ok {
 		t.Fatalf("expected request to be stored")
 	}
+
+	if resp.Request.Requester != requesterUUID {
+		t.Fatalf("expected requester %s got %s", requesterUUID, resp.Request.Requester)
+	}
+}
+
+func TestFriendHandlerInviteIgnoresRequesterInBody(t *testing.T) {
+	const victimUUID = "33333333-3333-3333-3333-333333333333"
+	store := newInMemoryFriendStore()
+	handler := FriendHandler{Friends: store}
+
+	body := []byte(`{"requesterId":"` + victimUUID + `","receiverId":"` + receiverUUID + `"}`)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader(body)), requesterUUID)
+	rec := httptest.NewRecorder()
+
+	handler.Invite(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d got %d", http.StatusCreated, rec.Code)
+	}
+
+	for _, request := range store.requests {
+		if request.Requester != requesterUUID {
+			t.Fatalf("expected invite to be sent by the authenticated user, got %s", request.Requester)
+		}
+	}
 }
 
 func TestFriendHandlerInviteFailures(t *testing.T) {
-	body := []byte(`{"requesterId":"` + requesterUUID + `","receiverId":"` + receiverUUID + `"}`)
+	body := []byte(`{"receiverId":"` + receiverUUID + `"}`)
 
 	cases := []struct {
 		name       string
 		handler    FriendHandler
 		method     string
+		userID     string
 		body       []byte
 		wantStatus int
 	}{
-		{"wrongMethod", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodGet, body, http.StatusMethodNotAllowed},
-		{"missingStore", FriendHandler{}, http.MethodPost, body, http.StatusInternalServerError},
-		{"badJSON", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, []byte("{"), http.StatusBadRequest},
-		{"missingFields", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, []byte(`{"requesterId":"","receiverId":""}`), http.StatusBadRequest},
-		{"selfInvite", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, []byte(`{"requesterId":"` + requesterUUID + `","receiverId":"` + requesterUUID + `"}`), http.StatusBadRequest},
-		{"invalidRequester", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, []byte(`{"requesterId":"bad","receiverId":"` + receiverUUID + `"}`), http.StatusBadRequest},
-		{"invalidReceiver", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, []byte(`{"requesterId":"` + requesterUUID + `","receiverId":"bad"}`), http.StatusBadRequest},
-		{"conflict", FriendHandler{Friends: &stubFriendStore{createErr: repositories.ErrConflict}}, http.MethodPost, body, http.StatusConflict},
-		{"notFound", FriendHandler{Friends: &stubFriendStore{createErr: repositories.ErrNotFound}}, http.MethodPost, body, http.StatusNotFound},
-		{"internal", FriendHandler{Friends: &stubFriendStore{createErr: errors.New("boom")}}, http.MethodPost, body, http.StatusInternalServerError},
+		{"wrongMethod", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodGet, requesterUUID, body, http.StatusMethodNotAllowed},
+		{"missingStore", FriendHandler{}, http.MethodPost, requesterUUID, body, http.StatusInternalServerError},
+		{"unauthenticated", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, "", body, http.StatusUnauthorized},
+		{"badJSON", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, requesterUUID, []byte("{"), http.StatusBadRequest},
+		{"missingFields", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, requesterUUID, []byte(`{"receiverId":""}`), http.StatusBadRequest},
+		{"handleWithoutProfiles", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, requesterUUID, []byte(`{"receiver":"bob"}`), http.StatusInternalServerError},
+		{"selfInvite", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, requesterUUID, []byte(`{"receiverId":"` + requesterUUID + `"}`), http.StatusBadRequest},
+		{"invalidReceiver", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, requesterUUID, []byte(`{"receiverId":"bad"}`), http.StatusBadRequest},
+		{"conflict", FriendHandler{Friends: &stubFriendStore{createErr: repositories.ErrConflict}}, http.MethodPost, requesterUUID, body, http.StatusConflict},
+		{"notFound", FriendHandler{Friends: &stubFriendStore{createErr: repositories.ErrNotFound}}, http.MethodPost, requesterUUID, body, http.StatusNotFound},
+		{"internal", FriendHandler{Friends: &stubFriendStore{createErr: errors.New("boom")}}, http.MethodPost, requesterUUID, body, http.StatusInternalServerError},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			req := httptest.NewRequest(tc.method, "/api/v1/friends/invite", bytes.NewReader(tc.body))
+			req := withUser(httptest.NewRequest(tc.method, "/api/v1/friends/invite", bytes.NewReader(tc.body)), tc.userID)
 			rec := httptest.NewRecorder()
 
 			tc.handler.Invite(rec, req)
@@ -159,10 +397,141 @@ func TestFriendHandlerInviteFailures(t *testing.T) {
 	}
 }
 
+func TestFriendHandlerInviteByEmailOrHandle(t *testing.T) {
+	users := newInMemoryUserStore()
+	users.users["bob@example.com"] = models.User{ID: receiverUUID, Email: "bob@example.com"}
+	profileStore := newInMemoryProfileStore()
+	profileStore.profiles[receiverUUID] = models.Profile{UserID: receiverUUID, Handle: "bob"}
+	profileStore.profiles[requesterUUID] = models.Profile{UserID: requesterUUID, Handle: "alice"}
+
+	cases := []struct {
+		name       string
+		receiver   string
+		wantStatus int
+	}{
+		{"email", "Bob@Example.com", http.StatusCreated},
+		{"handle", "bob", http.StatusCreated},
+		{"atHandle", "@BOB", http.StatusCreated},
+		{"unknownEmail", "carol@example.com", http.StatusNotFound},
+		{"unknownHandle", "carol", http.StatusNotFound},
+		{"malformedHandle", "no such user", http.StatusNotFound},
+		{"self", "@alice", http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newInMemoryFriendStore()
+			handler := FriendHandler{Friends: store, Users: users, Profiles: profileStore}
+
+			body, err := json.Marshal(inviteFriendRequest{Receiver: tc.receiver})
+			if err != nil {
+				t.Fatalf("marshal request: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			handler.Invite(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader(body)), requesterUUID))
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tc.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var resp friendRequestResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Request.Receiver != receiverUUID {
+				t.Fatalf("expected receiver %s got %s", receiverUUID, resp.Request.Receiver)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler := FriendHandler{Friends: newInMemoryFriendStore(), Users: failingUserStore{findErr: errors.New("database unavailable")}, Profiles: profileStore}
+	handler.Invite(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader([]byte(`{"receiver":"bob@example.com"}`))), requesterUUID))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected lookup failure to return 500, got %d", rec.Code)
+	}
+}
+
+func TestFriendHandlerSearch(t *testing.T) {
+	users := newInMemoryUserStore()
+	users.users["bob@example.com"] = models.User{ID: "user-2", Email: "bob@example.com"}
+	users.users["dan@example.com"] = models.User{ID: "user-4", Email: "dan@example.com"}
+	profileStore := newInMemoryProfileStore()
+	profileStore.profiles["user-1"] = models.Profile{UserID: "user-1", Handle: "bo_self"}
+	profileStore.profiles["user-2"] = models.Profile{UserID: "user-2", Handle: "bob", DisplayName: "Bob"}
+	profileStore.profiles["user-3"] = models.Profile{UserID: "user-3", Handle: "bobby"}
+	handler := FriendHandler{Users: users, Profiles: profileStore}
+
+	search := func(query string) (int, []models.ProfileSummary) {
+		t.Helper()
+		req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/users/search?q="+url.QueryEscape(query), nil), "user-1")
+		rec := httptest.NewRecorder()
+		handler.Search(rec, req)
+		if rec.Code != http.StatusOK {
+			return rec.Code, nil
+		}
+		var resp searchUsersResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.Users == nil {
+			t.Fatal("expected users to be an array")
+		}
+		return rec.Code, resp.Users
+	}
+
+	if _, got := search("@Bo"); len(got) != 2 || got[0].Handle != "bob" || got[1].Handle != "bobby" {
+		t.Fatalf("expected handle prefix matches without the caller, got %+v", got)
+	}
+	if _, got := search("BOB@example.com"); len(got) != 1 || got[0].UserID != "user-2" || got[0].DisplayName != "Bob" {
+		t.Fatalf("expected exact email match with profile, got %+v", got)
+	}
+	if _, got := search("dan@example.com"); len(got) != 1 || got[0] != (models.ProfileSummary{UserID: "user-4"}) {
+		t.Fatalf("expected email match without profile to carry its ID, got %+v", got)
+	}
+	if _, got := search("bob@example"); len(got) != 0 {
+		t.Fatalf("expected partial email to match nothing, got %+v", got)
+	}
+	for _, query := range []string{"", "b", "bo%"} {
+		if code, _ := search(query); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %q, got %d", query, code)
+		}
+	}
+
+	for i := 0; i < userSearchLimit+5; i++ {
+		id := fmt.Sprintf("many-%d", i)
+		profileStore.profiles[id] = models.Profile{UserID: id, Handle: fmt.Sprintf("many_%02d", i)}
+	}
+	if _, got := search("many"); len(got) != userSearchLimit {
+		t.Fatalf("expected %d results, got %d", userSearchLimit, len(got))
+	}
+
+	rec := httptest.NewRecorder()
+	FriendHandler{Users: users, Profiles: profileStore, RateLimiter: friendStubRateLimiter{allow: false}}.Search(rec,
+		withUser(httptest.NewRequest(http.MethodGet, "/api/v1/users/search?q=bob", nil), "user-1"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	FriendHandler{Users: users}.Search(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/users/search?q=bob", nil), "user-1"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 without a profile store, got %d", rec.Code)
+	}
+
+	profileStore.err = errors.New("database unavailable")
+	if code, _ := search("bob"); code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when search fails, got %d", code)
+	}
+}
+
 func TestFriendHandlerInviteRateLimited(t *testing.T) {
 	handler := FriendHandler{RateLimiter: friendStubRateLimiter{allow: false}}
 
-	body := []byte(`{"requesterId":"` + requesterUUID + `","receiverId":"` + receiverUUID + `"}`)
+	body := []byte(`{"receiverId":"` + receiverUUID + `"}`)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader(body))
 	rec := httptest.NewRecorder()
 
@@ -178,7 +547,7 @@ func TestFriendHandlerList(t *testing.T) {
 	store.requests["req-1"] = models.FriendRequest{ID: "req-1", Requester: "user-1", Receiver: "user-2", Status: friendStatusPending}
 	handler := FriendHandler{Friends: store}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/friends?user=user-1", nil)
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/friends", nil), "user-1")
 	rec := httptest.NewRecorder()
 
 	handler.List(rec, req)
@@ -197,6 +566,61 @@ func TestFriendHandlerList(t *testing.T) {
 	}
 }
 
+func TestFriendHandlerListEmbedsProfiles(t *testing.T) {
+	store := newInMemoryFriendStore()
+	store.requests["req-1"] = models.FriendRequest{ID: "req-1", Requester: "user-1", Receiver: "user-2", Status: friendStatusAccepted}
+	profileStore := newInMemoryProfileStore()
+	profileStore.profiles["user-2"] = models.Profile{UserID: "user-2", Handle: "bob", DisplayName: "Bob", Avatar: models.Avatar{Small: "https://cdn.example.com/bob.jpg"}}
+	handler := FriendHandler{Friends: store, Profiles: profileStore}
+
+	rec := httptest.NewRecorder()
+	handler.List(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/friends", nil), "user-1"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+
+	var resp listFriendsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if len(resp.Requests) != 1 {
+		t.Fatalf("unexpected response payload: %+v", resp)
+	}
+	entry := resp.Requests[0]
+	if entry.ReceiverProfile.Handle != "bob" || entry.ReceiverProfile.DisplayName != "Bob" || entry.ReceiverProfile.AvatarURL != "https://cdn.example.com/bob.jpg" {
+		t.Fatalf("expected receiver profile to be embedded, got %+v", entry.ReceiverProfile)
+	}
+	if entry.RequesterProfile != (models.ProfileSummary{UserID: "user-1"}) {
+		t.Fatalf("expected requester without a profile to be identified by ID, got %+v", entry.RequesterProfile)
+	}
+}
+
+func TestFriendHandlerListIgnoresUserQuery(t *testing.T) {
+	store := newInMemoryFriendStore()
+	store.requests["req-1"] = models.FriendRequest{ID: "req-1", Requester: "victim-1", Receiver: "victim-2", Status: friendStatusAccepted}
+	handler := FriendHandler{Friends: store}
+
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/friends?user=victim-1", nil), "attacker-1")
+	rec := httptest.NewRecorder()
+
+	handler.List(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+
+	var resp listFriendsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if len(resp.Requests) != 0 {
+		t.Fatalf("expected no requests for the authenticated user, got %+v", resp.Requests)
+	}
+}
+
 func TestFriendHandlerListFailures(t *testing.T) {
 	handler := FriendHandler{Friends: &stubFriendStore{listErr: errors.New("db down")}}
 
@@ -210,11 +634,11 @@ func TestFriendHandlerListFailures(t *testing.T) {
 	req = httptest.NewRequest(http.MethodGet, "/api/v1/friends", nil)
 	rec = httptest.NewRecorder()
 	handler.List(rec, req)
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected bad request got %d", rec.Code)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized got %d", rec.Code)
 	}
 
-	req = httptest.NewRequest(http.MethodGet, "/api/v1/friends?user=user-1", nil)
+	req = withUser(httptest.NewRequest(http.MethodGet, "/api/v1/friends", nil), "user-1")
 	rec = httptest.NewRecorder()
 	handler = FriendHandler{}
 	handler.List(rec, req)
@@ -240,7 +664,7 @@ func TestFriendHandlerRespond(t *testing.T) {
 		t.Fatalf("marshal request: %v", err)
 	}
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body))
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body)), "user-2")
 	rec := httptest.NewRecorder()
 
 	handler.Respond(rec, req)
@@ -279,21 +703,28 @@ func TestFriendHandlerRespondFailures(t *testing.T) {
 	}
 
 	handler = FriendHandler{Friends: &stubFriendStore{}}
-	req = httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader([]byte("{")))
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+	handler.Respond(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized got %d", rec.Code)
+	}
+
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader([]byte("{"))), "user-2")
 	rec = httptest.NewRecorder()
 	handler.Respond(rec, req)
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected bad request got %d", rec.Code)
 	}
 
-	req = httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader([]byte(`{"requestId":"","action":""}`)))
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader([]byte(`{"requestId":"","action":""}`))), "user-2")
 	rec = httptest.NewRecorder()
 	handler.Respond(rec, req)
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected bad request got %d", rec.Code)
 	}
 
-	req = httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader([]byte(`{"requestId":"req-1","action":"maybe"}`)))
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader([]byte(`{"requestId":"req-1","action":"maybe"}`))), "user-2")
 	rec = httptest.NewRecorder()
 	handler.Respond(rec, req)
 	if rec.Code != http.StatusBadRequest {
@@ -301,7 +732,7 @@ func TestFriendHandlerRespondFailures(t *testing.T) {
 	}
 
 	handler = FriendHandler{Friends: &stubFriendStore{updateErr: repositories.ErrNotFound}}
-	req = httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body))
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body)), "user-2")
 	rec = httptest.NewRecorder()
 	handler.Respond(rec, req)
 	if rec.Code != http.StatusNotFound {
@@ -309,10 +740,243 @@ func TestFriendHandlerRespondFailures(t *testing.T) {
 	}
 
 	handler = FriendHandler{Friends: &stubFriendStore{updateErr: errors.New("db down")}}
-	req = httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body))
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body)), "user-2")
 	rec = httptest.NewRecorder()
 	handler.Respond(rec, req)
 	if rec.Code != http.StatusInternalServerError {
 		t.Fatalf("expected internal error got %d", rec.Code)
 	}
 }
+
+func TestFriendHandlerRespondLifecycle(t *testing.T) {
+	const (
+		requester = "user-1"
+		receiver  = "user-2"
+		stranger  = "user-3"
+	)
+
+	cases := []struct {
+		name       string
+		status     string
+		actorID    string
+		userID     string
+		action     string
+		wantCode   int
+		wantStatus string
+	}{
+		{"receiverAccepts", friendStatusPending, requester, receiver, "accept", http.StatusOK, friendStatusAccepted},
+		{"requesterCannotAccept", friendStatusPending, requester, requester, "accept", http.StatusForbidden, friendStatusPending},
+		{"receiverDeclines", friendStatusPending, requester, receiver, "decline", http.StatusOK, friendStatusDeclined},
+		{"requesterCannotDecline", friendStatusPending, requester, requester, "decline", http.StatusForbidden, friendStatusPending},
+		{"requesterCancels", friendStatusPending, requester, requester, "cancel", http.StatusOK, friendStatusCancelled},
+		{"receiverCannotCancel", friendStatusPending, requester, receiver, "cancel", http.StatusForbidden, friendStatusPending},
+		{"cannotAcceptTwice", friendStatusAccepted, receiver, receiver, "accept", http.StatusConflict, friendStatusAccepted},
+		{"requesterUnfriends", friendStatusAccepted, receiver, requester, "unfriend", http.StatusOK, friendStatusRemoved},
+		{"receiverUnfriends", friendStatusAccepted, receiver, receiver, "unfriend", http.StatusOK, friendStatusRemoved},
+		{"cannotUnfriendPending", friendStatusPending, requester, receiver, "unfriend", http.StatusConflict, friendStatusPending},
+		{"requesterBlocksFriend", friendStatusAccepted, receiver, requester, "block", http.StatusOK, friendStatusBlocked},
+		{"blockAfterDecline", friendStatusDeclined, receiver, receiver, "block", http.StatusOK, friendStatusBlocked},
+		{"blockerUnblocks", friendStatusBlocked, requester, requester, "unblock", http.StatusOK, friendStatusRemoved},
+		{"blockedUserCannotUnblock", friendStatusBlocked, requester, receiver, "unblock", http.StatusForbidden, friendStatusBlocked},
+		{"strangerSeesNothing", friendStatusPending, requester, stranger, "accept", http.StatusNotFound, friendStatusPending},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newInMemoryFriendStore()
+			store.requests["req-1"] = models.FriendRequest{ID: "req-1", Requester: requester, Receiver: receiver, Status: tc.status, ActorID: tc.actorID}
+			now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+			handler := FriendHandler{Friends: store, NowFunc: func() time.Time { return now }}
+
+			body, err := json.Marshal(respondFriendRequest{RequestID: "req-1", Action: tc.action})
+			if err != nil {
+				t.Fatalf("marshal request: %v", err)
+			}
+			req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/respond", bytes.NewReader(body)), tc.userID)
+			rec := httptest.NewRecorder()
+
+			handler.Respond(rec, req)
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected status %d got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+			updated := store.requests["req-1"]
+			if updated.Status != tc.wantStatus {
+				t.Fatalf("expected request status %q got %q", tc.wantStatus, updated.Status)
+			}
+			if rec.Code == http.StatusOK && (updated.ActorID != tc.userID || !updated.UpdatedAt.Equal(now)) {
+				t.Fatalf("expected transition to record actor and time, got %+v", updated)
+			}
+		})
+	}
+}
+
+func TestFriendHandlerReinvite(t *testing.T) {
+	invite := func(handler FriendHandler, from, to string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(inviteFriendRequest{ReceiverID: to})
+		if err != nil {
+			t.Fatalf("marshal request: %v", err)
+		}
+		req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/friends/invite", bytes.NewReader(body)), from)
+		rec := httptest.NewRecorder()
+		handler.Invite(rec, req)
+		return rec
+	}
+
+	cases := []struct {
+		name     string
+		status   string
+		actorID  string
+		wantCode int
+	}{
+		{"afterDecline", friendStatusDeclined, receiverUUID, http.StatusCreated},
+		{"afterCancel", friendStatusCancelled, requesterUUID, http.StatusCreated},
+		{"afterUnfriend", friendStatusRemoved, requesterUUID, http.StatusCreated},
+		{"pending", friendStatusPending, requesterUUID, http.StatusConflict},
+		{"friends", friendStatusAccepted, requesterUUID, http.StatusConflict},
+		{"blockedByOther", friendStatusBlocked, requesterUUID, http.StatusForbidden},
+		{"blockedBySelf", friendStatusBlocked, receiverUUID, http.StatusConflict},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newInMemoryFriendStore()
+			store.requests["req-1"] = models.FriendRequest{ID: "req-1", Requester: requesterUUID, Receiver: receiverUUID, Status: tc.status, ActorID: tc.actorID}
+			handler := FriendHandler{Friends: store}
+
+			// The original receiver sends the new invite, so a reopened request points the other way.
+			rec := invite(handler, receiverUUID, requesterUUID)
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected status %d got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+			if len(store.requests) != 1 {
+				t.Fatalf("expected the existing request to be reused, got %d requests", len(store.requests))
+			}
+			if tc.wantCode != http.StatusCreated {
+				return
+			}
+			reopened := store.requests["req-1"]
+			if reopened.Status != friendStatusPending || reopened.Requester != receiverUUID || reopened.Receiver != requesterUUID || reopened.RespondedAt != nil {
+				t.Fatalf("expected request to be reopened from the new sender, got %+v", reopened)
+			}
+		})
+	}
+}
+
+func TestFriendHandlerView(t *testing.T) {
+	const me = "user-0"
+	store := newInMemoryFriendStore()
+	base := time.Date(2024, time.April, 1, 12, 0, 0, 0, time.UTC)
+	add := func(id, requester, receiver, status, actor string, offset time.Duration) {
+		at := base.Add(offset)
+		request := models.FriendRequest{ID: id, Requester: requester, Receiver: receiver, Status: status, CreatedAt: at, ActorID: actor, UpdatedAt: at}
+		if status != friendStatusPending {
+			request.RespondedAt = &at
+		}
+		store.requests[id] = request
+	}
+	for i := 1; i <= 5; i++ {
+		add(fmt.Sprintf("friend-%d", i), me, fmt.Sprintf("friend-user-%d", i), friendStatusAccepted, me, time.Duration(i)*time.Hour)
+	}
+	add("incoming-1", "asker", me, friendStatusPending, "asker", time.Minute)
+	add("outgoing-1", me, "askee", friendStatusPending, me, time.Minute)
+	add("blocked-1", "pest", me, friendStatusBlocked, me, time.Minute)
+	add("blocked-by-other", "grump", me, friendStatusBlocked, "grump", time.Minute)
+	add("declined-1", "stranger", me, friendStatusDeclined, me, time.Minute)
+
+	profiles := newInMemoryProfileStore()
+	profiles.profiles["friend-user-5"] = models.Profile{UserID: "friend-user-5", Handle: "five"}
+	handler := FriendHandler{Friends: store, Profiles: profiles}
+
+	get := func(view, query string) (*httptest.ResponseRecorder, friendViewResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/friends/"+view+query, nil)
+		req.SetPathValue("view", view)
+		rec := httptest.NewRecorder()
+		handler.View(rec, withUser(req, me))
+		var resp friendViewResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+		}
+		return rec, resp
+	}
+
+	rec, page := get("accepted", "?limit=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+	wantCounts := friendCountsView{Accepted: 5, Incoming: 1, Outgoing: 1, Blocked: 1}
+	if page.Counts != wantCounts {
+		t.Fatalf("expected counts %+v got %+v", wantCounts, page.Counts)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].RequestID != "friend-5" || page.Entries[1].RequestID != "friend-4" {
+		t.Fatalf("expected newest friends first, got %+v", page.Entries)
+	}
+	if page.Entries[0].User.Handle != "five" || !page.Entries[0].Since.Equal(base.Add(5*time.Hour)) {
+		t.Fatalf("expected profile and since date, got %+v", page.Entries[0])
+	}
+
+	var seen []string
+	for page.NextCursor != "" {
+		for _, entry := range page.Entries {
+			seen = append(seen, entry.RequestID)
+		}
+		_, page = get("accepted", "?limit=2&cursor="+url.QueryEscape(page.NextCursor))
+	}
+	for _, entry := range page.Entries {
+		seen = append(seen, entry.RequestID)
+	}
+	if fmt.Sprint(seen) != "[friend-5 friend-4 friend-3 friend-2 friend-1]" {
+		t.Fatalf("expected every friend exactly once across pages, got %v", seen)
+	}
+
+	for view, want := range map[string]string{"incoming": "asker", "outgoing": "askee", "blocked": "pest"} {
+		_, page := get(view, "")
+		if len(page.Entries) != 1 || page.Entries[0].User.UserID != want || page.NextCursor != "" {
+			t.Fatalf("%s: expected only %s, got %+v", view, want, page)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		view, query string
+		want        int
+	}{
+		"unknownView":  {"declined", "", http.StatusNotFound},
+		"zeroLimit":    {"accepted", "?limit=0", http.StatusBadRequest},
+		"hugeLimit":    {"accepted", "?limit=1000", http.StatusBadRequest},
+		"badCursor":    {"accepted", "?cursor=not-a-cursor", http.StatusBadRequest},
+		"forgedCursor": {"accepted", "?cursor=" + base64.RawURLEncoding.EncodeToString([]byte("yesterday|friend-1")), http.StatusBadRequest},
+	} {
+		if rec, _ := get(tc.view, tc.query); rec.Code != tc.want {
+			t.Fatalf("%s: expected status %d got %d", name, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestFriendHandlerViewFailures(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler FriendHandler
+		method  string
+		userID  string
+		want    int
+	}{
+		{"wrongMethod", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodPost, "user-1", http.StatusMethodNotAllowed},
+		{"missingStore", FriendHandler{}, http.MethodGet, "user-1", http.StatusInternalServerError},
+		{"unauthenticated", FriendHandler{Friends: newInMemoryFriendStore()}, http.MethodGet, "", http.StatusUnauthorized},
+		{"storeError", FriendHandler{Friends: &stubFriendStore{listErr: errors.New("db down")}}, http.MethodGet, "user-1", http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/v1/friends/accepted", nil)
+			req.SetPathValue("view", "accepted")
+			rec := httptest.NewRecorder()
+			tc.handler.View(rec, withUser(req, tc.userID))
+			if rec.Code != tc.want {
+				t.Fatalf("expected status %d got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
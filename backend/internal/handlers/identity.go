@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/logging"
+)
+
+// maxUserAgentLength bounds how much of the User-Agent header is stored with a session.
+const maxUserAgentLength = 512
+
+// authenticatedUser returns the caller resolved by middleware.RequireAuth. Handlers must use it
+// instead of trusting user identifiers supplied in request bodies or query strings.
+func authenticatedUser(ctx context.Context, w http.ResponseWriter) (string, bool) {
+	userID := logging.UserIDFromContext(ctx)
+	if userID == "" {
+		logging.FromContext(ctx).Warn("request missing authenticated user")
+		respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+		return "", false
+	}
+	return userID, true
+}
+
+// clientInfo describes the client making the request for session bookkeeping.
+func clientInfo(r *http.Request) auth.ClientInfo {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return auth.ClientInfo{UserAgent: userAgent, IPAddress: clientIP(r)}
+}
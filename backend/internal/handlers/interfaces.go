@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/invites"
 	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/oidc"
 	"github.com/vidfriends/backend/internal/videos"
 )
 
@@ -11,25 +17,220 @@ import (
 type UserStore interface {
 	Create(ctx context.Context, user models.User) error
 	FindByEmail(ctx context.Context, email string) (models.User, error)
+	FindByID(ctx context.Context, id string) (models.User, error)
+	Update(ctx context.Context, user models.User) error
+	Delete(ctx context.Context, id string) error
 }
 
-// SessionManager issues and refreshes authentication tokens for users.
+// UserTokenManager issues and redeems the single-use tokens e-mailed to users.
+type UserTokenManager interface {
+	Issue(ctx context.Context, userID string, purpose auth.TokenPurpose, ttl time.Duration) (string, error)
+	Consume(ctx context.Context, token string, purpose auth.TokenPurpose) (string, error)
+	IssueWithPayload(ctx context.Context, userID string, purpose auth.TokenPurpose, ttl time.Duration, payload string) (string, error)
+	Redeem(ctx context.Context, token string, purpose auth.TokenPurpose) (auth.UserToken, error)
+}
+
+// Mailer delivers transactional e-mail.
+type Mailer interface {
+	Send(ctx context.Context, msg email.Message) error
+}
+
+// SessionManager issues, refreshes, verifies, and revokes authentication tokens for users.
 type SessionManager interface {
-	Issue(ctx context.Context, userID string) (models.SessionTokens, error)
-	Refresh(ctx context.Context, refreshToken string) (models.SessionTokens, error)
+	Issue(ctx context.Context, userID string, client auth.ClientInfo) (models.SessionTokens, error)
+	Refresh(ctx context.Context, refreshToken string, client auth.ClientInfo) (models.SessionTokens, error)
+	Authenticate(ctx context.Context, accessToken string) (auth.Principal, error)
+	Sessions(ctx context.Context, userID string) ([]auth.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// TwoFactorService manages TOTP enrollment and the second login step.
+type TwoFactorService interface {
+	Enabled(ctx context.Context, userID string) (bool, error)
+	BeginEnrollment(ctx context.Context, userID, account string) (auth.TOTPEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	IssueChallenge(userID string) (auth.LoginChallenge, error)
+	CompleteChallenge(ctx context.Context, challenge, code string) (string, error)
+}
+
+// PasswordHasher hashes and verifies user passwords.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the stored hash and whether the hash should be
+	// replaced because it uses an outdated algorithm or parameters.
+	Verify(password, encoded string) (ok, needsRehash bool, err error)
+	// CompareDummy takes as long as Verify so missing accounts cannot be detected by timing.
+	CompareDummy(password string)
+}
+
+// APITokenService manages personal access tokens.
+type APITokenService interface {
+	Create(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (auth.APIToken, string, error)
+	Authenticate(ctx context.Context, secret string) (auth.Principal, error)
+	List(ctx context.Context, userID string) ([]auth.APIToken, error)
+	Get(ctx context.Context, userID, id string) (auth.APIToken, error)
+	Rename(ctx context.Context, userID, id, name string) (auth.APIToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// LoginLockout throttles password guessing against individual accounts.
+type LoginLockout interface {
+	Allow(ctx context.Context, email string) (bool, error)
+	// RecordFailure returns the lock expiry when this failure locked the account.
+	RecordFailure(ctx context.Context, email string) (*time.Time, error)
+	RecordSuccess(ctx context.Context, email string) error
+}
+
+// OIDCProvider runs the OpenID Connect authorization-code flow against one identity provider.
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (oidc.Identity, error)
+}
+
+// IdentityStore persists links between users and external identity providers.
+type IdentityStore interface {
+	Find(ctx context.Context, provider, subject string) (models.UserIdentity, error)
+	Create(ctx context.Context, identity models.UserIdentity) error
+}
+
+// StateSigner protects short-lived state handed to browsers, such as OIDC login cookies.
+type StateSigner interface {
+	Sign(typ string, claims any) (string, error)
+	Verify(typ, token string, into any) error
+}
+
+// ProfileSummaries resolves the compact profiles embedded in friend lists and the feed.
+type ProfileSummaries interface {
+	// Summaries omits users without a profile.
+	Summaries(ctx context.Context, userIDs []string) (map[string]models.ProfileSummary, error)
+}
+
+// ProfileStore persists user profiles.
+type ProfileStore interface {
+	ProfileSummaries
+	// Upsert returns repositories.ErrConflict when another user holds the handle.
+	Upsert(ctx context.Context, profile models.Profile) error
+	FindByUserID(ctx context.Context, userID string) (models.Profile, error)
+	FindByHandle(ctx context.Context, handle string) (models.Profile, error)
+	// SearchHandles returns up to limit profiles whose handle starts with prefix.
+	SearchHandles(ctx context.Context, prefix string, limit int) ([]models.ProfileSummary, error)
+	// SetAvatar returns repositories.ErrNotFound when the user has no profile yet.
+	SetAvatar(ctx context.Context, userID string, avatar models.Avatar, at time.Time) error
+}
+
+// AvatarService resizes uploaded profile pictures and manages the stored copies.
+type AvatarService interface {
+	Store(ctx context.Context, userID string, r io.Reader) (models.Avatar, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// InviteService issues and redeems friend invites for people without an account.
+type InviteService interface {
+	Create(ctx context.Context, inviterID string, opts invites.CreateOptions) (invites.Invite, string, error)
+	ListOutstanding(ctx context.Context, inviterID string) ([]invites.Invite, error)
+	// Revoke returns invites.ErrInviteNotFound for unknown, foreign or already revoked invites.
+	Revoke(ctx context.Context, inviterID, id string) error
+	// Lookup and Redeem return invites.ErrInvalidCode unless the invite can still be used.
+	Lookup(ctx context.Context, code string) (invites.Invite, error)
+	Redeem(ctx context.Context, code string) (invites.Invite, error)
 }
 
 // FriendStore captures operations required by the friend handlers.
 type FriendStore interface {
 	CreateRequest(ctx context.Context, request models.FriendRequest) error
 	ListForUser(ctx context.Context, userID string) ([]models.FriendRequest, error)
-	UpdateStatus(ctx context.Context, requestID, status string) error
+	// ListView returns up to limit entries of a friend view, newest first, after the cursor.
+	ListView(ctx context.Context, userID string, view models.FriendView, after *models.FriendCursor, limit int) ([]models.FriendConnection, error)
+	CountViews(ctx context.Context, userID string) (models.FriendCounts, error)
+	// FindByID returns repositories.ErrNotFound when the request does not exist.
+	FindByID(ctx context.Context, requestID string) (models.FriendRequest, error)
+	// FindBetween returns the request between the two users, whichever of them sent it, or
+	// repositories.ErrNotFound.
+	FindBetween(ctx context.Context, userID, otherID string) (models.FriendRequest, error)
+	// Transition returns repositories.ErrConflict when the request is no longer in transition.From.
+	Transition(ctx context.Context, transition models.FriendTransition) (models.FriendRequest, error)
+	// ListMutual returns the IDs of the accepted friends both users have in common.
+	ListMutual(ctx context.Context, userID, otherID string) ([]string, error)
+	// ListSuggestions returns up to limit friends of friends the user has no request with and did
+	// not dismiss, most mutual friends first.
+	ListSuggestions(ctx context.Context, userID string, limit int) ([]models.FriendSuggestion, error)
+	// DismissSuggestion returns repositories.ErrNotFound when the candidate does not exist.
+	DismissSuggestion(ctx context.Context, userID, candidateID string, at time.Time) error
+}
+
+// CircleStore persists the named groups of friends videos can be shared with. Every operation is
+// limited to the owner's circles; others are reported as repositories.ErrNotFound.
+type CircleStore interface {
+	// Create and Update return repositories.ErrNotFriends when a member is not an accepted friend
+	// of the owner and repositories.ErrConflict when the owner has another circle with the name.
+	Create(ctx context.Context, circle models.Circle) error
+	ListForOwner(ctx context.Context, ownerID string) ([]models.Circle, error)
+	FindByID(ctx context.Context, ownerID, circleID string) (models.Circle, error)
+	Update(ctx context.Context, circle models.Circle) error
+	Delete(ctx context.Context, ownerID, circleID string) error
+}
+
+// FeedFilterStore persists the friends users muted and the shares they hid from their feed.
+type FeedFilterStore interface {
+	// Mute returns repositories.ErrNotFriends when the muted user is not an accepted friend.
+	Mute(ctx context.Context, mute models.FriendMute) error
+	// ListMutes returns the mutes still in effect at the given time.
+	ListMutes(ctx context.Context, userID string, at time.Time) ([]models.FriendMute, error)
+	Unmute(ctx context.Context, userID, mutedID string) error
+	// HideShare returns repositories.ErrNotFound when the user cannot see the share.
+	HideShare(ctx context.Context, userID, shareID string, at time.Time) error
+	ListHidden(ctx context.Context, userID string) ([]models.HiddenShare, error)
+	UnhideShare(ctx context.Context, userID, shareID string) error
+}
+
+// CommentStore persists the comments on video shares. Callers check that the user can see the
+// share first.
+type CommentStore interface {
+	// Create returns repositories.ErrNotFound when the share or the parent comment is gone.
+	Create(ctx context.Context, comment models.Comment) error
+	FindByID(ctx context.Context, shareID, commentID string) (models.Comment, error)
+	ListThreads(ctx context.Context, shareID string, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	ListReplies(ctx context.Context, shareID, parentID string, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	// ListByOffset lists the comments starting threads whose offset lies in [fromMs, toMs).
+	ListByOffset(ctx context.Context, shareID string, fromMs, toMs int64, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	CountByOffset(ctx context.Context, shareID string, bucketMs int64) ([]models.CommentMarker, error)
+	// Update returns repositories.ErrNotFound when the comment was deleted.
+	Update(ctx context.Context, comment models.Comment) error
+	Delete(ctx context.Context, shareID, commentID string, at time.Time) error
+}
+
+// ReactionStore persists the emoji reactions users leave on shares. Callers check that the user
+// can see the share first.
+type ReactionStore interface {
+	// Toggle adds the reaction or removes an existing one, reporting whether it is now in place.
+	// It returns repositories.ErrNotFound when the share is gone.
+	Toggle(ctx context.Context, reaction models.Reaction) (bool, error)
+	// Summaries aggregates the reactions to many shares at once for one viewer. Shares without
+	// reactions may be left out.
+	Summaries(ctx context.Context, userID string, shareIDs []string) (map[string]models.ReactionSummary, error)
 }
 
 // VideoStore captures persistence for video sharing workflows.
 type VideoStore interface {
+	// Create returns repositories.ErrCircleNotFound when a circle in share.CircleIDs is not the owner's
+	// and repositories.ErrNotFriends when a user in share.UserIDs is not the owner's friend.
 	Create(ctx context.Context, share models.VideoShare) error
-	ListFeed(ctx context.Context, userID string) ([]models.VideoShare, error)
+	// ListFeed returns up to limit feed shares matching the filter, newest first, starting after
+	// the cursor when one is given.
+	ListFeed(ctx context.Context, userID string, filter models.FeedFilter, after *models.FeedCursor, limit int) ([]models.VideoShare, error)
+	// FindVisible returns repositories.ErrNotFound unless the share is the user's own or an
+	// accepted friend's whose audience includes the user.
+	FindVisible(ctx context.Context, userID, shareID string) (models.VideoShare, error)
+	// Update stores the note of one of the owner's shares and, unless share.Visibility is empty,
+	// its audience. It returns repositories.ErrNotFound when the share is not the owner's and
+	// otherwise fails like Create.
+	Update(ctx context.Context, share models.VideoShare) error
+	Delete(ctx context.Context, ownerID, shareID string) error
+	ListByOwner(ctx context.Context, ownerID string) ([]models.VideoShare, error)
 }
 
 // VideoMetadataProvider resolves video details for shared URLs.
@@ -40,4 +241,11 @@ type VideoMetadataProvider interface {
 // VideoAssetIngestor schedules background persistence of video files.
 type VideoAssetIngestor interface {
 	Enqueue(ctx context.Context, share models.VideoShare) error
+	// Cancel stops the share's pending job and returns once it no longer writes to storage.
+	Cancel(ctx context.Context, shareID string) error
+}
+
+// AssetRemover deletes stored video files. Each share's files live under "<share id>/".
+type AssetRemover interface {
+	DeletePrefix(ctx context.Context, prefix string) error
 }
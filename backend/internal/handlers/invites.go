@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/invites"
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// InviteHandler implements the endpoints for inviting people who do not have an account yet.
+type InviteHandler struct {
+	Invites InviteService
+	// Users is optional; when set, e-mail invites to existing accounts are rejected.
+	Users UserStore
+	// Profiles is optional; when set, invite e-mails name the inviter.
+	Profiles    ProfileSummaries
+	Mailer      Mailer
+	AppBaseURL  string
+	RateLimiter RateLimiter
+}
+
+// Create handles POST /api/v1/invites requests. Without an e-mail address it creates a shareable
+// link; with one it e-mails a single-use invite to that address. The code is only returned here.
+func (h InviteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "InviteHandler.Create")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "invites:create") {
+		logger.Warn("rate limit exceeded", "scope", "invites:create")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many invites"})
+		return
+	}
+
+	if h.Invites == nil {
+		logger.Error("invite service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "invite service unavailable"})
+		return
+	}
+
+	inviterID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req createInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid invite payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if req.Email != "" {
+		if h.Mailer == nil {
+			logger.Error("invite e-mail skipped; mail delivery unavailable", "userId", inviterID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "invite e-mails unavailable"})
+			return
+		}
+		if h.Users != nil {
+			if _, err := h.Users.FindByEmail(ctx, req.Email); err == nil {
+				respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "this person already has an account; send them a friend request instead"})
+				return
+			} else if !errors.Is(err, repositories.ErrNotFound) {
+				logger.Error("invite user lookup failed", "error", err, "userId", inviterID)
+				respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to create invite"})
+				return
+			}
+		}
+	}
+
+	if req.ExpiresInHours < 0 {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "expiresInHours must not be negative"})
+		return
+	}
+
+	invite, code, err := h.Invites.Create(ctx, inviterID, invites.CreateOptions{
+		Email:   req.Email,
+		MaxUses: req.MaxUses,
+		TTL:     time.Duration(req.ExpiresInHours) * time.Hour,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, invites.ErrInvalidInvite):
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, invites.ErrInviteLimit):
+			logger.Warn("invite limit reached", "userId", inviterID)
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "invite limit reached; revoke an unused invite first"})
+		default:
+			logger.Error("create invite failed", "error", err, "userId", inviterID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to create invite"})
+		}
+		return
+	}
+
+	link := h.inviteLink(code)
+	if invite.Email != "" {
+		if err := h.sendInvite(ctx, invite, link); err != nil {
+			logger.Error("invite e-mail failed", "error", err, "userId", inviterID, "inviteId", invite.ID)
+			if err := h.Invites.Revoke(ctx, inviterID, invite.ID); err != nil {
+				logger.Error("revoke unsent invite failed", "error", err, "inviteId", invite.ID)
+			}
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to send invite e-mail"})
+			return
+		}
+	}
+
+	logger.Info("invite created", "userId", inviterID, "inviteId", invite.ID, "email", invite.Email != "")
+	respondJSON(ctx, w, http.StatusCreated, createInviteResponse{Invite: newInviteView(invite), Code: code, Link: link})
+}
+
+// List handles GET /api/v1/invites requests for the caller's outstanding invites.
+func (h InviteHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "InviteHandler.List")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Invites == nil {
+		logger.Error("invite service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "invite service unavailable"})
+		return
+	}
+
+	inviterID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	outstanding, err := h.Invites.ListOutstanding(ctx, inviterID)
+	if err != nil {
+		logger.Error("list invites failed", "error", err, "userId", inviterID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load invites"})
+		return
+	}
+
+	resp := listInvitesResponse{Invites: make([]inviteView, 0, len(outstanding))}
+	for _, invite := range outstanding {
+		resp.Invites = append(resp.Invites, newInviteView(invite))
+	}
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Delete handles DELETE /api/v1/invites/{id} requests.
+func (h InviteHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "InviteHandler.Delete")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Invites == nil {
+		logger.Error("invite service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "invite service unavailable"})
+		return
+	}
+
+	inviterID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	inviteID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(inviteID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "invite not found"})
+		return
+	}
+
+	if err := h.Invites.Revoke(ctx, inviterID, inviteID); err != nil {
+		if errors.Is(err, invites.ErrInviteNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "invite not found"})
+			return
+		}
+		logger.Error("revoke invite failed", "error", err, "userId", inviterID, "inviteId", inviteID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to revoke invite"})
+		return
+	}
+
+	logger.Info("invite revoked", "userId", inviterID, "inviteId", inviteID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h InviteHandler) inviteLink(code string) string {
+	return strings.TrimRight(h.AppBaseURL, "/") + "/signup?invite=" + url.QueryEscape(code)
+}
+
+func (h InviteHandler) sendInvite(ctx context.Context, invite invites.Invite, link string) error {
+	inviter := "A friend"
+	summary := profileSummaries(ctx, h.Profiles, []string{invite.InviterID})[invite.InviterID]
+	switch {
+	case summary.DisplayName != "":
+		inviter = summary.DisplayName
+	case summary.Handle != "":
+		inviter = "@" + summary.Handle
+	}
+
+	return h.Mailer.Send(ctx, email.Message{
+		To:      invite.Email,
+		Subject: inviter + " invited you to VidFriends",
+		Body: fmt.Sprintf("%s invited you to join VidFriends and share videos with each other.\n\n"+
+			"Create your account with the link below before %s and you will be friends right away:\n\n%s\n\n"+
+			"If you are not interested, you can ignore this e-mail.\n",
+			inviter, invite.ExpiresAt.UTC().Format("2 January 2006 15:04 UTC"), link),
+	})
+}
+
+// acceptInvite makes the newly created user a friend of whoever invited them. The account
+// already exists at this point, so failures are logged rather than failing the signup.
+func (h AuthHandler) acceptInvite(ctx context.Context, code string, user models.User) {
+	logger := logging.FromContext(ctx)
+
+	invite, err := h.Invites.Redeem(ctx, code)
+	if err != nil {
+		logger.Warn("signup invite could not be redeemed", "error", err, "userId", user.ID)
+		return
+	}
+
+	if h.Friends == nil {
+		logger.Error("signup invite redeemed without friend store", "userId", user.ID, "inviteId", invite.ID)
+		return
+	}
+
+	now := h.now()
+	if err := h.Friends.CreateRequest(ctx, models.FriendRequest{
+		ID:          uuid.NewString(),
+		Requester:   invite.InviterID,
+		Receiver:    user.ID,
+		Status:      friendStatusAccepted,
+		CreatedAt:   now,
+		RespondedAt: &now,
+		ActorID:     user.ID,
+		UpdatedAt:   now,
+	}); err != nil {
+		logger.Error("signup invite friendship failed", "error", err, "userId", user.ID, "inviteId", invite.ID)
+		return
+	}
+
+	logger.Info("signup invite accepted", "userId", user.ID, "inviterId", invite.InviterID, "inviteId", invite.ID)
+}
+
+type createInviteRequest struct {
+	Email          string `json:"email"`
+	MaxUses        int    `json:"maxUses"`
+	ExpiresInHours int    `json:"expiresInHours"`
+}
+
+type inviteView struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email,omitempty"`
+	MaxUses   int       `json:"maxUses"`
+	Uses      int       `json:"uses"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type createInviteResponse struct {
+	Invite inviteView `json:"invite"`
+	Code   string     `json:"code"`
+	Link   string     `json:"link"`
+}
+
+type listInvitesResponse struct {
+	Invites []inviteView `json:"invites"`
+}
+
+func newInviteView(invite invites.Invite) inviteView {
+	return inviteView{
+		ID:        invite.ID,
+		Email:     invite.Email,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+	}
+}
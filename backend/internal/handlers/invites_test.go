@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/invites"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+func newInviteHandler() (InviteHandler, *recordingMailer) {
+	mailer := &recordingMailer{}
+	return InviteHandler{
+		Invites:     invites.NewManager(invites.NewInMemoryStore()),
+		Users:       newInMemoryUserStore(),
+		Mailer:      mailer,
+		AppBaseURL:  "https://app.vidfriends.test/",
+		RateLimiter: stubRateLimiter{allow: true},
+	}, mailer
+}
+
+func createInvite(t *testing.T, handler InviteHandler, payload any) createInviteResponse {
+	t.Helper()
+	rec := postJSON(t, handler.Create, "/api/v1/invites", payload, requesterUUID)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp createInviteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestInviteHandlerCreateLink(t *testing.T) {
+	handler, mailer := newInviteHandler()
+
+	resp := createInvite(t, handler, createInviteRequest{MaxUses: 3, ExpiresInHours: 48})
+
+	if !strings.HasPrefix(resp.Code, invites.CodePrefix) {
+		t.Fatalf("expected invite code, got %q", resp.Code)
+	}
+	if resp.Link != "https://app.vidfriends.test/signup?invite="+resp.Code {
+		t.Fatalf("unexpected link %q", resp.Link)
+	}
+	if resp.Invite.MaxUses != 3 || resp.Invite.Uses != 0 || resp.Invite.Email != "" {
+		t.Fatalf("unexpected invite: %+v", resp.Invite)
+	}
+	if got := resp.Invite.ExpiresAt.Sub(resp.Invite.CreatedAt); got.Hours() != 48 {
+		t.Fatalf("expected invite to expire after 48 hours, got %v", got)
+	}
+	if len(mailer.messages) != 0 {
+		t.Fatalf("expected no e-mail for link invites, got %d", len(mailer.messages))
+	}
+}
+
+func TestInviteHandlerCreateEmail(t *testing.T) {
+	handler, mailer := newInviteHandler()
+	profiles := newInMemoryProfileStore()
+	profiles.profiles[requesterUUID] = models.Profile{UserID: requesterUUID, Handle: "alice", DisplayName: "Alice"}
+	handler.Profiles = profiles
+
+	resp := createInvite(t, handler, createInviteRequest{Email: "Friend@Example.com"})
+
+	if resp.Invite.Email != "friend@example.com" || resp.Invite.MaxUses != 1 {
+		t.Fatalf("expected single-use e-mail invite, got %+v", resp.Invite)
+	}
+	if len(mailer.messages) != 1 {
+		t.Fatalf("expected one invite e-mail, got %d", len(mailer.messages))
+	}
+	msg := mailer.messages[0]
+	if msg.To != "friend@example.com" || !strings.Contains(msg.Subject, "Alice") || !strings.Contains(msg.Body, resp.Link) {
+		t.Fatalf("unexpected invite e-mail: %+v", msg)
+	}
+}
+
+func TestInviteHandlerCreateFailures(t *testing.T) {
+	t.Run("existingAccount", func(t *testing.T) {
+		handler, mailer := newInviteHandler()
+		users := newInMemoryUserStore()
+		users.users["friend@example.com"] = models.User{ID: receiverUUID, Email: "friend@example.com"}
+		handler.Users = users
+
+		rec := postJSON(t, handler.Create, "/api/v1/invites", createInviteRequest{Email: "friend@example.com"}, requesterUUID)
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status %d got %d", http.StatusConflict, rec.Code)
+		}
+		if len(mailer.messages) != 0 {
+			t.Fatal("expected no e-mail to be sent")
+		}
+	})
+
+	t.Run("mailFailureRevokesInvite", func(t *testing.T) {
+		handler, mailer := newInviteHandler()
+		mailer.err = errors.New("smtp unavailable")
+
+		rec := postJSON(t, handler.Create, "/api/v1/invites", createInviteRequest{Email: "friend@example.com"}, requesterUUID)
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d got %d", http.StatusInternalServerError, rec.Code)
+		}
+		outstanding, err := handler.Invites.ListOutstanding(context.Background(), requesterUUID)
+		if err != nil || len(outstanding) != 0 {
+			t.Fatalf("expected unsent invite to be revoked, got %+v (err %v)", outstanding, err)
+		}
+	})
+
+	cases := []struct {
+		name       string
+		handler    func(InviteHandler) InviteHandler
+		payload    any
+		userID     string
+		wantStatus int
+	}{
+		{"unauthenticated", nil, createInviteRequest{}, "", http.StatusUnauthorized},
+		{"invalidBody", nil, "not an object", requesterUUID, http.StatusBadRequest},
+		{"invalidEmail", nil, createInviteRequest{Email: "nope"}, requesterUUID, http.StatusBadRequest},
+		{"reusableEmail", nil, createInviteRequest{Email: "friend@example.com", MaxUses: 2}, requesterUUID, http.StatusBadRequest},
+		{"tooManyUses", nil, createInviteRequest{MaxUses: invites.MaxUses + 1}, requesterUUID, http.StatusBadRequest},
+		{"negativeLifetime", nil, createInviteRequest{ExpiresInHours: -1}, requesterUUID, http.StatusBadRequest},
+		{"lifetimeTooLong", nil, createInviteRequest{ExpiresInHours: 31 * 24}, requesterUUID, http.StatusBadRequest},
+		{"rateLimited", func(h InviteHandler) InviteHandler {
+			h.RateLimiter = stubRateLimiter{allow: false}
+			return h
+		}, createInviteRequest{}, requesterUUID, http.StatusTooManyRequests},
+		{"missingService", func(h InviteHandler) InviteHandler {
+			h.Invites = nil
+			return h
+		}, createInviteRequest{}, requesterUUID, http.StatusInternalServerError},
+		{"missingMailer", func(h InviteHandler) InviteHandler {
+			h.Mailer = nil
+			return h
+		}, createInviteRequest{Email: "friend@example.com"}, requesterUUID, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, _ := newInviteHandler()
+			if tc.handler != nil {
+				handler = tc.handler(handler)
+			}
+			rec := postJSON(t, handler.Create, "/api/v1/invites", tc.payload, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestInviteHandlerCreateLimit(t *testing.T) {
+	handler, _ := newInviteHandler()
+	for i := 0; i < invites.MaxOutstanding; i++ {
+		createInvite(t, handler, createInviteRequest{})
+	}
+
+	rec := postJSON(t, handler.Create, "/api/v1/invites", createInviteRequest{}, requesterUUID)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d got %d", http.StatusConflict, rec.Code)
+	}
+}
+
+func TestInviteHandlerListAndDelete(t *testing.T) {
+	handler, _ := newInviteHandler()
+	kept := createInvite(t, handler, createInviteRequest{})
+	revoked := createInvite(t, handler, createInviteRequest{})
+
+	deleteInvite := func(id, userID string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/invites/"+id, nil)
+		req.SetPathValue("id", id)
+		if userID != "" {
+			req = withUser(req, userID)
+		}
+		rec := httptest.NewRecorder()
+		handler.Delete(rec, req)
+		return rec.Code
+	}
+
+	if code := deleteInvite(revoked.Invite.ID, receiverUUID); code != http.StatusNotFound {
+		t.Fatalf("expected other users' invites to be hidden, got %d", code)
+	}
+	if code := deleteInvite("not-a-uuid", requesterUUID); code != http.StatusNotFound {
+		t.Fatalf("expected malformed id to be rejected, got %d", code)
+	}
+	if code := deleteInvite(revoked.Invite.ID, ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthenticated delete to be rejected, got %d", code)
+	}
+	if code := deleteInvite(revoked.Invite.ID, requesterUUID); code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, code)
+	}
+	if code := deleteInvite(revoked.Invite.ID, requesterUUID); code != http.StatusNotFound {
+		t.Fatalf("expected revoked invite to be gone, got %d", code)
+	}
+
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/invites", nil), requesterUUID)
+	rec := httptest.NewRecorder()
+	handler.List(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+	var resp listInvitesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Invites) != 1 || resp.Invites[0].ID != kept.Invite.ID {
+		t.Fatalf("expected only the outstanding invite, got %+v", resp.Invites)
+	}
+	if strings.Contains(rec.Body.String(), kept.Code) {
+		t.Fatal("expected invite codes not to be listed")
+	}
+}
+
+func TestAuthHandlerSignUpWithInvite(t *testing.T) {
+	inviteHandler, _ := newInviteHandler()
+	link := createInvite(t, inviteHandler, createInviteRequest{MaxUses: 1})
+
+	users := newInMemoryUserStore()
+	friends := newInMemoryFriendStore()
+	handler := AuthHandler{Users: users, Sessions: newSessionManager(), Invites: inviteHandler.Invites, Friends: friends}
+
+	rec := postJSON(t, handler.SignUp, "/api/v1/auth/signup", signUpRequest{
+		Email:      "friend@example.com",
+		Password:   "supersafe",
+		InviteCode: link.Code,
+	}, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	user, err := users.FindByEmail(context.Background(), "friend@example.com")
+	if err != nil {
+		t.Fatalf("expected user to be stored: %v", err)
+	}
+	if len(friends.requests) != 1 {
+		t.Fatalf("expected one friendship, got %d", len(friends.requests))
+	}
+	for _, request := range friends.requests {
+		if request.Requester != requesterUUID || request.Receiver != user.ID || request.Status != friendStatusAccepted || request.RespondedAt == nil {
+			t.Fatalf("unexpected friendship: %+v", request)
+		}
+	}
+
+	rec = postJSON(t, handler.SignUp, "/api/v1/auth/signup", signUpRequest{
+		Email:      "another@example.com",
+		Password:   "supersafe",
+		InviteCode: link.Code,
+	}, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected used up invite to be rejected, got %d", rec.Code)
+	}
+	if _, err := users.FindByEmail(context.Background(), "another@example.com"); err == nil {
+		t.Fatal("expected no account to be created with a used up invite")
+	}
+}
+
+func TestAuthHandlerSignUpWithInvalidInvite(t *testing.T) {
+	users := newInMemoryUserStore()
+	handler := AuthHandler{
+		Users:    users,
+		Sessions: newSessionManager(),
+		Invites:  invites.NewManager(invites.NewInMemoryStore()),
+		Friends:  newInMemoryFriendStore(),
+	}
+
+	rec := postJSON(t, handler.SignUp, "/api/v1/auth/signup", signUpRequest{
+		Email:      "friend@example.com",
+		Password:   "supersafe",
+		InviteCode: invites.CodePrefix + "unknown",
+	}, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d got %d", http.StatusBadRequest, rec.Code)
+	}
+	if len(users.users) != 0 {
+		t.Fatal("expected no account to be created")
+	}
+}
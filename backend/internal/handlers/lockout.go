@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// recordLoginFailure counts a failed login for the e-mail address and tells the account owner,
+// when there is one, that their account was locked. Errors are logged rather than returned so the
+// caller always answers with the same "invalid credentials" response.
+func (h AuthHandler) recordLoginFailure(ctx context.Context, emailAddress string, user *models.User) {
+	if h.Lockout == nil {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	lockedUntil, err := h.Lockout.RecordFailure(ctx, emailAddress)
+	if err != nil {
+		logger.Error("login failure could not be recorded", "error", err, "email", emailAddress)
+		return
+	}
+	if lockedUntil == nil {
+		return
+	}
+
+	logger.Warn("account locked after repeated login failures", "event", "security.account_locked", "email", emailAddress, "lockedUntil", lockedUntil.Format(time.RFC3339))
+	if user != nil {
+		h.sendLockoutNotice(ctx, *user, *lockedUntil)
+	}
+}
+
+// sendLockoutNotice warns the user that someone is guessing their password.
+func (h AuthHandler) sendLockoutNotice(ctx context.Context, user models.User, until time.Time) {
+	if h.Mailer == nil {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	link := strings.TrimRight(h.AppBaseURL, "/") + "/forgot-password"
+	msg := email.Message{
+		To:      user.Email,
+		Subject: "Your VidFriends account was temporarily locked",
+		Body: fmt.Sprintf("We noticed several failed attempts to sign in to your VidFriends account, so we "+
+			"have blocked new sign-ins until %s.\n\n"+
+			"If this was you, wait until then and try again. If it was not, we recommend resetting your "+
+			"password:\n\n%s\n", until.UTC().Format("2006-01-02 15:04 MST"), link),
+	}
+
+	if err := h.Mailer.Send(ctx, msg); err != nil {
+		logger.Error("lockout notice email failed", "error", err, "userId", user.ID)
+		return
+	}
+	logger.Info("lockout notice email sent", "userId", user.ID)
+}
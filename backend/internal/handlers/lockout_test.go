@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+func newLockoutHandler(t *testing.T) (AuthHandler, *auth.InMemoryLoginAttemptStore, *recordingMailer, *time.Time) {
+	t.Helper()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	users := newInMemoryUserStore()
+	users.users["user@example.com"] = models.User{ID: "user-1", Email: "user@example.com", Password: string(hashed)}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	attempts := auth.NewInMemoryLoginAttemptStore()
+	guard := auth.NewLoginGuard(attempts, auth.LockoutPolicy{
+		FreeAttempts: 2,
+		BaseDelay:    time.Second,
+		MaxDelay:     4 * time.Second,
+		LockAfter:    5,
+		LockDuration: 15 * time.Minute,
+		Window:       time.Hour,
+	})
+	guard.NowFunc = func() time.Time { return now }
+
+	mailer := &recordingMailer{}
+	handler := AuthHandler{
+		Users:      users,
+		Sessions:   newSessionManager(),
+		Mailer:     mailer,
+		Lockout:    guard,
+		AppBaseURL: "https://app.example.com",
+	}
+	return handler, attempts, mailer, &now
+}
+
+func attemptLogin(t *testing.T, handler AuthHandler, emailAddress, password string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(loginRequest{Email: emailAddress, Password: password})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.Login(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body)))
+	return rec
+}
+
+func TestAuthHandlerLoginLocksAccount(t *testing.T) {
+	handler, _, mailer, now := newLockoutHandler(t)
+
+	wrong := attemptLogin(t, handler, "user@example.com", "wrongpass")
+	if wrong.Code != http.StatusUnauthorized {
+		t.Fatalf("expected wrong password to be rejected, got %d", wrong.Code)
+	}
+	missing := attemptLogin(t, handler, "missing@example.com", "password123")
+	if missing.Code != http.StatusUnauthorized {
+		t.Fatalf("expected missing account to be rejected, got %d", missing.Code)
+	}
+
+	for i := 0; i < 4; i++ {
+		*now = now.Add(time.Minute)
+		if rec := attemptLogin(t, handler, "User@Example.com", "wrongpass"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i+2, rec.Code)
+		}
+	}
+
+	if len(mailer.messages) != 1 {
+		t.Fatalf("expected one lockout notice, got %d", len(mailer.messages))
+	}
+	notice := mailer.messages[0]
+	if notice.To != "user@example.com" || !strings.Contains(notice.Body, "https://app.example.com/forgot-password") {
+		t.Fatalf("unexpected lockout notice %+v", notice)
+	}
+
+	*now = now.Add(time.Minute)
+	locked := attemptLogin(t, handler, "user@example.com", "password123")
+	if locked.Code != http.StatusUnauthorized {
+		t.Fatalf("expected correct password to be rejected while locked, got %d", locked.Code)
+	}
+
+	if wrong.Body.String() != missing.Body.String() || locked.Body.String() != wrong.Body.String() {
+		t.Fatalf("expected identical responses, got %q, %q and %q", wrong.Body.String(), missing.Body.String(), locked.Body.String())
+	}
+
+	*now = now.Add(15 * time.Minute)
+	if rec := attemptLogin(t, handler, "user@example.com", "password123"); rec.Code != http.StatusOK {
+		t.Fatalf("expected login after the lock expired, got %d", rec.Code)
+	}
+}
+
+func TestAuthHandlerLoginThrottlesAndResets(t *testing.T) {
+	handler, attempts, _, now := newLockoutHandler(t)
+
+	for i := 0; i < 3; i++ {
+		attemptLogin(t, handler, "user@example.com", "wrongpass")
+	}
+
+	if rec := attemptLogin(t, handler, "user@example.com", "password123"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected login during the delay to be rejected, got %d", rec.Code)
+	}
+
+	*now = now.Add(time.Second)
+	if rec := attemptLogin(t, handler, "user@example.com", "password123"); rec.Code != http.StatusOK {
+		t.Fatalf("expected login after the delay, got %d", rec.Code)
+	}
+
+	record, err := attempts.Find(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("find attempts: %v", err)
+	}
+	if record.FailedCount != 0 {
+		t.Fatalf("expected successful login to reset failures, got %d", record.FailedCount)
+	}
+}
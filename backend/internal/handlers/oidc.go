@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/oidc"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+const (
+	// oidcStateCookie carries the signed state, nonce and PKCE verifier between start and callback.
+	oidcStateCookie = "vidfriends_oidc"
+	// oidcStateType is the typ header of the signed state cookie.
+	oidcStateType = "vidfriends-oidc-state"
+	// oidcStateTTL bounds how long a user has to finish signing in with the provider.
+	oidcStateTTL = 10 * time.Minute
+	// oidcCookiePath scopes the state cookie to the OIDC routes.
+	oidcCookiePath = "/api/v1/auth/oidc/"
+)
+
+var (
+	errOIDCEmailUnverified = errors.New("identity provider did not share a verified email address")
+	errOIDCAccountExists   = errors.New("an unverified account already uses this email address")
+)
+
+// OIDCHandler implements sign-in through external OpenID Connect providers.
+type OIDCHandler struct {
+	Providers   map[string]OIDCProvider
+	Users       UserStore
+	Identities  IdentityStore
+	Sessions    SessionManager
+	TwoFactor   TwoFactorService
+	States      StateSigner
+	NowFunc     func() time.Time
+	RateLimiter RateLimiter
+	// Passwords is optional; when nil, passwords are hashed with auth.DefaultPasswordHashConfig.
+	Passwords PasswordHasher
+}
+
+type oidcState struct {
+	Provider  string `json:"provider"`
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Start handles GET /api/v1/auth/oidc/{provider}/start requests by redirecting the browser to
+// the provider with a fresh state, nonce and PKCE challenge.
+func (h OIDCHandler) Start(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "OIDCHandler.Start")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:oidc") {
+		logger.Warn("rate limit exceeded", "scope", "auth:oidc")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many login attempts"})
+		return
+	}
+
+	if h.States == nil {
+		logger.Error("oidc state signer unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+		return
+	}
+
+	name := r.PathValue("provider")
+	provider, ok := h.Providers[name]
+	if !ok {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "unknown identity provider"})
+		return
+	}
+
+	state := oidcState{Provider: name, ExpiresAt: h.now().Add(oidcStateTTL).Unix()}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		random, err := oidc.RandomValue()
+		if err != nil {
+			logger.Error("oidc failed to generate state", "error", err)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to start sign in"})
+			return
+		}
+		*value = random
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		logger.Error("oidc provider unavailable", "error", err, "provider", name)
+		respondJSON(ctx, w, http.StatusBadGateway, map[string]string{"error": "identity provider unavailable"})
+		return
+	}
+
+	cookie, err := h.States.Sign(oidcStateType, state)
+	if err != nil {
+		logger.Error("oidc failed to sign state", "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to start sign in"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    cookie,
+		Path:     oidcCookiePath,
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback handles GET /api/v1/auth/oidc/{provider}/callback requests. It verifies the state
+// cookie, redeems the authorization code, links the external identity to a user and issues a
+// session, or a two-factor challenge when the user has enabled 2FA.
+func (h OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "OIDCHandler.Callback")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:oidc") {
+		logger.Warn("rate limit exceeded", "scope", "auth:oidc")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many login attempts"})
+		return
+	}
+
+	if h.States == nil || h.Users == nil || h.Identities == nil || h.Sessions == nil {
+		logger.Error("oidc dependencies unavailable", "hasStates", h.States != nil, "hasUsers", h.Users != nil, "hasIdentities", h.Identities != nil, "hasSessions", h.Sessions != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+		return
+	}
+
+	name := r.PathValue("provider")
+	provider, ok := h.Providers[name]
+	if !ok {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "unknown identity provider"})
+		return
+	}
+
+	// The state cookie is single use whatever the outcome.
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Value: "", Path: oidcCookiePath, MaxAge: -1, HttpOnly: true, Secure: isSecureRequest(r), SameSite: http.SameSiteLaxMode})
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		logger.Warn("oidc provider returned an error", "provider", name, "error", providerErr)
+		respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "sign in was cancelled or denied"})
+		return
+	}
+
+	state, ok := h.readState(r, name)
+	if !ok {
+		logger.Warn("oidc state missing or invalid", "provider", name)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "sign in expired; please try again"})
+		return
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "authorization code is required"})
+		return
+	}
+
+	identity, err := provider.Exchange(ctx, code, state.Verifier, state.Nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidIDToken) || errors.Is(err, oidc.ErrExchangeFailed) {
+			logger.Warn("oidc exchange rejected", "error", err, "provider", name)
+			respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "unable to verify sign in with identity provider"})
+			return
+		}
+		logger.Error("oidc exchange failed", "error", err, "provider", name)
+		respondJSON(ctx, w, http.StatusBadGateway, map[string]string{"error": "identity provider unavailable"})
+		return
+	}
+
+	user, err := h.resolveUser(ctx, name, identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOIDCEmailUnverified):
+			logger.Warn("oidc identity without verified email", "provider", name)
+			respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "your identity provider did not share a verified email address"})
+		case errors.Is(err, errOIDCAccountExists):
+			logger.Warn("oidc identity matches unverified account", "event", "security.oidc_link_refused", "provider", name)
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "an account with this email already exists; sign in with your password and verify your email first"})
+		default:
+			logger.Error("oidc failed to resolve user", "error", err, "provider", name)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to sign in"})
+		}
+		return
+	}
+
+	logger.Info("oidc sign in", "provider", name, "userId", user.ID)
+	completeSignIn(w, r, h.Sessions, h.TwoFactor, user.ID)
+}
+
+// readState verifies the signed state cookie against the provider and the state query parameter.
+func (h OIDCHandler) readState(r *http.Request, provider string) (oidcState, bool) {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return oidcState{}, false
+	}
+
+	var state oidcState
+	if err := h.States.Verify(oidcStateType, cookie.Value, &state); err != nil {
+		return oidcState{}, false
+	}
+
+	if state.Provider != provider || !h.now().Before(time.Unix(state.ExpiresAt, 0)) {
+		return oidcState{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(state.State), []byte(r.URL.Query().Get("state"))) != 1 {
+		return oidcState{}, false
+	}
+	return state, true
+}
+
+// resolveUser returns the user linked to the external identity. Unknown identities are linked to
+// the account with the same e-mail address when both the provider and VidFriends have verified
+// it, and otherwise get a new account. Linking to an unverified account is refused because its
+// password may have been chosen by someone who does not own the address.
+func (h OIDCHandler) resolveUser(ctx context.Context, provider string, identity oidc.Identity) (models.User, error) {
+	linked, err := h.Identities.Find(ctx, provider, identity.Subject)
+	if err == nil {
+		return h.Users.FindByID(ctx, linked.UserID)
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return models.User{}, fmt.Errorf("find identity: %w", err)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return models.User{}, errOIDCEmailUnverified
+	}
+
+	logger := logging.FromContext(ctx)
+	user, err := h.Users.FindByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		if user.EmailVerifiedAt == nil {
+			return models.User{}, errOIDCAccountExists
+		}
+		logger.Info("oidc identity linked to existing account", "event", "security.oidc_identity_linked", "provider", provider, "userId", user.ID)
+	case errors.Is(err, repositories.ErrNotFound):
+		user, err = h.createUser(ctx, identity.Email)
+		if err != nil {
+			return models.User{}, err
+		}
+		logger.Info("account created from oidc identity", "provider", provider, "userId", user.ID)
+	default:
+		return models.User{}, fmt.Errorf("find user by email: %w", err)
+	}
+
+	if err := h.Identities.Create(ctx, models.UserIdentity{
+		Provider:  provider,
+		Subject:   identity.Subject,
+		UserID:    user.ID,
+		Email:     identity.Email,
+		CreatedAt: h.now(),
+	}); err != nil {
+		if !errors.Is(err, repositories.ErrConflict) {
+			return models.User{}, fmt.Errorf("link identity: %w", err)
+		}
+		// A concurrent callback linked the identity first; use whatever it linked.
+		linked, err := h.Identities.Find(ctx, provider, identity.Subject)
+		if err != nil {
+			return models.User{}, fmt.Errorf("find identity: %w", err)
+		}
+		return h.Users.FindByID(ctx, linked.UserID)
+	}
+
+	return user, nil
+}
+
+// createUser creates an account for a new external identity. The account gets a random password
+// nobody knows; the user can set one through password reset.
+func (h OIDCHandler) createUser(ctx context.Context, email string) (models.User, error) {
+	password, err := oidc.RandomValue()
+	if err != nil {
+		return models.User{}, fmt.Errorf("generate password: %w", err)
+	}
+	passwords := h.Passwords
+	if passwords == nil {
+		passwords = defaultPasswordHasher
+	}
+	hashed, err := passwords.Hash(password)
+	if err != nil {
+		return models.User{}, fmt.Errorf("hash password: %w", err)
+	}
+
+	now := h.now()
+	user := models.User{
+		ID:              uuid.NewString(),
+		Email:           email,
+		Password:        hashed,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		EmailVerifiedAt: &now,
+	}
+	if err := h.Users.Create(ctx, user); err != nil {
+		return models.User{}, fmt.Errorf("create user: %w", err)
+	}
+	return user, nil
+}
+
+func (h OIDCHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+// isSecureRequest reports whether the request reached us over HTTPS, directly or via a proxy.
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
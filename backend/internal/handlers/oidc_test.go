@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/config"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/oidc"
+	"github.com/vidfriends/backend/internal/oidc/oidctest"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+type inMemoryIdentityStore struct {
+	identities map[string]models.UserIdentity
+}
+
+func newInMemoryIdentityStore() *inMemoryIdentityStore {
+	return &inMemoryIdentityStore{identities: make(map[string]models.UserIdentity)}
+}
+
+func (s *inMemoryIdentityStore) Find(_ context.Context, provider, subject string) (models.UserIdentity, error) {
+	identity, ok := s.identities[provider+"|"+subject]
+	if !ok {
+		return models.UserIdentity{}, repositories.ErrNotFound
+	}
+	return identity, nil
+}
+
+func (s *inMemoryIdentityStore) Create(_ context.Context, identity models.UserIdentity) error {
+	key := identity.Provider + "|" + identity.Subject
+	if _, exists := s.identities[key]; exists {
+		return repositories.ErrConflict
+	}
+	s.identities[key] = identity
+	return nil
+}
+
+type oidcTestEnv struct {
+	mux        *http.ServeMux
+	issuer     *oidctest.Issuer
+	users      *inMemoryUserStore
+	identities *inMemoryIdentityStore
+	keys       *auth.Keyring
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	issuer := oidctest.NewIssuer(t, "vidfriends", "client-secret")
+	provider := oidc.NewProvider(config.OIDCProviderConfig{
+		Name:         "fake",
+		IssuerURL:    issuer.URL,
+		ClientID:     "vidfriends",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/fake/callback",
+		Scopes:       []string{"openid", "email"},
+	}, issuer.Client())
+
+	keys, err := auth.NewKeyring(auth.Key{ID: "test", Secret: []byte("handler-test-secret-handler-test")})
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+
+	env := &oidcTestEnv{issuer: issuer, users: newInMemoryUserStore(), identities: newInMemoryIdentityStore(), keys: keys}
+	handler := OIDCHandler{
+		Providers:  map[string]OIDCProvider{"fake": provider},
+		Users:      env.users,
+		Identities: env.identities,
+		Sessions:   auth.NewManager(time.Minute, time.Hour, auth.NewInMemorySessionStore(), keys),
+		States:     keys,
+	}
+	env.mux = http.NewServeMux()
+	env.mux.HandleFunc("/api/v1/auth/oidc/{provider}/start", handler.Start)
+	env.mux.HandleFunc("/api/v1/auth/oidc/{provider}/callback", handler.Callback)
+	return env
+}
+
+// signIn runs the browser side of the flow: start, the provider's redirect, then the callback.
+func (env *oidcTestEnv) signIn(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	start := httptest.NewRecorder()
+	env.mux.ServeHTTP(start, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/fake/start", nil))
+	if start.Code != http.StatusFound {
+		t.Fatalf("expected start to redirect, got %d: %s", start.Code, start.Body.String())
+	}
+
+	cookies := start.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("expected an http-only state cookie, got %+v", cookies)
+	}
+
+	client := env.issuer.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(start.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	req.AddCookie(cookies[0])
+	rec := httptest.NewRecorder()
+	env.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeTokens(t *testing.T, rec *httptest.ResponseRecorder) models.SessionTokens {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp authResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Tokens.AccessToken == "" || resp.Tokens.RefreshToken == "" {
+		t.Fatalf("expected tokens, got %+v", resp.Tokens)
+	}
+	return resp.Tokens
+}
+
+func TestOIDCHandlerCreatesAndReusesAccount(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	decodeTokens(t, env.signIn(t))
+
+	user, err := env.users.FindByEmail(context.Background(), "oidc-user@example.com")
+	if err != nil {
+		t.Fatalf("expected account to be created: %v", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Fatal("expected provider-verified email to be marked verified")
+	}
+	identity, err := env.identities.Find(context.Background(), "fake", "subject-1")
+	if err != nil || identity.UserID != user.ID {
+		t.Fatalf("expected identity linked to %s, got %+v %v", user.ID, identity, err)
+	}
+
+	env.issuer.Email = "changed@example.com"
+	decodeTokens(t, env.signIn(t))
+	if len(env.users.users) != 1 {
+		t.Fatalf("expected the linked account to be reused, got %d users", len(env.users.users))
+	}
+}
+
+func TestOIDCHandlerLinksVerifiedAccount(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	verifiedAt := time.Now().UTC()
+	env.users.users["oidc-user@example.com"] = models.User{ID: "existing", Email: "oidc-user@example.com", EmailVerifiedAt: &verifiedAt}
+
+	decodeTokens(t, env.signIn(t))
+
+	identity, err := env.identities.Find(context.Background(), "fake", "subject-1")
+	if err != nil || identity.UserID != "existing" {
+		t.Fatalf("expected identity linked to existing account, got %+v %v", identity, err)
+	}
+}
+
+func TestOIDCHandlerRefusesUnverifiedLinks(t *testing.T) {
+	t.Run("unverified local account", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.users.users["oidc-user@example.com"] = models.User{ID: "existing", Email: "oidc-user@example.com"}
+
+		rec := env.signIn(t)
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status %d got %d", http.StatusConflict, rec.Code)
+		}
+		if len(env.identities.identities) != 0 {
+			t.Fatal("expected no identity to be linked")
+		}
+	})
+
+	t.Run("unverified provider email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.issuer.EmailVerified = false
+
+		rec := env.signIn(t)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("expected status %d got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestOIDCHandlerCallbackFailures(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	start := httptest.NewRecorder()
+	env.mux.ServeHTTP(start, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/fake/start", nil))
+	cookie := start.Result().Cookies()[0]
+
+	cases := []struct {
+		name   string
+		path   string
+		cookie *http.Cookie
+		status int
+	}{
+		{"unknown provider", "/api/v1/auth/oidc/other/callback?code=c&state=s", cookie, http.StatusNotFound},
+		{"missing cookie", "/api/v1/auth/oidc/fake/callback?code=c&state=s", nil, http.StatusBadRequest},
+		{"state mismatch", "/api/v1/auth/oidc/fake/callback?code=c&state=forged", cookie, http.StatusBadRequest},
+		{"tampered cookie", "/api/v1/auth/oidc/fake/callback?code=c&state=s", &http.Cookie{Name: oidcStateCookie, Value: cookie.Value + "x"}, http.StatusBadRequest},
+		{"provider error", "/api/v1/auth/oidc/fake/callback?error=access_denied", cookie, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			env.mux.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("expected status %d got %d: %s", tc.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("stolen code with another login's cookie", func(t *testing.T) {
+		other := httptest.NewRecorder()
+		env.mux.ServeHTTP(other, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/fake/start", nil))
+		otherURL, _ := url.Parse(other.Header().Get("Location"))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/fake/callback?code=c&state="+url.QueryEscape(otherURL.Query().Get("state")), nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		env.mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
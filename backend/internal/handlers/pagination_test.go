@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, time.April, 1, 12, 0, 0, 123456789, time.FixedZone("CEST", 2*60*60))
+
+	gotAt, gotID, err := decodeCursor(encodeCursor(at, "req-1"))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !gotAt.Equal(at) || gotID != "req-1" {
+		t.Fatalf("expected %v and req-1, got %v and %q", at, gotAt, gotID)
+	}
+
+	for _, cursor := range []string{"", "%%%", "bm8tc2VwYXJhdG9y", encodeCursor(at, "")} {
+		if _, _, err := decodeCursor(cursor); err != errInvalidCursor {
+			t.Fatalf("decodeCursor(%q): expected errInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	cases := map[string]struct {
+		want    int
+		wantErr bool
+	}{
+		"":           {want: defaultPageSize},
+		"?limit=1":   {want: 1},
+		"?limit=100": {want: maxPageSize},
+		"?limit=0":   {wantErr: true},
+		"?limit=101": {wantErr: true},
+		"?limit=ten": {wantErr: true},
+	}
+
+	for query, tc := range cases {
+		got, err := pageSize(httptest.NewRequest("GET", "/items"+query, nil))
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("pageSize(%q) = %d, %v; want %d, error %v", query, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
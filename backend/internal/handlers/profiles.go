@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/profiles"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// ProfileHandler implements the profile endpoints.
+type ProfileHandler struct {
+	Profiles ProfileStore
+	// Avatars is optional; when nil, avatar uploads are rejected.
+	Avatars     AvatarService
+	NowFunc     func() time.Time
+	RateLimiter RateLimiter
+}
+
+// Get handles GET /api/v1/profile requests for the caller's own profile.
+func (h ProfileHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "ProfileHandler.Get")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Profiles == nil {
+		logger.Error("profile service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "profile service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	profile, err := h.Profiles.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "profile not found"})
+			return
+		}
+		logger.Error("load profile failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load profile"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, profileResponse{Profile: newProfileView(profile)})
+}
+
+// Update handles PUT /api/v1/profile requests. It creates the caller's profile or replaces its
+// handle, display name and bio.
+func (h ProfileHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "ProfileHandler.Update")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPut {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "profile:update") {
+		logger.Warn("rate limit exceeded", "scope", "profile:update")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many profile updates"})
+		return
+	}
+
+	if h.Profiles == nil {
+		logger.Error("profile service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "profile service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req updateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid profile payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	handle, err := profiles.NormalizeHandle(req.Handle)
+	if err != nil {
+		logger.Warn("profile handle rejected", "userId", userID, "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	displayName, err := profiles.NormalizeText("displayName", req.DisplayName, profiles.MaxDisplayNameLength, false)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	bio, err := profiles.NormalizeText("bio", req.Bio, profiles.MaxBioLength, true)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	now := h.now()
+	if err := h.Profiles.Upsert(ctx, models.Profile{
+		UserID:      userID,
+		Handle:      handle,
+		DisplayName: displayName,
+		Bio:         bio,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "handle already taken"})
+			return
+		}
+		logger.Error("save profile failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to save profile"})
+		return
+	}
+
+	h.respondWithProfile(ctx, w, userID)
+}
+
+// UploadAvatar handles PUT /api/v1/profile/avatar requests. The body is the raw JPEG, PNG or GIF
+// image, which is cropped to a square and stored at each avatar size.
+func (h ProfileHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "ProfileHandler.UploadAvatar")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPut {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "profile:avatar") {
+		logger.Warn("rate limit exceeded", "scope", "profile:avatar")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many avatar uploads"})
+		return
+	}
+
+	if h.Profiles == nil || h.Avatars == nil {
+		logger.Error("avatar dependencies unavailable", "hasProfiles", h.Profiles != nil, "hasAvatars", h.Avatars != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "avatar uploads unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		logger.Warn("avatar content type rejected", "userId", userID, "contentType", mediaType)
+		respondJSON(ctx, w, http.StatusUnsupportedMediaType, map[string]string{"error": "avatar must be a JPEG, PNG or GIF image"})
+		return
+	}
+
+	previous, err := h.Profiles.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "choose a handle before uploading an avatar"})
+			return
+		}
+		logger.Error("avatar profile lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to update avatar"})
+		return
+	}
+
+	avatar, err := h.Avatars.Store(ctx, userID, r.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, profiles.ErrImageTooLarge):
+			respondJSON(ctx, w, http.StatusRequestEntityTooLarge, map[string]string{"error": "avatar must be at most 5 MiB and 4096×4096 pixels"})
+		case errors.Is(err, profiles.ErrUnsupportedImage):
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "avatar is not a valid JPEG, PNG or GIF image"})
+		default:
+			logger.Error("store avatar failed", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to update avatar"})
+		}
+		return
+	}
+
+	if err := h.Profiles.SetAvatar(ctx, userID, avatar, h.now()); err != nil {
+		h.deleteAvatar(ctx, userID, avatar.Key)
+		logger.Error("save avatar failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to update avatar"})
+		return
+	}
+	h.deleteAvatar(ctx, userID, previous.Avatar.Key)
+
+	h.respondWithProfile(ctx, w, userID)
+}
+
+// DeleteAvatar handles DELETE /api/v1/profile/avatar requests.
+func (h ProfileHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "ProfileHandler.DeleteAvatar")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Profiles == nil || h.Avatars == nil {
+		logger.Error("avatar dependencies unavailable", "hasProfiles", h.Profiles != nil, "hasAvatars", h.Avatars != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "avatar uploads unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	profile, err := h.Profiles.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "profile not found"})
+			return
+		}
+		logger.Error("avatar profile lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to remove avatar"})
+		return
+	}
+
+	if profile.Avatar.Key != "" {
+		if err := h.Profiles.SetAvatar(ctx, userID, models.Avatar{}, h.now()); err != nil {
+			logger.Error("clear avatar failed", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to remove avatar"})
+			return
+		}
+		h.deleteAvatar(ctx, userID, profile.Avatar.Key)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetByHandle handles GET /api/v1/users/{handle} requests.
+func (h ProfileHandler) GetByHandle(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "ProfileHandler.GetByHandle")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "users:lookup") {
+		logger.Warn("rate limit exceeded", "scope", "users:lookup")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many profile lookups"})
+		return
+	}
+
+	if h.Profiles == nil {
+		logger.Error("profile service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "profile service unavailable"})
+		return
+	}
+
+	if _, ok := authenticatedUser(ctx, w); !ok {
+		return
+	}
+
+	handle, err := profiles.NormalizeHandle(r.PathValue("handle"))
+	if err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	}
+
+	profile, err := h.Profiles.FindByHandle(ctx, handle)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "user not found"})
+			return
+		}
+		logger.Error("profile lookup failed", "error", err, "handle", handle)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load profile"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, profileResponse{Profile: newProfileView(profile)})
+}
+
+// respondWithProfile answers with the caller's freshly stored profile.
+func (h ProfileHandler) respondWithProfile(ctx context.Context, w http.ResponseWriter, userID string) {
+	profile, err := h.Profiles.FindByUserID(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("reload profile failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load profile"})
+		return
+	}
+	respondJSON(ctx, w, http.StatusOK, profileResponse{Profile: newProfileView(profile)})
+}
+
+// deleteAvatar removes stored avatar copies that are no longer referenced. Failures only leave
+// unused files behind, so they are logged.
+func (h ProfileHandler) deleteAvatar(ctx context.Context, userID, key string) {
+	if err := h.Avatars.Delete(ctx, key); err != nil {
+		logging.FromContext(ctx).Error("delete old avatar failed", "error", err, "userId", userID, "key", key)
+	}
+}
+
+func (h ProfileHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+// profileSummaries resolves the compact profiles of userIDs. Listings must not fail because
+// profiles are unavailable, so lookup errors are logged and every user still gets a summary
+// carrying at least its ID.
+func profileSummaries(ctx context.Context, store ProfileSummaries, userIDs []string) map[string]models.ProfileSummary {
+	summaries := make(map[string]models.ProfileSummary, len(userIDs))
+	unique := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if _, seen := summaries[id]; seen {
+			continue
+		}
+		summaries[id] = models.ProfileSummary{UserID: id}
+		unique = append(unique, id)
+	}
+
+	if store == nil || len(unique) == 0 {
+		return summaries
+	}
+
+	found, err := store.Summaries(ctx, unique)
+	if err != nil {
+		logging.FromContext(ctx).Error("load profile summaries failed", "error", err)
+		return summaries
+	}
+	for id, summary := range found {
+		summaries[id] = summary
+	}
+	return summaries
+}
+
+type updateProfileRequest struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	Bio         string `json:"bio"`
+}
+
+type profileResponse struct {
+	Profile profileView `json:"profile"`
+}
+
+type profileView struct {
+	UserID      string      `json:"userId"`
+	Handle      string      `json:"handle"`
+	DisplayName string      `json:"displayName"`
+	Bio         string      `json:"bio"`
+	Avatar      *avatarView `json:"avatar,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+type avatarView struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+}
+
+func newProfileView(profile models.Profile) profileView {
+	view := profileView{
+		UserID:      profile.UserID,
+		Handle:      profile.Handle,
+		DisplayName: profile.DisplayName,
+		Bio:         profile.Bio,
+		CreatedAt:   profile.CreatedAt,
+		UpdatedAt:   profile.UpdatedAt,
+	}
+	if profile.Avatar.Key != "" {
+		view.Avatar = &avatarView{Small: profile.Avatar.Small, Medium: profile.Avatar.Medium, Large: profile.Avatar.Large}
+	}
+	return view
+}
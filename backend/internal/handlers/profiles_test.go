@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/profiles"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+type inMemoryProfileStore struct {
+	profiles map[string]models.Profile
+	err      error
+}
+
+func newInMemoryProfileStore() *inMemoryProfileStore {
+	return &inMemoryProfileStore{profiles: make(map[string]models.Profile)}
+}
+
+func (s *inMemoryProfileStore) Upsert(_ context.Context, profile models.Profile) error {
+	if s.err != nil {
+		return s.err
+	}
+	for id, existing := range s.profiles {
+		if id != profile.UserID && existing.Handle == profile.Handle {
+			return repositories.ErrConflict
+		}
+	}
+	if existing, ok := s.profiles[profile.UserID]; ok {
+		profile.CreatedAt = existing.CreatedAt
+		profile.Avatar = existing.Avatar
+	}
+	s.profiles[profile.UserID] = profile
+	return nil
+}
+
+func (s *inMemoryProfileStore) FindByUserID(_ context.Context, userID string) (models.Profile, error) {
+	if s.err != nil {
+		return models.Profile{}, s.err
+	}
+	profile, ok := s.profiles[userID]
+	if !ok {
+		return models.Profile{}, repositories.ErrNotFound
+	}
+	return profile, nil
+}
+
+func (s *inMemoryProfileStore) FindByHandle(_ context.Context, handle string) (models.Profile, error) {
+	if s.err != nil {
+		return models.Profile{}, s.err
+	}
+	for _, profile := range s.profiles {
+		if profile.Handle == handle {
+			return profile, nil
+		}
+	}
+	return models.Profile{}, repositories.ErrNotFound
+}
+
+func (s *inMemoryProfileStore) SearchHandles(_ context.Context, prefix string, limit int) ([]models.ProfileSummary, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var matches []models.ProfileSummary
+	for _, profile := range s.profiles {
+		if strings.HasPrefix(profile.Handle, prefix) {
+			matches = append(matches, profile.Summary())
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Handle < matches[j].Handle })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func (s *inMemoryProfileStore) SetAvatar(_ context.Context, userID string, avatar models.Avatar, at time.Time) error {
+	profile, ok := s.profiles[userID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	profile.Avatar = avatar
+	profile.UpdatedAt = at
+	s.profiles[userID] = profile
+	return nil
+}
+
+func (s *inMemoryProfileStore) Summaries(_ context.Context, userIDs []string) (map[string]models.ProfileSummary, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	summaries := make(map[string]models.ProfileSummary)
+	for _, id := range userIDs {
+		if profile, ok := s.profiles[id]; ok {
+			summaries[id] = profile.Summary()
+		}
+	}
+	return summaries, nil
+}
+
+type avatarServiceStub struct {
+	stored  int
+	deleted []string
+	err     error
+}
+
+func (s *avatarServiceStub) Store(_ context.Context, userID string, r io.Reader) (models.Avatar, error) {
+	if s.err != nil {
+		return models.Avatar{}, s.err
+	}
+	if _, err := io.ReadAll(r); err != nil {
+		return models.Avatar{}, err
+	}
+	s.stored++
+	key := profiles.AvatarPrefix(userID) + strings.Repeat("v", s.stored) + "/"
+	return models.Avatar{
+		Key:    key,
+		Small:  "https://cdn.example.com/" + key + "64.jpg",
+		Medium: "https://cdn.example.com/" + key + "256.jpg",
+		Large:  "https://cdn.example.com/" + key + "512.jpg",
+	}, nil
+}
+
+func (s *avatarServiceStub) Delete(_ context.Context, key string) error {
+	if key != "" {
+		s.deleted = append(s.deleted, key)
+	}
+	return nil
+}
+
+func decodeProfile(t *testing.T, rec *httptest.ResponseRecorder) profileView {
+	t.Helper()
+	var resp profileResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.Profile
+}
+
+func TestProfileHandlerUpdateAndGet(t *testing.T) {
+	store := newInMemoryProfileStore()
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	handler := ProfileHandler{Profiles: store, NowFunc: func() time.Time { return now }}
+
+	rec := httptest.NewRecorder()
+	handler.Get(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil), "user-1"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before a profile exists, got %d", rec.Code)
+	}
+
+	body, _ := json.Marshal(updateProfileRequest{Handle: "@Alice", DisplayName: "  Alice A.  ", Bio: "Likes\ncats"})
+	rec = httptest.NewRecorder()
+	handler.Update(rec, withUser(httptest.NewRequest(http.MethodPut, "/api/v1/profile", bytes.NewReader(body)), "user-1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	profile := decodeProfile(t, rec)
+	if profile.UserID != "user-1" || profile.Handle != "alice" || profile.DisplayName != "Alice A." || profile.Bio != "Likes\ncats" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	if profile.Avatar != nil {
+		t.Fatalf("expected no avatar yet, got %+v", profile.Avatar)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.Get(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil), "user-1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	if got := decodeProfile(t, rec); got.Handle != "alice" || !got.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected stored profile: %+v", got)
+	}
+}
+
+func TestProfileHandlerUpdateRejectsTakenHandle(t *testing.T) {
+	store := newInMemoryProfileStore()
+	store.profiles["user-2"] = models.Profile{UserID: "user-2", Handle: "alice"}
+	handler := ProfileHandler{Profiles: store}
+
+	body, _ := json.Marshal(updateProfileRequest{Handle: "Alice"})
+	rec := httptest.NewRecorder()
+	handler.Update(rec, withUser(httptest.NewRequest(http.MethodPut, "/api/v1/profile", bytes.NewReader(body)), "user-1"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 got %d", rec.Code)
+	}
+}
+
+func TestProfileHandlerUpdateValidation(t *testing.T) {
+	handler := ProfileHandler{Profiles: newInMemoryProfileStore()}
+
+	cases := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "invalid json", body: "{", code: http.StatusBadRequest},
+		{name: "short handle", body: `{"handle":"ab"}`, code: http.StatusBadRequest},
+		{name: "reserved handle", body: `{"handle":"admin"}`, code: http.StatusBadRequest},
+		{name: "invalid characters", body: `{"handle":"al-ice"}`, code: http.StatusBadRequest},
+		{name: "long display name", body: `{"handle":"alice","displayName":"` + strings.Repeat("x", profiles.MaxDisplayNameLength+1) + `"}`, code: http.StatusBadRequest},
+		{name: "multiline display name", body: `{"handle":"alice","displayName":"a\nb"}`, code: http.StatusBadRequest},
+		{name: "long bio", body: `{"handle":"alice","bio":"` + strings.Repeat("x", profiles.MaxBioLength+1) + `"}`, code: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.Update(rec, withUser(httptest.NewRequest(http.MethodPut, "/api/v1/profile", strings.NewReader(tc.body)), "user-1"))
+			if rec.Code != tc.code {
+				t.Fatalf("expected %d got %d: %s", tc.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	ProfileHandler{Profiles: newInMemoryProfileStore(), RateLimiter: stubRateLimiter{allow: false}}.Update(rec,
+		withUser(httptest.NewRequest(http.MethodPut, "/api/v1/profile", strings.NewReader(`{"handle":"alice"}`)), "user-1"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	ProfileHandler{}.Update(rec, withUser(httptest.NewRequest(http.MethodPut, "/api/v1/profile", strings.NewReader(`{"handle":"alice"}`)), "user-1"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 without a profile store, got %d", rec.Code)
+	}
+}
+
+func TestProfileHandlerUploadAvatar(t *testing.T) {
+	store := newInMemoryProfileStore()
+	avatars := &avatarServiceStub{}
+	handler := ProfileHandler{Profiles: store, Avatars: avatars}
+
+	upload := func(contentType string) *httptest.ResponseRecorder {
+		req := withUser(httptest.NewRequest(http.MethodPut, "/api/v1/profile/avatar", strings.NewReader("image-bytes")), "user-1")
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.UploadAvatar(rec, req)
+		return rec
+	}
+
+	if rec := upload("image/png"); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 before a handle is chosen, got %d", rec.Code)
+	}
+
+	store.profiles["user-1"] = models.Profile{UserID: "user-1", Handle: "alice"}
+
+	if rec := upload("text/plain"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 for non-image upload, got %d", rec.Code)
+	}
+
+	rec := upload("image/png")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	first := decodeProfile(t, rec)
+	if first.Avatar == nil || !strings.HasSuffix(first.Avatar.Small, "64.jpg") || !strings.HasSuffix(first.Avatar.Large, "512.jpg") {
+		t.Fatalf("expected avatar locations in response, got %+v", first.Avatar)
+	}
+	if len(avatars.deleted) != 0 {
+		t.Fatalf("expected nothing to be deleted on first upload, got %v", avatars.deleted)
+	}
+
+	previousKey := store.profiles["user-1"].Avatar.Key
+	if rec := upload("image/jpeg; charset=binary"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	if len(avatars.deleted) != 1 || avatars.deleted[0] != previousKey {
+		t.Fatalf("expected previous avatar %q to be deleted, got %v", previousKey, avatars.deleted)
+	}
+
+	avatars.err = profiles.ErrImageTooLarge
+	if rec := upload("image/png"); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 got %d", rec.Code)
+	}
+	avatars.err = profiles.ErrUnsupportedImage
+	if rec := upload("image/png"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d", rec.Code)
+	}
+	avatars.err = errors.New("bucket unavailable")
+	if rec := upload("image/png"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 got %d", rec.Code)
+	}
+}
+
+func TestProfileHandlerDeleteAvatar(t *testing.T) {
+	store := newInMemoryProfileStore()
+	avatars := &avatarServiceStub{}
+	handler := ProfileHandler{Profiles: store, Avatars: avatars}
+	store.profiles["user-1"] = models.Profile{UserID: "user-1", Handle: "alice", Avatar: models.Avatar{Key: "avatars/user-1/v/", Small: "small"}}
+
+	rec := httptest.NewRecorder()
+	handler.DeleteAvatar(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/v1/profile/avatar", nil), "user-1"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+	if store.profiles["user-1"].Avatar != (models.Avatar{}) {
+		t.Fatalf("expected avatar to be cleared, got %+v", store.profiles["user-1"].Avatar)
+	}
+	if len(avatars.deleted) != 1 || avatars.deleted[0] != "avatars/user-1/v/" {
+		t.Fatalf("expected stored copies to be deleted, got %v", avatars.deleted)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeleteAvatar(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/v1/profile/avatar", nil), "user-2"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without a profile, got %d", rec.Code)
+	}
+}
+
+func TestProfileHandlerGetByHandle(t *testing.T) {
+	store := newInMemoryProfileStore()
+	store.profiles["user-2"] = models.Profile{UserID: "user-2", Handle: "bob", DisplayName: "Bob", Avatar: models.Avatar{Key: "k", Small: "s", Medium: "m", Large: "l"}}
+	handler := ProfileHandler{Profiles: store}
+
+	lookup := func(handle string) *httptest.ResponseRecorder {
+		req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/users/"+handle, nil), "user-1")
+		req.SetPathValue("handle", handle)
+		rec := httptest.NewRecorder()
+		handler.GetByHandle(rec, req)
+		return rec
+	}
+
+	rec := lookup("@Bob")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	profile := decodeProfile(t, rec)
+	if profile.UserID != "user-2" || profile.DisplayName != "Bob" || profile.Avatar == nil || profile.Avatar.Medium != "m" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	for _, handle := range []string{"carol", "no!"} {
+		if rec := lookup(handle); rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for %q, got %d", handle, rec.Code)
+		}
+	}
+
+	store.err = errors.New("database unavailable")
+	if rec := lookup("bob"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetByHandle(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/bob", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without authentication, got %d", rec.Code)
+	}
+}
+
+func TestProfileSummariesFallBackToIDs(t *testing.T) {
+	store := newInMemoryProfileStore()
+	store.profiles["user-1"] = models.Profile{UserID: "user-1", Handle: "alice", Avatar: models.Avatar{Small: "small"}}
+
+	summaries := profileSummaries(context.Background(), store, []string{"user-1", "user-2", "user-1"})
+	if summaries["user-1"].Handle != "alice" || summaries["user-1"].AvatarURL != "small" {
+		t.Fatalf("unexpected summary: %+v", summaries["user-1"])
+	}
+	if summaries["user-2"] != (models.ProfileSummary{UserID: "user-2"}) {
+		t.Fatalf("expected ID-only summary, got %+v", summaries["user-2"])
+	}
+
+	store.err = errors.New("database unavailable")
+	summaries = profileSummaries(context.Background(), store, []string{"user-1"})
+	if summaries["user-1"] != (models.ProfileSummary{UserID: "user-1"}) {
+		t.Fatalf("expected lookup failures to fall back to IDs, got %+v", summaries["user-1"])
+	}
+}
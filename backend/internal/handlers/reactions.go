@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// reactionKinds is the fixed set of reactions users can leave on shares.
+var reactionKinds = []string{
+	models.ReactionLike,
+	models.ReactionLove,
+	models.ReactionLaugh,
+	models.ReactionWow,
+	models.ReactionSad,
+	models.ReactionFire,
+}
+
+// ReactionHandler implements the endpoint for reacting to video shares. Only users who can see a
+// share, including its owner, may react to it.
+type ReactionHandler struct {
+	Reactions ReactionStore
+	Videos    VideoStore
+	NowFunc   func() time.Time
+}
+
+// Toggle handles POST /api/v1/videos/{id}/reactions requests. Reacting with a kind the caller
+// already reacted with takes that reaction back.
+func (h ReactionHandler) Toggle(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "ReactionHandler.Toggle")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Reactions == nil || h.Videos == nil {
+		logger.Error("reaction service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "reaction service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req toggleReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid reaction payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(req.Kind))
+	if !slices.Contains(reactionKinds, kind) {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "kind must be one of " + strings.Join(reactionKinds, ", ")})
+		return
+	}
+
+	share, ok := VideoHandler{Videos: h.Videos}.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	reacted, err := h.Reactions.Toggle(ctx, models.Reaction{ShareID: share.ID, UserID: userID, Kind: kind, CreatedAt: h.now()})
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+			return
+		}
+		logger.Error("toggle reaction failed", "error", err, "shareId", share.ID, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to update reaction"})
+		return
+	}
+
+	logger.Info("reaction toggled", "shareId", share.ID, "userId", userID, "kind", kind, "reacted", reacted)
+	summaries := reactionSummaries(ctx, h.Reactions, userID, []string{share.ID})
+	respondJSON(ctx, w, http.StatusOK, toggleReactionResponse{Kind: kind, Reacted: reacted, Reactions: summaries[share.ID]})
+}
+
+func (h ReactionHandler) now() time.Time {
+	if h.NowFunc != nil {
+		return h.NowFunc().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// reactionSummaries aggregates the reactions to shareIDs for the viewer. Like profile summaries,
+// reactions must not break listings, so lookup errors are logged and every share still gets an
+// empty summary.
+func reactionSummaries(ctx context.Context, store ReactionStore, userID string, shareIDs []string) map[string]models.ReactionSummary {
+	summaries := make(map[string]models.ReactionSummary, len(shareIDs))
+	var found map[string]models.ReactionSummary
+	if store != nil && len(shareIDs) > 0 {
+		var err error
+		if found, err = store.Summaries(ctx, userID, shareIDs); err != nil {
+			logging.FromContext(ctx).Error("load reaction summaries failed", "error", err)
+		}
+	}
+	for _, id := range shareIDs {
+		summary := found[id]
+		if summary.Counts == nil {
+			summary.Counts = map[string]int{}
+		}
+		if summary.Mine == nil {
+			summary.Mine = []string{}
+		}
+		summaries[id] = summary
+	}
+	return summaries
+}
+
+type toggleReactionRequest struct {
+	Kind string `json:"kind"`
+}
+
+type toggleReactionResponse struct {
+	Kind    string `json:"kind"`
+	Reacted bool   `json:"reacted"`
+	// Reactions are the share's reactions after the change.
+	Reactions models.ReactionSummary `json:"reactions"`
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// inMemoryReactionStore keeps reactions keyed by share, user and kind and counts how often the
+// summaries are loaded.
+type inMemoryReactionStore struct {
+	reactions    map[[3]string]bool
+	summaryCalls int
+	toggleErr    error
+	summariesErr error
+}
+
+func newInMemoryReactionStore() *inMemoryReactionStore {
+	return &inMemoryReactionStore{reactions: make(map[[3]string]bool)}
+}
+
+func (s *inMemoryReactionStore) Toggle(_ context.Context, reaction models.Reaction) (bool, error) {
+	if s.toggleErr != nil {
+		return false, s.toggleErr
+	}
+	key := [3]string{reaction.ShareID, reaction.UserID, reaction.Kind}
+	if s.reactions[key] {
+		delete(s.reactions, key)
+		return false, nil
+	}
+	s.reactions[key] = true
+	return true, nil
+}
+
+func (s *inMemoryReactionStore) Summaries(_ context.Context, userID string, shareIDs []string) (map[string]models.ReactionSummary, error) {
+	s.summaryCalls++
+	if s.summariesErr != nil {
+		return nil, s.summariesErr
+	}
+	summaries := make(map[string]models.ReactionSummary)
+	for key := range s.reactions {
+		if !slices.Contains(shareIDs, key[0]) {
+			continue
+		}
+		summary, ok := summaries[key[0]]
+		if !ok {
+			summary.Counts = make(map[string]int)
+		}
+		summary.Counts[key[2]]++
+		if key[1] == userID {
+			summary.Mine = append(summary.Mine, key[2])
+			sort.Strings(summary.Mine)
+		}
+		summaries[key[0]] = summary
+	}
+	return summaries, nil
+}
+
+func toggleReaction(t *testing.T, handler ReactionHandler, shareID string, payload any, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	return commentRequest(t, handler.Toggle, http.MethodPost, shareID+"/reactions", "", payload, userID)
+}
+
+func TestReactionHandlerToggle(t *testing.T) {
+	store := newInMemoryReactionStore()
+	handler := ReactionHandler{Reactions: store, Videos: newSharedVideoStore()}
+
+	toggle := func(kind, userID string) toggleReactionResponse {
+		t.Helper()
+		rec := toggleReaction(t, handler, shareUUID, toggleReactionRequest{Kind: kind}, userID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp toggleReactionResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+
+	toggle(models.ReactionFire, requesterUUID)
+	toggle(models.ReactionLike, receiverUUID)
+	resp := toggle(" Fire ", receiverUUID)
+	if !resp.Reacted || resp.Kind != models.ReactionFire || resp.Reactions.Counts[models.ReactionFire] != 2 || resp.Reactions.Counts[models.ReactionLike] != 1 {
+		t.Fatalf("unexpected reactions: %+v", resp)
+	}
+	if !slices.Equal(resp.Reactions.Mine, []string{models.ReactionFire, models.ReactionLike}) {
+		t.Fatalf("expected the caller's own reactions, got %v", resp.Reactions.Mine)
+	}
+
+	resp = toggle(models.ReactionFire, receiverUUID)
+	if resp.Reacted || resp.Reactions.Counts[models.ReactionFire] != 1 || !slices.Equal(resp.Reactions.Mine, []string{models.ReactionLike}) {
+		t.Fatalf("expected second reaction of a kind to take it back, got %+v", resp)
+	}
+	resp = toggle(models.ReactionLike, receiverUUID)
+	if resp.Reacted || len(resp.Reactions.Mine) != 0 || resp.Reactions.Mine == nil {
+		t.Fatalf("expected an empty list of own reactions, got %+v", resp)
+	}
+}
+
+func TestReactionHandlerToggleFailures(t *testing.T) {
+	valid := toggleReactionRequest{Kind: models.ReactionLove}
+
+	cases := []struct {
+		name       string
+		handler    ReactionHandler
+		shareID    string
+		payload    any
+		userID     string
+		wantStatus int
+	}{
+		{"unauthenticated", ReactionHandler{Reactions: newInMemoryReactionStore(), Videos: newSharedVideoStore()}, shareUUID, valid, "", http.StatusUnauthorized},
+		{"invalidBody", ReactionHandler{Reactions: newInMemoryReactionStore(), Videos: newSharedVideoStore()}, shareUUID, "love", receiverUUID, http.StatusBadRequest},
+		{"unknownKind", ReactionHandler{Reactions: newInMemoryReactionStore(), Videos: newSharedVideoStore()}, shareUUID, toggleReactionRequest{Kind: "meh"}, receiverUUID, http.StatusBadRequest},
+		{"unknownShare", ReactionHandler{Reactions: newInMemoryReactionStore(), Videos: newSharedVideoStore()}, hiddenShareUUID, valid, receiverUUID, http.StatusNotFound},
+		{"storeError", ReactionHandler{Reactions: &inMemoryReactionStore{toggleErr: errors.New("db down")}, Videos: newSharedVideoStore()}, shareUUID, valid, receiverUUID, http.StatusInternalServerError},
+		{"missingStore", ReactionHandler{Videos: newSharedVideoStore()}, shareUUID, valid, receiverUUID, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := toggleReaction(t, tc.handler, tc.shareID, tc.payload, tc.userID)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestVideoHandlerFeedReactions(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 15, 0, 0, 0, time.UTC)
+	reactions := newInMemoryReactionStore()
+	reactions.reactions[[3]string{"share-1", "user-123", models.ReactionLove}] = true
+	reactions.reactions[[3]string{"share-1", "friend-1", models.ReactionLove}] = true
+	reactions.reactions[[3]string{"share-2", "friend-1", models.ReactionWow}] = true
+	store := &videoStoreStub{feed: []models.VideoShare{
+		{ID: "share-1", OwnerID: "friend-1", CreatedAt: now},
+		{ID: "share-2", OwnerID: "friend-1", CreatedAt: now.Add(-time.Minute)},
+		{ID: "share-3", OwnerID: "friend-1", CreatedAt: now.Add(-2 * time.Minute)},
+	}}
+	handler := VideoHandler{Videos: store, Reactions: reactions}
+
+	feed := func() feedResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.Feed(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil), "user-123"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp feedResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	resp := feed()
+	if reactions.summaryCalls != 1 {
+		t.Fatalf("expected one summaries lookup for the page, got %d", reactions.summaryCalls)
+	}
+	first, second, third := resp.Entries[0].Reactions, resp.Entries[1].Reactions, resp.Entries[2].Reactions
+	if first.Counts[models.ReactionLove] != 2 || !slices.Equal(first.Mine, []string{models.ReactionLove}) {
+		t.Fatalf("unexpected reactions on share-1: %+v", first)
+	}
+	if second.Counts[models.ReactionWow] != 1 || len(second.Mine) != 0 {
+		t.Fatalf("unexpected reactions on share-2: %+v", second)
+	}
+	if third.Counts == nil || len(third.Counts) != 0 || third.Mine == nil {
+		t.Fatalf("expected empty reactions on share-3, got %+v", third)
+	}
+
+	reactions.summariesErr = errors.New("db down")
+	if resp := feed(); len(resp.Entries) != 3 || len(resp.Entries[0].Reactions.Counts) != 0 {
+		t.Fatalf("expected the feed to load without reactions, got %+v", resp)
+	}
+}
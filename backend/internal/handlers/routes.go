@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	authpkg "github.com/vidfriends/backend/internal/auth"
 	"github.com/vidfriends/backend/internal/middleware"
 )
 
@@ -13,29 +14,180 @@ func RegisterRoutes(mux *http.ServeMux, deps Dependencies) {
 
 	authLimiter := middleware.NewIPRateLimiter(10, time.Minute, 5, 15*time.Minute)
 	inviteLimiter := middleware.NewIPRateLimiter(5, time.Minute, 3, 15*time.Minute)
+	resendLimiter := middleware.NewIPRateLimiter(3, time.Hour, 1, 2*time.Hour)
 
-	auth := AuthHandler{Users: deps.Users, Sessions: deps.Sessions, RateLimiter: authLimiter}
-	friends := FriendHandler{Friends: deps.Friends, RateLimiter: inviteLimiter}
-	videos := VideoHandler{Videos: deps.Videos, Metadata: deps.VideoMetadata, Assets: deps.VideoAssets}
+	auth := AuthHandler{
+		Users:         deps.Users,
+		Sessions:      deps.Sessions,
+		Tokens:        deps.Tokens,
+		Mailer:        deps.Mailer,
+		TwoFactor:     deps.TwoFactor,
+		Passwords:     deps.Passwords,
+		Lockout:       deps.Lockout,
+		AppBaseURL:    deps.AppBaseURL,
+		RateLimiter:   authLimiter,
+		ResendLimiter: resendLimiter,
+		Invites:       deps.Invites,
+		Friends:       deps.Friends,
+	}
+	oidcHandler := OIDCHandler{
+		Providers:   deps.OIDCProviders,
+		Users:       deps.Users,
+		Identities:  deps.Identities,
+		Sessions:    deps.Sessions,
+		TwoFactor:   deps.TwoFactor,
+		States:      deps.States,
+		Passwords:   deps.Passwords,
+		RateLimiter: authLimiter,
+	}
+	account := AccountHandler{
+		Users:       deps.Users,
+		Sessions:    deps.Sessions,
+		Tokens:      deps.Tokens,
+		Mailer:      deps.Mailer,
+		Passwords:   deps.Passwords,
+		APITokens:   deps.APITokens,
+		Videos:      deps.Videos,
+		Ingestor:    deps.VideoAssets,
+		Assets:      deps.Assets,
+		AppBaseURL:  deps.AppBaseURL,
+		RateLimiter: authLimiter,
+	}
+	profilesHandler := ProfileHandler{Profiles: deps.Profiles, Avatars: deps.Avatars, RateLimiter: authLimiter}
+	friends := FriendHandler{Friends: deps.Friends, Users: deps.Users, Profiles: deps.Profiles, RateLimiter: inviteLimiter}
+	videos := VideoHandler{Videos: deps.Videos, Metadata: deps.VideoMetadata, Assets: deps.VideoAssets, Storage: deps.Assets, Profiles: deps.Profiles, Reactions: deps.Reactions}
+	invitesHandler := InviteHandler{
+		Invites:     deps.Invites,
+		Users:       deps.Users,
+		Profiles:    deps.Profiles,
+		Mailer:      deps.Mailer,
+		AppBaseURL:  deps.AppBaseURL,
+		RateLimiter: inviteLimiter,
+	}
+	circles := CircleHandler{Circles: deps.Circles, Profiles: deps.Profiles}
+	feedFilters := FeedFilterHandler{Filters: deps.FeedFilters, Profiles: deps.Profiles}
+	comments := CommentHandler{Comments: deps.Comments, Videos: deps.Videos, Profiles: deps.Profiles}
+	reactions := ReactionHandler{Reactions: deps.Reactions, Videos: deps.Videos}
+	apiTokens := APITokenHandler{Tokens: deps.APITokens, RateLimiter: authLimiter}
+	requireAuth := middleware.RequireAuth(newBearerAuthenticator(deps.Sessions, deps.APITokens))
+	requireVerified := requireVerifiedEmail(deps.Users, deps.EmailVerification)
+	// sessionOnly guards routes that manage the account itself; personal access tokens are refused.
+	sessionOnly := func(h http.HandlerFunc) http.Handler { return requireAuth(middleware.RequireSession(h)) }
+	// scoped guards routes personal access tokens may call when they were granted scope.
+	scoped := func(scope string, h http.Handler) http.Handler { return requireAuth(middleware.RequireScope(scope)(h)) }
 
 	mux.HandleFunc("/healthz", health.Handle)
 	mux.HandleFunc("/api/v1/auth/login", auth.Login)
+	mux.HandleFunc("/api/v1/auth/login/2fa", auth.CompleteLogin)
+	mux.HandleFunc("/api/v1/auth/oidc/{provider}/start", oidcHandler.Start)
+	mux.HandleFunc("/api/v1/auth/oidc/{provider}/callback", oidcHandler.Callback)
 	mux.HandleFunc("/api/v1/auth/signup", auth.SignUp)
 	mux.HandleFunc("/api/v1/auth/refresh", auth.Refresh)
 	mux.HandleFunc("/api/v1/auth/password-reset", auth.RequestPasswordReset)
-	mux.HandleFunc("/api/v1/friends", friends.List)
-	mux.HandleFunc("/api/v1/friends/invite", friends.Invite)
-	mux.HandleFunc("/api/v1/friends/respond", friends.Respond)
-	mux.HandleFunc("/api/v1/videos", videos.Create)
-	mux.HandleFunc("/api/v1/videos/feed", videos.Feed)
+	mux.HandleFunc("/api/v1/auth/password-reset/confirm", auth.ConfirmPasswordReset)
+	mux.HandleFunc("/api/v1/auth/verify-email", auth.VerifyEmail)
+	mux.Handle("/api/v1/auth/verify-email/resend", sessionOnly(auth.ResendVerification))
+	mux.Handle("/api/v1/auth/password", sessionOnly(account.ChangePassword))
+	mux.Handle("/api/v1/auth/email", sessionOnly(account.ChangeEmail))
+	mux.HandleFunc("/api/v1/auth/email/confirm", account.ConfirmEmailChange)
+	mux.Handle("/api/v1/auth/account", sessionOnly(account.DeleteAccount))
+	mux.Handle("/api/v1/auth/logout", sessionOnly(auth.Logout))
+	mux.Handle("/api/v1/auth/logout-all", sessionOnly(auth.LogoutAll))
+	mux.Handle("/api/v1/auth/sessions", sessionOnly(auth.ListSessions))
+	mux.Handle("/api/v1/auth/sessions/{id}", sessionOnly(auth.DeleteSession))
+	mux.Handle("/api/v1/auth/2fa", sessionOnly(auth.TwoFactorStatus))
+	mux.Handle("/api/v1/auth/2fa/enroll", sessionOnly(auth.BeginTwoFactorEnrollment))
+	mux.Handle("/api/v1/auth/2fa/confirm", sessionOnly(auth.ConfirmTwoFactorEnrollment))
+	mux.Handle("/api/v1/auth/2fa/disable", sessionOnly(auth.DisableTwoFactor))
+	mux.Handle("/api/v1/auth/2fa/recovery-codes", sessionOnly(auth.RegenerateRecoveryCodes))
+	mux.Handle("GET /api/v1/auth/tokens", sessionOnly(apiTokens.List))
+	mux.Handle("POST /api/v1/auth/tokens", sessionOnly(apiTokens.Create))
+	mux.Handle("POST /api/v1/auth/tokens/revoke-all", sessionOnly(apiTokens.RevokeAll))
+	mux.Handle("GET /api/v1/auth/tokens/{id}", sessionOnly(apiTokens.Get))
+	mux.Handle("PATCH /api/v1/auth/tokens/{id}", sessionOnly(apiTokens.Update))
+	mux.Handle("DELETE /api/v1/auth/tokens/{id}", sessionOnly(apiTokens.Delete))
+	mux.Handle("GET /api/v1/profile", sessionOnly(profilesHandler.Get))
+	mux.Handle("PUT /api/v1/profile", sessionOnly(profilesHandler.Update))
+	mux.Handle("PUT /api/v1/profile/avatar", sessionOnly(profilesHandler.UploadAvatar))
+	mux.Handle("DELETE /api/v1/profile/avatar", sessionOnly(profilesHandler.DeleteAvatar))
+	mux.Handle("GET /api/v1/users/search", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(friends.Search)))
+	mux.Handle("GET /api/v1/users/{handle}", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(profilesHandler.GetByHandle)))
+	mux.Handle("/api/v1/friends", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(friends.List)))
+	mux.Handle("/api/v1/friends/{view}", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(friends.View)))
+	mux.Handle("/api/v1/friends/invite", scoped(authpkg.ScopeFriendsWrite, requireVerified(http.HandlerFunc(friends.Invite))))
+	mux.Handle("POST /api/v1/invites", scoped(authpkg.ScopeFriendsWrite, requireVerified(http.HandlerFunc(invitesHandler.Create))))
+	mux.Handle("GET /api/v1/invites", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(invitesHandler.List)))
+	mux.Handle("DELETE /api/v1/invites/{id}", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(invitesHandler.Delete)))
+	mux.Handle("/api/v1/friends/respond", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(friends.Respond)))
+	mux.Handle("/api/v1/friends/suggestions", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(friends.Suggestions)))
+	mux.Handle("DELETE /api/v1/friends/suggestions/{id}", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(friends.DismissSuggestion)))
+	mux.Handle("GET /api/v1/friends/mutual/{id}", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(friends.Mutual)))
+	mux.Handle("GET /api/v1/circles", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(circles.List)))
+	mux.Handle("POST /api/v1/circles", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(circles.Create)))
+	mux.Handle("GET /api/v1/circles/{id}", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(circles.Get)))
+	mux.Handle("PATCH /api/v1/circles/{id}", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(circles.Update)))
+	mux.Handle("DELETE /api/v1/circles/{id}", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(circles.Delete)))
+	mux.Handle("GET /api/v1/mutes", scoped(authpkg.ScopeFriendsRead, http.HandlerFunc(feedFilters.ListMutes)))
+	mux.Handle("PUT /api/v1/mutes/{id}", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(feedFilters.Mute)))
+	mux.Handle("DELETE /api/v1/mutes/{id}", scoped(authpkg.ScopeFriendsWrite, http.HandlerFunc(feedFilters.Unmute)))
+	mux.Handle("GET /api/v1/hidden-shares", scoped(authpkg.ScopeFeedRead, http.HandlerFunc(feedFilters.ListHidden)))
+	mux.Handle("PUT /api/v1/hidden-shares/{id}", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(feedFilters.Hide)))
+	mux.Handle("DELETE /api/v1/hidden-shares/{id}", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(feedFilters.Unhide)))
+	mux.Handle("/api/v1/videos", scoped(authpkg.ScopeVideosWrite, requireVerified(http.HandlerFunc(videos.Create))))
+	mux.Handle("GET /api/v1/videos/feed", scoped(authpkg.ScopeFeedRead, http.HandlerFunc(videos.Feed)))
+	mux.Handle("GET /api/v1/videos/{id}", scoped(authpkg.ScopeFeedRead, http.HandlerFunc(videos.Get)))
+	mux.Handle("PATCH /api/v1/videos/{id}", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(videos.Update)))
+	mux.Handle("DELETE /api/v1/videos/{id}", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(videos.Delete)))
+	mux.Handle("POST /api/v1/videos/{id}/reactions", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(reactions.Toggle)))
+	mux.Handle("GET /api/v1/videos/{id}/comments", scoped(authpkg.ScopeFeedRead, http.HandlerFunc(comments.List)))
+	mux.Handle("POST /api/v1/videos/{id}/comments", scoped(authpkg.ScopeVideosWrite, requireVerified(http.HandlerFunc(comments.Create))))
+	mux.Handle("GET /api/v1/videos/{id}/comments/markers", scoped(authpkg.ScopeFeedRead, http.HandlerFunc(comments.Markers)))
+	mux.Handle("GET /api/v1/videos/{id}/comments/{commentId}/replies", scoped(authpkg.ScopeFeedRead, http.HandlerFunc(comments.Replies)))
+	mux.Handle("PATCH /api/v1/videos/{id}/comments/{commentId}", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(comments.Update)))
+	mux.Handle("DELETE /api/v1/videos/{id}/comments/{commentId}", scoped(authpkg.ScopeVideosWrite, http.HandlerFunc(comments.Delete)))
 }
 
 // Dependencies aggregates collaborators required by HTTP handlers.
 type Dependencies struct {
-	Users         UserStore
-	Sessions      SessionManager
+	Users    UserStore
+	Sessions SessionManager
+	Tokens   UserTokenManager
+	Mailer   Mailer
+	// TwoFactor enables TOTP second-factor login when set.
+	TwoFactor TwoFactorService
+	// Passwords hashes new passwords and upgrades outdated hashes at login.
+	Passwords PasswordHasher
+	// APITokens enables personal access tokens when set.
+	APITokens APITokenService
+	// Lockout throttles and locks accounts after repeated failed logins when set.
+	Lockout LoginLockout
+	// OIDCProviders maps provider names used in login URLs to external identity providers.
+	OIDCProviders map[string]OIDCProvider
+	Identities    IdentityStore
+	// States signs the cookies that carry OIDC login state between redirects.
+	States        StateSigner
 	Friends       FriendStore
 	Videos        VideoStore
 	VideoMetadata VideoMetadataProvider
 	VideoAssets   VideoAssetIngestor
+	// Assets removes stored video files when a share or an account is deleted.
+	Assets AssetRemover
+	// Profiles stores user profiles; friend lists and the feed embed their summaries when set.
+	Profiles ProfileStore
+	// Avatars resizes and stores profile pictures.
+	Avatars AvatarService
+	// Invites issues invite links and e-mail invites for people without an account.
+	Invites InviteService
+	// Circles stores the groups of friends videos can be shared with.
+	Circles CircleStore
+	// FeedFilters stores the friends users muted and the shares they hid.
+	FeedFilters FeedFilterStore
+	// Comments stores the comments on video shares.
+	Comments CommentStore
+	// Reactions stores the emoji reactions to video shares; the feed embeds their counts when set.
+	Reactions ReactionStore
+	// AppBaseURL is the public URL of the web app used when building links in e-mails.
+	AppBaseURL string
+	// EmailVerification decides whether unverified users may share videos and send invites.
+	EmailVerification EmailVerificationPolicy
 }
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/auth"
+)
+
+func TestRegisterRoutesRequiresAuthentication(t *testing.T) {
+	manager := newSessionManager()
+	videos := &videoStoreStub{}
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Dependencies{
+		Sessions: manager,
+		Friends:  newInMemoryFriendStore(),
+		Videos:   videos,
+	})
+
+	protected := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/v1/auth/2fa"},
+		{http.MethodPost, "/api/v1/auth/2fa/enroll"},
+		{http.MethodPost, "/api/v1/auth/2fa/confirm"},
+		{http.MethodPost, "/api/v1/auth/2fa/disable"},
+		{http.MethodPost, "/api/v1/auth/2fa/recovery-codes"},
+		{http.MethodGet, "/api/v1/auth/tokens"},
+		{http.MethodPost, "/api/v1/auth/tokens"},
+		{http.MethodPost, "/api/v1/auth/tokens/revoke-all"},
+		{http.MethodDelete, "/api/v1/auth/tokens/00000000-0000-0000-0000-000000000000"},
+		{http.MethodPost, "/api/v1/auth/password"},
+		{http.MethodPost, "/api/v1/auth/email"},
+		{http.MethodDelete, "/api/v1/auth/account"},
+		{http.MethodGet, "/api/v1/profile"},
+		{http.MethodPut, "/api/v1/profile"},
+		{http.MethodPut, "/api/v1/profile/avatar"},
+		{http.MethodDelete, "/api/v1/profile/avatar"},
+		{http.MethodGet, "/api/v1/users/somebody"},
+		{http.MethodGet, "/api/v1/users/search?q=somebody"},
+		{http.MethodGet, "/api/v1/friends"},
+		{http.MethodGet, "/api/v1/friends/accepted"},
+		{http.MethodGet, "/api/v1/friends/incoming"},
+		{http.MethodPost, "/api/v1/friends/invite"},
+		{http.MethodPost, "/api/v1/friends/respond"},
+		{http.MethodGet, "/api/v1/friends/suggestions"},
+		{http.MethodDelete, "/api/v1/friends/suggestions/00000000-0000-0000-0000-000000000000"},
+		{http.MethodGet, "/api/v1/friends/mutual/00000000-0000-0000-0000-000000000000"},
+		{http.MethodGet, "/api/v1/invites"},
+		{http.MethodPost, "/api/v1/invites"},
+		{http.MethodDelete, "/api/v1/invites/00000000-0000-0000-0000-000000000000"},
+		{http.MethodGet, "/api/v1/circles"},
+		{http.MethodPost, "/api/v1/circles"},
+		{http.MethodGet, "/api/v1/circles/00000000-0000-0000-0000-000000000000"},
+		{http.MethodPatch, "/api/v1/circles/00000000-0000-0000-0000-000000000000"},
+		{http.MethodDelete, "/api/v1/circles/00000000-0000-0000-0000-000000000000"},
+		{http.MethodGet, "/api/v1/mutes"},
+		{http.MethodPut, "/api/v1/mutes/00000000-0000-0000-0000-000000000000"},
+		{http.MethodDelete, "/api/v1/mutes/00000000-0000-0000-0000-000000000000"},
+		{http.MethodGet, "/api/v1/hidden-shares"},
+		{http.MethodPut, "/api/v1/hidden-shares/00000000-0000-0000-0000-000000000000"},
+		{http.MethodDelete, "/api/v1/hidden-shares/00000000-0000-0000-0000-000000000000"},
+		{http.MethodGet, "/api/v1/videos/00000000-0000-0000-0000-000000000000"},
+		{http.MethodPatch, "/api/v1/videos/00000000-0000-0000-0000-000000000000"},
+		{http.MethodDelete, "/api/v1/videos/00000000-0000-0000-0000-000000000000"},
+		{http.MethodPost, "/api/v1/videos/00000000-0000-0000-0000-000000000000/reactions"},
+		{http.MethodGet, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments"},
+		{http.MethodPost, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments"},
+		{http.MethodGet, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments/markers"},
+		{http.MethodGet, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments/00000000-0000-0000-0000-000000000000/replies"},
+		{http.MethodPatch, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments/00000000-0000-0000-0000-000000000000"},
+		{http.MethodDelete, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments/00000000-0000-0000-0000-000000000000"},
+		{http.MethodPost, "/api/v1/videos"},
+		{http.MethodGet, "/api/v1/videos/feed"},
+	}
+
+	for _, route := range protected {
+		for _, header := range []string{"", "Bearer forged.token.value", "Bearer vfp_forged"} {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("%s %s with %q: expected 401 got %d", route.method, route.path, header, rec.Code)
+			}
+		}
+	}
+
+	tokens, err := manager.Issue(context.Background(), "user-123", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue tokens: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?user=someone-else", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	if videos.feedUser != "user-123" {
+		t.Fatalf("expected feed for token subject, got %s", videos.feedUser)
+	}
+}
+
+func TestRegisterRoutesAPITokenScopes(t *testing.T) {
+	manager := newSessionManager()
+	apiTokens := auth.NewAPITokens(auth.NewInMemoryAPITokenStore())
+	videos := &videoStoreStub{}
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Dependencies{
+		Sessions:  manager,
+		APITokens: apiTokens,
+		Friends:   newInMemoryFriendStore(),
+		Videos:    videos,
+	})
+
+	_, secret, err := apiTokens.Create(context.Background(), "user-123", "feed reader", []string{auth.ScopeFeedRead}, nil)
+	if err != nil {
+		t.Fatalf("create api token: %v", err)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/videos/feed", http.StatusOK},
+		{http.MethodGet, "/api/v1/friends", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/friends/accepted", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/videos", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/auth/tokens", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/auth/sessions", http.StatusForbidden},
+		{http.MethodDelete, "/api/v1/auth/account", http.StatusForbidden},
+		{http.MethodPut, "/api/v1/profile", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/users/somebody", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/users/search?q=somebody", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/invites", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/invites", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/circles", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/friends/suggestions", http.StatusForbidden},
+		{http.MethodPatch, "/api/v1/circles/00000000-0000-0000-0000-000000000000", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/mutes", http.StatusForbidden},
+		{http.MethodDelete, "/api/v1/videos/00000000-0000-0000-0000-000000000000", http.StatusForbidden},
+		{http.MethodPut, "/api/v1/hidden-shares/00000000-0000-0000-0000-000000000000", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/videos/00000000-0000-0000-0000-000000000000/comments", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+secret)
+		rec := httptest.NewRecorder()
+
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tc.want {
+			t.Fatalf("%s %s: expected %d got %d", tc.method, tc.path, tc.want, rec.Code)
+		}
+	}
+
+	if videos.feedUser != "user-123" {
+		t.Fatalf("expected feed for token owner, got %s", videos.feedUser)
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// Logout handles POST /api/v1/auth/logout requests by ending the caller's current session.
+func (h AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.Logout")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Sessions == nil {
+		logger.Error("session manager unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "session service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	sessionID := logging.SessionIDFromContext(ctx)
+	if err := h.Sessions.RevokeSession(ctx, userID, sessionID); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+		logger.Error("logout failed", "error", err, "userId", userID, "sessionId", sessionID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to end session"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll handles POST /api/v1/auth/logout-all requests by ending every session of the caller.
+func (h AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.LogoutAll")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Sessions == nil {
+		logger.Error("session manager unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "session service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	if err := h.Sessions.RevokeAll(ctx, userID); err != nil {
+		logger.Error("logout everywhere failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to end sessions"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSessions handles GET /api/v1/auth/sessions requests.
+func (h AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.ListSessions")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Sessions == nil {
+		logger.Error("session manager unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "session service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.Sessions.Sessions(ctx, userID)
+	if err != nil {
+		logger.Error("list sessions failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load sessions"})
+		return
+	}
+
+	currentID := logging.SessionIDFromContext(ctx)
+	active := make([]models.ActiveSession, 0, len(sessions))
+	for _, session := range sessions {
+		active = append(active, models.ActiveSession{
+			ID:              session.ID,
+			CreatedAt:       session.CreatedAt,
+			LastRefreshedAt: session.LastRefreshedAt,
+			ExpiresAt:       session.ExpiresAt,
+			UserAgent:       session.UserAgent,
+			ClientIP:        session.ClientIP,
+			Current:         session.ID == currentID,
+		})
+	}
+
+	respondJSON(ctx, w, http.StatusOK, listSessionsResponse{Sessions: active})
+}
+
+// DeleteSession handles DELETE /api/v1/auth/sessions/{id} requests.
+func (h AuthHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.DeleteSession")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Sessions == nil {
+		logger.Error("session manager unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "session service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	sessionID := strings.TrimSpace(r.PathValue("id"))
+	if sessionID == "" {
+		logger.Warn("delete session missing id")
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "session id is required"})
+		return
+	}
+
+	if err := h.Sessions.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "session not found"})
+			return
+		}
+		logger.Error("delete session failed", "error", err, "userId", userID, "sessionId", sessionID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to end session"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type listSessionsResponse struct {
+	Sessions []models.ActiveSession `json:"sessions"`
+}
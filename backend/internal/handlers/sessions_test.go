@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+func newSessionsMux(manager *auth.Manager) *http.ServeMux {
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Dependencies{Sessions: manager})
+	return mux
+}
+
+func authorizedRequest(method, path, accessToken string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return req
+}
+
+func TestAuthHandlerListSessions(t *testing.T) {
+	manager := newSessionManager()
+	mux := newSessionsMux(manager)
+
+	laptop, err := manager.Issue(context.Background(), "user-1", auth.ClientInfo{UserAgent: "Firefox", IPAddress: "203.0.113.10"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if _, err := manager.Issue(context.Background(), "user-1", auth.ClientInfo{UserAgent: "VidFriends iOS", IPAddress: "198.51.100.7"}); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if _, err := manager.Issue(context.Background(), "user-2", auth.ClientInfo{}); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, authorizedRequest(http.MethodGet, "/api/v1/auth/sessions", laptop.AccessToken))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+
+	var resp struct {
+		Sessions []models.ActiveSession `json:"sessions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("expected only the caller's sessions, got %+v", resp.Sessions)
+	}
+
+	current := 0
+	for _, session := range resp.Sessions {
+		if session.CreatedAt.IsZero() || session.ClientIP == "" || session.UserAgent == "" {
+			t.Fatalf("expected session details, got %+v", session)
+		}
+		if session.Current {
+			current++
+			if session.UserAgent != "Firefox" {
+				t.Fatalf("expected the laptop session to be current, got %+v", session)
+			}
+		}
+	}
+	if current != 1 {
+		t.Fatalf("expected exactly one current session, got %d", current)
+	}
+}
+
+func TestAuthHandlerDeleteSession(t *testing.T) {
+	manager := newSessionManager()
+	mux := newSessionsMux(manager)
+
+	laptop, err := manager.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	phone, err := manager.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	stranger, err := manager.Issue(context.Background(), "user-2", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	phoneClaims, err := manager.ParseAccessToken(phone.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, authorizedRequest(http.MethodDelete, "/api/v1/auth/sessions/"+phoneClaims.SessionID, stranger.AccessToken))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting another user's session, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, authorizedRequest(http.MethodDelete, "/api/v1/auth/sessions/"+phoneClaims.SessionID, laptop.AccessToken))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+
+	if _, err := manager.Refresh(context.Background(), phone.RefreshToken, auth.ClientInfo{}); err != auth.ErrSessionNotFound {
+		t.Fatalf("expected deleted session to be unusable, got %v", err)
+	}
+	if _, err := manager.Refresh(context.Background(), laptop.RefreshToken, auth.ClientInfo{}); err != nil {
+		t.Fatalf("expected other session to survive: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, authorizedRequest(http.MethodGet, "/api/v1/auth/sessions/"+phoneClaims.SessionID, laptop.AccessToken))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 got %d", rec.Code)
+	}
+}
+
+func TestAuthHandlerLogout(t *testing.T) {
+	manager := newSessionManager()
+	mux := newSessionsMux(manager)
+
+	current, err := manager.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	other, err := manager.Issue(context.Background(), "user-1", auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, authorizedRequest(http.MethodPost, "/api/v1/auth/logout", current.AccessToken))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+
+	if _, err := manager.Refresh(context.Background(), current.RefreshToken, auth.ClientInfo{}); err != auth.ErrSessionNotFound {
+		t.Fatalf("expected logged out session to be revoked, got %v", err)
+	}
+
+	otherTokens, err := manager.Refresh(context.Background(), other.RefreshToken, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("expected other session to survive logout: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, authorizedRequest(http.MethodPost, "/api/v1/auth/logout-all", otherTokens.AccessToken))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+
+	if sessions, _ := manager.Sessions(context.Background(), "user-1"); len(sessions) != 0 {
+		t.Fatalf("expected every session to be revoked, got %d", len(sessions))
+	}
+}
+
+func TestAuthHandlerLoginRecordsClient(t *testing.T) {
+	store := newInMemoryUserStore()
+	manager := newSessionManager()
+	handler := AuthHandler{Users: store, Sessions: manager}
+
+	body, err := json.Marshal(signUpRequest{Email: "device@example.com", Password: "supersafe"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	signUp := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", bytes.NewReader(body))
+	signUp.Header.Set("User-Agent", "VidFriends Android")
+	signUp.Header.Set("X-Forwarded-For", "192.0.2.44, 10.0.0.1")
+	rec := httptest.NewRecorder()
+	handler.SignUp(rec, signUp)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d", rec.Code)
+	}
+
+	user, err := store.FindByEmail(context.Background(), "device@example.com")
+	if err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+
+	sessions, err := manager.Sessions(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].UserAgent != "VidFriends Android" || sessions[0].ClientIP != "192.0.2.44" {
+		t.Fatalf("expected client details to be recorded, got %+v", sessions)
+	}
+}
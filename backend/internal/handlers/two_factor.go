@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/logging"
+)
+
+// maxTwoFactorCodeLength bounds submitted codes; recovery codes with separators are the longest.
+const maxTwoFactorCodeLength = 32
+
+// CompleteLogin handles POST /api/v1/auth/login/2fa requests, exchanging the challenge returned by
+// Login and a TOTP or recovery code for session tokens.
+func (h AuthHandler) CompleteLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.CompleteLogin")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:login-2fa") {
+		logger.Warn("rate limit exceeded", "scope", "auth:login-2fa")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many login attempts"})
+		return
+	}
+
+	if h.Sessions == nil || h.TwoFactor == nil {
+		logger.Error("two-factor login dependencies unavailable", "hasSessions", h.Sessions != nil, "hasTwoFactor", h.TwoFactor != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+		return
+	}
+
+	var req completeLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid two-factor login payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	req.Challenge = strings.TrimSpace(req.Challenge)
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Challenge == "" || req.Code == "" || len(req.Code) > maxTwoFactorCodeLength {
+		logger.Warn("two-factor login missing challenge or code")
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "challenge and code are required"})
+		return
+	}
+
+	userID, err := h.TwoFactor.CompleteChallenge(ctx, req.Challenge, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidChallenge):
+			logger.Warn("two-factor login challenge rejected")
+			respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "login challenge expired; sign in again"})
+		case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+			logger.Warn("two-factor login code rejected")
+			respondJSON(ctx, w, http.StatusUnauthorized, map[string]string{"error": "invalid two-factor code"})
+		default:
+			logger.Error("two-factor login failed", "error", err)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to verify two-factor code"})
+		}
+		return
+	}
+
+	tokens, err := h.Sessions.Issue(ctx, userID, clientInfo(r))
+	if err != nil {
+		logger.Error("failed to issue session", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, authResponse{Tokens: tokens})
+}
+
+// completeSignIn finishes a first-factor sign-in. Users with two-factor authentication enabled
+// receive a login challenge to exchange at /api/v1/auth/login/2fa; everyone else gets session
+// tokens straight away.
+func completeSignIn(w http.ResponseWriter, r *http.Request, sessions SessionManager, twoFactor TwoFactorService, userID string) {
+	ctx := r.Context()
+	logger := logging.FromContext(ctx)
+
+	if twoFactor != nil {
+		enabled, err := twoFactor.Enabled(ctx, userID)
+		if err != nil {
+			logger.Error("two-factor status lookup failed", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
+			return
+		}
+		if enabled {
+			challenge, err := twoFactor.IssueChallenge(userID)
+			if err != nil {
+				logger.Error("failed to issue login challenge", "error", err, "userId", userID)
+				respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
+				return
+			}
+			respondJSON(ctx, w, http.StatusOK, loginChallengeResponse{
+				TwoFactorRequired: true,
+				Challenge:         loginChallenge{Token: challenge.Token, ExpiresAt: challenge.ExpiresAt},
+			})
+			return
+		}
+	}
+
+	tokens, err := sessions.Issue(ctx, userID, clientInfo(r))
+	if err != nil {
+		logger.Error("failed to issue session", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, authResponse{Tokens: tokens})
+}
+
+// TwoFactorStatus handles GET /api/v1/auth/2fa requests.
+func (h AuthHandler) TwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.TwoFactorStatus")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.TwoFactor == nil {
+		logger.Error("two-factor service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "two-factor service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	enabled, err := h.TwoFactor.Enabled(ctx, userID)
+	if err != nil {
+		logger.Error("two-factor status lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to load two-factor status"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, twoFactorStatusResponse{Enabled: enabled})
+}
+
+// BeginTwoFactorEnrollment handles POST /api/v1/auth/2fa/enroll requests. The returned secret is
+// not enforced until it is confirmed with a first code.
+func (h AuthHandler) BeginTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.BeginTwoFactorEnrollment")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Users == nil || h.TwoFactor == nil {
+		logger.Error("two-factor dependencies unavailable", "hasUsers", h.Users != nil, "hasTwoFactor", h.TwoFactor != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "two-factor service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error("two-factor enrollment user lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to start two-factor enrollment"})
+		return
+	}
+
+	enrollment, err := h.TwoFactor.BeginEnrollment(ctx, user.ID, user.Email)
+	if err != nil {
+		if errors.Is(err, auth.ErrTwoFactorAlreadyEnabled) {
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "two-factor authentication is already enabled"})
+			return
+		}
+		logger.Error("two-factor enrollment failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to start two-factor enrollment"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, twoFactorEnrollmentResponse{Secret: enrollment.Secret, URI: enrollment.URI})
+}
+
+// ConfirmTwoFactorEnrollment handles POST /api/v1/auth/2fa/confirm requests, enabling 2FA and
+// returning the recovery codes.
+func (h AuthHandler) ConfirmTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.ConfirmTwoFactorEnrollment")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	userID, code, ok := h.twoFactorCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.TwoFactor.ConfirmEnrollment(ctx, userID, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrTwoFactorNotEnrolled):
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "start two-factor enrollment first"})
+		case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+			respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "two-factor authentication is already enabled"})
+		case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+			logger.Warn("two-factor enrollment code rejected", "userId", userID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid two-factor code"})
+		default:
+			logger.Error("two-factor confirmation failed", "error", err, "userId", userID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to enable two-factor authentication"})
+		}
+		return
+	}
+
+	logger.Info("two-factor authentication enabled", "event", "security.two_factor_enabled", "userId", userID)
+	respondJSON(ctx, w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor handles POST /api/v1/auth/2fa/disable requests.
+func (h AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.DisableTwoFactor")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	userID, code, ok := h.twoFactorCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.TwoFactor.Disable(ctx, userID, code); err != nil {
+		if errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+			logger.Warn("two-factor disable code rejected", "userId", userID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid two-factor code"})
+			return
+		}
+		logger.Error("two-factor disable failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to disable two-factor authentication"})
+		return
+	}
+
+	logger.Info("two-factor authentication disabled", "event", "security.two_factor_disabled", "userId", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/auth/2fa/recovery-codes requests. Earlier recovery
+// codes stop working.
+func (h AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.RegenerateRecoveryCodes")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	userID, code, ok := h.twoFactorCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.TwoFactor.RegenerateRecoveryCodes(ctx, userID, code)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+			logger.Warn("recovery code regeneration code rejected", "userId", userID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid two-factor code"})
+			return
+		}
+		logger.Error("recovery code regeneration failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to regenerate recovery codes"})
+		return
+	}
+
+	logger.Info("recovery codes regenerated", "event", "security.recovery_codes_regenerated", "userId", userID)
+	respondJSON(ctx, w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// twoFactorCodeRequest performs the checks shared by the endpoints that take a code from a
+// signed-in user. It writes the error response and returns false when the request cannot proceed.
+func (h AuthHandler) twoFactorCodeRequest(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	ctx := r.Context()
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return "", "", false
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:2fa") {
+		logger.Warn("rate limit exceeded", "scope", "auth:2fa")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many two-factor attempts"})
+		return "", "", false
+	}
+
+	if h.TwoFactor == nil {
+		logger.Error("two-factor service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "two-factor service unavailable"})
+		return "", "", false
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return "", "", false
+	}
+
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid two-factor payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return "", "", false
+	}
+
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Code == "" || len(req.Code) > maxTwoFactorCodeLength {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "code is required"})
+		return "", "", false
+	}
+
+	return userID, req.Code, true
+}
+
+type completeLoginRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type loginChallengeResponse struct {
+	TwoFactorRequired bool           `json:"twoFactorRequired"`
+	Challenge         loginChallenge `json:"challenge"`
+}
+
+type loginChallenge struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type twoFactorStatusResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+type twoFactorEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
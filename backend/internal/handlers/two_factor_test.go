@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+func newTwoFactorHandler(t *testing.T, now *time.Time) AuthHandler {
+	t.Helper()
+	keys, err := auth.NewKeyring(auth.Key{ID: "test", Secret: []byte("handler-test-secret-handler-test")})
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+	twoFactor := auth.NewTwoFactor(auth.NewInMemoryTwoFactorStore(), keys, "VidFriends")
+	twoFactor.NowFunc = func() time.Time { return *now }
+
+	store := newInMemoryUserStore()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	store.users["totp@example.com"] = models.User{ID: "user-1", Email: "totp@example.com", Password: string(hashed)}
+
+	return AuthHandler{Users: store, Sessions: newSessionManager(), TwoFactor: twoFactor}
+}
+
+func postJSON(t *testing.T, handler http.HandlerFunc, path string, payload any, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if userID != "" {
+		req = withUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func enableTwoFactor(t *testing.T, handler AuthHandler, now time.Time) (string, []string) {
+	t.Helper()
+	rec := postJSON(t, handler.BeginTwoFactorEnrollment, "/api/v1/auth/2fa/enroll", struct{}{}, "user-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected enrollment status %d got %d", http.StatusOK, rec.Code)
+	}
+	var enrollment twoFactorEnrollmentResponse
+	if err := json.NewDecoder(rec.Body).Decode(&enrollment); err != nil {
+		t.Fatalf("decode enrollment: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.URI, "otpauth://totp/VidFriends:totp@example.com?") {
+		t.Fatalf("unexpected otpauth uri %q", enrollment.URI)
+	}
+
+	code, err := auth.TOTPCode(enrollment.Secret, now)
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	rec = postJSON(t, handler.ConfirmTwoFactorEnrollment, "/api/v1/auth/2fa/confirm", twoFactorCodeRequest{Code: code}, "user-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected confirm status %d got %d", http.StatusOK, rec.Code)
+	}
+	var codes recoveryCodesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&codes); err != nil {
+		t.Fatalf("decode recovery codes: %v", err)
+	}
+	if len(codes.RecoveryCodes) == 0 {
+		t.Fatal("expected recovery codes to be returned")
+	}
+	return enrollment.Secret, codes.RecoveryCodes
+}
+
+func loginChallengeFor(t *testing.T, handler AuthHandler) loginChallengeResponse {
+	t.Helper()
+	rec := postJSON(t, handler.Login, "/api/v1/auth/login", loginRequest{Email: "totp@example.com", Password: "password123"}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected login status %d got %d", http.StatusOK, rec.Code)
+	}
+	var resp loginChallengeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode login: %v", err)
+	}
+	if !resp.TwoFactorRequired || resp.Challenge.Token == "" {
+		t.Fatalf("expected a login challenge, got %+v", resp)
+	}
+	return resp
+}
+
+func TestAuthHandlerTwoFactorLogin(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	handler := newTwoFactorHandler(t, &now)
+	secret, _ := enableTwoFactor(t, handler, now)
+
+	challenge := loginChallengeFor(t, handler)
+	if !challenge.Challenge.ExpiresAt.After(now) {
+		t.Fatalf("expected challenge expiry after now, got %v", challenge.Challenge.ExpiresAt)
+	}
+
+	rec := postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: challenge.Challenge.Token, Code: "000000"}, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected wrong code status %d got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	now = now.Add(30 * time.Second)
+	code, _ := auth.TOTPCode(secret, now)
+	rec = postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: challenge.Challenge.Token, Code: code}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d got %d", http.StatusOK, rec.Code)
+	}
+	var resp authResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Tokens.AccessToken == "" || resp.Tokens.RefreshToken == "" {
+		t.Fatalf("expected tokens to be issued, got %+v", resp.Tokens)
+	}
+
+	rec = postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: challenge.Challenge.Token, Code: code}, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected replayed code status %d got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	now = now.Add(10 * time.Minute)
+	code, _ = auth.TOTPCode(secret, now)
+	rec = postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: challenge.Challenge.Token, Code: code}, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected expired challenge status %d got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestAuthHandlerTwoFactorRecoveryCodes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	handler := newTwoFactorHandler(t, &now)
+	secret, recoveryCodes := enableTwoFactor(t, handler, now)
+
+	challenge := loginChallengeFor(t, handler)
+	rec := postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: challenge.Challenge.Token, Code: recoveryCodes[0]}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected recovery code login status %d got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = postJSON(t, handler.RegenerateRecoveryCodes, "/api/v1/auth/2fa/recovery-codes", twoFactorCodeRequest{Code: recoveryCodes[0]}, "user-1")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected used recovery code status %d got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec = postJSON(t, handler.RegenerateRecoveryCodes, "/api/v1/auth/2fa/recovery-codes", twoFactorCodeRequest{Code: recoveryCodes[1]}, "user-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected regenerate status %d got %d", http.StatusOK, rec.Code)
+	}
+
+	now = now.Add(30 * time.Second)
+	code, _ := auth.TOTPCode(secret, now)
+	rec = postJSON(t, handler.DisableTwoFactor, "/api/v1/auth/2fa/disable", twoFactorCodeRequest{Code: code}, "user-1")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected disable status %d got %d", http.StatusNoContent, rec.Code)
+	}
+
+	rec = postJSON(t, handler.Login, "/api/v1/auth/login", loginRequest{Email: "totp@example.com", Password: "password123"}, "")
+	var resp authResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rec.Code != http.StatusOK || resp.Tokens.AccessToken == "" {
+		t.Fatalf("expected tokens once 2FA is disabled, got status %d", rec.Code)
+	}
+}
+
+func TestAuthHandlerTwoFactorFailures(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("confirm before enroll", func(t *testing.T) {
+		handler := newTwoFactorHandler(t, &now)
+		rec := postJSON(t, handler.ConfirmTwoFactorEnrollment, "/api/v1/auth/2fa/confirm", twoFactorCodeRequest{Code: "123456"}, "user-1")
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status %d got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("enroll twice", func(t *testing.T) {
+		handler := newTwoFactorHandler(t, &now)
+		enableTwoFactor(t, handler, now)
+		rec := postJSON(t, handler.BeginTwoFactorEnrollment, "/api/v1/auth/2fa/enroll", struct{}{}, "user-1")
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status %d got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		handler := newTwoFactorHandler(t, &now)
+		rec := postJSON(t, handler.DisableTwoFactor, "/api/v1/auth/2fa/disable", twoFactorCodeRequest{Code: "123456"}, "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("tampered challenge", func(t *testing.T) {
+		handler := newTwoFactorHandler(t, &now)
+		rec := postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: "not-a-token", Code: "123456"}, "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		handler := newTwoFactorHandler(t, &now)
+		handler.RateLimiter = stubRateLimiter{allow: false}
+		rec := postJSON(t, handler.CompleteLogin, "/api/v1/auth/login/2fa", completeLoginRequest{Challenge: "token", Code: "123456"}, "")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %d got %d", http.StatusTooManyRequests, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/email"
+	"github.com/vidfriends/backend/internal/logging"
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/repositories"
+)
+
+// emailVerificationTTL bounds how long an e-mail verification link stays usable.
+const emailVerificationTTL = 48 * time.Hour
+
+// EmailVerificationPolicy decides what unverified users may do.
+type EmailVerificationPolicy string
+
+const (
+	// EmailVerificationFlag lets unverified users act but marks their responses and logs the action.
+	EmailVerificationFlag EmailVerificationPolicy = "flag"
+	// EmailVerificationRestrict blocks unverified users from sharing videos and sending invites.
+	EmailVerificationRestrict EmailVerificationPolicy = "restrict"
+)
+
+// unverifiedEmailHeader is set on responses to unverified users under the flag policy.
+const unverifiedEmailHeader = "X-VidFriends-Email-Unverified"
+
+// VerifyEmail handles POST /api/v1/auth/verify-email requests.
+func (h AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.VerifyEmail")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !allowRequest(h.RateLimiter, r, "auth:verify-email") {
+		logger.Warn("rate limit exceeded", "scope", "auth:verify-email")
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "too many verification attempts"})
+		return
+	}
+
+	if h.Users == nil || h.Tokens == nil {
+		logger.Error("email verification dependencies unavailable", "hasUsers", h.Users != nil, "hasTokens", h.Tokens != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+		return
+	}
+
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid verify email payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" || len(req.Token) > 512 {
+		logger.Warn("verify email token missing or too long", "length", len(req.Token))
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired verification token"})
+		return
+	}
+
+	userID, err := h.Tokens.Consume(ctx, req.Token, auth.PurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserTokenInvalid) {
+			logger.Warn("email verification token rejected")
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired verification token"})
+			return
+		}
+		logger.Error("email verification token lookup failed", "error", err)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to verify email"})
+		return
+	}
+
+	user, err := h.Users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			logger.Warn("email verification user missing", "userId", userID)
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid or expired verification token"})
+			return
+		}
+		logger.Error("email verification user lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to verify email"})
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := h.now()
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		if err := h.Users.Update(ctx, user); err != nil {
+			logger.Error("email verification failed to update user", "error", err, "userId", user.ID)
+			respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to verify email"})
+			return
+		}
+	}
+
+	logger.Info("email verified", "userId", user.ID)
+	respondJSON(ctx, w, http.StatusOK, map[string]string{"status": "Your email address has been verified."})
+}
+
+// ResendVerification handles POST /api/v1/auth/verify-email/resend requests from signed-in users.
+func (h AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "AuthHandler.ResendVerification")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPost {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Users == nil || h.Tokens == nil || h.Mailer == nil {
+		logger.Error("email verification dependencies unavailable", "hasUsers", h.Users != nil, "hasTokens", h.Tokens != nil, "hasMailer", h.Mailer != nil)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	if h.ResendLimiter != nil && !h.ResendLimiter.Allow("auth:verify-email-resend:"+userID) {
+		logger.Warn("rate limit exceeded", "scope", "auth:verify-email-resend", "userId", userID)
+		respondJSON(ctx, w, http.StatusTooManyRequests, map[string]string{"error": "verification email was sent recently; try again later"})
+		return
+	}
+
+	user, err := h.Users.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error("resend verification user lookup failed", "error", err, "userId", userID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to send verification email"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		respondJSON(ctx, w, http.StatusConflict, map[string]string{"error": "email already verified"})
+		return
+	}
+
+	if err := h.sendVerification(ctx, user); err != nil {
+		logger.Error("resend verification failed", "error", err, "userId", user.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to send verification email"})
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusAccepted, map[string]string{"status": "A new verification email has been sent."})
+}
+
+// sendVerification e-mails a verification link to the user.
+func (h AuthHandler) sendVerification(ctx context.Context, user models.User) error {
+	token, err := h.Tokens.Issue(ctx, user.ID, auth.PurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return fmt.Errorf("issue verification token: %w", err)
+	}
+
+	link := strings.TrimRight(h.AppBaseURL, "/") + "/verify-email?token=" + url.QueryEscape(token)
+	msg := email.Message{
+		To:      user.Email,
+		Subject: "Confirm your VidFriends email address",
+		Body: fmt.Sprintf("Welcome to VidFriends!\n\n"+
+			"Confirm this is your email address by opening the link below within %d hours:\n\n%s\n\n"+
+			"If you did not create an account, you can ignore this e-mail.\n", int(emailVerificationTTL.Hours()), link),
+	}
+
+	if err := h.Mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send verification email: %w", err)
+	}
+	return nil
+}
+
+// requireVerifiedEmail guards actions that reach other users. Under EmailVerificationRestrict,
+// unverified callers receive 403; under EmailVerificationFlag the request proceeds with the
+// X-VidFriends-Email-Unverified response header set. It must run after middleware.RequireAuth.
+func requireVerifiedEmail(users UserStore, policy EmailVerificationPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			logger := logging.FromContext(ctx)
+
+			if users == nil {
+				logger.Error("user store unavailable for email verification check")
+				respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "authentication services unavailable"})
+				return
+			}
+
+			userID, ok := authenticatedUser(ctx, w)
+			if !ok {
+				return
+			}
+
+			user, err := users.FindByID(ctx, userID)
+			if err != nil {
+				logger.Error("email verification check failed", "error", err, "userId", userID)
+				respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "unable to verify account status"})
+				return
+			}
+
+			if user.EmailVerifiedAt == nil {
+				if policy == EmailVerificationRestrict {
+					logger.Warn("unverified user blocked", "event", "security.unverified_email_blocked", "userId", userID, "path", r.URL.Path)
+					respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "email verification required"})
+					return
+				}
+				logger.Info("unverified user action", "event", "security.unverified_email_action", "userId", userID, "path", r.URL.Path)
+				w.Header().Set(unverifiedEmailHeader, "true")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
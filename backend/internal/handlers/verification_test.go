@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+func tokenFromLink(t *testing.T, body string) string {
+	t.Helper()
+	for _, field := range strings.Fields(body) {
+		if strings.HasPrefix(field, "http") {
+			link, err := url.Parse(field)
+			if err != nil {
+				t.Fatalf("parse link: %v", err)
+			}
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no link in %q", body)
+	return ""
+}
+
+func TestAuthHandlerSignUpSendsVerification(t *testing.T) {
+	store := newInMemoryUserStore()
+	mailer := &recordingMailer{}
+	handler := AuthHandler{
+		Users:      store,
+		Sessions:   newSessionManager(),
+		Tokens:     auth.NewUserTokens(auth.NewInMemoryUserTokenStore()),
+		Mailer:     mailer,
+		AppBaseURL: "https://vidfriends.example",
+	}
+
+	body, err := json.Marshal(signUpRequest{Email: "new@example.com", Password: "supersafe"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.SignUp(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", bytes.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d", rec.Code)
+	}
+
+	if len(mailer.messages) != 1 || mailer.messages[0].To != "new@example.com" {
+		t.Fatalf("expected a verification email, got %+v", mailer.messages)
+	}
+	if !strings.Contains(mailer.messages[0].Body, "https://vidfriends.example/verify-email?token=") {
+		t.Fatalf("unexpected verification email %q", mailer.messages[0].Body)
+	}
+
+	if user := store.users["new@example.com"]; user.EmailVerifiedAt != nil {
+		t.Fatal("expected new account to start unverified")
+	}
+
+	verify := func(token string) int {
+		payload, _ := json.Marshal(verifyEmailRequest{Token: token})
+		rec := httptest.NewRecorder()
+		handler.VerifyEmail(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email", bytes.NewReader(payload)))
+		return rec.Code
+	}
+
+	token := tokenFromLink(t, mailer.messages[0].Body)
+	if code := verify(token); code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", code)
+	}
+	if user := store.users["new@example.com"]; user.EmailVerifiedAt == nil {
+		t.Fatal("expected account to be verified")
+	}
+	if code := verify(token); code != http.StatusBadRequest {
+		t.Fatalf("expected used token to be rejected, got %d", code)
+	}
+}
+
+func TestAuthHandlerResendVerification(t *testing.T) {
+	store := newInMemoryUserStore()
+	store.users["user@example.com"] = models.User{ID: "user-1", Email: "user@example.com"}
+	mailer := &recordingMailer{}
+	limiter := &countingRateLimiter{limit: 1}
+	handler := AuthHandler{
+		Users:         store,
+		Tokens:        auth.NewUserTokens(auth.NewInMemoryUserTokenStore()),
+		Mailer:        mailer,
+		ResendLimiter: limiter,
+	}
+
+	resend := func(userID string) int {
+		req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email/resend", nil), userID)
+		rec := httptest.NewRecorder()
+		handler.ResendVerification(rec, req)
+		return rec.Code
+	}
+
+	if code := resend("user-1"); code != http.StatusAccepted {
+		t.Fatalf("expected 202 got %d", code)
+	}
+	if len(mailer.messages) != 1 {
+		t.Fatalf("expected one email, got %d", len(mailer.messages))
+	}
+	if code := resend("user-1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected resend to be throttled, got %d", code)
+	}
+	if limiter.keys[0] != "auth:verify-email-resend:user-1" {
+		t.Fatalf("expected throttling per user, got key %q", limiter.keys[0])
+	}
+
+	verifiedAt := time.Now()
+	store.users["verified@example.com"] = models.User{ID: "user-2", Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+	if code := resend("user-2"); code != http.StatusConflict {
+		t.Fatalf("expected 409 for verified account, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ResendVerification(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email/resend", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a user, got %d", rec.Code)
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	store := newInMemoryUserStore()
+	verifiedAt := time.Now()
+	store.users["verified@example.com"] = models.User{ID: "verified", Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+	store.users["pending@example.com"] = models.User{ID: "pending", Email: "pending@example.com"}
+
+	cases := []struct {
+		name       string
+		policy     EmailVerificationPolicy
+		userID     string
+		wantStatus int
+		wantFlag   bool
+	}{
+		{"restrictVerified", EmailVerificationRestrict, "verified", http.StatusNoContent, false},
+		{"restrictUnverified", EmailVerificationRestrict, "pending", http.StatusForbidden, false},
+		{"flagVerified", EmailVerificationFlag, "verified", http.StatusNoContent, false},
+		{"flagUnverified", EmailVerificationFlag, "pending", http.StatusNoContent, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := requireVerifiedEmail(store, tc.policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", nil), tc.userID)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d", tc.wantStatus, rec.Code)
+			}
+			if flagged := rec.Header().Get(unverifiedEmailHeader) == "true"; flagged != tc.wantFlag {
+				t.Fatalf("expected flag header %v got %v", tc.wantFlag, flagged)
+			}
+		})
+	}
+}
+
+type countingRateLimiter struct {
+	limit int
+	keys  []string
+}
+
+func (l *countingRateLimiter) Allow(key string) bool {
+	l.keys = append(l.keys, key)
+	count := 0
+	for _, seen := range l.keys {
+		if seen == key {
+			count++
+		}
+	}
+	return count <= l.limit
+}
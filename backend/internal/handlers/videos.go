@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 
@@ -16,12 +21,25 @@ import (
 	"github.com/vidfriends/backend/internal/videos"
 )
 
+const (
+	// maxShareUsers caps how many friends a video can be shared with individually.
+	maxShareUsers = 100
+	// maxShareNoteLength caps the owner's note on a share, in characters.
+	maxShareNoteLength = 500
+)
+
 // VideoHandler provides endpoints for sharing and fetching videos.
 type VideoHandler struct {
 	Videos   VideoStore
 	Metadata VideoMetadataProvider
 	Assets   VideoAssetIngestor
-	NowFunc  func() time.Time
+	// Storage removes the files of deleted shares. When nil, they are left in place.
+	Storage AssetRemover
+	// Profiles is optional; when nil, feed entries identify their owner by ID only.
+	Profiles ProfileSummaries
+	// Reactions is optional; when nil, feed entries carry no reactions.
+	Reactions ReactionStore
+	NowFunc   func() time.Time
 }
 
 // Create handles POST /api/v1/videos.
@@ -43,6 +61,11 @@ func (h VideoHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ownerID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
 	var req createVideoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Warn("invalid create video payload", "error", err)
@@ -50,11 +73,10 @@ func (h VideoHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req.OwnerID = strings.TrimSpace(req.OwnerID)
 	req.URL = strings.TrimSpace(req.URL)
-	if req.OwnerID == "" || req.URL == "" {
-		logger.Warn("missing create video fields", "ownerId", req.OwnerID, "url", req.URL)
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "ownerId and url are required"})
+	if req.URL == "" {
+		logger.Warn("missing create video url", "ownerId", ownerID)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "url is required"})
 		return
 	}
 
@@ -64,6 +86,18 @@ func (h VideoHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	visibility, circleIDs, userIDs, err := shareAudience(ownerID, req)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	note, err := shareNote(req.Note)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
 	metadata, err := h.Metadata.Lookup(ctx, req.URL)
 	if err != nil {
 		status := http.StatusBadGateway
@@ -78,16 +112,24 @@ func (h VideoHandler) Create(w http.ResponseWriter, r *http.Request) {
 	now := h.now()
 	share := models.VideoShare{
 		ID:          uuid.NewString(),
-		OwnerID:     req.OwnerID,
+		OwnerID:     ownerID,
 		URL:         req.URL,
 		Title:       metadata.Title,
 		Description: metadata.Description,
 		Thumbnail:   metadata.Thumbnail,
+		Note:        note,
+		DurationMs:  metadata.Duration.Milliseconds(),
 		CreatedAt:   now,
 		AssetStatus: models.AssetStatusPending,
+		Visibility:  visibility,
+		CircleIDs:   circleIDs,
+		UserIDs:     userIDs,
 	}
 
 	if err := h.Videos.Create(ctx, share); err != nil {
+		if respondAudienceError(ctx, w, err) {
+			return
+		}
 		status := http.StatusInternalServerError
 		if errors.Is(err, repositories.ErrConflict) {
 			status = http.StatusConflict
@@ -106,7 +148,8 @@ func (h VideoHandler) Create(w http.ResponseWriter, r *http.Request) {
 	respondJSON(ctx, w, http.StatusCreated, createVideoResponse{Share: share})
 }
 
-// Feed handles GET /api/v1/videos/feed.
+// Feed handles GET /api/v1/videos/feed. The limit and cursor query parameters page through the
+// feed, newest first; ownerId, assetStatus, domain, since and before narrow it.
 func (h VideoHandler) Feed(w http.ResponseWriter, r *http.Request) {
 	ctx, span := logging.StartSpan(r.Context(), "VideoHandler.Feed")
 	defer span.End()
@@ -125,21 +168,288 @@ func (h VideoHandler) Feed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := strings.TrimSpace(r.URL.Query().Get("user"))
-	if userID == "" {
-		logger.Warn("feed missing user id")
-		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "user query parameter is required"})
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	limit, err := pageSize(r)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	filter, err := feedFilter(r)
+	if err != nil {
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
-	feed, err := h.Videos.ListFeed(ctx, userID)
+	var after *models.FeedCursor
+	if cursor := strings.TrimSpace(r.URL.Query().Get("cursor")); cursor != "" {
+		createdAt, shareID, err := decodeCursor(cursor)
+		if err == nil {
+			_, err = uuid.Parse(shareID)
+		}
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": errInvalidCursor.Error()})
+			return
+		}
+		after = &models.FeedCursor{CreatedAt: createdAt, ShareID: shareID}
+	}
+
+	// Ask for one extra share to learn whether another page follows.
+	feed, err := h.Videos.ListFeed(ctx, userID, filter, after, limit+1)
 	if err != nil {
 		logger.Error("failed to load video feed", "error", err, "userId", userID)
 		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch video feed"})
 		return
 	}
 
-	respondJSON(ctx, w, http.StatusOK, feedResponse{Entries: feed})
+	var resp feedResponse
+	if len(feed) > limit {
+		feed = feed[:limit]
+		last := feed[len(feed)-1]
+		resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	ownerIDs := make([]string, 0, len(feed))
+	shareIDs := make([]string, 0, len(feed))
+	for _, share := range feed {
+		ownerIDs = append(ownerIDs, share.OwnerID)
+		shareIDs = append(shareIDs, share.ID)
+	}
+	owners := profileSummaries(ctx, h.Profiles, ownerIDs)
+	reactions := reactionSummaries(ctx, h.Reactions, userID, shareIDs)
+
+	resp.Entries = make([]feedEntry, 0, len(feed))
+	for _, share := range feed {
+		resp.Entries = append(resp.Entries, feedEntry{VideoShare: share, Owner: owners[share.OwnerID], Reactions: reactions[share.ID]})
+	}
+
+	respondJSON(ctx, w, http.StatusOK, resp)
+}
+
+// Get handles GET /api/v1/videos/{id} requests for a share the caller can see in their feed or
+// owns. Only the owner sees the circles and users it is limited to.
+func (h VideoHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "VideoHandler.Get")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodGet {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Videos == nil {
+		logger.Error("video service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "video service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, h.shareResponse(ctx, share))
+}
+
+// Update handles PATCH /api/v1/videos/{id} requests from the owner to change the note or who can
+// see the share. Omitted fields are unchanged; circleIds and userIds replace the current lists.
+func (h VideoHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "VideoHandler.Update")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodPatch {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Videos == nil {
+		logger.Error("video service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "video service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	var req updateVideoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("invalid update video payload", "error", err)
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	share, ok := h.findOwnShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	update := share
+	// An empty visibility tells the store to leave the audience alone.
+	update.Visibility = ""
+	if req.Note != nil {
+		note, err := shareNote(*req.Note)
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		update.Note = note
+	}
+	if req.Visibility != nil || req.CircleIDs != nil || req.UserIDs != nil {
+		audience := createVideoRequest{Visibility: share.Visibility, CircleIDs: share.CircleIDs, UserIDs: share.UserIDs}
+		if req.Visibility != nil {
+			audience = createVideoRequest{Visibility: *req.Visibility}
+		}
+		if req.CircleIDs != nil {
+			audience.CircleIDs = *req.CircleIDs
+		}
+		if req.UserIDs != nil {
+			audience.UserIDs = *req.UserIDs
+		}
+		visibility, circleIDs, userIDs, err := shareAudience(userID, audience)
+		if err != nil {
+			respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		update.Visibility, update.CircleIDs, update.UserIDs = visibility, circleIDs, userIDs
+	}
+
+	if err := h.Videos.Update(ctx, update); err != nil {
+		// The share was deleted in the meantime.
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+			return
+		}
+		if respondAudienceError(ctx, w, err) {
+			return
+		}
+		logger.Error("failed to update video share", "error", err, "shareId", share.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to update video share"})
+		return
+	}
+
+	if update.Visibility == "" {
+		update.Visibility = share.Visibility
+	}
+	logger.Info("video share updated", "userId", userID, "shareId", share.ID, "visibility", update.Visibility)
+	respondJSON(ctx, w, http.StatusOK, h.shareResponse(ctx, update))
+}
+
+// Delete handles DELETE /api/v1/videos/{id} requests from the owner. It deletes the share first,
+// then stops any download still in progress and removes the share's stored files, so a failure
+// never leaves a share pointing at files that are gone.
+func (h VideoHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx, span := logging.StartSpan(r.Context(), "VideoHandler.Delete")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	logger := logging.FromContext(ctx)
+	if r.Method != http.MethodDelete {
+		logger.Warn("method not allowed", "method", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.Videos == nil {
+		logger.Error("video service unavailable")
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "video service unavailable"})
+		return
+	}
+
+	userID, ok := authenticatedUser(ctx, w)
+	if !ok {
+		return
+	}
+
+	share, ok := h.findOwnShare(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.Videos.Delete(ctx, userID, share.ID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+			return
+		}
+		logger.Error("failed to delete video share", "error", err, "shareId", share.ID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to delete video share"})
+		return
+	}
+
+	// The share is gone, so failures from here on only leave files behind; they are logged for
+	// cleanup rather than failing the request.
+	if h.Assets != nil {
+		if err := h.Assets.Cancel(ctx, share.ID); err != nil {
+			logger.Error("failed to cancel asset ingestion of deleted share", "event", "storage.cleanup_required", "error", err, "shareId", share.ID)
+		}
+	}
+	if h.Storage != nil {
+		if err := h.Storage.DeletePrefix(ctx, share.ID+"/"); err != nil {
+			logger.Error("failed to delete stored files of deleted share", "event", "storage.cleanup_required", "error", err, "shareId", share.ID, "prefix", share.ID+"/")
+		}
+	} else {
+		logger.Warn("asset storage unavailable; stored video files were not removed", "shareId", share.ID)
+	}
+
+	logger.Info("video share deleted", "userId", userID, "shareId", share.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findShare loads the share named by the id path value if the user can see it, responding with
+// 404 Not Found otherwise.
+func (h VideoHandler) findShare(ctx context.Context, w http.ResponseWriter, r *http.Request, userID string) (models.VideoShare, bool) {
+	shareID := strings.TrimSpace(r.PathValue("id"))
+	if _, err := uuid.Parse(shareID); err != nil {
+		respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+		return models.VideoShare{}, false
+	}
+
+	share, err := h.Videos.FindVisible(ctx, userID, shareID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondJSON(ctx, w, http.StatusNotFound, map[string]string{"error": "share not found"})
+			return models.VideoShare{}, false
+		}
+		logging.FromContext(ctx).Error("failed to load video share", "error", err, "shareId", shareID)
+		respondJSON(ctx, w, http.StatusInternalServerError, map[string]string{"error": "failed to load video share"})
+		return models.VideoShare{}, false
+	}
+	return share, true
+}
+
+// findOwnShare is findShare for changes only the owner may make; other users who can see the
+// share receive 403 Forbidden.
+func (h VideoHandler) findOwnShare(ctx context.Context, w http.ResponseWriter, r *http.Request, userID string) (models.VideoShare, bool) {
+	share, ok := h.findShare(ctx, w, r, userID)
+	if !ok {
+		return models.VideoShare{}, false
+	}
+	if share.OwnerID != userID {
+		respondJSON(ctx, w, http.StatusForbidden, map[string]string{"error": "only the owner can change this share"})
+		return models.VideoShare{}, false
+	}
+	return share, true
+}
+
+func (h VideoHandler) shareResponse(ctx context.Context, share models.VideoShare) videoShareResponse {
+	owner := profileSummaries(ctx, h.Profiles, []string{share.OwnerID})[share.OwnerID]
+	return videoShareResponse{Share: share, Owner: owner}
 }
 
 func (h VideoHandler) now() time.Time {
@@ -149,9 +459,160 @@ func (h VideoHandler) now() time.Time {
 	return time.Now().UTC()
 }
 
+// shareAudience validates who besides the owner may see a new share, returning its visibility
+// and the circles or users it is limited to.
+func shareAudience(ownerID string, req createVideoRequest) (string, []string, []string, error) {
+	switch strings.TrimSpace(req.Visibility) {
+	case "", models.VisibilityFriends:
+		if len(req.CircleIDs) > 0 || len(req.UserIDs) > 0 {
+			return "", nil, nil, errors.New("circleIds and userIds require circles or users visibility")
+		}
+		return models.VisibilityFriends, nil, nil, nil
+	case models.VisibilityCircles:
+		if len(req.UserIDs) > 0 {
+			return "", nil, nil, errors.New("userIds requires users visibility")
+		}
+		circleIDs, err := uniqueIDs("circleIds", req.CircleIDs, maxCircles)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if len(circleIDs) == 0 {
+			return "", nil, nil, errors.New("circleIds is required for circles visibility")
+		}
+		return models.VisibilityCircles, circleIDs, nil, nil
+	case models.VisibilityUsers:
+		if len(req.CircleIDs) > 0 {
+			return "", nil, nil, errors.New("circleIds requires circles visibility")
+		}
+		userIDs, err := uniqueIDs("userIds", req.UserIDs, maxShareUsers)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if len(userIDs) == 0 {
+			return "", nil, nil, errors.New("userIds is required for users visibility")
+		}
+		if slices.Contains(userIDs, ownerID) {
+			return "", nil, nil, errors.New("userIds must not include yourself")
+		}
+		return models.VisibilityUsers, nil, userIDs, nil
+	default:
+		return "", nil, nil, errors.New("visibility must be friends, circles or users")
+	}
+}
+
+// respondAudienceError reports the audience errors of VideoStore.Create and Update as 400 Bad
+// Request. It returns false for other errors.
+func respondAudienceError(ctx context.Context, w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, repositories.ErrCircleNotFound):
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "circleIds must name your circles"})
+	case errors.Is(err, repositories.ErrNotFriends):
+		respondJSON(ctx, w, http.StatusBadRequest, map[string]string{"error": "userIds must name your friends"})
+	default:
+		return false
+	}
+	return true
+}
+
+// shareNote trims and validates the owner's note on a share. Line breaks are allowed.
+func shareNote(note string) (string, error) {
+	note = strings.TrimSpace(note)
+	switch {
+	case utf8.RuneCountInString(note) > maxShareNoteLength:
+		return "", fmt.Errorf("note must be at most %d characters", maxShareNoteLength)
+	case strings.IndexFunc(note, func(r rune) bool { return r != '\n' && unicode.IsControl(r) }) >= 0:
+		return "", errors.New("note must not contain control characters other than line breaks")
+	}
+	return note, nil
+}
+
+// feedFilter reads the feed filters from the query string.
+func feedFilter(r *http.Request) (models.FeedFilter, error) {
+	query := r.URL.Query()
+	var filter models.FeedFilter
+
+	if owner := strings.TrimSpace(query.Get("ownerId")); owner != "" {
+		id, err := uuid.Parse(owner)
+		if err != nil {
+			return models.FeedFilter{}, errors.New("ownerId must be a user id")
+		}
+		filter.OwnerID = id.String()
+	}
+
+	switch status := strings.ToLower(strings.TrimSpace(query.Get("assetStatus"))); status {
+	case "", models.AssetStatusPending, models.AssetStatusReady, models.AssetStatusFailed:
+		filter.AssetStatus = status
+	default:
+		return models.FeedFilter{}, errors.New("assetStatus must be pending, ready or failed")
+	}
+
+	if domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(query.Get("domain"))), "."); domain != "" {
+		if !validDomain(domain) {
+			return models.FeedFilter{}, errors.New("domain must be a host name such as youtube.com")
+		}
+		filter.Domain = domain
+	}
+
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &filter.Since}, {"before", &filter.Before}} {
+		raw := strings.TrimSpace(query.Get(bound.name))
+		if raw == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return models.FeedFilter{}, fmt.Errorf("%s must be an RFC 3339 time", bound.name)
+		}
+		at = at.UTC()
+		*bound.dst = &at
+	}
+	if filter.Since != nil && filter.Before != nil && !filter.Since.Before(*filter.Before) {
+		return models.FeedFilter{}, errors.New("since must be earlier than before")
+	}
+
+	return filter, nil
+}
+
+// validDomain reports whether domain is a lower-case DNS host name.
+func validDomain(domain string) bool {
+	if len(domain) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 type createVideoRequest struct {
-	OwnerID string `json:"ownerId"`
-	URL     string `json:"url"`
+	URL  string `json:"url"`
+	Note string `json:"note"`
+	// Visibility defaults to models.VisibilityFriends.
+	Visibility string   `json:"visibility"`
+	CircleIDs  []string `json:"circleIds"`
+	UserIDs    []string `json:"userIds"`
+}
+
+type updateVideoRequest struct {
+	Note       *string   `json:"note"`
+	Visibility *string   `json:"visibility"`
+	CircleIDs  *[]string `json:"circleIds"`
+	UserIDs    *[]string `json:"userIds"`
+}
+
+// videoShareResponse is a single share together with the profile of the user who shared it.
+type videoShareResponse struct {
+	Share models.VideoShare     `json:"share"`
+	Owner models.ProfileSummary `json:"owner"`
 }
 
 type createVideoResponse struct {
@@ -159,5 +620,14 @@ type createVideoResponse struct {
 }
 
 type feedResponse struct {
-	Entries []models.VideoShare `json:"entries"`
+	Entries    []feedEntry `json:"entries"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// feedEntry is a shared video together with the profile of the user who shared it and the
+// reactions to it as the viewer sees them.
+type feedEntry struct {
+	models.VideoShare
+	Owner     models.ProfileSummary
+	Reactions models.ReactionSummary
 }
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,11 +17,19 @@ import (
 )
 
 type videoStoreStub struct {
-	share     models.VideoShare
-	feed      []models.VideoShare
-	feedUser  string
-	createErr error
-	feedErr   error
+	share      models.VideoShare
+	feed       []models.VideoShare
+	feedUser   string
+	feedFilter models.FeedFilter
+	createErr  error
+	feedErr    error
+	owned      []models.VideoShare
+	ownedErr   error
+	// visible holds the shares FindVisible returns, keyed by ID; Update and Delete change it.
+	visible   map[string]models.VideoShare
+	updated   models.VideoShare
+	updateErr error
+	deleteErr error
 }
 
 func (s *videoStoreStub) Create(ctx context.Context, share models.VideoShare) error {
@@ -29,13 +38,73 @@ func (s *videoStoreStub) Create(ctx context.Context, share models.VideoShare) er
 	return s.createErr
 }
 
-func (s *videoStoreStub) ListFeed(ctx context.Context, userID string) ([]models.VideoShare, error) {
+// ListFeed pages through feed, which must be ordered newest first, and records the filter
+// without applying it.
+func (s *videoStoreStub) ListFeed(ctx context.Context, userID string, filter models.FeedFilter, after *models.FeedCursor, limit int) ([]models.VideoShare, error) {
 	_ = ctx
 	s.feedUser = userID
+	s.feedFilter = filter
 	if s.feedErr != nil {
 		return nil, s.feedErr
 	}
-	return s.feed, nil
+	var page []models.VideoShare
+	for _, share := range s.feed {
+		if after != nil && !share.CreatedAt.Before(after.CreatedAt) &&
+			!(share.CreatedAt.Equal(after.CreatedAt) && share.ID < after.ShareID) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, share)
+	}
+	return page, nil
+}
+
+func (s *videoStoreStub) FindVisible(_ context.Context, _ string, shareID string) (models.VideoShare, error) {
+	share, ok := s.visible[shareID]
+	if !ok {
+		return models.VideoShare{}, repositories.ErrNotFound
+	}
+	return share, nil
+}
+
+func (s *videoStoreStub) Update(_ context.Context, share models.VideoShare) error {
+	s.updated = share
+	if s.updateErr != nil {
+		return s.updateErr
+	}
+	if share.Visibility == "" {
+		existing := s.visible[share.ID]
+		share.Visibility, share.CircleIDs, share.UserIDs = existing.Visibility, existing.CircleIDs, existing.UserIDs
+	}
+	s.visible[share.ID] = share
+	return nil
+}
+
+func (s *videoStoreStub) Delete(_ context.Context, ownerID, shareID string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	share, ok := s.visible[shareID]
+	if !ok || share.OwnerID != ownerID {
+		return repositories.ErrNotFound
+	}
+	delete(s.visible, shareID)
+	return nil
+}
+
+func (s *videoStoreStub) ListByOwner(_ context.Context, ownerID string) ([]models.VideoShare, error) {
+	if s.ownedErr != nil {
+		return nil, s.ownedErr
+	}
+	var owned []models.VideoShare
+	for _, share := range s.owned {
+		if share.OwnerID == ownerID {
+			owned = append(owned, share)
+		}
+	}
+	return owned, nil
 }
 
 type metadataProviderStub struct {
@@ -48,8 +117,10 @@ func (m metadataProviderStub) Lookup(ctx context.Context, url string) (videos.Me
 }
 
 type assetIngestorStub struct {
-	share models.VideoShare
-	err   error
+	share     models.VideoShare
+	err       error
+	cancelled []string
+	cancelErr error
 }
 
 func (a *assetIngestorStub) Enqueue(ctx context.Context, share models.VideoShare) error {
@@ -58,9 +129,17 @@ func (a *assetIngestorStub) Enqueue(ctx context.Context, share models.VideoShare
 	return a.err
 }
 
+func (a *assetIngestorStub) Cancel(_ context.Context, shareID string) error {
+	if a.cancelErr != nil {
+		return a.cancelErr
+	}
+	a.cancelled = append(a.cancelled, shareID)
+	return nil
+}
+
 func TestVideoHandlerCreateSuccess(t *testing.T) {
 	store := &videoStoreStub{}
-	metadata := metadataProviderStub{metadata: videos.Metadata{Title: "Test", Description: "Desc", Thumbnail: "thumb.jpg"}}
+	metadata := metadataProviderStub{metadata: videos.Metadata{Title: "Test", Description: "Desc", Thumbnail: "thumb.jpg", Duration: 3*time.Minute + 42*time.Second}}
 
 	assets := &assetIngestorStub{}
 
@@ -74,11 +153,10 @@ func TestVideoHandlerCreateSuccess(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(map[string]string{
-		"ownerId": "user-123",
-		"url":     "https://example.com/watch?v=123",
+		"url": "https://example.com/watch?v=123",
 	})
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewReader(body))
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewReader(body)), "user-123")
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -90,7 +168,7 @@ func TestVideoHandlerCreateSuccess(t *testing.T) {
 	if store.share.ID == "" {
 		t.Fatal("expected share ID to be set")
 	}
-	if store.share.Title != "Test" || store.share.Description != "Desc" || store.share.Thumbnail != "thumb.jpg" {
+	if store.share.Title != "Test" || store.share.Description != "Desc" || store.share.Thumbnail != "thumb.jpg" || store.share.DurationMs != 222000 {
 		t.Fatalf("unexpected share metadata: %+v", store.share)
 	}
 	if store.share.OwnerID != "user-123" || store.share.URL != "https://example.com/watch?v=123" {
@@ -122,8 +200,8 @@ func TestVideoHandlerCreateMetadataError(t *testing.T) {
 		Metadata: metadataProviderStub{err: errors.New("boom")},
 	}
 
-	body := bytes.NewBufferString(`{"ownerId":"user","url":"https://example.com"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/videos", body)
+	body := bytes.NewBufferString(`{"url":"https://example.com"}`)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", body), "user")
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -139,8 +217,8 @@ func TestVideoHandlerCreateStoreConflict(t *testing.T) {
 		Metadata: metadataProviderStub{metadata: videos.Metadata{Title: "Test"}},
 	}
 
-	body := bytes.NewBufferString(`{"ownerId":"user","url":"https://example.com"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/videos", body)
+	body := bytes.NewBufferString(`{"url":"https://example.com"}`)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", body), "user")
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -171,15 +249,15 @@ func TestVideoHandlerCreateValidationFailures(t *testing.T) {
 		body       string
 		wantStatus int
 	}{
-		{"wrongMethod", http.MethodGet, `{"ownerId":"u","url":"https://example.com"}`, http.StatusMethodNotAllowed},
+		{"wrongMethod", http.MethodGet, `{"url":"https://example.com"}`, http.StatusMethodNotAllowed},
 		{"badJSON", http.MethodPost, "{", http.StatusBadRequest},
-		{"missingFields", http.MethodPost, `{"ownerId":"","url":""}`, http.StatusBadRequest},
-		{"invalidURL", http.MethodPost, `{"ownerId":"user","url":"not-a-url"}`, http.StatusBadRequest},
+		{"missingFields", http.MethodPost, `{"url":""}`, http.StatusBadRequest},
+		{"invalidURL", http.MethodPost, `{"url":"not-a-url"}`, http.StatusBadRequest},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			req := httptest.NewRequest(tc.method, "/api/v1/videos", bytes.NewBufferString(tc.body))
+			req := withUser(httptest.NewRequest(tc.method, "/api/v1/videos", bytes.NewBufferString(tc.body)), "user")
 			rec := httptest.NewRecorder()
 
 			handler.Create(rec, req)
@@ -191,13 +269,90 @@ func TestVideoHandlerCreateValidationFailures(t *testing.T) {
 	}
 }
 
+func TestVideoHandlerCreateVisibility(t *testing.T) {
+	t.Run("defaultsToFriends", func(t *testing.T) {
+		store := &videoStoreStub{}
+		handler := VideoHandler{Videos: store, Metadata: metadataProviderStub{}}
+		rec := postJSON(t, handler.Create, "/api/v1/videos", createVideoRequest{URL: "https://example.com"}, requesterUUID)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		if store.share.Visibility != models.VisibilityFriends || store.share.CircleIDs != nil || store.share.UserIDs != nil {
+			t.Fatalf("unexpected audience: %+v", store.share)
+		}
+	})
+
+	t.Run("circles", func(t *testing.T) {
+		store := &videoStoreStub{}
+		handler := VideoHandler{Videos: store, Metadata: metadataProviderStub{}}
+		rec := postJSON(t, handler.Create, "/api/v1/videos", createVideoRequest{
+			URL:        "https://example.com",
+			Visibility: models.VisibilityCircles,
+			CircleIDs:  []string{thirdUUID, thirdUUID},
+		}, requesterUUID)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		if store.share.Visibility != models.VisibilityCircles || len(store.share.CircleIDs) != 1 || store.share.CircleIDs[0] != thirdUUID {
+			t.Fatalf("unexpected audience: %+v", store.share)
+		}
+	})
+
+	t.Run("users", func(t *testing.T) {
+		store := &videoStoreStub{}
+		handler := VideoHandler{Videos: store, Metadata: metadataProviderStub{}}
+		rec := postJSON(t, handler.Create, "/api/v1/videos", createVideoRequest{
+			URL:        "https://example.com",
+			Visibility: models.VisibilityUsers,
+			UserIDs:    []string{receiverUUID},
+		}, requesterUUID)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var resp createVideoResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.Share.Visibility != models.VisibilityUsers || len(resp.Share.UserIDs) != 1 || resp.Share.UserIDs[0] != receiverUUID {
+			t.Fatalf("unexpected audience: %+v", resp.Share)
+		}
+	})
+
+	cases := []struct {
+		name      string
+		req       createVideoRequest
+		createErr error
+	}{
+		{"unknownVisibility", createVideoRequest{Visibility: "public"}, nil},
+		{"friendsWithAudience", createVideoRequest{UserIDs: []string{receiverUUID}}, nil},
+		{"circlesWithoutCircles", createVideoRequest{Visibility: models.VisibilityCircles}, nil},
+		{"circlesWithUsers", createVideoRequest{Visibility: models.VisibilityCircles, CircleIDs: []string{thirdUUID}, UserIDs: []string{receiverUUID}}, nil},
+		{"malformedCircle", createVideoRequest{Visibility: models.VisibilityCircles, CircleIDs: []string{"family"}}, nil},
+		{"usersWithoutUsers", createVideoRequest{Visibility: models.VisibilityUsers}, nil},
+		{"usersIncludingSelf", createVideoRequest{Visibility: models.VisibilityUsers, UserIDs: []string{requesterUUID}}, nil},
+		{"foreignCircle", createVideoRequest{Visibility: models.VisibilityCircles, CircleIDs: []string{thirdUUID}}, repositories.ErrCircleNotFound},
+		{"notFriends", createVideoRequest{Visibility: models.VisibilityUsers, UserIDs: []string{strangerUUID}}, repositories.ErrNotFriends},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := VideoHandler{Videos: &videoStoreStub{createErr: tc.createErr}, Metadata: metadataProviderStub{}}
+			tc.req.URL = "https://example.com"
+			rec := postJSON(t, handler.Create, "/api/v1/videos", tc.req, requesterUUID)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
 func TestVideoHandlerCreateProviderUnavailable(t *testing.T) {
 	handler := VideoHandler{
 		Videos:   &videoStoreStub{},
 		Metadata: metadataProviderStub{err: videos.ErrProviderUnavailable},
 	}
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewBufferString(`{"ownerId":"u","url":"https://example.com"}`))
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewBufferString(`{"url":"https://example.com"}`)), "u")
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -213,7 +368,7 @@ func TestVideoHandlerCreateStoreFailure(t *testing.T) {
 		Metadata: metadataProviderStub{metadata: videos.Metadata{Title: "ok"}},
 	}
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewBufferString(`{"ownerId":"u","url":"https://example.com"}`))
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewBufferString(`{"url":"https://example.com"}`)), "u")
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -235,7 +390,7 @@ func TestVideoHandlerFeedSuccess(t *testing.T) {
 	store := &videoStoreStub{feed: entries}
 	handler := VideoHandler{Videos: store}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?user=user-123", nil)
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil), "user-123")
 	rec := httptest.NewRecorder()
 
 	handler.Feed(rec, req)
@@ -257,6 +412,105 @@ func TestVideoHandlerFeedSuccess(t *testing.T) {
 	if resp.Entries[0].ID != entries[0].ID {
 		t.Fatalf("unexpected feed response: %+v", resp.Entries[0])
 	}
+	if resp.Entries[0].Owner != (models.ProfileSummary{UserID: "friend-1"}) {
+		t.Fatalf("expected owner identified by ID without a profile store, got %+v", resp.Entries[0].Owner)
+	}
+}
+
+func TestVideoHandlerFeedPagination(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 15, 0, 0, 0, time.UTC)
+	store := &videoStoreStub{feed: []models.VideoShare{
+		{ID: "cccccccc-0000-0000-0000-000000000000", OwnerID: "friend-1", CreatedAt: now},
+		{ID: "bbbbbbbb-0000-0000-0000-000000000000", OwnerID: "friend-1", CreatedAt: now.Add(-time.Minute)},
+		{ID: "aaaaaaaa-0000-0000-0000-000000000000", OwnerID: "friend-1", CreatedAt: now.Add(-time.Minute)},
+	}}
+	handler := VideoHandler{Videos: store}
+
+	page := func(query string) feedResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.Feed(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?"+query, nil), "user-123"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp feedResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	first := page("limit=2")
+	if len(first.Entries) != 2 || first.Entries[1].ID != store.feed[1].ID || first.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	second := page("limit=2&cursor=" + first.NextCursor)
+	if len(second.Entries) != 1 || second.Entries[0].ID != store.feed[2].ID || second.NextCursor != "" {
+		t.Fatalf("unexpected last page: %+v", second)
+	}
+}
+
+func TestVideoHandlerFeedFilters(t *testing.T) {
+	const ownerID = "3a1f0c6e-8a52-4a55-9a4e-2f0d6c1b7e11"
+
+	store := &videoStoreStub{}
+	handler := VideoHandler{Videos: store}
+	rec := httptest.NewRecorder()
+	target := "/api/v1/videos/feed?ownerId=" + ownerID + "&assetStatus=Ready&domain=YouTube.com.&since=2024-01-01T00:00:00Z&before=2024-02-01T00:00:00%2B01:00"
+	handler.Feed(rec, withUser(httptest.NewRequest(http.MethodGet, target, nil), "user-123"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	got := store.feedFilter
+	since := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, time.January, 31, 23, 0, 0, 0, time.UTC)
+	if got.OwnerID != ownerID || got.AssetStatus != models.AssetStatusReady || got.Domain != "youtube.com" ||
+		got.Since == nil || !got.Since.Equal(since) || got.Before == nil || !got.Before.Equal(before) {
+		t.Fatalf("unexpected filter: %+v", got)
+	}
+
+	for _, query := range []string{
+		"ownerId=bob",
+		"assetStatus=deleted",
+		"domain=you%20tube.com",
+		"domain=-youtube.com",
+		"domain=youtube..com",
+		"since=yesterday",
+		"before=2024-01-01",
+		"since=2024-02-01T00:00:00Z&before=2024-01-01T00:00:00Z",
+		"cursor=not-a-cursor",
+		"cursor=" + encodeCursor(since, "share-1"),
+		"limit=0",
+	} {
+		rec := httptest.NewRecorder()
+		handler.Feed(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?"+query, nil), "user-123"))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400 got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestVideoHandlerFeedEmbedsOwnerProfile(t *testing.T) {
+	store := &videoStoreStub{feed: []models.VideoShare{{ID: "share-1", OwnerID: "friend-1"}}}
+	profileStore := newInMemoryProfileStore()
+	profileStore.profiles["friend-1"] = models.Profile{UserID: "friend-1", Handle: "carol", DisplayName: "Carol"}
+	handler := VideoHandler{Videos: store, Profiles: profileStore}
+
+	rec := httptest.NewRecorder()
+	handler.Feed(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil), "user-123"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+
+	var resp feedResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Owner.Handle != "carol" || resp.Entries[0].Owner.DisplayName != "Carol" {
+		t.Fatalf("expected owner profile to be embedded, got %+v", resp.Entries)
+	}
 }
 
 func TestVideoHandlerFeedValidation(t *testing.T) {
@@ -272,14 +526,14 @@ func TestVideoHandlerFeedValidation(t *testing.T) {
 	req = httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil)
 	rec = httptest.NewRecorder()
 	handler.Feed(rec, req)
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400 got %d", rec.Code)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 got %d", rec.Code)
 	}
 }
 
 func TestVideoHandlerFeedServiceUnavailable(t *testing.T) {
 	handler := VideoHandler{}
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?user=user-123", nil)
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil), "user-123")
 	rec := httptest.NewRecorder()
 
 	handler.Feed(rec, req)
@@ -291,7 +545,7 @@ func TestVideoHandlerFeedServiceUnavailable(t *testing.T) {
 
 func TestVideoHandlerFeedStoreError(t *testing.T) {
 	handler := VideoHandler{Videos: &videoStoreStub{feedErr: errors.New("query failed")}}
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?user=user-123", nil)
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil), "user-123")
 	rec := httptest.NewRecorder()
 
 	handler.Feed(rec, req)
@@ -300,3 +554,229 @@ func TestVideoHandlerFeedStoreError(t *testing.T) {
 		t.Fatalf("expected 500 got %d", rec.Code)
 	}
 }
+
+func TestVideoHandlerCreateIgnoresOwnerInBody(t *testing.T) {
+	store := &videoStoreStub{}
+	handler := VideoHandler{Videos: store, Metadata: metadataProviderStub{metadata: videos.Metadata{Title: "Test"}}}
+
+	body := bytes.NewBufferString(`{"ownerId":"victim-456","url":"https://example.com"}`)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/videos", body), "attacker-123")
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d", rec.Code)
+	}
+	if store.share.OwnerID != "attacker-123" {
+		t.Fatalf("expected share to be owned by the authenticated user, got %s", store.share.OwnerID)
+	}
+}
+
+func TestVideoHandlerFeedIgnoresUserQuery(t *testing.T) {
+	store := &videoStoreStub{}
+	handler := VideoHandler{Videos: store}
+
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed?user=victim-456", nil), "attacker-123")
+	rec := httptest.NewRecorder()
+
+	handler.Feed(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	if store.feedUser != "attacker-123" {
+		t.Fatalf("expected feed for the authenticated user, got %s", store.feedUser)
+	}
+}
+
+func TestVideoHandlerCreateRequiresAuthentication(t *testing.T) {
+	store := &videoStoreStub{}
+	handler := VideoHandler{Videos: store, Metadata: metadataProviderStub{metadata: videos.Metadata{Title: "Test"}}}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/videos", bytes.NewBufferString(`{"ownerId":"victim-456","url":"https://example.com"}`))
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 got %d", rec.Code)
+	}
+	if store.share.ID != "" {
+		t.Fatal("expected no share to be stored for unauthenticated request")
+	}
+}
+
+const shareUUID = "5a7c1e2d-0b8f-4c3a-9d6e-1f2a3b4c5d6e"
+
+func shareRequest(t *testing.T, handler http.HandlerFunc, method, id, body, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/v1/videos/"+id, bytes.NewBufferString(body))
+	req.SetPathValue("id", id)
+	if userID != "" {
+		req = withUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func newSharedVideoStore() *videoStoreStub {
+	return &videoStoreStub{visible: map[string]models.VideoShare{shareUUID: {
+		ID:         shareUUID,
+		OwnerID:    requesterUUID,
+		URL:        "https://example.com/watch?v=abc",
+		Visibility: models.VisibilityUsers,
+		UserIDs:    []string{receiverUUID},
+	}}}
+}
+
+func decodeShare(t *testing.T, rec *httptest.ResponseRecorder, wantStatus int) videoShareResponse {
+	t.Helper()
+	if rec.Code != wantStatus {
+		t.Fatalf("expected status %d got %d: %s", wantStatus, rec.Code, rec.Body.String())
+	}
+	var resp videoShareResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestVideoHandlerGet(t *testing.T) {
+	profiles := newInMemoryProfileStore()
+	profiles.profiles[requesterUUID] = models.Profile{UserID: requesterUUID, Handle: "alice"}
+	handler := VideoHandler{Videos: newSharedVideoStore(), Profiles: profiles}
+
+	resp := decodeShare(t, shareRequest(t, handler.Get, http.MethodGet, shareUUID, "", receiverUUID), http.StatusOK)
+	if resp.Share.ID != shareUUID || resp.Owner.Handle != "alice" {
+		t.Fatalf("unexpected share: %+v", resp)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		id         string
+		userID     string
+		wantStatus int
+	}{
+		{"unknown", thirdUUID, receiverUUID, http.StatusNotFound},
+		{"malformedID", "share-1", receiverUUID, http.StatusNotFound},
+		{"unauthenticated", shareUUID, "", http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := shareRequest(t, handler.Get, http.MethodGet, tc.id, "", tc.userID); rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d", tc.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestVideoHandlerUpdate(t *testing.T) {
+	store := newSharedVideoStore()
+	handler := VideoHandler{Videos: store}
+
+	resp := decodeShare(t, shareRequest(t, handler.Update, http.MethodPatch, shareUUID, `{"note":"  Watch the ending\n"}`, requesterUUID), http.StatusOK)
+	if resp.Share.Note != "Watch the ending" || resp.Share.Visibility != models.VisibilityUsers || store.updated.Visibility != "" {
+		t.Fatalf("expected note-only update to keep the audience, got %+v (stored %+v)", resp.Share, store.updated)
+	}
+
+	resp = decodeShare(t, shareRequest(t, handler.Update, http.MethodPatch, shareUUID, `{"userIds":["`+thirdUUID+`"]}`, requesterUUID), http.StatusOK)
+	if resp.Share.Visibility != models.VisibilityUsers || len(resp.Share.UserIDs) != 1 || resp.Share.UserIDs[0] != thirdUUID || resp.Share.Note != "Watch the ending" {
+		t.Fatalf("expected users to be replaced, got %+v", resp.Share)
+	}
+
+	resp = decodeShare(t, shareRequest(t, handler.Update, http.MethodPatch, shareUUID, `{"visibility":"friends"}`, requesterUUID), http.StatusOK)
+	if resp.Share.Visibility != models.VisibilityFriends || len(resp.Share.UserIDs) != 0 {
+		t.Fatalf("expected share with all friends, got %+v", resp.Share)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		store      *videoStoreStub
+		userID     string
+		body       string
+		wantStatus int
+	}{
+		{"notOwner", newSharedVideoStore(), receiverUUID, `{"note":"mine now"}`, http.StatusForbidden},
+		{"unauthenticated", newSharedVideoStore(), "", `{"note":"hi"}`, http.StatusUnauthorized},
+		{"invalidBody", newSharedVideoStore(), requesterUUID, `[]`, http.StatusBadRequest},
+		{"longNote", newSharedVideoStore(), requesterUUID, `{"note":"` + strings.Repeat("a", maxShareNoteLength+1) + `"}`, http.StatusBadRequest},
+		{"controlCharacters", newSharedVideoStore(), requesterUUID, `{"note":"bell\u0007"}`, http.StatusBadRequest},
+		{"circlesWithoutIDs", newSharedVideoStore(), requesterUUID, `{"visibility":"circles"}`, http.StatusBadRequest},
+		{"circleIDsForUsersShare", newSharedVideoStore(), requesterUUID, `{"circleIds":["` + thirdUUID + `"]}`, http.StatusBadRequest},
+		{"notFriend", &videoStoreStub{visible: newSharedVideoStore().visible, updateErr: repositories.ErrNotFriends}, requesterUUID, `{"userIds":["` + strangerUUID + `"]}`, http.StatusBadRequest},
+		{"foreignCircle", &videoStoreStub{visible: newSharedVideoStore().visible, updateErr: repositories.ErrCircleNotFound}, requesterUUID, `{"visibility":"circles","circleIds":["` + thirdUUID + `"]}`, http.StatusBadRequest},
+		{"deletedMeanwhile", &videoStoreStub{visible: newSharedVideoStore().visible, updateErr: repositories.ErrNotFound}, requesterUUID, `{"note":"hi"}`, http.StatusNotFound},
+		{"deletedMeanwhileWithCircles", &videoStoreStub{visible: newSharedVideoStore().visible, updateErr: repositories.ErrNotFound}, requesterUUID, `{"visibility":"circles","circleIds":["` + thirdUUID + `"]}`, http.StatusNotFound},
+		{"storeError", &videoStoreStub{visible: newSharedVideoStore().visible, updateErr: errors.New("db down")}, requesterUUID, `{"note":"hi"}`, http.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := VideoHandler{Videos: tc.store}
+			if rec := shareRequest(t, handler.Update, http.MethodPatch, shareUUID, tc.body, tc.userID); rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestVideoHandlerDelete(t *testing.T) {
+	store := newSharedVideoStore()
+	ingestor := &assetIngestorStub{}
+	files := &recordingAssetRemover{}
+	handler := VideoHandler{Videos: store, Assets: ingestor, Storage: files}
+
+	if rec := shareRequest(t, handler.Delete, http.MethodDelete, shareUUID, "", receiverUUID); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected viewers to be unable to delete, got %d", rec.Code)
+	}
+	if rec := shareRequest(t, handler.Delete, http.MethodDelete, shareUUID, "", requesterUUID); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+	if len(ingestor.cancelled) != 1 || ingestor.cancelled[0] != shareUUID {
+		t.Fatalf("expected ingestion to be cancelled, got %v", ingestor.cancelled)
+	}
+	if len(files.prefixes) != 1 || files.prefixes[0] != shareUUID+"/" {
+		t.Fatalf("expected stored files to be removed, got %v", files.prefixes)
+	}
+	if _, ok := store.visible[shareUUID]; ok {
+		t.Fatal("expected share to be deleted")
+	}
+	if rec := shareRequest(t, handler.Delete, http.MethodDelete, shareUUID, "", requesterUUID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d got %d", http.StatusNotFound, rec.Code)
+	}
+
+	t.Run("storeFails", func(t *testing.T) {
+		store := newSharedVideoStore()
+		store.deleteErr = errors.New("db down")
+		ingestor := &assetIngestorStub{}
+		files := &recordingAssetRemover{}
+		handler := VideoHandler{Videos: store, Assets: ingestor, Storage: files}
+		if rec := shareRequest(t, handler.Delete, http.MethodDelete, shareUUID, "", requesterUUID); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d got %d", http.StatusInternalServerError, rec.Code)
+		}
+		if _, ok := store.visible[shareUUID]; !ok {
+			t.Fatal("expected share to be kept")
+		}
+		if len(ingestor.cancelled) != 0 || len(files.prefixes) != 0 {
+			t.Fatalf("expected the files of a kept share to stay, got cancelled %v and removed %v", ingestor.cancelled, files.prefixes)
+		}
+	})
+
+	for _, tc := range []struct {
+		name    string
+		handler VideoHandler
+	}{
+		{"cancelFails", VideoHandler{Assets: &assetIngestorStub{cancelErr: errors.New("timeout")}, Storage: &recordingAssetRemover{}}},
+		{"storageFails", VideoHandler{Assets: &assetIngestorStub{}, Storage: &recordingAssetRemover{err: errors.New("s3 down")}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newSharedVideoStore()
+			tc.handler.Videos = store
+			if rec := shareRequest(t, tc.handler.Delete, http.MethodDelete, shareUUID, "", requesterUUID); rec.Code != http.StatusNoContent {
+				t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+			}
+			if _, ok := store.visible[shareUUID]; ok {
+				t.Fatal("expected share to be deleted even though its files could not be cleaned up")
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package invites
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInviteNotFound indicates the invite does not exist, belongs to another user or was
+	// already revoked.
+	ErrInviteNotFound = errors.New("invite not found")
+	// ErrInvalidCode indicates a presented code is unknown, expired, revoked or used up.
+	ErrInvalidCode = errors.New("invite code invalid")
+	// ErrInviteLimit indicates the user already has the maximum number of outstanding invites.
+	ErrInviteLimit = errors.New("invite limit reached")
+	// ErrInvalidInvite indicates the requested e-mail address, use limit or lifetime was rejected.
+	ErrInvalidInvite = errors.New("invalid invite")
+)
+
+// CodePrefix starts every invite code so codes are easy to recognise in links and logs.
+const CodePrefix = "vfi_"
+
+// Invite limits.
+const (
+	// DefaultTTL is how long an invite stays usable when no lifetime is requested.
+	DefaultTTL = 7 * 24 * time.Hour
+	// MaxTTL bounds the lifetime of an invite.
+	MaxTTL = 30 * 24 * time.Hour
+	// DefaultMaxUses is how many people may sign up with an invite link when no limit is requested.
+	DefaultMaxUses = 5
+	// MaxUses bounds how many people may sign up with one invite link.
+	MaxUses = 100
+	// MaxOutstanding bounds how many usable invites one user may hold.
+	MaxOutstanding = 50
+)
+
+// Invite is a shareable link or e-mail invite. Only the SHA-256 hash of its code is stored.
+type Invite struct {
+	ID        string
+	InviterID string
+	Hash      string
+	// Email is the address an e-mail invite was sent to; it is empty for links.
+	Email     string
+	MaxUses   int
+	Uses      int
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// Usable reports whether someone may still sign up with the invite at now.
+func (i Invite) Usable(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && i.Uses < i.MaxUses
+}
+
+// Store persists invites.
+type Store interface {
+	Create(ctx context.Context, invite Invite) error
+	// FindByHash returns ErrInviteNotFound when no invite has the hash.
+	FindByHash(ctx context.Context, hash string) (Invite, error)
+	// ListForInviter returns every invite the user created, newest first.
+	ListForInviter(ctx context.Context, inviterID string) ([]Invite, error)
+	// Revoke returns ErrInviteNotFound when the invite does not exist, belongs to another user or
+	// is already revoked.
+	Revoke(ctx context.Context, inviterID, id string, at time.Time) error
+	// Redeem atomically records one use of the invite with the hash and returns it. It returns
+	// ErrInvalidCode when the invite is unknown or not usable at at.
+	Redeem(ctx context.Context, hash string, at time.Time) (Invite, error)
+}
+
+// CreateOptions configures a new invite. Zero values select the defaults.
+type CreateOptions struct {
+	// Email marks an e-mail invite, which can only be redeemed once.
+	Email   string
+	MaxUses int
+	TTL     time.Duration
+}
+
+// Manager issues, lists, revokes and redeems invites.
+type Manager struct {
+	store Store
+
+	// NowFunc overrides the clock, primarily for tests.
+	NowFunc func() time.Time
+}
+
+// NewManager constructs a Manager backed by the store.
+func NewManager(store Store) *Manager {
+	if store == nil {
+		panic("invites: store must not be nil")
+	}
+	return &Manager{store: store}
+}
+
+// Create issues an invite from the user and returns it together with its code, which is only
+// available now.
+func (m *Manager) Create(ctx context.Context, inviterID string, opts CreateOptions) (Invite, string, error) {
+	if inviterID == "" {
+		return Invite{}, "", errors.New("inviter id must be provided")
+	}
+
+	email := strings.TrimSpace(strings.ToLower(opts.Email))
+	maxUses := opts.MaxUses
+	if email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return Invite{}, "", fmt.Errorf("%w: invalid email address", ErrInvalidInvite)
+		}
+		if maxUses > 1 {
+			return Invite{}, "", fmt.Errorf("%w: e-mail invites can only be used once", ErrInvalidInvite)
+		}
+		maxUses = 1
+	}
+	if maxUses == 0 {
+		maxUses = DefaultMaxUses
+	}
+	if maxUses < 1 || maxUses > MaxUses {
+		return Invite{}, "", fmt.Errorf("%w: maxUses must be between 1 and %d", ErrInvalidInvite, MaxUses)
+	}
+
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return Invite{}, "", fmt.Errorf("%w: lifetime must be at most %d days", ErrInvalidInvite, int(MaxTTL/(24*time.Hour)))
+	}
+
+	outstanding, err := m.ListOutstanding(ctx, inviterID)
+	if err != nil {
+		return Invite{}, "", err
+	}
+	if len(outstanding) >= MaxOutstanding {
+		return Invite{}, "", ErrInviteLimit
+	}
+
+	code, err := randomCode()
+	if err != nil {
+		return Invite{}, "", err
+	}
+
+	now := m.now()
+	invite := Invite{
+		ID:        uuid.NewString(),
+		InviterID: inviterID,
+		Hash:      hashCode(code),
+		Email:     email,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := m.store.Create(ctx, invite); err != nil {
+		return Invite{}, "", err
+	}
+	return invite, code, nil
+}
+
+// ListOutstanding returns the user's invites that can still be used, newest first.
+func (m *Manager) ListOutstanding(ctx context.Context, inviterID string) ([]Invite, error) {
+	all, err := m.store.ListForInviter(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	outstanding := make([]Invite, 0, len(all))
+	for _, invite := range all {
+		if invite.Usable(now) {
+			outstanding = append(outstanding, invite)
+		}
+	}
+	return outstanding, nil
+}
+
+// Revoke stops one of the user's invites from being used.
+func (m *Manager) Revoke(ctx context.Context, inviterID, id string) error {
+	return m.store.Revoke(ctx, inviterID, id, m.now())
+}
+
+// Lookup returns the invite a code belongs to without using it up. It returns ErrInvalidCode
+// unless the invite is usable.
+func (m *Manager) Lookup(ctx context.Context, code string) (Invite, error) {
+	if !strings.HasPrefix(code, CodePrefix) {
+		return Invite{}, ErrInvalidCode
+	}
+	invite, err := m.store.FindByHash(ctx, hashCode(code))
+	if err != nil {
+		if errors.Is(err, ErrInviteNotFound) {
+			return Invite{}, ErrInvalidCode
+		}
+		return Invite{}, err
+	}
+	if !invite.Usable(m.now()) {
+		return Invite{}, ErrInvalidCode
+	}
+	return invite, nil
+}
+
+// Redeem records one use of the invite a code belongs to and returns the invite. It returns
+// ErrInvalidCode unless the invite is usable.
+func (m *Manager) Redeem(ctx context.Context, code string) (Invite, error) {
+	if !strings.HasPrefix(code, CodePrefix) {
+		return Invite{}, ErrInvalidCode
+	}
+	return m.store.Redeem(ctx, hashCode(code), m.now())
+}
+
+func (m *Manager) now() time.Time {
+	if m.NowFunc != nil {
+		return m.NowFunc()
+	}
+	return time.Now().UTC()
+}
+
+func randomCode() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return CodePrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
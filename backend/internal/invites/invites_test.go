@@ -0,0 +1,172 @@
+package invites
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestManager(now *time.Time) (*Manager, *InMemoryStore) {
+	store := NewInMemoryStore()
+	manager := NewManager(store)
+	manager.NowFunc = func() time.Time { return *now }
+	return manager, store
+}
+
+func TestManagerCreateAndRedeem(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	manager, store := newTestManager(&now)
+
+	invite, code, err := manager.Create(ctx, "user-1", CreateOptions{MaxUses: 2})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(code, CodePrefix) {
+		t.Fatalf("expected code to start with %q, got %q", CodePrefix, code)
+	}
+	if invite.Hash == code || strings.Contains(invite.Hash, code) {
+		t.Fatal("expected only the code hash to be stored")
+	}
+	if !invite.ExpiresAt.Equal(now.Add(DefaultTTL)) || invite.MaxUses != 2 {
+		t.Fatalf("unexpected invite: %+v", invite)
+	}
+
+	if found, err := manager.Lookup(ctx, code); err != nil || found.ID != invite.ID || found.Uses != 0 {
+		t.Fatalf("expected lookup to leave the invite unused, got %+v (err %v)", found, err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		redeemed, err := manager.Redeem(ctx, code)
+		if err != nil {
+			t.Fatalf("redeem %d: %v", i, err)
+		}
+		if redeemed.InviterID != "user-1" || redeemed.Uses != i {
+			t.Fatalf("unexpected redeemed invite: %+v", redeemed)
+		}
+	}
+
+	if _, err := manager.Redeem(ctx, code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected used up invite to be rejected, got %v", err)
+	}
+	if _, err := manager.Lookup(ctx, code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected lookup of used up invite to fail, got %v", err)
+	}
+	if stored := store.invites[invite.ID]; stored.Uses != 2 {
+		t.Fatalf("expected two uses to be recorded, got %d", stored.Uses)
+	}
+}
+
+func TestManagerRejectsUnknownCodes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	manager, _ := newTestManager(&now)
+
+	for _, code := range []string{"", "not-an-invite", CodePrefix + "unknown"} {
+		if _, err := manager.Lookup(ctx, code); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("Lookup(%q): expected ErrInvalidCode, got %v", code, err)
+		}
+		if _, err := manager.Redeem(ctx, code); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("Redeem(%q): expected ErrInvalidCode, got %v", code, err)
+		}
+	}
+}
+
+func TestManagerExpiryAndRevocation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	manager, _ := newTestManager(&now)
+
+	expiring, expiringCode, err := manager.Create(ctx, "user-1", CreateOptions{TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	revoked, revokedCode, err := manager.Create(ctx, "user-1", CreateOptions{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := manager.Revoke(ctx, "user-2", revoked.ID); !errors.Is(err, ErrInviteNotFound) {
+		t.Fatalf("expected other users to be unable to revoke, got %v", err)
+	}
+	if err := manager.Revoke(ctx, "user-1", revoked.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := manager.Revoke(ctx, "user-1", revoked.ID); !errors.Is(err, ErrInviteNotFound) {
+		t.Fatalf("expected second revoke to report not found, got %v", err)
+	}
+	if _, err := manager.Redeem(ctx, revokedCode); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected revoked invite to be rejected, got %v", err)
+	}
+
+	outstanding, err := manager.ListOutstanding(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(outstanding) != 1 || outstanding[0].ID != expiring.ID {
+		t.Fatalf("expected only the usable invite, got %+v", outstanding)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := manager.Redeem(ctx, expiringCode); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected expired invite to be rejected, got %v", err)
+	}
+	if outstanding, err := manager.ListOutstanding(ctx, "user-1"); err != nil || len(outstanding) != 0 {
+		t.Fatalf("expected no outstanding invites, got %+v (err %v)", outstanding, err)
+	}
+}
+
+func TestManagerCreateValidation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	manager, _ := newTestManager(&now)
+
+	invite, _, err := manager.Create(ctx, "user-1", CreateOptions{Email: " Friend@Example.com "})
+	if err != nil {
+		t.Fatalf("create e-mail invite: %v", err)
+	}
+	if invite.Email != "friend@example.com" || invite.MaxUses != 1 {
+		t.Fatalf("expected single-use e-mail invite, got %+v", invite)
+	}
+
+	for name, opts := range map[string]CreateOptions{
+		"badEmail":        {Email: "not an address"},
+		"reusableEmail":   {Email: "friend@example.com", MaxUses: 3},
+		"negativeUses":    {MaxUses: -1},
+		"tooManyUses":     {MaxUses: MaxUses + 1},
+		"negativeTTL":     {TTL: -time.Hour},
+		"ttlBeyondMaxTTL": {TTL: MaxTTL + time.Hour},
+	} {
+		if _, _, err := manager.Create(ctx, "user-1", opts); !errors.Is(err, ErrInvalidInvite) {
+			t.Fatalf("%s: expected ErrInvalidInvite, got %v", name, err)
+		}
+	}
+}
+
+func TestManagerLimitsOutstandingInvites(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	manager, _ := newTestManager(&now)
+
+	var last Invite
+	for i := 0; i < MaxOutstanding; i++ {
+		invite, _, err := manager.Create(ctx, "user-1", CreateOptions{})
+		if err != nil {
+			t.Fatalf("create %d: %v", i, err)
+		}
+		last = invite
+	}
+
+	if _, _, err := manager.Create(ctx, "user-1", CreateOptions{}); !errors.Is(err, ErrInviteLimit) {
+		t.Fatalf("expected ErrInviteLimit, got %v", err)
+	}
+
+	if err := manager.Revoke(ctx, "user-1", last.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := manager.Create(ctx, "user-1", CreateOptions{}); err != nil {
+		t.Fatalf("expected revoking to free a slot, got %v", err)
+	}
+}
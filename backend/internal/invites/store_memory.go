@@ -0,0 +1,85 @@
+package invites
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewInMemoryStore returns a Store backed by an in-memory map.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{invites: make(map[string]Invite)}
+}
+
+// InMemoryStore implements Store for tests and local development.
+type InMemoryStore struct {
+	mu      sync.Mutex
+	invites map[string]Invite
+}
+
+// Create persists the invite.
+func (s *InMemoryStore) Create(_ context.Context, invite Invite) error {
+	s.mu.Lock()
+	s.invites[invite.ID] = invite
+	s.mu.Unlock()
+	return nil
+}
+
+// FindByHash returns the invite with the hash.
+func (s *InMemoryStore) FindByHash(_ context.Context, hash string) (Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, invite := range s.invites {
+		if invite.Hash == hash {
+			return invite, nil
+		}
+	}
+	return Invite{}, ErrInviteNotFound
+}
+
+// ListForInviter returns the user's invites, newest first.
+func (s *InMemoryStore) ListForInviter(_ context.Context, inviterID string) ([]Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var invites []Invite
+	for _, invite := range s.invites {
+		if invite.InviterID == inviterID {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].CreatedAt.After(invites[j].CreatedAt) })
+	return invites, nil
+}
+
+// Revoke marks one of the user's invites as revoked.
+func (s *InMemoryStore) Revoke(_ context.Context, inviterID, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invite, ok := s.invites[id]
+	if !ok || invite.InviterID != inviterID || invite.RevokedAt != nil {
+		return ErrInviteNotFound
+	}
+	revokedAt := at.UTC()
+	invite.RevokedAt = &revokedAt
+	s.invites[id] = invite
+	return nil
+}
+
+// Redeem records one use of the invite with the hash.
+func (s *InMemoryStore) Redeem(_ context.Context, hash string, at time.Time) (Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, invite := range s.invites {
+		if invite.Hash != hash {
+			continue
+		}
+		if !invite.Usable(at) {
+			return Invite{}, ErrInvalidCode
+		}
+		invite.Uses++
+		s.invites[id] = invite
+		return invite, nil
+	}
+	return Invite{}, ErrInvalidCode
+}
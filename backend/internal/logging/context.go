@@ -13,8 +13,17 @@ const (
 	requestIDKey ctxKey = "requestID"
 	traceIDKey   ctxKey = "traceID"
 	spanIDKey    ctxKey = "spanID"
+	userIDKey    ctxKey = "userID"
+	sessionIDKey ctxKey = "sessionID"
+	tokenKey     ctxKey = "apiToken"
 )
 
+// apiToken records the personal access token a request was authenticated with.
+type apiToken struct {
+	id     string
+	scopes []string
+}
+
 // WithLogger stores the provided logger on the context.
 func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
 	if ctx == nil || logger == nil {
@@ -90,3 +99,63 @@ func SpanIDFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+// WithUserID stores the authenticated user identifier on the context.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	if ctx == nil || userID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext retrieves the authenticated user identifier from the context.
+func UserIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if userID, ok := ctx.Value(userIDKey).(string); ok {
+		return userID
+	}
+	return ""
+}
+
+// WithSessionID stores the identifier of the session the request was authenticated with.
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	if ctx == nil || sessionID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// SessionIDFromContext retrieves the authenticated session identifier from the context.
+func SessionIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if sessionID, ok := ctx.Value(sessionIDKey).(string); ok {
+		return sessionID
+	}
+	return ""
+}
+
+// WithAPIToken records that the request was authenticated with the personal access token id,
+// which grants only the listed scopes.
+func WithAPIToken(ctx context.Context, tokenID string, scopes []string) context.Context {
+	if ctx == nil || tokenID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenKey, apiToken{id: tokenID, scopes: scopes})
+}
+
+// APITokenFromContext returns the personal access token the request was authenticated with and
+// its scopes. ok is false for requests authenticated with a session.
+func APITokenFromContext(ctx context.Context) (tokenID string, scopes []string, ok bool) {
+	if ctx == nil {
+		return "", nil, false
+	}
+	token, ok := ctx.Value(tokenKey).(apiToken)
+	if !ok {
+		return "", nil, false
+	}
+	return token.id, token.scopes, true
+}
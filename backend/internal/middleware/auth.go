@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/logging"
+)
+
+// Authenticator resolves a bearer credential to the user and session it was issued for.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (auth.Principal, error)
+}
+
+// RequireAuth rejects requests that lack a valid `Authorization: Bearer` token. Authenticated
+// requests continue with the user and session IDs stored on the context via logging.WithUserID
+// and logging.WithSessionID, or, for personal access tokens, the token recorded with
+// logging.WithAPIToken.
+func RequireAuth(authenticator Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			logger := logging.FromContext(ctx)
+
+			if authenticator == nil {
+				logger.Error("authenticator unavailable")
+				writeJSONError(w, http.StatusInternalServerError, "authentication services unavailable")
+				return
+			}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				logger.Warn("missing bearer token")
+				unauthorized(w)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(ctx, token)
+			if err != nil || principal.UserID == "" {
+				logger.Warn("bearer token rejected", "error", err)
+				unauthorized(w)
+				return
+			}
+
+			ctx = logging.WithUserID(ctx, principal.UserID)
+			ctx = logging.WithSessionID(ctx, principal.SessionID)
+			ctx = logging.WithAPIToken(ctx, principal.TokenID, principal.Scopes)
+			logger = logger.With(slog.String("user_id", principal.UserID))
+			if principal.TokenID != "" {
+				logger = logger.With(slog.String("api_token_id", principal.TokenID))
+			}
+			ctx = logging.WithLogger(ctx, logger)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects requests made with a personal access token that was not granted scope.
+// Session tokens may use every scope. It must run after RequireAuth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenID, scopes, ok := logging.APITokenFromContext(r.Context())
+			if ok && !slices.Contains(scopes, scope) {
+				logging.FromContext(r.Context()).Warn("api token missing scope", "tokenId", tokenID, "scope", scope)
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="vidfriends", error="insufficient_scope", scope=%q`, scope))
+				writeJSONError(w, http.StatusForbidden, "token lacks required scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects requests made with a personal access token. Routes that manage the
+// account itself, such as sessions, two-factor settings and the tokens themselves, use it so a
+// leaked token cannot be used to take the account over. It must run after RequireAuth.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokenID, _, ok := logging.APITokenFromContext(r.Context()); ok {
+			logging.FromContext(r.Context()).Warn("api token used for session-only route", "tokenId", tokenID, "path", r.URL.Path)
+			writeJSONError(w, http.StatusForbidden, "this endpoint requires a signed-in session")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="vidfriends"`)
+	writeJSONError(w, http.StatusUnauthorized, "authentication required")
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/logging"
+)
+
+type stubAuthenticator struct {
+	tokens map[string]string
+}
+
+func (s stubAuthenticator) Authenticate(_ context.Context, token string) (auth.Principal, error) {
+	if token == "vfp_feed" {
+		return auth.Principal{UserID: "user-1", TokenID: "token-1", Scopes: []string{auth.ScopeFeedRead}}, nil
+	}
+	userID, ok := s.tokens[token]
+	if !ok {
+		return auth.Principal{}, errors.New("unknown token")
+	}
+	return auth.Principal{UserID: userID, SessionID: "session-" + userID}, nil
+}
+
+func TestRequireAuth(t *testing.T) {
+	authenticator := stubAuthenticator{tokens: map[string]string{"good-token": "user-1"}}
+
+	var seenUser, seenSession string
+	handler := RequireAuth(authenticator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenUser = logging.UserIDFromContext(r.Context())
+		seenSession = logging.SessionIDFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/friends", nil)
+	req.Header.Set("Authorization", "Bearer good-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+	if seenUser != "user-1" {
+		t.Fatalf("expected user-1 on context got %q", seenUser)
+	}
+	if seenSession != "session-user-1" {
+		t.Fatalf("expected session-user-1 on context got %q", seenSession)
+	}
+}
+
+func TestRequireAuthRejectsUnauthenticated(t *testing.T) {
+	authenticator := stubAuthenticator{tokens: map[string]string{"good-token": "user-1"}}
+
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{"missingHeader", ""},
+		{"wrongScheme", "Basic good-token"},
+		{"emptyToken", "Bearer "},
+		{"unknownToken", "Bearer forged-token"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			handler := RequireAuth(authenticator)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/friends", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if called {
+				t.Fatal("expected next handler not to be called")
+			}
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status %d got %d", http.StatusUnauthorized, rec.Code)
+			}
+			if rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("expected WWW-Authenticate challenge header")
+			}
+		})
+	}
+}
+
+func TestRequireScopeAndSession(t *testing.T) {
+	authenticator := stubAuthenticator{tokens: map[string]string{"good-token": "user-1"}}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		token   string
+		want    int
+	}{
+		{"sessionHasEveryScope", RequireScope(auth.ScopeVideosWrite)(ok), "good-token", http.StatusNoContent},
+		{"tokenWithScope", RequireScope(auth.ScopeFeedRead)(ok), "vfp_feed", http.StatusNoContent},
+		{"tokenWithoutScope", RequireScope(auth.ScopeVideosWrite)(ok), "vfp_feed", http.StatusForbidden},
+		{"sessionOnlyAllowsSession", RequireSession(ok), "good-token", http.StatusNoContent},
+		{"sessionOnlyRejectsToken", RequireSession(ok), "vfp_feed", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/videos/feed", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+
+			RequireAuth(authenticator)(tc.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Fatalf("expected status %d got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
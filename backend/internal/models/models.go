@@ -2,16 +2,63 @@ package models
 
 import "time"
 
-// User represents an account within the VidFriends platform.
+// User represents an account within the VidFriends platform. EmailVerifiedAt is nil until the
+// user follows the verification link e-mailed to them.
 type User struct {
-	ID        string
+	ID              string
+	Email           string
+	Password        string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	EmailVerifiedAt *time.Time
+}
+
+// Profile is the public face of a user. Handles are unique and stored lowercase.
+type Profile struct {
+	UserID      string
+	Handle      string
+	DisplayName string
+	Bio         string
+	Avatar      Avatar
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Avatar locates the resized copies of a profile picture. Key is the storage prefix shared by
+// the copies and is empty when no picture was uploaded.
+type Avatar struct {
+	Key    string
+	Small  string
+	Medium string
+	Large  string
+}
+
+// ProfileSummary is the compact profile embedded next to user IDs in friend lists and the feed.
+// Handle and DisplayName are empty for users who have not set up a profile.
+type ProfileSummary struct {
+	UserID      string
+	Handle      string
+	DisplayName string
+	AvatarURL   string
+}
+
+// Summary returns the compact form of the profile using the smallest avatar.
+func (p Profile) Summary() ProfileSummary {
+	return ProfileSummary{UserID: p.UserID, Handle: p.Handle, DisplayName: p.DisplayName, AvatarURL: p.Avatar.Small}
+}
+
+// UserIdentity links an account at an external OpenID Connect provider to a user.
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    string
 	Email     string
-	Password  string
 	CreatedAt time.Time
-	UpdatedAt time.Time
 }
 
-// FriendRequest represents the invitation workflow between two users.
+// FriendRequest represents the invitation workflow between two users. There is at most one
+// request per pair of users; declined, cancelled and removed requests are reopened when either
+// user sends a new invite.
 type FriendRequest struct {
 	ID          string
 	Requester   string
@@ -19,6 +66,76 @@ type FriendRequest struct {
 	Status      string
 	CreatedAt   time.Time
 	RespondedAt *time.Time
+	// ActorID is the user who made the latest change, such as the user who blocked the other.
+	ActorID   string
+	UpdatedAt time.Time
+}
+
+// FriendTransition moves a friend request from one status to another on behalf of ActorID.
+type FriendTransition struct {
+	RequestID string
+	From      string
+	To        string
+	ActorID   string
+	At        time.Time
+	// Requester and Receiver are set when a closed request is reopened as a new invite, which
+	// may point the other way.
+	Requester string
+	Receiver  string
+}
+
+// FriendEvent records one change of a friend request. FromStatus is empty for the creation.
+type FriendEvent struct {
+	ID         string
+	RequestID  string
+	ActorID    string
+	FromStatus string
+	ToStatus   string
+	CreatedAt  time.Time
+}
+
+// FriendView selects one list of a user's friend requests.
+type FriendView string
+
+// Friend views.
+const (
+	// FriendViewAccepted lists the user's friends.
+	FriendViewAccepted FriendView = "accepted"
+	// FriendViewIncoming lists pending requests sent to the user.
+	FriendViewIncoming FriendView = "incoming"
+	// FriendViewOutgoing lists pending requests the user sent.
+	FriendViewOutgoing FriendView = "outgoing"
+	// FriendViewBlocked lists the users the user blocked.
+	FriendViewBlocked FriendView = "blocked"
+)
+
+// FriendConnection is one entry of a FriendView: the other user and when the entry began, such
+// as when a friendship was accepted or a request was sent.
+type FriendConnection struct {
+	RequestID string
+	UserID    string
+	Since     time.Time
+}
+
+// FriendCursor is the position after which the next page of a FriendView starts.
+type FriendCursor struct {
+	Since     time.Time
+	RequestID string
+}
+
+// FriendCounts holds the number of entries in each FriendView.
+type FriendCounts struct {
+	Accepted int
+	Incoming int
+	Outgoing int
+	Blocked  int
+}
+
+// FriendSuggestion is a friend of the user's friends who could be invited, together with the
+// number of friends they have in common.
+type FriendSuggestion struct {
+	UserID      string
+	MutualCount int
 }
 
 // VideoShare stores references to a shared video along with cached metadata.
@@ -29,10 +146,19 @@ type VideoShare struct {
 	Title       string
 	Description string
 	Thumbnail   string
+	// Note is the owner's own caption for the share.
+	Note string
+	// DurationMs is the video's length in milliseconds, or zero when its source did not report one.
+	DurationMs  int64
 	CreatedAt   time.Time
 	AssetURL    string
 	AssetStatus string
 	AssetSize   int64
+	// Visibility selects which friends see the share. CircleIDs and UserIDs hold the audience
+	// of VisibilityCircles and VisibilityUsers shares; feeds leave them empty.
+	Visibility string
+	CircleIDs  []string
+	UserIDs    []string
 }
 
 const (
@@ -41,6 +167,122 @@ const (
 	AssetStatusFailed  = "failed"
 )
 
+const (
+	// VisibilityFriends shares a video with every accepted friend of the owner.
+	VisibilityFriends = "friends"
+	// VisibilityCircles shares a video with the members of some of the owner's circles.
+	VisibilityCircles = "circles"
+	// VisibilityUsers shares a video with hand-picked friends.
+	VisibilityUsers = "users"
+)
+
+// FeedFilter narrows a user's feed. Zero fields match every share.
+type FeedFilter struct {
+	OwnerID     string
+	AssetStatus string
+	// Domain matches shares whose URL host is the domain or one of its subdomains.
+	Domain string
+	// Since and Before bound when shares were created. Since is inclusive and Before exclusive.
+	Since  *time.Time
+	Before *time.Time
+}
+
+// FeedCursor is the position after which the next page of a feed starts.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ShareID   string
+}
+
+// FriendMute keeps a friend's shares out of the user's feed until Until, or until the mute is
+// undone when Until is nil.
+type FriendMute struct {
+	UserID    string
+	MutedID   string
+	Until     *time.Time
+	CreatedAt time.Time
+}
+
+// HiddenShare is a share the user removed from their feed.
+type HiddenShare struct {
+	ShareID  string
+	OwnerID  string
+	URL      string
+	Title    string
+	HiddenAt time.Time
+}
+
+// Comment is a message about a share. Replies name the comment that started their thread in
+// ParentID; replies cannot be replied to. A deleted comment stays behind as a tombstone with an
+// empty Body so its replies keep their place.
+type Comment struct {
+	ID      string
+	ShareID string
+	// AuthorID is empty once the author's account has been deleted.
+	AuthorID string
+	ParentID string
+	Body     string
+	// OffsetMs anchors a comment that starts a thread to a position in the video, in milliseconds
+	// from its start. It is nil for comments about the share as a whole.
+	OffsetMs  *int64
+	CreatedAt time.Time
+	EditedAt  *time.Time
+	DeletedAt *time.Time
+	// ReplyCount is only filled in for comments that start a thread.
+	ReplyCount int
+}
+
+// CommentCursor is the position after which the next page of comments starts. OffsetMs is only
+// used when paging through comments in the order of their offsets.
+type CommentCursor struct {
+	OffsetMs  int64
+	CreatedAt time.Time
+	CommentID string
+}
+
+// CommentMarker counts the live comments anchored to one stretch of a video's timeline, starting
+// OffsetMs milliseconds into the video.
+type CommentMarker struct {
+	OffsetMs int64
+	Count    int
+}
+
+// Reaction kinds friends can respond to a share with. Clients render them as 👍, ❤️, 😂, 😮, 😢
+// and 🔥.
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionFire  = "fire"
+)
+
+// Reaction is a user's reaction of one kind to a share. A user may react to a share with several
+// kinds, but with each kind only once.
+type Reaction struct {
+	ShareID   string
+	UserID    string
+	Kind      string
+	CreatedAt time.Time
+}
+
+// ReactionSummary aggregates the reactions to a share for one viewer: how many users reacted with
+// each kind, and the kinds the viewer reacted with.
+type ReactionSummary struct {
+	Counts map[string]int
+	Mine   []string
+}
+
+// Circle is a named group of the owner's friends that videos can be shared with.
+type Circle struct {
+	ID        string
+	OwnerID   string
+	Name      string
+	MemberIDs []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 // SessionTokens groups the bearer credentials issued to authenticated users.
 type SessionTokens struct {
 	AccessToken      string
@@ -48,3 +290,14 @@ type SessionTokens struct {
 	RefreshToken     string
 	RefreshExpiresAt time.Time
 }
+
+// ActiveSession describes a signed-in device as shown to the account owner.
+type ActiveSession struct {
+	ID              string
+	CreatedAt       time.Time
+	LastRefreshedAt *time.Time
+	ExpiresAt       time.Time
+	UserAgent       string
+	ClientIP        string
+	Current         bool
+}
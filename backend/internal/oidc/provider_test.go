@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/vidfriends/backend/internal/config"
+	"github.com/vidfriends/backend/internal/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	t.Helper()
+	issuer := oidctest.NewIssuer(t, "vidfriends", "client-secret")
+	provider := NewProvider(config.OIDCProviderConfig{
+		Name:         "test",
+		IssuerURL:    issuer.URL,
+		ClientID:     "vidfriends",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/test/callback",
+		Scopes:       []string{"openid", "email"},
+	}, issuer.Client())
+	return provider, issuer
+}
+
+// authorize follows the authorization URL and returns the code and state sent to the callback.
+func authorize(t *testing.T, issuer *oidctest.Issuer, authURL string) (string, string) {
+	t.Helper()
+	client := issuer.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected redirect from authorize, got %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func startFlow(t *testing.T, provider *Provider, issuer *oidctest.Issuer) (code, verifier, nonce string) {
+	t.Helper()
+	verifier, _ = RandomValue()
+	nonce, _ = RandomValue()
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, verifier)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+	if strings.Contains(authURL, verifier) {
+		t.Fatal("expected only the PKCE challenge to be sent")
+	}
+
+	code, state := authorize(t, issuer, authURL)
+	if state != "state-1" {
+		t.Fatalf("expected state to round trip, got %q", state)
+	}
+	return code, verifier, nonce
+}
+
+func TestProviderExchange(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	issuer.Name = "Alice"
+
+	code, verifier, nonce := startFlow(t, provider, issuer)
+	identity, err := provider.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	want := Identity{Subject: "subject-1", Email: "oidc-user@example.com", EmailVerified: true, Name: "Alice"}
+	if identity != want {
+		t.Fatalf("expected %+v got %+v", want, identity)
+	}
+
+	if _, err := provider.Exchange(context.Background(), code, verifier, nonce); !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("expected code to be single use, got %v", err)
+	}
+}
+
+func TestProviderExchangeRejectsWrongVerifier(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+
+	code, _, nonce := startFlow(t, provider, issuer)
+	other, _ := RandomValue()
+	if _, err := provider.Exchange(context.Background(), code, other, nonce); !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("expected PKCE mismatch to fail, got %v", err)
+	}
+}
+
+func TestProviderExchangeRejectsInvalidTokens(t *testing.T) {
+	cases := map[string]struct {
+		mutate func(claims map[string]any)
+		nonce  string
+	}{
+		"nonce mismatch":   {nonce: "other-nonce"},
+		"wrong audience":   {mutate: func(c map[string]any) { c["aud"] = "someone-else" }},
+		"wrong issuer":     {mutate: func(c map[string]any) { c["iss"] = "https://evil.example.com" }},
+		"expired":          {mutate: func(c map[string]any) { c["exp"] = int64(1000) }},
+		"missing subject":  {mutate: func(c map[string]any) { c["sub"] = "" }},
+		"multi aud no azp": {mutate: func(c map[string]any) { c["aud"] = []string{"vidfriends", "other"} }},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			provider, issuer := newTestProvider(t)
+			issuer.MutateClaims = tc.mutate
+
+			code, verifier, nonce := startFlow(t, provider, issuer)
+			if tc.nonce != "" {
+				nonce = tc.nonce
+			}
+			if _, err := provider.Exchange(context.Background(), code, verifier, nonce); !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("expected invalid id token, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProviderPicksUpRotatedKeys(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+
+	code, verifier, nonce := startFlow(t, provider, issuer)
+	if _, err := provider.Exchange(context.Background(), code, verifier, nonce); err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	issuer.RotateKey(t, "rotated-key")
+	provider.keysFetchedAt = provider.keysFetchedAt.Add(-2 * minKeyRefreshInterval)
+
+	code, verifier, nonce = startFlow(t, provider, issuer)
+	if _, err := provider.Exchange(context.Background(), code, verifier, nonce); err != nil {
+		t.Fatalf("exchange after rotation: %v", err)
+	}
+}
+
+func TestProviderDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"issuer":"https://evil.example.com","authorization_endpoint":"https://evil.example.com/a","token_endpoint":"https://evil.example.com/t","jwks_uri":"https://evil.example.com/k"}`))
+	}))
+	defer server.Close()
+
+	provider := NewProvider(config.OIDCProviderConfig{Name: "test", IssuerURL: server.URL, ClientID: "vidfriends"}, server.Client())
+	if _, err := provider.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected discovery to reject a mismatched issuer, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package profiles
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder for uploads
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder for uploads
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/vidfriends/backend/internal/models"
+	"github.com/vidfriends/backend/internal/videos"
+)
+
+var (
+	// ErrUnsupportedImage indicates an upload is not a JPEG, PNG or GIF image.
+	ErrUnsupportedImage = errors.New("unsupported image format")
+	// ErrImageTooLarge indicates an upload exceeds MaxAvatarBytes or MaxAvatarDimension.
+	ErrImageTooLarge = errors.New("image too large")
+)
+
+const (
+	// MaxAvatarBytes bounds the size of an uploaded avatar.
+	MaxAvatarBytes = 5 << 20
+	// MaxAvatarDimension bounds the width and height of an uploaded avatar so a small file cannot
+	// decode into a huge bitmap.
+	MaxAvatarDimension = 4096
+	// avatarQuality is the JPEG quality of the resized copies.
+	avatarQuality = 85
+)
+
+// Avatar sizes in pixels. Every upload is cropped to a centred square and stored at each size.
+const (
+	AvatarSmall  = 64
+	AvatarMedium = 256
+	AvatarLarge  = 512
+)
+
+// AvatarStorage persists avatar images. storage.S3Storage implements it.
+type AvatarStorage interface {
+	videos.AssetStorage
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// AvatarPrefix is the storage prefix holding every avatar a user uploaded.
+func AvatarPrefix(userID string) string {
+	return "avatars/" + userID + "/"
+}
+
+// Avatars resizes uploaded profile pictures and stores the copies.
+type Avatars struct {
+	storage AvatarStorage
+}
+
+// NewAvatars constructs an Avatars service backed by storage.
+func NewAvatars(storage AvatarStorage) *Avatars {
+	if storage == nil {
+		panic("profiles: avatar storage must not be nil")
+	}
+	return &Avatars{storage: storage}
+}
+
+// Store decodes the image in r, saves a copy at each avatar size under a fresh key and returns
+// their locations. Earlier uploads are left in place; remove them with Delete once the profile
+// points at the new copies.
+func (a *Avatars) Store(ctx context.Context, userID string, r io.Reader) (models.Avatar, error) {
+	src, err := decodeAvatar(r)
+	if err != nil {
+		return models.Avatar{}, err
+	}
+
+	avatar := models.Avatar{Key: AvatarPrefix(userID) + uuid.NewString() + "/"}
+	for _, size := range []struct {
+		pixels int
+		dest   *string
+	}{
+		{AvatarSmall, &avatar.Small},
+		{AvatarMedium, &avatar.Medium},
+		{AvatarLarge, &avatar.Large},
+	} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resizeSquare(src, size.pixels), &jpeg.Options{Quality: avatarQuality}); err != nil {
+			return models.Avatar{}, fmt.Errorf("encode %dpx avatar: %w", size.pixels, err)
+		}
+		location, err := a.storage.Save(ctx, fmt.Sprintf("%s%d.jpg", avatar.Key, size.pixels), &buf)
+		if err != nil {
+			_ = a.storage.DeletePrefix(ctx, avatar.Key)
+			return models.Avatar{}, fmt.Errorf("store %dpx avatar: %w", size.pixels, err)
+		}
+		*size.dest = location
+	}
+	return avatar, nil
+}
+
+// Delete removes the copies stored under key. An empty key is ignored.
+func (a *Avatars) Delete(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+	return a.storage.DeletePrefix(ctx, key)
+}
+
+func decodeAvatar(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxAvatarBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read avatar: %w", err)
+	}
+	if len(data) > MaxAvatarBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width > MaxAvatarDimension || cfg.Height > MaxAvatarDimension {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	return img, nil
+}
+
+// resizeSquare crops the centred square of src and scales it to size×size by averaging the source
+// pixels each destination pixel covers. Transparent areas are flattened onto white because JPEG
+// has no alpha channel.
+func resizeSquare(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	// Flatten the crop onto white once so the averaging below works on opaque RGBA pixels.
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), src, image.Point{X: x0, Y: y0}, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0, sy1 := sourceSpan(dy, size, side)
+		for dx := 0; dx < size; dx++ {
+			sx0, sx1 := sourceSpan(dx, size, side)
+
+			var r, g, bl, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				row := square.Pix[sy*square.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4:]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					bl += uint32(p[2])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// sourceSpan returns the half-open range of source pixels covered by destination pixel d when
+// scaling src pixels to dst pixels. The range always holds at least one pixel so upscaling works.
+func sourceSpan(d, dst, src int) (int, int) {
+	start := d * src / dst
+	end := (d + 1) * src / dst
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
@@ -0,0 +1,147 @@
+package profiles
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+type avatarStorageStub struct {
+	saved   map[string][]byte
+	deleted []string
+	err     error
+}
+
+func (s *avatarStorageStub) Save(_ context.Context, name string, r io.Reader) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if s.saved == nil {
+		s.saved = make(map[string][]byte)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	s.saved[name] = data
+	return "https://cdn.example.com/" + name, nil
+}
+
+func (s *avatarStorageStub) DeletePrefix(_ context.Context, prefix string) error {
+	s.deleted = append(s.deleted, prefix)
+	for name := range s.saved {
+		if strings.HasPrefix(name, prefix) {
+			delete(s.saved, name)
+		}
+	}
+	return nil
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 40, B: 40, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAvatarsStoreResizesToEverySize(t *testing.T) {
+	storage := &avatarStorageStub{}
+	avatars := NewAvatars(storage)
+
+	avatar, err := avatars.Store(context.Background(), "user-1", bytes.NewReader(encodePNG(t, 300, 200)))
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	if !strings.HasPrefix(avatar.Key, AvatarPrefix("user-1")) || !strings.HasSuffix(avatar.Key, "/") {
+		t.Fatalf("expected key under the user's prefix, got %q", avatar.Key)
+	}
+	if len(storage.saved) != 3 {
+		t.Fatalf("expected three copies, got %d", len(storage.saved))
+	}
+
+	for size, location := range map[int]string{AvatarSmall: avatar.Small, AvatarMedium: avatar.Medium, AvatarLarge: avatar.Large} {
+		name := strings.TrimPrefix(location, "https://cdn.example.com/")
+		data, ok := storage.saved[name]
+		if !ok {
+			t.Fatalf("expected %dpx copy at %q", size, name)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decode %dpx copy: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Fatalf("expected %dx%d copy, got %dx%d", size, size, b.Dx(), b.Dy())
+		}
+		r, g, _, _ := img.At(size/2, size/2).RGBA()
+		if r>>8 < 150 || g>>8 > 90 {
+			t.Fatalf("expected resized copy to keep the source colour, got r=%d g=%d", r>>8, g>>8)
+		}
+	}
+}
+
+func TestAvatarsStoreRejectsInvalidImages(t *testing.T) {
+	storage := &avatarStorageStub{}
+	avatars := NewAvatars(storage)
+
+	if _, err := avatars.Store(context.Background(), "user-1", strings.NewReader("not an image")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Fatalf("expected ErrUnsupportedImage, got %v", err)
+	}
+
+	if _, err := avatars.Store(context.Background(), "user-1", bytes.NewReader(encodePNG(t, MaxAvatarDimension+1, 1))); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("expected oversized dimensions to be rejected, got %v", err)
+	}
+
+	if _, err := avatars.Store(context.Background(), "user-1", bytes.NewReader(make([]byte, MaxAvatarBytes+1))); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("expected oversized upload to be rejected, got %v", err)
+	}
+
+	if len(storage.saved) != 0 {
+		t.Fatalf("expected nothing to be stored, got %d files", len(storage.saved))
+	}
+}
+
+func TestAvatarsStoreCleansUpAfterStorageFailure(t *testing.T) {
+	storage := &avatarStorageStub{err: errors.New("bucket unavailable")}
+	avatars := NewAvatars(storage)
+
+	if _, err := avatars.Store(context.Background(), "user-1", bytes.NewReader(encodePNG(t, 10, 10))); err == nil {
+		t.Fatal("expected storage failure to be reported")
+	}
+	if len(storage.deleted) != 1 || !strings.HasPrefix(storage.deleted[0], AvatarPrefix("user-1")) {
+		t.Fatalf("expected partial upload to be removed, got %v", storage.deleted)
+	}
+}
+
+func TestAvatarsDeleteIgnoresEmptyKey(t *testing.T) {
+	storage := &avatarStorageStub{}
+	avatars := NewAvatars(storage)
+
+	if err := avatars.Delete(context.Background(), ""); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(storage.deleted) != 0 {
+		t.Fatalf("expected empty key to be ignored, got %v", storage.deleted)
+	}
+	if err := avatars.Delete(context.Background(), "avatars/user-1/abc/"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(storage.deleted) != 1 || storage.deleted[0] != "avatars/user-1/abc/" {
+		t.Fatalf("expected key to be deleted, got %v", storage.deleted)
+	}
+}
@@ -0,0 +1,88 @@
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidHandle indicates a handle does not follow the allowed format or is reserved.
+	ErrInvalidHandle = errors.New("invalid handle")
+	// ErrInvalidProfile indicates a display name or bio is too long or contains control characters.
+	ErrInvalidProfile = errors.New("invalid profile")
+)
+
+// Profile field limits.
+const (
+	MinHandleLength      = 3
+	MaxHandleLength      = 30
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 300
+)
+
+// MinHandlePrefixLength is the shortest partial handle accepted by user search.
+const MinHandlePrefixLength = 2
+
+// reservedHandles would be confusing in URLs or could be mistaken for staff accounts.
+var reservedHandles = map[string]struct{}{
+	"admin": {}, "administrator": {}, "api": {}, "me": {}, "root": {}, "search": {}, "settings": {},
+	"staff": {}, "support": {}, "system": {}, "vidfriends": {},
+}
+
+// NormalizeHandle lowercases handle, strips a leading "@" and checks it is 3 to 30 characters of
+// a-z, 0-9 and underscore and not reserved.
+func NormalizeHandle(handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength {
+		return "", fmt.Errorf("%w: must be between %d and %d characters", ErrInvalidHandle, MinHandleLength, MaxHandleLength)
+	}
+	if !validHandleChars(handle) {
+		return "", fmt.Errorf("%w: may only contain letters, digits and underscores", ErrInvalidHandle)
+	}
+	if _, reserved := reservedHandles[handle]; reserved {
+		return "", fmt.Errorf("%w: %q is reserved", ErrInvalidHandle, handle)
+	}
+	return handle, nil
+}
+
+// NormalizeHandlePrefix normalizes a partially typed handle like NormalizeHandle but accepts
+// anything from MinHandlePrefixLength characters, including the start of reserved handles.
+func NormalizeHandlePrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(prefix), "@"))
+	if len(prefix) < MinHandlePrefixLength || len(prefix) > MaxHandleLength {
+		return "", fmt.Errorf("%w: search needs between %d and %d characters", ErrInvalidHandle, MinHandlePrefixLength, MaxHandleLength)
+	}
+	if !validHandleChars(prefix) {
+		return "", fmt.Errorf("%w: may only contain letters, digits and underscores", ErrInvalidHandle)
+	}
+	return prefix, nil
+}
+
+func validHandleChars(handle string) bool {
+	for _, c := range handle {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeText trims s and checks it has at most max characters and no control characters other
+// than newlines, which are only allowed when multiline is set.
+func NormalizeText(field, s string, max int, multiline bool) (string, error) {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) > max {
+		return "", fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidProfile, field, max)
+	}
+	for _, c := range s {
+		if c == '\n' && multiline {
+			continue
+		}
+		if c < 0x20 || c == 0x7f {
+			return "", fmt.Errorf("%w: %s contains control characters", ErrInvalidProfile, field)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,73 @@
+package profiles
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHandle(t *testing.T) {
+	valid := map[string]string{
+		"alice":                              "alice",
+		"@Alice_99":                          "alice_99",
+		"  bob  ":                            "bob",
+		"abc":                                "abc",
+		strings.Repeat("x", MaxHandleLength): strings.Repeat("x", MaxHandleLength),
+	}
+	for input, want := range valid {
+		got, err := NormalizeHandle(input)
+		if err != nil {
+			t.Fatalf("NormalizeHandle(%q): %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("NormalizeHandle(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"", "ab", strings.Repeat("x", MaxHandleLength+1), "al ice", "al-ice", "ålice", "admin", "@Me"} {
+		if _, err := NormalizeHandle(input); !errors.Is(err, ErrInvalidHandle) {
+			t.Fatalf("NormalizeHandle(%q): expected ErrInvalidHandle, got %v", input, err)
+		}
+	}
+}
+
+func TestNormalizeHandlePrefix(t *testing.T) {
+	for input, want := range map[string]string{"al": "al", "@Ad": "ad", "admin": "admin", " bo_ ": "bo_"} {
+		got, err := NormalizeHandlePrefix(input)
+		if err != nil {
+			t.Fatalf("NormalizeHandlePrefix(%q): %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("NormalizeHandlePrefix(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"", "a", "@b", "a%", strings.Repeat("x", MaxHandleLength+1)} {
+		if _, err := NormalizeHandlePrefix(input); !errors.Is(err, ErrInvalidHandle) {
+			t.Fatalf("NormalizeHandlePrefix(%q): expected ErrInvalidHandle, got %v", input, err)
+		}
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	got, err := NormalizeText("bio", "  line one\nline two  ", MaxBioLength, true)
+	if err != nil {
+		t.Fatalf("NormalizeText: %v", err)
+	}
+	if got != "line one\nline two" {
+		t.Fatalf("expected trimmed bio, got %q", got)
+	}
+
+	if _, err := NormalizeText("displayName", "two\nlines", MaxDisplayNameLength, false); !errors.Is(err, ErrInvalidProfile) {
+		t.Fatalf("expected newline to be rejected in single-line fields, got %v", err)
+	}
+	if _, err := NormalizeText("bio", "bell\a", MaxBioLength, true); !errors.Is(err, ErrInvalidProfile) {
+		t.Fatalf("expected control characters to be rejected, got %v", err)
+	}
+	if _, err := NormalizeText("displayName", strings.Repeat("é", MaxDisplayNameLength), MaxDisplayNameLength, false); err != nil {
+		t.Fatalf("expected length to be counted in characters, got %v", err)
+	}
+	if _, err := NormalizeText("displayName", strings.Repeat("é", MaxDisplayNameLength+1), MaxDisplayNameLength, false); !errors.Is(err, ErrInvalidProfile) {
+		t.Fatalf("expected overlong value to be rejected, got %v", err)
+	}
+}
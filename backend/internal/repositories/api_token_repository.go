@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/db"
+)
+
+// PostgresAPITokenStore persists personal access tokens to PostgreSQL.
+type PostgresAPITokenStore struct {
+	pool db.Pool
+}
+
+// NewPostgresAPITokenStore constructs an API token store backed by PostgreSQL.
+func NewPostgresAPITokenStore(pool db.Pool) *PostgresAPITokenStore {
+	return &PostgresAPITokenStore{pool: pool}
+}
+
+const apiTokenColumns = `id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at`
+
+// Create stores a new token.
+func (s *PostgresAPITokenStore) Create(ctx context.Context, token auth.APIToken) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `
+        INSERT INTO api_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, token.ID, token.UserID, token.Name, token.Hash, token.Scopes, nullableTime(token.ExpiresAt), token.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("insert api token: %w", err)
+	}
+	return nil
+}
+
+// FindByHash returns the token with the hash.
+func (s *PostgresAPITokenStore) FindByHash(ctx context.Context, hash string) (auth.APIToken, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return auth.APIToken{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	token, err := scanAPIToken(conn.QueryRow(ctx, `SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_hash = $1`, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.APIToken{}, auth.ErrAPITokenNotFound
+		}
+		return auth.APIToken{}, fmt.Errorf("select api token: %w", err)
+	}
+	return token, nil
+}
+
+// Find returns one of the user's tokens.
+func (s *PostgresAPITokenStore) Find(ctx context.Context, userID, id string) (auth.APIToken, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return auth.APIToken{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	token, err := scanAPIToken(conn.QueryRow(ctx, `SELECT `+apiTokenColumns+` FROM api_tokens WHERE id = $1 AND user_id = $2`, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.APIToken{}, auth.ErrAPITokenNotFound
+		}
+		return auth.APIToken{}, fmt.Errorf("select api token: %w", err)
+	}
+	return token, nil
+}
+
+// ListForUser returns the user's tokens, newest first.
+func (s *PostgresAPITokenStore) ListForUser(ctx context.Context, userID string) ([]auth.APIToken, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT `+apiTokenColumns+`
+        FROM api_tokens
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []auth.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// Rename changes the label of one of the user's tokens.
+func (s *PostgresAPITokenStore) Rename(ctx context.Context, userID, id, name string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `UPDATE api_tokens SET name = $3 WHERE id = $1 AND user_id = $2`, id, userID, name)
+	if err != nil {
+		return fmt.Errorf("rename api token: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Delete removes one of the user's tokens.
+func (s *PostgresAPITokenStore) Delete(ctx context.Context, userID, id string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `DELETE FROM api_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete api token: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// DeleteForUser removes every token belonging to the user.
+func (s *PostgresAPITokenStore) DeleteForUser(ctx context.Context, userID string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `DELETE FROM api_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete user api tokens: %w", err)
+	}
+	return nil
+}
+
+// TouchLastUsed records when the token was last used.
+func (s *PostgresAPITokenStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `UPDATE api_tokens SET last_used_at = $2 WHERE id = $1`, id, at.UTC()); err != nil {
+		return fmt.Errorf("touch api token: %w", err)
+	}
+	return nil
+}
+
+func scanAPIToken(row pgx.Row) (auth.APIToken, error) {
+	var token auth.APIToken
+	var expiresAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &token.Scopes, &expiresAt, &lastUsedAt, &token.CreatedAt); err != nil {
+		return auth.APIToken{}, err
+	}
+	token.ExpiresAt = timePtr(expiresAt)
+	token.LastUsedAt = timePtr(lastUsedAt)
+	token.CreatedAt = token.CreatedAt.UTC()
+	return token, nil
+}
+
+var _ auth.APITokenStore = (*PostgresAPITokenStore)(nil)
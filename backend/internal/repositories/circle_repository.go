@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// PostgresCircleRepository persists friend circles.
+type PostgresCircleRepository struct {
+	pool db.Pool
+}
+
+// NewPostgresCircleRepository constructs a circle repository backed by PostgreSQL.
+func NewPostgresCircleRepository(pool db.Pool) *PostgresCircleRepository {
+	return &PostgresCircleRepository{pool: pool}
+}
+
+const circleSelect = `
+        SELECT c.id, c.owner_id, c.name, c.created_at, c.updated_at,
+            COALESCE(array_agg(cm.member_id::TEXT ORDER BY cm.member_id) FILTER (WHERE cm.member_id IS NOT NULL), '{}')
+        FROM circles c
+        LEFT JOIN circle_members cm ON cm.circle_id = c.id
+`
+
+const circleGroupBy = `GROUP BY c.id, c.owner_id, c.name, c.created_at, c.updated_at`
+
+// Create stores a new circle with its members. Member IDs must be unique; it returns
+// ErrNotFriends when a member is not an accepted friend of the owner and ErrConflict when the
+// owner already has a circle with the same name, ignoring case.
+func (r *PostgresCircleRepository) Create(ctx context.Context, circle models.Circle) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin circle create: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO circles (id, owner_id, name, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, circle.ID, circle.OwnerID, circle.Name, circle.CreatedAt.UTC(), circle.UpdatedAt.UTC())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrConflict
+		}
+		return fmt.Errorf("insert circle: %w", err)
+	}
+
+	if err := insertCircleMembers(ctx, tx, circle); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit circle create: %w", err)
+	}
+	return nil
+}
+
+// ListForOwner returns the user's circles ordered by name.
+func (r *PostgresCircleRepository) ListForOwner(ctx context.Context, ownerID string) ([]models.Circle, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, circleSelect+`
+        WHERE c.owner_id = $1
+        `+circleGroupBy+`
+        ORDER BY lower(c.name)
+    `, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("list circles: %w", err)
+	}
+	defer rows.Close()
+
+	var circles []models.Circle
+	for rows.Next() {
+		circle, err := scanCircle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan circle: %w", err)
+		}
+		circles = append(circles, circle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate circles: %w", err)
+	}
+	return circles, nil
+}
+
+// FindByID returns one of the owner's circles.
+func (r *PostgresCircleRepository) FindByID(ctx context.Context, ownerID, circleID string) (models.Circle, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.Circle{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	circle, err := scanCircle(conn.QueryRow(ctx, circleSelect+`
+        WHERE c.id = $1 AND c.owner_id = $2
+        `+circleGroupBy, circleID, ownerID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Circle{}, ErrNotFound
+		}
+		return models.Circle{}, fmt.Errorf("select circle: %w", err)
+	}
+	return circle, nil
+}
+
+// Update renames one of the owner's circles and replaces its members, with the same rules as
+// Create.
+func (r *PostgresCircleRepository) Update(ctx context.Context, circle models.Circle) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin circle update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE circles
+        SET name = $3, updated_at = $4
+        WHERE id = $1 AND owner_id = $2
+    `, circle.ID, circle.OwnerID, circle.Name, circle.UpdatedAt.UTC())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrConflict
+		}
+		return fmt.Errorf("update circle: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM circle_members WHERE circle_id = $1`, circle.ID); err != nil {
+		return fmt.Errorf("clear circle members: %w", err)
+	}
+	if err := insertCircleMembers(ctx, tx, circle); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit circle update: %w", err)
+	}
+	return nil
+}
+
+// Delete removes one of the owner's circles. Shares limited to the circle are no longer shown to
+// its members.
+func (r *PostgresCircleRepository) Delete(ctx context.Context, ownerID, circleID string) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `DELETE FROM circles WHERE id = $1 AND owner_id = $2`, circleID, ownerID)
+	if err != nil {
+		return fmt.Errorf("delete circle: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// insertCircleMembers adds the circle's members, skipping anyone who is not an accepted friend of
+// the owner and failing with ErrNotFriends if that skipped someone.
+func insertCircleMembers(ctx context.Context, tx pgx.Tx, circle models.Circle) error {
+	if len(circle.MemberIDs) == 0 {
+		return nil
+	}
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO circle_members (circle_id, member_id)
+        SELECT $1, m.id
+        FROM unnest($3::UUID[]) AS m(id)
+        WHERE EXISTS (
+            SELECT 1 FROM friend_requests fr
+            WHERE fr.status = 'accepted'
+              AND ((fr.requester_id = $2 AND fr.receiver_id = m.id) OR (fr.receiver_id = $2 AND fr.requester_id = m.id))
+        )
+    `, circle.ID, circle.OwnerID, circle.MemberIDs)
+	if err != nil {
+		return fmt.Errorf("insert circle members: %w", err)
+	}
+	if tag.RowsAffected() != int64(len(circle.MemberIDs)) {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+func scanCircle(row pgx.Row) (models.Circle, error) {
+	var circle models.Circle
+	if err := row.Scan(&circle.ID, &circle.OwnerID, &circle.Name, &circle.CreatedAt, &circle.UpdatedAt, &circle.MemberIDs); err != nil {
+		return models.Circle{}, err
+	}
+	circle.CreatedAt = circle.CreatedAt.UTC()
+	circle.UpdatedAt = circle.UpdatedAt.UTC()
+	return circle, nil
+}
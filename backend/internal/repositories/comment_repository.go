@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// CommentRepository stores the comments on video shares. It does not check who may see a share;
+// callers do that before reading or writing its comments.
+type CommentRepository interface {
+	// Create returns ErrNotFound when the share does not exist or the parent is not a live
+	// comment starting a thread on the same share.
+	Create(ctx context.Context, comment models.Comment) error
+	FindByID(ctx context.Context, shareID, commentID string) (models.Comment, error)
+	// ListThreads returns up to limit comments starting threads on the share, oldest first,
+	// starting after the cursor when one is given.
+	ListThreads(ctx context.Context, shareID string, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	// ListReplies pages through the replies to a comment like ListThreads.
+	ListReplies(ctx context.Context, shareID, parentID string, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	// ListByOffset pages through the comments starting threads whose offset lies in
+	// [fromMs, toMs), in the order of their offsets. The cursor's OffsetMs must be set.
+	ListByOffset(ctx context.Context, shareID string, fromMs, toMs int64, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	// CountByOffset counts the live comments starting threads in each bucketMs-long stretch of the
+	// video that has any, in timeline order.
+	CountByOffset(ctx context.Context, shareID string, bucketMs int64) ([]models.CommentMarker, error)
+	// Update replaces the body of one of the author's comments and sets EditedAt. Deleted comments
+	// cannot be edited and return ErrNotFound.
+	Update(ctx context.Context, comment models.Comment) error
+	// Delete turns a comment into a tombstone. Deleting a comment twice returns ErrNotFound.
+	Delete(ctx context.Context, shareID, commentID string, at time.Time) error
+}
+
+// PostgresCommentRepository persists comments on video shares.
+type PostgresCommentRepository struct {
+	pool db.Pool
+}
+
+// NewPostgresCommentRepository constructs a comment repository backed by PostgreSQL.
+func NewPostgresCommentRepository(pool db.Pool) *PostgresCommentRepository {
+	return &PostgresCommentRepository{pool: pool}
+}
+
+// Create stores a new comment or reply.
+func (r *PostgresCommentRepository) Create(ctx context.Context, comment models.Comment) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var parentID any
+	if comment.ParentID != "" {
+		parentID = comment.ParentID
+	}
+
+	tag, err := conn.Exec(ctx, `
+        INSERT INTO share_comments (id, share_id, author_id, parent_id, body, offset_ms, created_at)
+        SELECT $1, $2, $3, $4::UUID, $5, $6, $7
+        WHERE $4::UUID IS NULL OR EXISTS (
+            SELECT 1 FROM share_comments p
+            WHERE p.id = $4::UUID AND p.share_id = $2 AND p.parent_id IS NULL AND p.deleted_at IS NULL
+        )
+    `, comment.ID, comment.ShareID, comment.AuthorID, parentID, comment.Body, comment.OffsetMs, comment.CreatedAt.UTC())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23503":
+				return ErrNotFound
+			case "23505":
+				return ErrConflict
+			}
+		}
+		return fmt.Errorf("insert comment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindByID returns a comment on the share, including tombstones.
+func (r *PostgresCommentRepository) FindByID(ctx context.Context, shareID, commentID string) (models.Comment, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	comment, err := scanComment(conn.QueryRow(ctx, `
+        SELECT `+commentColumns+`
+        FROM share_comments c
+        WHERE c.id = $1 AND c.share_id = $2
+    `, commentID, shareID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Comment{}, ErrNotFound
+		}
+		return models.Comment{}, fmt.Errorf("query comment: %w", err)
+	}
+	return comment, nil
+}
+
+// ListThreads returns the comments starting threads on the share with their reply counts.
+func (r *PostgresCommentRepository) ListThreads(ctx context.Context, shareID string, after *models.CommentCursor, limit int) ([]models.Comment, error) {
+	return r.list(ctx, "c.share_id = $1 AND c.parent_id IS NULL", []any{shareID}, after, false, limit)
+}
+
+// ListReplies returns the replies to a comment on the share.
+func (r *PostgresCommentRepository) ListReplies(ctx context.Context, shareID, parentID string, after *models.CommentCursor, limit int) ([]models.Comment, error) {
+	return r.list(ctx, "c.share_id = $1 AND c.parent_id = $2", []any{shareID, parentID}, after, false, limit)
+}
+
+// ListByOffset returns the comments anchored to a stretch of the video with their reply counts.
+func (r *PostgresCommentRepository) ListByOffset(ctx context.Context, shareID string, fromMs, toMs int64, after *models.CommentCursor, limit int) ([]models.Comment, error) {
+	return r.list(ctx, "c.share_id = $1 AND c.parent_id IS NULL AND c.offset_ms >= $2 AND c.offset_ms < $3", []any{shareID, fromMs, toMs}, after, true, limit)
+}
+
+// list pages through the comments matching filter oldest first, or in the order of their offsets
+// when byOffset is set.
+func (r *PostgresCommentRepository) list(ctx context.Context, filter string, args []any, after *models.CommentCursor, byOffset bool, limit int) ([]models.Comment, error) {
+	args = append(args, limit)
+	limitParam := len(args)
+	order := "c.created_at, c.id"
+	if byOffset {
+		order = "c.offset_ms, c.created_at, c.id"
+	}
+	if after != nil {
+		if byOffset {
+			args = append(args, after.OffsetMs, after.CreatedAt.UTC(), after.CommentID)
+			filter += fmt.Sprintf(" AND (c.offset_ms, c.created_at, c.id) > ($%d::INT8, $%d::TIMESTAMPTZ, $%d::UUID)", len(args)-2, len(args)-1, len(args))
+		} else {
+			args = append(args, after.CreatedAt.UTC(), after.CommentID)
+			filter += fmt.Sprintf(" AND (c.created_at, c.id) > ($%d::TIMESTAMPTZ, $%d::UUID)", len(args)-1, len(args))
+		}
+	}
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+        SELECT `+commentColumns+`
+        FROM share_comments c
+        WHERE %s
+        ORDER BY %s
+        LIMIT $%d
+    `, filter, order, limitParam), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate comments: %w", err)
+	}
+	return comments, nil
+}
+
+// CountByOffset returns how many live comments are anchored to each stretch of the video.
+func (r *PostgresCommentRepository) CountByOffset(ctx context.Context, shareID string, bucketMs int64) ([]models.CommentMarker, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// offset_ms - offset_ms % bucket rounds down in both PostgreSQL and CockroachDB, whose "/"
+	// on integers returns a decimal.
+	rows, err := conn.Query(ctx, `
+        SELECT c.offset_ms - c.offset_ms % $2::INT8 AS bucket, COUNT(*)
+        FROM share_comments c
+        WHERE c.share_id = $1 AND c.parent_id IS NULL AND c.offset_ms IS NOT NULL AND c.deleted_at IS NULL
+        GROUP BY bucket
+        ORDER BY bucket
+    `, shareID, bucketMs)
+	if err != nil {
+		return nil, fmt.Errorf("count comments by offset: %w", err)
+	}
+	defer rows.Close()
+
+	var markers []models.CommentMarker
+	for rows.Next() {
+		var marker models.CommentMarker
+		if err := rows.Scan(&marker.OffsetMs, &marker.Count); err != nil {
+			return nil, fmt.Errorf("scan comment marker: %w", err)
+		}
+		markers = append(markers, marker)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate comment markers: %w", err)
+	}
+	return markers, nil
+}
+
+// Update stores the comment's new body.
+func (r *PostgresCommentRepository) Update(ctx context.Context, comment models.Comment) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	editedAt := comment.CreatedAt
+	if comment.EditedAt != nil {
+		editedAt = *comment.EditedAt
+	}
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE share_comments SET body = $4, edited_at = $5
+        WHERE id = $1 AND share_id = $2 AND author_id = $3 AND deleted_at IS NULL
+    `, comment.ID, comment.ShareID, comment.AuthorID, comment.Body, editedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("update comment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete clears the comment's body and marks it deleted.
+func (r *PostgresCommentRepository) Delete(ctx context.Context, shareID, commentID string, at time.Time) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE share_comments SET body = '', deleted_at = $3
+        WHERE id = $1 AND share_id = $2 AND deleted_at IS NULL
+    `, commentID, shareID, at.UTC())
+	if err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// commentColumns selects share_comments rows c in the order scanComment reads them. The author of
+// a deleted account reads as empty. Replies have no replies of their own, so their count is always
+// zero.
+const commentColumns = `c.id, c.share_id, COALESCE(c.author_id::TEXT, ''), COALESCE(c.parent_id::TEXT, ''), c.body, c.offset_ms, c.created_at, c.edited_at, c.deleted_at,
+            (SELECT COUNT(*) FROM share_comments r WHERE r.parent_id = c.id)`
+
+func scanComment(row pgx.Row) (models.Comment, error) {
+	var comment models.Comment
+	var editedAt, deletedAt sql.NullTime
+	if err := row.Scan(&comment.ID, &comment.ShareID, &comment.AuthorID, &comment.ParentID, &comment.Body, &comment.OffsetMs, &comment.CreatedAt, &editedAt, &deletedAt, &comment.ReplyCount); err != nil {
+		return models.Comment{}, err
+	}
+	comment.CreatedAt = comment.CreatedAt.UTC()
+	comment.EditedAt = timePtr(editedAt)
+	comment.DeletedAt = timePtr(deletedAt)
+	return comment, nil
+}
+
+var _ CommentRepository = (*PostgresCommentRepository)(nil)
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// NewInMemoryCommentRepository returns a CommentRepository backed by an in-memory map.
+func NewInMemoryCommentRepository() *InMemoryCommentRepository {
+	return &InMemoryCommentRepository{comments: make(map[string]models.Comment)}
+}
+
+// InMemoryCommentRepository implements CommentRepository for tests and local development. It does
+// not know which shares exist, so Create accepts comments on any share.
+type InMemoryCommentRepository struct {
+	mu       sync.Mutex
+	comments map[string]models.Comment
+}
+
+// Create stores a new comment or reply.
+func (r *InMemoryCommentRepository) Create(_ context.Context, comment models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.comments[comment.ID]; ok {
+		return ErrConflict
+	}
+	if comment.ParentID != "" {
+		parent, ok := r.comments[comment.ParentID]
+		if !ok || parent.ShareID != comment.ShareID || parent.ParentID != "" || parent.DeletedAt != nil {
+			return ErrNotFound
+		}
+	}
+	comment.ReplyCount = 0
+	r.comments[comment.ID] = comment
+	return nil
+}
+
+// FindByID returns a comment on the share, including tombstones.
+func (r *InMemoryCommentRepository) FindByID(_ context.Context, shareID, commentID string) (models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment, ok := r.comments[commentID]
+	if !ok || comment.ShareID != shareID {
+		return models.Comment{}, ErrNotFound
+	}
+	return r.withReplyCount(comment), nil
+}
+
+// ListThreads returns the comments starting threads on the share with their reply counts.
+func (r *InMemoryCommentRepository) ListThreads(_ context.Context, shareID string, after *models.CommentCursor, limit int) ([]models.Comment, error) {
+	return r.list(func(c models.Comment) bool { return c.ShareID == shareID && c.ParentID == "" }, after, false, limit), nil
+}
+
+// ListReplies returns the replies to a comment on the share.
+func (r *InMemoryCommentRepository) ListReplies(_ context.Context, shareID, parentID string, after *models.CommentCursor, limit int) ([]models.Comment, error) {
+	return r.list(func(c models.Comment) bool { return c.ShareID == shareID && c.ParentID == parentID }, after, false, limit), nil
+}
+
+// ListByOffset returns the comments anchored to a stretch of the video with their reply counts.
+func (r *InMemoryCommentRepository) ListByOffset(_ context.Context, shareID string, fromMs, toMs int64, after *models.CommentCursor, limit int) ([]models.Comment, error) {
+	return r.list(func(c models.Comment) bool {
+		return c.ShareID == shareID && c.ParentID == "" && c.OffsetMs != nil && *c.OffsetMs >= fromMs && *c.OffsetMs < toMs
+	}, after, true, limit), nil
+}
+
+func (r *InMemoryCommentRepository) list(match func(models.Comment) bool, after *models.CommentCursor, byOffset bool, limit int) []models.Comment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var comments []models.Comment
+	for _, comment := range r.comments {
+		if !match(comment) {
+			continue
+		}
+		if after != nil && !commentAfter(comment, *after, byOffset) {
+			continue
+		}
+		comments = append(comments, r.withReplyCount(comment))
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return commentAfter(comments[j], commentCursor(comments[i]), byOffset)
+	})
+	if len(comments) > limit {
+		comments = comments[:limit]
+	}
+	return comments
+}
+
+// CountByOffset returns how many live comments are anchored to each stretch of the video.
+func (r *InMemoryCommentRepository) CountByOffset(_ context.Context, shareID string, bucketMs int64) ([]models.CommentMarker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[int64]int)
+	for _, comment := range r.comments {
+		if comment.ShareID == shareID && comment.ParentID == "" && comment.OffsetMs != nil && comment.DeletedAt == nil {
+			counts[*comment.OffsetMs-*comment.OffsetMs%bucketMs]++
+		}
+	}
+	markers := make([]models.CommentMarker, 0, len(counts))
+	for offset, count := range counts {
+		markers = append(markers, models.CommentMarker{OffsetMs: offset, Count: count})
+	}
+	sort.Slice(markers, func(i, j int) bool { return markers[i].OffsetMs < markers[j].OffsetMs })
+	return markers, nil
+}
+
+// Update stores the comment's new body.
+func (r *InMemoryCommentRepository) Update(_ context.Context, comment models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.comments[comment.ID]
+	if !ok || stored.ShareID != comment.ShareID || stored.AuthorID != comment.AuthorID || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	editedAt := comment.CreatedAt
+	if comment.EditedAt != nil {
+		editedAt = *comment.EditedAt
+	}
+	stored.Body = comment.Body
+	stored.EditedAt = &editedAt
+	r.comments[comment.ID] = stored
+	return nil
+}
+
+// Delete clears the comment's body and marks it deleted.
+func (r *InMemoryCommentRepository) Delete(_ context.Context, shareID, commentID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.comments[commentID]
+	if !ok || stored.ShareID != shareID || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	stored.Body = ""
+	stored.DeletedAt = &at
+	r.comments[commentID] = stored
+	return nil
+}
+
+func (r *InMemoryCommentRepository) withReplyCount(comment models.Comment) models.Comment {
+	comment.ReplyCount = 0
+	for _, reply := range r.comments {
+		if reply.ParentID == comment.ID {
+			comment.ReplyCount++
+		}
+	}
+	return comment
+}
+
+func commentCursor(comment models.Comment) models.CommentCursor {
+	cursor := models.CommentCursor{CreatedAt: comment.CreatedAt, CommentID: comment.ID}
+	if comment.OffsetMs != nil {
+		cursor.OffsetMs = *comment.OffsetMs
+	}
+	return cursor
+}
+
+// commentAfter reports whether the comment sorts after the cursor, oldest first or, when byOffset
+// is set, in the order of their offsets.
+func commentAfter(comment models.Comment, cursor models.CommentCursor, byOffset bool) bool {
+	if byOffset && comment.OffsetMs != nil && *comment.OffsetMs != cursor.OffsetMs {
+		return *comment.OffsetMs > cursor.OffsetMs
+	}
+	if !comment.CreatedAt.Equal(cursor.CreatedAt) {
+		return comment.CreatedAt.After(cursor.CreatedAt)
+	}
+	return comment.ID > cursor.CommentID
+}
+
+var _ CommentRepository = (*InMemoryCommentRepository)(nil)
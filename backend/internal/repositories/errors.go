@@ -7,4 +7,8 @@ var (
 	ErrNotFound = errors.New("record not found")
 	// ErrConflict indicates the attempted write would violate a uniqueness constraint.
 	ErrConflict = errors.New("record conflict")
+	// ErrCircleNotFound indicates the write names a circle the owner does not own.
+	ErrCircleNotFound = errors.New("circle not found")
+	// ErrNotFriends indicates the write names a user who is not an accepted friend of the owner.
+	ErrNotFriends = errors.New("not an accepted friend")
 )
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// PostgresFeedFilterRepository persists the friends users muted and the shares they hid, which
+// PostgresVideoRepository.ListFeed leaves out.
+type PostgresFeedFilterRepository struct {
+	pool db.Pool
+}
+
+// NewPostgresFeedFilterRepository constructs a feed filter repository backed by PostgreSQL.
+func NewPostgresFeedFilterRepository(pool db.Pool) *PostgresFeedFilterRepository {
+	return &PostgresFeedFilterRepository{pool: pool}
+}
+
+// Mute creates or replaces the user's mute of a friend. It returns ErrNotFriends when the muted
+// user is not an accepted friend.
+func (r *PostgresFeedFilterRepository) Mute(ctx context.Context, mute models.FriendMute) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var until sql.NullTime
+	if mute.Until != nil {
+		until = sql.NullTime{Time: mute.Until.UTC(), Valid: true}
+	}
+
+	tag, err := conn.Exec(ctx, `
+        INSERT INTO friend_mutes (user_id, muted_id, muted_until, created_at)
+        SELECT $1, $2, $3, $4
+        WHERE EXISTS (
+            SELECT 1 FROM friend_requests fr
+            WHERE fr.status = 'accepted'
+              AND ((fr.requester_id = $1 AND fr.receiver_id = $2) OR (fr.receiver_id = $1 AND fr.requester_id = $2))
+        )
+        ON CONFLICT (user_id, muted_id) DO UPDATE
+        SET muted_until = excluded.muted_until, created_at = excluded.created_at
+    `, mute.UserID, mute.MutedID, until, mute.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("upsert friend mute: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+// ListMutes returns the user's mutes that are still in effect at the given time, newest first.
+func (r *PostgresFeedFilterRepository) ListMutes(ctx context.Context, userID string, at time.Time) ([]models.FriendMute, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT user_id, muted_id, muted_until, created_at
+        FROM friend_mutes
+        WHERE user_id = $1 AND (muted_until IS NULL OR muted_until > $2)
+        ORDER BY created_at DESC, muted_id
+    `, userID, at.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list friend mutes: %w", err)
+	}
+	defer rows.Close()
+
+	var mutes []models.FriendMute
+	for rows.Next() {
+		var mute models.FriendMute
+		var until sql.NullTime
+		if err := rows.Scan(&mute.UserID, &mute.MutedID, &until, &mute.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan friend mute: %w", err)
+		}
+		mute.Until = timePtr(until)
+		mute.CreatedAt = mute.CreatedAt.UTC()
+		mutes = append(mutes, mute)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate friend mutes: %w", err)
+	}
+	return mutes, nil
+}
+
+// Unmute removes the user's mute of another user.
+func (r *PostgresFeedFilterRepository) Unmute(ctx context.Context, userID, mutedID string) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `DELETE FROM friend_mutes WHERE user_id = $1 AND muted_id = $2`, userID, mutedID)
+	if err != nil {
+		return fmt.Errorf("delete friend mute: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// HideShare removes a share from the user's feed. Hiding a share twice keeps the first time. It
+// returns ErrNotFound when the share does not exist or is not visible to the user.
+func (r *PostgresFeedFilterRepository) HideShare(ctx context.Context, userID, shareID string, at time.Time) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        INSERT INTO hidden_shares (user_id, share_id, hidden_at)
+        SELECT $1, vs.id, $3
+        FROM video_shares vs
+        WHERE vs.id = $2 AND `+fmt.Sprintf(visibleShareCondition, "$1")+`
+        ON CONFLICT (user_id, share_id) DO UPDATE SET hidden_at = hidden_shares.hidden_at
+    `, userID, shareID, at.UTC())
+	if err != nil {
+		return fmt.Errorf("insert hidden share: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListHidden returns the shares the user hid, most recently hidden first.
+func (r *PostgresFeedFilterRepository) ListHidden(ctx context.Context, userID string) ([]models.HiddenShare, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT vs.id, vs.owner_id, vs.url, vs.title, hs.hidden_at
+        FROM hidden_shares hs
+        JOIN video_shares vs ON vs.id = hs.share_id
+        WHERE hs.user_id = $1
+        ORDER BY hs.hidden_at DESC, vs.id
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list hidden shares: %w", err)
+	}
+	defer rows.Close()
+
+	var hidden []models.HiddenShare
+	for rows.Next() {
+		var share models.HiddenShare
+		if err := rows.Scan(&share.ShareID, &share.OwnerID, &share.URL, &share.Title, &share.HiddenAt); err != nil {
+			return nil, fmt.Errorf("scan hidden share: %w", err)
+		}
+		share.HiddenAt = share.HiddenAt.UTC()
+		hidden = append(hidden, share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate hidden shares: %w", err)
+	}
+	return hidden, nil
+}
+
+// UnhideShare puts a hidden share back into the user's feed.
+func (r *PostgresFeedFilterRepository) UnhideShare(ctx context.Context, userID, shareID string) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `DELETE FROM hidden_shares WHERE user_id = $1 AND share_id = $2`, userID, shareID)
+	if err != nil {
+		return fmt.Errorf("delete hidden share: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
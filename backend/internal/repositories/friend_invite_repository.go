@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/invites"
+)
+
+// PostgresInviteStore persists friend invites to PostgreSQL.
+type PostgresInviteStore struct {
+	pool db.Pool
+}
+
+// NewPostgresInviteStore constructs an invite store backed by PostgreSQL.
+func NewPostgresInviteStore(pool db.Pool) *PostgresInviteStore {
+	return &PostgresInviteStore{pool: pool}
+}
+
+const inviteColumns = `id, inviter_id, code_hash, email, max_uses, uses, expires_at, revoked_at, created_at`
+
+// Create stores a new invite.
+func (s *PostgresInviteStore) Create(ctx context.Context, invite invites.Invite) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `
+        INSERT INTO friend_invites (id, inviter_id, code_hash, email, max_uses, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, invite.ID, invite.InviterID, invite.Hash, invite.Email, invite.MaxUses, invite.ExpiresAt.UTC(), invite.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("insert friend invite: %w", err)
+	}
+	return nil
+}
+
+// FindByHash returns the invite with the hash.
+func (s *PostgresInviteStore) FindByHash(ctx context.Context, hash string) (invites.Invite, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return invites.Invite{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	invite, err := scanInvite(conn.QueryRow(ctx, `SELECT `+inviteColumns+` FROM friend_invites WHERE code_hash = $1`, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return invites.Invite{}, invites.ErrInviteNotFound
+		}
+		return invites.Invite{}, fmt.Errorf("select friend invite: %w", err)
+	}
+	return invite, nil
+}
+
+// ListForInviter returns the user's invites, newest first.
+func (s *PostgresInviteStore) ListForInviter(ctx context.Context, inviterID string) ([]invites.Invite, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT `+inviteColumns+`
+        FROM friend_invites
+        WHERE inviter_id = $1
+        ORDER BY created_at DESC
+    `, inviterID)
+	if err != nil {
+		return nil, fmt.Errorf("list friend invites: %w", err)
+	}
+	defer rows.Close()
+
+	var list []invites.Invite
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan friend invite: %w", err)
+		}
+		list = append(list, invite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate friend invites: %w", err)
+	}
+	return list, nil
+}
+
+// Revoke marks one of the user's invites as revoked.
+func (s *PostgresInviteStore) Revoke(ctx context.Context, inviterID, id string, at time.Time) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE friend_invites
+        SET revoked_at = $3
+        WHERE id = $1 AND inviter_id = $2 AND revoked_at IS NULL
+    `, id, inviterID, at.UTC())
+	if err != nil {
+		return fmt.Errorf("revoke friend invite: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return invites.ErrInviteNotFound
+	}
+	return nil
+}
+
+// Redeem records one use of the invite with the hash. The use limit is checked in the same
+// statement so concurrent signups cannot exceed it.
+func (s *PostgresInviteStore) Redeem(ctx context.Context, hash string, at time.Time) (invites.Invite, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return invites.Invite{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	invite, err := scanInvite(conn.QueryRow(ctx, `
+        UPDATE friend_invites
+        SET uses = uses + 1
+        WHERE code_hash = $1 AND revoked_at IS NULL AND expires_at > $2 AND uses < max_uses
+        RETURNING `+inviteColumns, hash, at.UTC()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return invites.Invite{}, invites.ErrInvalidCode
+		}
+		return invites.Invite{}, fmt.Errorf("redeem friend invite: %w", err)
+	}
+	return invite, nil
+}
+
+func scanInvite(row pgx.Row) (invites.Invite, error) {
+	var invite invites.Invite
+	var revokedAt sql.NullTime
+	if err := row.Scan(&invite.ID, &invite.InviterID, &invite.Hash, &invite.Email, &invite.MaxUses, &invite.Uses,
+		&invite.ExpiresAt, &revokedAt, &invite.CreatedAt); err != nil {
+		return invites.Invite{}, err
+	}
+	invite.RevokedAt = timePtr(revokedAt)
+	invite.ExpiresAt = invite.ExpiresAt.UTC()
+	invite.CreatedAt = invite.CreatedAt.UTC()
+	return invite, nil
+}
+
+var _ invites.Store = (*PostgresInviteStore)(nil)
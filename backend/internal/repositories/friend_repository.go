@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/vidfriends/backend/internal/models"
 )
@@ -10,5 +11,13 @@ import (
 type FriendRepository interface {
 	CreateRequest(ctx context.Context, request models.FriendRequest) error
 	ListForUser(ctx context.Context, userID string) ([]models.FriendRequest, error)
-	UpdateStatus(ctx context.Context, requestID, status string) error
+	ListView(ctx context.Context, userID string, view models.FriendView, after *models.FriendCursor, limit int) ([]models.FriendConnection, error)
+	CountViews(ctx context.Context, userID string) (models.FriendCounts, error)
+	FindByID(ctx context.Context, requestID string) (models.FriendRequest, error)
+	FindBetween(ctx context.Context, userID, otherID string) (models.FriendRequest, error)
+	Transition(ctx context.Context, transition models.FriendTransition) (models.FriendRequest, error)
+	ListEvents(ctx context.Context, requestID string) ([]models.FriendEvent, error)
+	ListMutual(ctx context.Context, userID, otherID string) ([]string, error)
+	ListSuggestions(ctx context.Context, userID string, limit int) ([]models.FriendSuggestion, error)
+	DismissSuggestion(ctx context.Context, userID, candidateID string, at time.Time) error
 }
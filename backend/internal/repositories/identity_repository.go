@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// PostgresIdentityRepository persists links between users and external identity providers.
+type PostgresIdentityRepository struct {
+	pool db.Pool
+}
+
+// NewPostgresIdentityRepository constructs an identity repository backed by PostgreSQL.
+func NewPostgresIdentityRepository(pool db.Pool) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{pool: pool}
+}
+
+// Find returns the identity for the provider's subject identifier.
+func (r *PostgresIdentityRepository) Find(ctx context.Context, provider, subject string) (models.UserIdentity, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.UserIdentity{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var identity models.UserIdentity
+	err = conn.QueryRow(ctx, `
+        SELECT provider, subject, user_id, email, created_at
+        FROM user_identities
+        WHERE provider = $1 AND subject = $2
+    `, provider, subject).Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.UserIdentity{}, ErrNotFound
+		}
+		return models.UserIdentity{}, fmt.Errorf("select identity: %w", err)
+	}
+
+	identity.CreatedAt = identity.CreatedAt.UTC()
+	return identity, nil
+}
+
+// Create links a new external identity to a user.
+func (r *PostgresIdentityRepository) Create(ctx context.Context, identity models.UserIdentity) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `
+        INSERT INTO user_identities (provider, subject, user_id, email, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, identity.Provider, identity.Subject, identity.UserID, identity.Email, identity.CreatedAt.UTC())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrConflict
+		}
+		return fmt.Errorf("insert identity: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/db"
+)
+
+// PostgresLoginAttemptStore persists failed login counters to PostgreSQL.
+type PostgresLoginAttemptStore struct {
+	pool db.Pool
+}
+
+// NewPostgresLoginAttemptStore constructs a login attempt store backed by PostgreSQL.
+func NewPostgresLoginAttemptStore(pool db.Pool) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{pool: pool}
+}
+
+// Find returns the attempts recorded for email.
+func (s *PostgresLoginAttemptStore) Find(ctx context.Context, email string) (auth.LoginAttempts, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return auth.LoginAttempts{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	attempts, err := scanLoginAttempts(conn.QueryRow(ctx, `
+        SELECT email, failed_count, last_failed_at, locked_until
+        FROM login_attempts
+        WHERE email = $1
+    `, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.LoginAttempts{Email: email}, nil
+		}
+		return auth.LoginAttempts{}, fmt.Errorf("select login attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// RecordFailure counts a failed login in a single statement so concurrent attempts are all
+// counted.
+func (s *PostgresLoginAttemptStore) RecordFailure(ctx context.Context, email string, at, windowStart time.Time) (auth.LoginAttempts, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return auth.LoginAttempts{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	attempts, err := scanLoginAttempts(conn.QueryRow(ctx, `
+        INSERT INTO login_attempts (email, failed_count, last_failed_at)
+        VALUES ($1, 1, $2)
+        ON CONFLICT (email) DO UPDATE
+        SET failed_count = CASE
+                WHEN login_attempts.last_failed_at <= $3 THEN 1
+                ELSE login_attempts.failed_count + 1
+            END,
+            last_failed_at = excluded.last_failed_at
+        RETURNING email, failed_count, last_failed_at, locked_until
+    `, email, at.UTC(), windowStart.UTC()))
+	if err != nil {
+		return auth.LoginAttempts{}, fmt.Errorf("record login failure: %w", err)
+	}
+	return attempts, nil
+}
+
+// Lock prevents logins for email until the given time.
+func (s *PostgresLoginAttemptStore) Lock(ctx context.Context, email string, until time.Time) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `
+        UPDATE login_attempts
+        SET locked_until = $2
+        WHERE email = $1
+    `, email, until.UTC()); err != nil {
+		return fmt.Errorf("lock login: %w", err)
+	}
+	return nil
+}
+
+// Reset clears the attempts recorded for email.
+func (s *PostgresLoginAttemptStore) Reset(ctx context.Context, email string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `DELETE FROM login_attempts WHERE email = $1`, email); err != nil {
+		return fmt.Errorf("reset login attempts: %w", err)
+	}
+	return nil
+}
+
+func scanLoginAttempts(row pgx.Row) (auth.LoginAttempts, error) {
+	var attempts auth.LoginAttempts
+	var lockedUntil sql.NullTime
+	if err := row.Scan(&attempts.Email, &attempts.FailedCount, &attempts.LastFailedAt, &lockedUntil); err != nil {
+		return auth.LoginAttempts{}, err
+	}
+	attempts.LastFailedAt = attempts.LastFailedAt.UTC()
+	attempts.LockedUntil = timePtr(lockedUntil)
+	return attempts, nil
+}
+
+var _ auth.LoginAttemptStore = (*PostgresLoginAttemptStore)(nil)
@@ -35,9 +35,9 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user models.User) e
 	defer conn.Release()
 
 	_, err = conn.Exec(ctx, `
-        INSERT INTO users (id, email, password_hash, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5)
-    `, user.ID, user.Email, user.Password, user.CreatedAt, user.UpdatedAt)
+        INSERT INTO users (id, email, password_hash, created_at, updated_at, email_verified_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, user.ID, user.Email, user.Password, user.CreatedAt, user.UpdatedAt, nullableTime(user.EmailVerifiedAt))
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -58,19 +58,48 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string)
 	defer conn.Release()
 
 	row := conn.QueryRow(ctx, `
-        SELECT id, email, password_hash, created_at, updated_at
+        SELECT id, email, password_hash, created_at, updated_at, email_verified_at
         FROM users
         WHERE email = $1
     `, email)
 
 	var user models.User
-	if err := row.Scan(&user.ID, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
+	var emailVerifiedAt sql.NullTime
+	if err := row.Scan(&user.ID, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &emailVerifiedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.User{}, ErrNotFound
 		}
 		return models.User{}, fmt.Errorf("select user by email: %w", err)
 	}
 
+	user.EmailVerifiedAt = timePtr(emailVerifiedAt)
+	return user, nil
+}
+
+// FindByID fetches a user by their identifier.
+func (r *PostgresUserRepository) FindByID(ctx context.Context, id string) (models.User, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.User{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	row := conn.QueryRow(ctx, `
+        SELECT id, email, password_hash, created_at, updated_at, email_verified_at
+        FROM users
+        WHERE id = $1
+    `, id)
+
+	var user models.User
+	var emailVerifiedAt sql.NullTime
+	if err := row.Scan(&user.ID, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &emailVerifiedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, fmt.Errorf("select user by id: %w", err)
+	}
+
+	user.EmailVerifiedAt = timePtr(emailVerifiedAt)
 	return user, nil
 }
 
@@ -84,9 +113,9 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user models.User) e
 
 	tag, err := conn.Exec(ctx, `
         UPDATE users
-        SET email = $2, password_hash = $3, updated_at = $4
+        SET email = $2, password_hash = $3, updated_at = $4, email_verified_at = $5
         WHERE id = $1
-    `, user.ID, user.Email, user.Password, user.UpdatedAt)
+    `, user.ID, user.Email, user.Password, user.UpdatedAt, nullableTime(user.EmailVerifiedAt))
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -102,6 +131,48 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user models.User) e
 	return nil
 }
 
+// Delete removes a user. Sessions, tokens, friend requests, video shares and every other row
+// referencing the user are removed by the ON DELETE CASCADE foreign keys. The user's comments on
+// other people's shares become tombstones instead, so the replies to them stay in place.
+func (r *PostgresUserRepository) Delete(ctx context.Context, id string) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin user deletion: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Deleting the user sets author_id to NULL through ON DELETE SET NULL.
+	if _, err := tx.Exec(ctx, `
+        UPDATE share_comments SET body = '', deleted_at = COALESCE(deleted_at, NOW())
+        WHERE author_id = $1
+    `, id); err != nil {
+		return fmt.Errorf("delete user comments: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+        DELETE FROM users
+        WHERE id = $1
+    `, id)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit user deletion: %w", err)
+	}
+	return nil
+}
+
 // PostgresFriendRepository provides PostgreSQL-backed persistence for friend requests.
 type PostgresFriendRepository struct {
 	pool db.Pool
@@ -112,7 +183,9 @@ func NewPostgresFriendRepository(pool db.Pool) *PostgresFriendRepository {
 	return &PostgresFriendRepository{pool: pool}
 }
 
-// CreateRequest persists a new friend request.
+const friendRequestColumns = `id, requester_id, receiver_id, status, created_at, responded_at, actor_id, updated_at`
+
+// CreateRequest persists a new friend request and records its creation as the first event.
 func (r *PostgresFriendRepository) CreateRequest(ctx context.Context, request models.FriendRequest) error {
 	conn, err := r.pool.Acquire(ctx)
 	if err != nil {
@@ -120,10 +193,26 @@ func (r *PostgresFriendRepository) CreateRequest(ctx context.Context, request mo
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(ctx, `
-        INSERT INTO friend_requests (id, requester_id, receiver_id, status, created_at, responded_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
-    `, request.ID, request.Requester, request.Receiver, request.Status, request.CreatedAt, request.RespondedAt)
+	actorID := request.ActorID
+	if actorID == "" {
+		actorID = request.Requester
+	}
+	// Answered requests always carry a response time; the accepted view orders friends by it.
+	respondedAt := request.RespondedAt
+	if respondedAt == nil && request.Status != "pending" {
+		respondedAt = &request.CreatedAt
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO friend_requests (id, requester_id, receiver_id, status, created_at, responded_at, actor_id, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $5)
+    `, request.ID, request.Requester, request.Receiver, request.Status, request.CreatedAt, respondedAt, actorID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -137,6 +226,13 @@ func (r *PostgresFriendRepository) CreateRequest(ctx context.Context, request mo
 		return fmt.Errorf("insert friend request: %w", err)
 	}
 
+	if err := insertFriendEvent(ctx, tx, request.ID, actorID, "", request.Status, request.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -149,7 +245,7 @@ func (r *PostgresFriendRepository) ListForUser(ctx context.Context, userID strin
 	defer conn.Release()
 
 	rows, err := conn.Query(ctx, `
-        SELECT id, requester_id, receiver_id, status, created_at, responded_at
+        SELECT `+friendRequestColumns+`
         FROM friend_requests
         WHERE requester_id = $1 OR receiver_id = $1
         ORDER BY created_at DESC
@@ -161,20 +257,10 @@ func (r *PostgresFriendRepository) ListForUser(ctx context.Context, userID strin
 
 	var requests []models.FriendRequest
 	for rows.Next() {
-		var (
-			req         models.FriendRequest
-			respondedAt sql.NullTime
-		)
-
-		if err := rows.Scan(&req.ID, &req.Requester, &req.Receiver, &req.Status, &req.CreatedAt, &respondedAt); err != nil {
+		req, err := scanFriendRequest(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan friend request: %w", err)
 		}
-
-		if respondedAt.Valid {
-			t := respondedAt.Time.UTC()
-			req.RespondedAt = &t
-		}
-
 		requests = append(requests, req)
 	}
 
@@ -185,35 +271,411 @@ func (r *PostgresFriendRepository) ListForUser(ctx context.Context, userID strin
 	return requests, nil
 }
 
-// UpdateStatus updates the status (and responded_at) for a friend request.
-func (r *PostgresFriendRepository) UpdateStatus(ctx context.Context, requestID, status string) error {
+// friendViewBranch is one indexed scan contributing entries to a friend view. since and other are
+// column expressions for the entry time and the other user.
+type friendViewBranch struct {
+	filter string
+	status string
+	since  string
+	other  string
+}
+
+// friendViewBranches lists the scans behind each view. Friends are found through two scans, one
+// per side of the request, so each can use its own index instead of an OR across columns.
+var friendViewBranches = map[models.FriendView][]friendViewBranch{
+	models.FriendViewAccepted: {
+		{filter: "requester_id", status: "accepted", since: "responded_at", other: "receiver_id"},
+		{filter: "receiver_id", status: "accepted", since: "responded_at", other: "requester_id"},
+	},
+	models.FriendViewIncoming: {
+		{filter: "receiver_id", status: "pending", since: "created_at", other: "requester_id"},
+	},
+	models.FriendViewOutgoing: {
+		{filter: "requester_id", status: "pending", since: "created_at", other: "receiver_id"},
+	},
+	models.FriendViewBlocked: {
+		{filter: "actor_id", status: "blocked", since: "updated_at", other: "CASE WHEN requester_id = $1 THEN receiver_id ELSE requester_id END"},
+	},
+}
+
+// ListView returns up to limit entries of one of the user's friend views, newest first, starting
+// after the cursor when one is given.
+func (r *PostgresFriendRepository) ListView(ctx context.Context, userID string, view models.FriendView, after *models.FriendCursor, limit int) ([]models.FriendConnection, error) {
+	branches, ok := friendViewBranches[view]
+	if !ok {
+		return nil, fmt.Errorf("unknown friend view %q", view)
+	}
+
+	args := []any{userID, limit}
+	if after != nil {
+		args = append(args, after.Since.UTC(), after.RequestID)
+	}
+
+	parts := make([]string, 0, len(branches))
+	for _, b := range branches {
+		keyset := ""
+		if after != nil {
+			keyset = fmt.Sprintf(" AND (%s, id) < ($3::TIMESTAMPTZ, $4::UUID)", b.since)
+		}
+		parts = append(parts, fmt.Sprintf(`(
+            SELECT id, %s AS other_id, %s AS since
+            FROM friend_requests
+            WHERE %s = $1 AND status = '%s'%s
+            ORDER BY %s DESC, id DESC
+            LIMIT $2
+        )`, b.other, b.since, b.filter, b.status, keyset, b.since))
+	}
+
 	conn, err := r.pool.Acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("acquire connection: %w", err)
+		return nil, fmt.Errorf("acquire connection: %w", err)
 	}
 	defer conn.Release()
 
-	respondedAt := sql.NullTime{}
-	if status != "pending" {
-		respondedAt = sql.NullTime{Valid: true, Time: time.Now().UTC()}
+	rows, err := conn.Query(ctx, `
+        SELECT id, other_id, since
+        FROM (`+strings.Join(parts, " UNION ALL ")+`) AS entries
+        ORDER BY since DESC, id DESC
+        LIMIT $2
+    `, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query friend view: %w", err)
 	}
+	defer rows.Close()
 
-	tag, err := conn.Exec(ctx, `
-        UPDATE friend_requests
-        SET status = $2, responded_at = $3
-        WHERE id = $1
-    `, requestID, status, respondedAt)
+	var entries []models.FriendConnection
+	for rows.Next() {
+		var entry models.FriendConnection
+		if err := rows.Scan(&entry.RequestID, &entry.UserID, &entry.Since); err != nil {
+			return nil, fmt.Errorf("scan friend view: %w", err)
+		}
+		entry.Since = entry.Since.UTC()
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate friend view: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CountViews returns the number of entries in each of the user's friend views.
+func (r *PostgresFriendRepository) CountViews(ctx context.Context, userID string) (models.FriendCounts, error) {
+	conn, err := r.pool.Acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("update friend request: %w", err)
+		return models.FriendCounts{}, fmt.Errorf("acquire connection: %w", err)
 	}
+	defer conn.Release()
 
-	if tag.RowsAffected() == 0 {
-		return ErrNotFound
+	var counts models.FriendCounts
+	err = conn.QueryRow(ctx, `
+        SELECT
+            COUNT(*) FILTER (WHERE status = 'accepted'),
+            COUNT(*) FILTER (WHERE status = 'pending' AND receiver_id = $1),
+            COUNT(*) FILTER (WHERE status = 'pending' AND requester_id = $1),
+            COUNT(*) FILTER (WHERE status = 'blocked' AND actor_id = $1)
+        FROM friend_requests
+        WHERE requester_id = $1 OR receiver_id = $1
+    `, userID).Scan(&counts.Accepted, &counts.Incoming, &counts.Outgoing, &counts.Blocked)
+	if err != nil {
+		return models.FriendCounts{}, fmt.Errorf("count friend views: %w", err)
+	}
+	return counts, nil
+}
+
+// FindByID returns the friend request with the ID.
+func (r *PostgresFriendRepository) FindByID(ctx context.Context, requestID string) (models.FriendRequest, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.FriendRequest{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	req, err := scanFriendRequest(conn.QueryRow(ctx, `SELECT `+friendRequestColumns+` FROM friend_requests WHERE id = $1`, requestID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.FriendRequest{}, ErrNotFound
+		}
+		return models.FriendRequest{}, fmt.Errorf("select friend request: %w", err)
+	}
+	return req, nil
+}
+
+// FindBetween returns the friend request between two users, whichever of them sent it.
+func (r *PostgresFriendRepository) FindBetween(ctx context.Context, userID, otherID string) (models.FriendRequest, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.FriendRequest{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	req, err := scanFriendRequest(conn.QueryRow(ctx, `
+        SELECT `+friendRequestColumns+`
+        FROM friend_requests
+        WHERE (requester_id = $1 AND receiver_id = $2) OR (requester_id = $2 AND receiver_id = $1)
+    `, userID, otherID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.FriendRequest{}, ErrNotFound
+		}
+		return models.FriendRequest{}, fmt.Errorf("select friend request: %w", err)
+	}
+	return req, nil
+}
+
+// Transition changes the status of a friend request and records the change as an event. The
+// update only applies while the request is still in t.From, so it returns ErrConflict when
+// another change got there first and ErrNotFound when the request does not exist.
+func (r *PostgresFriendRepository) Transition(ctx context.Context, t models.FriendTransition) (models.FriendRequest, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.FriendRequest{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return models.FriendRequest{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var row pgx.Row
+	if t.Requester != "" {
+		row = tx.QueryRow(ctx, `
+            UPDATE friend_requests
+            SET requester_id = $3, receiver_id = $4, status = $5, created_at = $6, responded_at = NULL,
+                actor_id = $7, updated_at = $6
+            WHERE id = $1 AND status = $2
+            RETURNING `+friendRequestColumns,
+			t.RequestID, t.From, t.Requester, t.Receiver, t.To, t.At.UTC(), t.ActorID)
+	} else {
+		// responded_at keeps the first answer to the request, such as when a friendship began.
+		row = tx.QueryRow(ctx, `
+            UPDATE friend_requests
+            SET status = $3, actor_id = $4, updated_at = $5,
+                responded_at = CASE WHEN status = 'pending' THEN $5 ELSE responded_at END
+            WHERE id = $1 AND status = $2
+            RETURNING `+friendRequestColumns,
+			t.RequestID, t.From, t.To, t.ActorID, t.At.UTC())
+	}
+
+	req, err := scanFriendRequest(row)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.FriendRequest{}, fmt.Errorf("update friend request: %w", err)
+		}
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM friend_requests WHERE id = $1)`, t.RequestID).Scan(&exists); err != nil {
+			return models.FriendRequest{}, fmt.Errorf("check friend request: %w", err)
+		}
+		if exists {
+			return models.FriendRequest{}, ErrConflict
+		}
+		return models.FriendRequest{}, ErrNotFound
+	}
+
+	if err := insertFriendEvent(ctx, tx, t.RequestID, t.ActorID, t.From, t.To, t.At); err != nil {
+		return models.FriendRequest{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.FriendRequest{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return req, nil
+}
+
+// ListEvents returns the changes made to a friend request, oldest first.
+func (r *PostgresFriendRepository) ListEvents(ctx context.Context, requestID string) ([]models.FriendEvent, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT id, request_id, actor_id, from_status, to_status, created_at
+        FROM friend_request_events
+        WHERE request_id = $1
+        ORDER BY created_at, id
+    `, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("query friend request events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.FriendEvent
+	for rows.Next() {
+		var event models.FriendEvent
+		if err := rows.Scan(&event.ID, &event.RequestID, &event.ActorID, &event.FromStatus, &event.ToStatus, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan friend request event: %w", err)
+		}
+		event.CreatedAt = event.CreatedAt.UTC()
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate friend request events: %w", err)
+	}
+
+	return events, nil
+}
+
+// acceptedFriendIDs selects the IDs of the accepted friends of the user in the %[1]s parameter.
+// Each branch is served by one of the partial indexes on accepted requests.
+const acceptedFriendIDs = `
+            SELECT receiver_id AS id FROM friend_requests WHERE status = 'accepted' AND requester_id = %[1]s
+            UNION ALL
+            SELECT requester_id AS id FROM friend_requests WHERE status = 'accepted' AND receiver_id = %[1]s`
+
+// ListMutual returns the IDs of the accepted friends userID and otherID have in common.
+func (r *PostgresFriendRepository) ListMutual(ctx context.Context, userID, otherID string) ([]string, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+        WITH mine AS (%s
+        ),
+        theirs AS (%s
+        )
+        SELECT m.id FROM mine m
+        WHERE m.id IN (SELECT id FROM theirs)
+        ORDER BY m.id
+    `, fmt.Sprintf(acceptedFriendIDs, "$1"), fmt.Sprintf(acceptedFriendIDs, "$2")), userID, otherID)
+	if err != nil {
+		return nil, fmt.Errorf("query mutual friends: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan mutual friend: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate mutual friends: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ListSuggestions returns up to limit friends of the user's friends, ranked by the number of
+// friends they have in common with the user. Anyone the user already has a friend request with,
+// whatever its status (including blocks in either direction), and anyone whose suggestion the
+// user dismissed is left out.
+func (r *PostgresFriendRepository) ListSuggestions(ctx context.Context, userID string, limit int) ([]models.FriendSuggestion, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
 	}
+	defer conn.Release()
 
+	rows, err := conn.Query(ctx, `
+        WITH my_friends AS (`+fmt.Sprintf(acceptedFriendIDs, "$1")+`
+        ),
+        friends_of_friends AS (
+            SELECT fr.receiver_id AS candidate_id, fr.requester_id AS via_id
+            FROM friend_requests fr
+            JOIN my_friends f ON fr.requester_id = f.id
+            WHERE fr.status = 'accepted'
+            UNION ALL
+            SELECT fr.requester_id AS candidate_id, fr.receiver_id AS via_id
+            FROM friend_requests fr
+            JOIN my_friends f ON fr.receiver_id = f.id
+            WHERE fr.status = 'accepted'
+        )
+        SELECT fof.candidate_id, COUNT(DISTINCT fof.via_id) AS mutual_count
+        FROM friends_of_friends fof
+        WHERE fof.candidate_id <> $1
+          AND NOT EXISTS (
+              SELECT 1 FROM friend_requests x
+              WHERE (x.requester_id = $1 AND x.receiver_id = fof.candidate_id)
+                 OR (x.receiver_id = $1 AND x.requester_id = fof.candidate_id)
+          )
+          AND NOT EXISTS (
+              SELECT 1 FROM friend_suggestion_dismissals d
+              WHERE d.user_id = $1 AND d.candidate_id = fof.candidate_id
+          )
+        GROUP BY fof.candidate_id
+        ORDER BY mutual_count DESC, fof.candidate_id
+        LIMIT $2
+    `, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query friend suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []models.FriendSuggestion
+	for rows.Next() {
+		var suggestion models.FriendSuggestion
+		if err := rows.Scan(&suggestion.UserID, &suggestion.MutualCount); err != nil {
+			return nil, fmt.Errorf("scan friend suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate friend suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// DismissSuggestion stops candidateID from being suggested to userID. It returns ErrNotFound when
+// either user does not exist.
+func (r *PostgresFriendRepository) DismissSuggestion(ctx context.Context, userID, candidateID string, at time.Time) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `
+        INSERT INTO friend_suggestion_dismissals (user_id, candidate_id, dismissed_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, candidate_id) DO UPDATE SET dismissed_at = excluded.dismissed_at
+    `, userID, candidateID, at.UTC())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrNotFound
+		}
+		return fmt.Errorf("dismiss friend suggestion: %w", err)
+	}
 	return nil
 }
 
+func insertFriendEvent(ctx context.Context, tx pgx.Tx, requestID, actorID, from, to string, at time.Time) error {
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO friend_request_events (request_id, actor_id, from_status, to_status, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, requestID, actorID, from, to, at.UTC()); err != nil {
+		return fmt.Errorf("insert friend request event: %w", err)
+	}
+	return nil
+}
+
+func scanFriendRequest(row pgx.Row) (models.FriendRequest, error) {
+	var (
+		req         models.FriendRequest
+		respondedAt sql.NullTime
+	)
+	if err := row.Scan(&req.ID, &req.Requester, &req.Receiver, &req.Status, &req.CreatedAt, &respondedAt, &req.ActorID, &req.UpdatedAt); err != nil {
+		return models.FriendRequest{}, err
+	}
+	req.CreatedAt = req.CreatedAt.UTC()
+	req.RespondedAt = timePtr(respondedAt)
+	req.UpdatedAt = req.UpdatedAt.UTC()
+	return req, nil
+}
+
 // PostgresVideoRepository provides PostgreSQL-backed persistence for shared videos.
 type PostgresVideoRepository struct {
 	pool db.Pool
@@ -224,7 +686,9 @@ func NewPostgresVideoRepository(pool db.Pool) *PostgresVideoRepository {
 	return &PostgresVideoRepository{pool: pool}
 }
 
-// Create stores a new shared video record.
+// Create stores a new shared video record together with its audience. It returns ErrCircleNotFound
+// when a circle in CircleIDs does not belong to the owner and ErrNotFriends when a user in UserIDs is
+// not an accepted friend of the owner. IDs in both lists must be unique.
 func (r *PostgresVideoRepository) Create(ctx context.Context, share models.VideoShare) error {
 	conn, err := r.pool.Acquire(ctx)
 	if err != nil {
@@ -236,11 +700,21 @@ func (r *PostgresVideoRepository) Create(ctx context.Context, share models.Video
 	if strings.TrimSpace(status) == "" {
 		status = models.AssetStatusPending
 	}
+	visibility := share.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityFriends
+	}
 
-	_, err = conn.Exec(ctx, `
-        INSERT INTO video_shares (id, owner_id, url, title, description, thumbnail, created_at, asset_status, asset_url, asset_size)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-    `, share.ID, share.OwnerID, share.URL, share.Title, share.Description, share.Thumbnail, share.CreatedAt, status, share.AssetURL, share.AssetSize)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin video share create: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO video_shares (id, owner_id, url, title, description, thumbnail, created_at, asset_status, asset_url, asset_size, visibility, note, duration_ms)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+    `, share.ID, share.OwnerID, share.URL, share.Title, share.Description, share.Thumbnail, share.CreatedAt, status, share.AssetURL, share.AssetSize, visibility, share.Note, share.DurationMs)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -249,11 +723,52 @@ func (r *PostgresVideoRepository) Create(ctx context.Context, share models.Video
 		return fmt.Errorf("insert video share: %w", err)
 	}
 
+	if err := insertShareAudience(ctx, tx, share); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit video share create: %w", err)
+	}
 	return nil
 }
 
-// ListFeed returns a simple reverse chronological feed of shared videos.
-func (r *PostgresVideoRepository) ListFeed(ctx context.Context, userID string) ([]models.VideoShare, error) {
+// ListFeed returns up to limit shares of the user's feed, newest first, starting after the cursor
+// when one is given. The feed holds the user's own shares and the shares of accepted friends that
+// are visible to the user: those shared with all friends, with a circle the user is in, or with
+// the user directly. Friends the user muted and shares the user hid are left out.
+func (r *PostgresVideoRepository) ListFeed(ctx context.Context, userID string, filter models.FeedFilter, after *models.FeedCursor, limit int) ([]models.VideoShare, error) {
+	args := []any{userID, limit}
+	var conditions []string
+	add := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.OwnerID != "" {
+		add("vs.owner_id = %s::UUID", filter.OwnerID)
+	}
+	if filter.AssetStatus != "" {
+		add("vs.asset_status = %s", filter.AssetStatus)
+	}
+	if filter.Domain != "" {
+		args = append(args, strings.ToLower(filter.Domain))
+		conditions = append(conditions, fmt.Sprintf(shareDomainCondition, fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.Since != nil {
+		add("vs.created_at >= %s", filter.Since.UTC())
+	}
+	if filter.Before != nil {
+		add("vs.created_at < %s", filter.Before.UTC())
+	}
+	if after != nil {
+		args = append(args, after.CreatedAt.UTC(), after.ShareID)
+		conditions = append(conditions, fmt.Sprintf("(vs.created_at, vs.id) < ($%d::TIMESTAMPTZ, $%d::UUID)", len(args)-1, len(args)))
+	}
+	extra := ""
+	for _, condition := range conditions {
+		extra += "\n          AND " + condition
+	}
+
 	conn, err := r.pool.Acquire(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("acquire connection: %w", err)
@@ -270,13 +785,21 @@ func (r *PostgresVideoRepository) ListFeed(ctx context.Context, userID string) (
             FROM friend_requests fr
             WHERE fr.status = 'accepted'
               AND (fr.requester_id = $1 OR fr.receiver_id = $1)
+              AND NOT EXISTS (
+                  SELECT 1 FROM friend_mutes fm
+                  WHERE fm.user_id = $1
+                    AND fm.muted_id IN (fr.requester_id, fr.receiver_id)
+                    AND (fm.muted_until IS NULL OR fm.muted_until > now())
+              )
         )
-        SELECT id, owner_id, url, title, description, thumbnail, created_at, asset_url, asset_status, asset_size
-        FROM video_shares
-        WHERE owner_id = $1 OR owner_id IN (SELECT friend_id FROM accepted_friends)
-        ORDER BY created_at DESC
-        LIMIT 100
-    `, userID)
+        SELECT `+videoShareColumns+`
+        FROM video_shares vs
+        WHERE (vs.owner_id = $1
+               OR (vs.owner_id IN (SELECT friend_id FROM accepted_friends) AND `+fmt.Sprintf(shareAudienceCondition, "$1")+`))
+          AND NOT EXISTS (SELECT 1 FROM hidden_shares hs WHERE hs.user_id = $1 AND hs.share_id = vs.id)`+extra+`
+        ORDER BY vs.created_at DESC, vs.id DESC
+        LIMIT $2
+    `, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query video feed: %w", err)
 	}
@@ -284,8 +807,8 @@ func (r *PostgresVideoRepository) ListFeed(ctx context.Context, userID string) (
 
 	var shares []models.VideoShare
 	for rows.Next() {
-		var share models.VideoShare
-		if err := rows.Scan(&share.ID, &share.OwnerID, &share.URL, &share.Title, &share.Description, &share.Thumbnail, &share.CreatedAt, &share.AssetURL, &share.AssetStatus, &share.AssetSize); err != nil {
+		share, err := scanVideoShare(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan video share: %w", err)
 		}
 		shares = append(shares, share)
@@ -298,6 +821,140 @@ func (r *PostgresVideoRepository) ListFeed(ctx context.Context, userID string) (
 	return shares, nil
 }
 
+// FindVisible returns a share the user may see: their own, or an accepted friend's whose audience
+// includes them. Only the owner receives the share's CircleIDs and UserIDs. It returns ErrNotFound
+// for every other share.
+func (r *PostgresVideoRepository) FindVisible(ctx context.Context, userID, shareID string) (models.VideoShare, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.VideoShare{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	share, err := scanVideoShare(conn.QueryRow(ctx, `
+        SELECT `+videoShareColumns+`
+        FROM video_shares vs
+        WHERE vs.id = $2 AND `+fmt.Sprintf(visibleShareCondition, "$1"), userID, shareID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.VideoShare{}, ErrNotFound
+		}
+		return models.VideoShare{}, fmt.Errorf("query video share: %w", err)
+	}
+	if share.OwnerID != userID {
+		return share, nil
+	}
+
+	err = conn.QueryRow(ctx, `
+        SELECT
+            ARRAY(SELECT circle_id::TEXT FROM video_share_circles WHERE share_id = $1 ORDER BY circle_id),
+            ARRAY(SELECT user_id::TEXT FROM video_share_users WHERE share_id = $1 ORDER BY user_id)
+    `, shareID).Scan(&share.CircleIDs, &share.UserIDs)
+	if err != nil {
+		return models.VideoShare{}, fmt.Errorf("query video share audience: %w", err)
+	}
+	return share, nil
+}
+
+// Update changes the owner's note on a share and, unless Visibility is empty, its audience,
+// replacing its CircleIDs and UserIDs. It returns ErrNotFound when the share is not the owner's
+// and otherwise fails like Create.
+func (r *PostgresVideoRepository) Update(ctx context.Context, share models.VideoShare) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin video share update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE video_shares SET note = $3, visibility = COALESCE(NULLIF($4, ''), visibility)
+        WHERE id = $1 AND owner_id = $2
+    `, share.ID, share.OwnerID, share.Note, share.Visibility)
+	if err != nil {
+		return fmt.Errorf("update video share: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if share.Visibility != "" {
+		if _, err := tx.Exec(ctx, `DELETE FROM video_share_circles WHERE share_id = $1`, share.ID); err != nil {
+			return fmt.Errorf("delete video share circles: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM video_share_users WHERE share_id = $1`, share.ID); err != nil {
+			return fmt.Errorf("delete video share users: %w", err)
+		}
+		if err := insertShareAudience(ctx, tx, share); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit video share update: %w", err)
+	}
+	return nil
+}
+
+// Delete removes one of the owner's shares together with its audience and the hides that
+// reference it. Stored files are left to the caller.
+func (r *PostgresVideoRepository) Delete(ctx context.Context, ownerID, shareID string) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `DELETE FROM video_shares WHERE id = $1 AND owner_id = $2`, shareID, ownerID)
+	if err != nil {
+		return fmt.Errorf("delete video share: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListByOwner returns every video shared by the owner, newest first.
+func (r *PostgresVideoRepository) ListByOwner(ctx context.Context, ownerID string) ([]models.VideoShare, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT `+videoShareColumns+`
+        FROM video_shares vs
+        WHERE vs.owner_id = $1
+        ORDER BY vs.created_at DESC
+    `, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("query owned video shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []models.VideoShare
+	for rows.Next() {
+		share, err := scanVideoShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan video share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate owned video shares: %w", err)
+	}
+
+	return shares, nil
+}
+
 // MarkAssetReady updates a share's asset metadata after successful ingestion.
 func (r *PostgresVideoRepository) MarkAssetReady(ctx context.Context, shareID, location string, size int64) error {
 	conn, err := r.pool.Acquire(ctx)
@@ -350,6 +1007,86 @@ func (r *PostgresVideoRepository) MarkAssetFailed(ctx context.Context, shareID s
 	return nil
 }
 
+const videoShareColumns = `vs.id, vs.owner_id, vs.url, vs.title, vs.description, vs.thumbnail, vs.created_at, vs.asset_url, vs.asset_status, vs.asset_size, vs.visibility, vs.note, vs.duration_ms`
+
+// shareAudienceCondition holds for video_shares rows vs whose audience includes the user in the
+// %[1]s parameter, provided that user is an accepted friend of the owner.
+const shareAudienceCondition = `(vs.visibility = 'friends'
+                    OR (vs.visibility = 'circles' AND EXISTS (
+                        SELECT 1
+                        FROM video_share_circles vsc
+                        JOIN circle_members cm ON cm.circle_id = vsc.circle_id
+                        WHERE vsc.share_id = vs.id AND cm.member_id = %[1]s
+                    ))
+                    OR (vs.visibility = 'users' AND EXISTS (
+                        SELECT 1 FROM video_share_users vsu WHERE vsu.share_id = vs.id AND vsu.user_id = %[1]s
+                    )))`
+
+// shareDomainCondition holds for video_shares rows vs whose URL host is the lower-case domain in
+// the %[1]s parameter or one of its subdomains.
+const shareDomainCondition = `(lower(substring(vs.url FROM '^[^:/?#]+://(?:[^/?#@]*@)?([^/?#:]+)')) = %[1]s
+               OR lower(substring(vs.url FROM '^[^:/?#]+://(?:[^/?#@]*@)?([^/?#:]+)')) LIKE '%%.' || %[1]s)`
+
+// visibleShareCondition holds for video_shares rows vs the user in the %[1]s parameter may see:
+// their own shares and the shares of accepted friends whose audience includes them. Unlike
+// ListFeed it ignores mutes and hidden shares.
+const visibleShareCondition = `(vs.owner_id = %[1]s
+               OR (EXISTS (
+                       SELECT 1 FROM friend_requests vfr
+                       WHERE vfr.status = 'accepted'
+                         AND ((vfr.requester_id = vs.owner_id AND vfr.receiver_id = %[1]s)
+                              OR (vfr.receiver_id = vs.owner_id AND vfr.requester_id = %[1]s))
+                   ) AND ` + shareAudienceCondition + `))`
+
+// insertShareAudience links a share to the circles or users it is limited to. It returns
+// ErrCircleNotFound when a circle does not belong to the owner and ErrNotFriends when a user is not
+// an accepted friend of the owner.
+func insertShareAudience(ctx context.Context, tx pgx.Tx, share models.VideoShare) error {
+	if len(share.CircleIDs) > 0 {
+		tag, err := tx.Exec(ctx, `
+            INSERT INTO video_share_circles (share_id, circle_id)
+            SELECT $1, c.id
+            FROM circles c
+            WHERE c.id = ANY($3::UUID[]) AND c.owner_id = $2
+        `, share.ID, share.OwnerID, share.CircleIDs)
+		if err != nil {
+			return fmt.Errorf("insert video share circles: %w", err)
+		}
+		if tag.RowsAffected() != int64(len(share.CircleIDs)) {
+			return ErrCircleNotFound
+		}
+	}
+
+	if len(share.UserIDs) > 0 {
+		tag, err := tx.Exec(ctx, `
+            INSERT INTO video_share_users (share_id, user_id)
+            SELECT $1, u.id
+            FROM unnest($3::UUID[]) AS u(id)
+            WHERE EXISTS (
+                SELECT 1 FROM friend_requests fr
+                WHERE fr.status = 'accepted'
+                  AND ((fr.requester_id = $2 AND fr.receiver_id = u.id) OR (fr.receiver_id = $2 AND fr.requester_id = u.id))
+            )
+        `, share.ID, share.OwnerID, share.UserIDs)
+		if err != nil {
+			return fmt.Errorf("insert video share users: %w", err)
+		}
+		if tag.RowsAffected() != int64(len(share.UserIDs)) {
+			return ErrNotFriends
+		}
+	}
+
+	return nil
+}
+
+func scanVideoShare(row pgx.Row) (models.VideoShare, error) {
+	var share models.VideoShare
+	if err := row.Scan(&share.ID, &share.OwnerID, &share.URL, &share.Title, &share.Description, &share.Thumbnail, &share.CreatedAt, &share.AssetURL, &share.AssetStatus, &share.AssetSize, &share.Visibility, &share.Note, &share.DurationMs); err != nil {
+		return models.VideoShare{}, err
+	}
+	return share, nil
+}
+
 var _ UserRepository = (*PostgresUserRepository)(nil)
 var _ FriendRepository = (*PostgresFriendRepository)(nil)
 var _ VideoRepository = (*PostgresVideoRepository)(nil)
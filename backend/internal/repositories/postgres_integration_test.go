@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"testing"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/invites"
 	"github.com/vidfriends/backend/internal/models"
 )
 
@@ -109,6 +112,30 @@ func TestPostgresUserRepository_CreateFindAndUpdate(t *testing.T) {
 		t.Fatalf("expected updated fields to persist, got %+v", fetched)
 	}
 
+	if fetched.EmailVerifiedAt != nil {
+		t.Fatalf("expected new user to be unverified, got %v", fetched.EmailVerifiedAt)
+	}
+
+	verifiedAt := time.Now().UTC().Truncate(time.Microsecond)
+	updated.EmailVerifiedAt = &verifiedAt
+	if err := repo.Update(ctx, updated); err != nil {
+		t.Fatalf("mark email verified: %v", err)
+	}
+
+	byID, err := repo.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("find by id: %v", err)
+	}
+	if byID.Email != updated.Email {
+		t.Fatalf("unexpected user fetched by id: %+v", byID)
+	}
+	if byID.EmailVerifiedAt == nil || !byID.EmailVerifiedAt.Equal(verifiedAt) {
+		t.Fatalf("expected verification time to persist, got %v", byID.EmailVerifiedAt)
+	}
+	if _, err := repo.FindByID(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown id, got %v", err)
+	}
+
 	missing := models.User{
 		ID:        uuid.NewString(),
 		Email:     "missing@example.com",
@@ -171,7 +198,7 @@ func TestPostgresFriendRepository_CreateListAndUpdate(t *testing.T) {
 		t.Fatalf("expected 2 requests, got %d", len(requests))
 	}
 
-	if err := repo.UpdateStatus(ctx, request.ID, "accepted"); err != nil {
+	if _, err := repo.Transition(ctx, models.FriendTransition{RequestID: request.ID, From: "pending", To: "accepted", ActorID: friend.ID, At: time.Now().UTC()}); err != nil {
 		t.Fatalf("update friend request status: %v", err)
 	}
 
@@ -192,11 +219,287 @@ func TestPostgresFriendRepository_CreateListAndUpdate(t *testing.T) {
 		t.Fatalf("expected responded_at to be set after acceptance")
 	}
 
-	if err := repo.UpdateStatus(ctx, uuid.NewString(), "accepted"); !errors.Is(err, ErrNotFound) {
+	if _, err := repo.Transition(ctx, models.FriendTransition{RequestID: uuid.NewString(), From: "pending", To: "accepted", ActorID: friend.ID, At: time.Now().UTC()}); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("expected ErrNotFound for unknown request, got %v", err)
 	}
 }
 
+func TestPostgresFriendRepository_TransitionsAndEvents(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	alice := createTestUser(t, userRepo, "alice@example.com")
+	bob := createTestUser(t, userRepo, "bob@example.com")
+	repo := NewPostgresFriendRepository(testPool)
+
+	created := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	request := models.FriendRequest{ID: uuid.NewString(), Requester: alice.ID, Receiver: bob.ID, Status: "pending", CreatedAt: created}
+	if err := repo.CreateRequest(ctx, request); err != nil {
+		t.Fatalf("create friend request: %v", err)
+	}
+
+	reverse := models.FriendRequest{ID: uuid.NewString(), Requester: bob.ID, Receiver: alice.ID, Status: "pending", CreatedAt: created}
+	if err := repo.CreateRequest(ctx, reverse); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a request in the other direction, got %v", err)
+	}
+
+	found, err := repo.FindBetween(ctx, bob.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("find between: %v", err)
+	}
+	if found.ID != request.ID || found.ActorID != alice.ID || !found.UpdatedAt.Equal(created) {
+		t.Fatalf("unexpected friend request: %+v", found)
+	}
+
+	declinedAt := created.Add(time.Minute)
+	declined, err := repo.Transition(ctx, models.FriendTransition{RequestID: request.ID, From: "pending", To: "declined", ActorID: bob.ID, At: declinedAt})
+	if err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	if declined.Status != "declined" || declined.ActorID != bob.ID || declined.RespondedAt == nil || !declined.RespondedAt.Equal(declinedAt) {
+		t.Fatalf("unexpected declined request: %+v", declined)
+	}
+
+	if _, err := repo.Transition(ctx, models.FriendTransition{RequestID: request.ID, From: "pending", To: "accepted", ActorID: bob.ID, At: declinedAt}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a stale transition, got %v", err)
+	}
+
+	reopenedAt := created.Add(2 * time.Minute)
+	reopened, err := repo.Transition(ctx, models.FriendTransition{
+		RequestID: request.ID, From: "declined", To: "pending", ActorID: bob.ID, At: reopenedAt,
+		Requester: bob.ID, Receiver: alice.ID,
+	})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if reopened.Requester != bob.ID || reopened.Receiver != alice.ID || reopened.RespondedAt != nil || !reopened.CreatedAt.Equal(reopenedAt) {
+		t.Fatalf("unexpected reopened request: %+v", reopened)
+	}
+
+	acceptedAt := created.Add(3 * time.Minute)
+	if _, err := repo.Transition(ctx, models.FriendTransition{RequestID: request.ID, From: "pending", To: "accepted", ActorID: alice.ID, At: acceptedAt}); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	blocked, err := repo.Transition(ctx, models.FriendTransition{RequestID: request.ID, From: "accepted", To: "blocked", ActorID: bob.ID, At: acceptedAt.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if blocked.ActorID != bob.ID || blocked.RespondedAt == nil || !blocked.RespondedAt.Equal(acceptedAt) {
+		t.Fatalf("expected block to keep the acceptance time and record the blocker, got %+v", blocked)
+	}
+
+	byID, err := repo.FindByID(ctx, request.ID)
+	if err != nil {
+		t.Fatalf("find by id: %v", err)
+	}
+	if byID.Status != "blocked" || byID.ActorID != bob.ID {
+		t.Fatalf("unexpected friend request: %+v", byID)
+	}
+	if _, err := repo.FindByID(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	events, err := repo.ListEvents(ctx, request.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	want := []struct{ actor, from, to string }{
+		{alice.ID, "", "pending"},
+		{bob.ID, "pending", "declined"},
+		{bob.ID, "declined", "pending"},
+		{alice.ID, "pending", "accepted"},
+		{bob.ID, "accepted", "blocked"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i].ActorID != w.actor || events[i].FromStatus != w.from || events[i].ToStatus != w.to {
+			t.Fatalf("event %d: expected %+v, got %+v", i, w, events[i])
+		}
+	}
+}
+
+func TestPostgresFriendRepository_ViewsPageAndCount(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	repo := NewPostgresFriendRepository(testPool)
+	me := createTestUser(t, userRepo, "me@example.com")
+	base := time.Now().UTC().Truncate(time.Second).Add(-24 * time.Hour)
+
+	create := func(email string, outgoing bool, status string, at time.Time) string {
+		other := createTestUser(t, userRepo, email)
+		request := models.FriendRequest{ID: uuid.NewString(), Requester: other.ID, Receiver: me.ID, Status: "pending", CreatedAt: at}
+		if outgoing {
+			request.Requester, request.Receiver = me.ID, other.ID
+		}
+		if err := repo.CreateRequest(ctx, request); err != nil {
+			t.Fatalf("create friend request: %v", err)
+		}
+		if status != "pending" {
+			if _, err := repo.Transition(ctx, models.FriendTransition{RequestID: request.ID, From: "pending", To: status, ActorID: me.ID, At: at.Add(time.Minute)}); err != nil {
+				t.Fatalf("transition to %s: %v", status, err)
+			}
+		}
+		return other.ID
+	}
+
+	// Friends on both sides of the request, with two sharing a since-date to exercise the id tiebreak.
+	var friends []string
+	for i := 0; i < 5; i++ {
+		at := base.Add(time.Duration(i) * time.Hour)
+		if i == 4 {
+			at = base.Add(3 * time.Hour)
+		}
+		friends = append(friends, create(fmt.Sprintf("friend%d@example.com", i), i%2 == 0, "accepted", at))
+	}
+	incoming := create("incoming@example.com", false, "pending", base)
+	outgoing := create("outgoing@example.com", true, "pending", base)
+	blocked := create("blocked@example.com", false, "blocked", base)
+	create("declined@example.com", false, "declined", base)
+
+	counts, err := repo.CountViews(ctx, me.ID)
+	if err != nil {
+		t.Fatalf("count views: %v", err)
+	}
+	if counts != (models.FriendCounts{Accepted: 5, Incoming: 1, Outgoing: 1, Blocked: 1}) {
+		t.Fatalf("unexpected counts: %+v", counts)
+	}
+
+	var seen []string
+	var after *models.FriendCursor
+	for {
+		page, err := repo.ListView(ctx, me.ID, models.FriendViewAccepted, after, 2)
+		if err != nil {
+			t.Fatalf("list accepted: %v", err)
+		}
+		for i, entry := range page {
+			if after != nil && !entry.Since.Before(after.Since) && !(entry.Since.Equal(after.Since) && entry.RequestID < after.RequestID) {
+				t.Fatalf("entry %d is not after the cursor: %+v", i, entry)
+			}
+			seen = append(seen, entry.UserID)
+		}
+		if len(page) < 2 {
+			break
+		}
+		last := page[len(page)-1]
+		after = &models.FriendCursor{Since: last.Since, RequestID: last.RequestID}
+	}
+	if len(seen) != len(friends) {
+		t.Fatalf("expected %d friends across pages, got %v", len(friends), seen)
+	}
+	sort.Strings(seen)
+	sort.Strings(friends)
+	for i := range friends {
+		if seen[i] != friends[i] {
+			t.Fatalf("expected friends %v, got %v", friends, seen)
+		}
+	}
+
+	for view, want := range map[models.FriendView]string{
+		models.FriendViewIncoming: incoming,
+		models.FriendViewOutgoing: outgoing,
+		models.FriendViewBlocked:  blocked,
+	} {
+		entries, err := repo.ListView(ctx, me.ID, view, nil, 10)
+		if err != nil {
+			t.Fatalf("list %s: %v", view, err)
+		}
+		if len(entries) != 1 || entries[0].UserID != want {
+			t.Fatalf("%s: expected only %s, got %+v", view, want, entries)
+		}
+	}
+
+	other, err := repo.ListView(ctx, blocked, models.FriendViewBlocked, nil, 10)
+	if err != nil {
+		t.Fatalf("list blocked for blocked user: %v", err)
+	}
+	if len(other) != 0 {
+		t.Fatalf("expected blocked users not to see the block, got %+v", other)
+	}
+}
+
+func TestPostgresFriendRepository_MutualAndSuggestions(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	repo := NewPostgresFriendRepository(testPool)
+
+	me := createTestUser(t, userRepo, "me@example.com")
+	alice := createTestUser(t, userRepo, "alice@example.com")
+	bob := createTestUser(t, userRepo, "bob@example.com")
+	popular := createTestUser(t, userRepo, "popular@example.com")
+	single := createTestUser(t, userRepo, "single@example.com")
+	pending := createTestUser(t, userRepo, "pending@example.com")
+	blocker := createTestUser(t, userRepo, "blocker@example.com")
+	dismissed := createTestUser(t, userRepo, "dismissed@example.com")
+	now := time.Now().UTC()
+
+	createFriendship(t, repo, me.ID, alice.ID)
+	createFriendship(t, repo, bob.ID, me.ID)
+	createFriendship(t, repo, alice.ID, popular.ID)
+	createFriendship(t, repo, popular.ID, bob.ID)
+	for _, candidate := range []models.User{single, pending, blocker, dismissed} {
+		createFriendship(t, repo, alice.ID, candidate.ID)
+	}
+	if err := repo.CreateRequest(ctx, models.FriendRequest{ID: uuid.NewString(), Requester: pending.ID, Receiver: me.ID, Status: "pending", CreatedAt: now}); err != nil {
+		t.Fatalf("create pending request: %v", err)
+	}
+	if err := repo.CreateRequest(ctx, models.FriendRequest{ID: uuid.NewString(), Requester: blocker.ID, Receiver: me.ID, Status: "blocked", CreatedAt: now}); err != nil {
+		t.Fatalf("create block: %v", err)
+	}
+	// A declined request is not a friendship, so bob does not count towards single.
+	if err := repo.CreateRequest(ctx, models.FriendRequest{ID: uuid.NewString(), Requester: single.ID, Receiver: bob.ID, Status: "declined", CreatedAt: now}); err != nil {
+		t.Fatalf("create declined request: %v", err)
+	}
+
+	mutual, err := repo.ListMutual(ctx, me.ID, popular.ID)
+	if err != nil {
+		t.Fatalf("list mutual friends: %v", err)
+	}
+	want := []string{alice.ID, bob.ID}
+	slices.Sort(want)
+	if !slices.Equal(mutual, want) {
+		t.Fatalf("expected mutual friends %v, got %v", want, mutual)
+	}
+	if mutual, err := repo.ListMutual(ctx, me.ID, dismissed.ID); err != nil || !slices.Equal(mutual, []string{alice.ID}) {
+		t.Fatalf("expected alice as the only mutual friend, got %v (err %v)", mutual, err)
+	}
+
+	if err := repo.DismissSuggestion(ctx, me.ID, dismissed.ID, now); err != nil {
+		t.Fatalf("dismiss suggestion: %v", err)
+	}
+	if err := repo.DismissSuggestion(ctx, me.ID, dismissed.ID, now.Add(time.Minute)); err != nil {
+		t.Fatalf("dismiss suggestion again: %v", err)
+	}
+	if err := repo.DismissSuggestion(ctx, me.ID, uuid.NewString(), now); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected unknown users to be rejected, got %v", err)
+	}
+
+	suggestions, err := repo.ListSuggestions(ctx, me.ID, 10)
+	if err != nil {
+		t.Fatalf("list suggestions: %v", err)
+	}
+	wantSuggestions := []models.FriendSuggestion{{UserID: popular.ID, MutualCount: 2}, {UserID: single.ID, MutualCount: 1}}
+	if !slices.Equal(suggestions, wantSuggestions) {
+		t.Fatalf("expected suggestions %+v, got %+v", wantSuggestions, suggestions)
+	}
+
+	if suggestions, err := repo.ListSuggestions(ctx, me.ID, 1); err != nil || len(suggestions) != 1 || suggestions[0].UserID != popular.ID {
+		t.Fatalf("expected limit to keep the best suggestion, got %+v (err %v)", suggestions, err)
+	}
+
+	// Suggestions disappear once a request exists.
+	createFriendship(t, repo, me.ID, popular.ID)
+	if suggestions, err := repo.ListSuggestions(ctx, me.ID, 10); err != nil || len(suggestions) != 1 || suggestions[0].UserID != single.ID {
+		t.Fatalf("expected new friend to be left out, got %+v (err %v)", suggestions, err)
+	}
+}
+
 func TestPostgresSessionStore_SaveFindAndDelete(t *testing.T) {
 	ctx := context.Background()
 	resetDatabase(t)
@@ -207,6 +510,7 @@ func TestPostgresSessionStore_SaveFindAndDelete(t *testing.T) {
 	store := NewPostgresSessionStore(testPool)
 	expires := time.Now().UTC().Add(24 * time.Hour)
 	session := auth.Session{
+		ID:           uuid.NewString(),
 		RefreshToken: uuid.NewString(),
 		UserID:       user.ID,
 		ExpiresAt:    expires,
@@ -221,7 +525,7 @@ func TestPostgresSessionStore_SaveFindAndDelete(t *testing.T) {
 		t.Fatalf("find session: %v", err)
 	}
 
-	if loaded.UserID != session.UserID || !timesClose(loaded.ExpiresAt, expires.UTC(), time.Millisecond) {
+	if loaded.ID != session.ID || loaded.UserID != session.UserID || !timesClose(loaded.ExpiresAt, expires.UTC(), time.Millisecond) {
 		t.Fatalf("unexpected session loaded: %+v", loaded)
 	}
 
@@ -253,273 +557,1829 @@ func TestPostgresSessionStore_SaveFindAndDelete(t *testing.T) {
 	}
 }
 
-func TestPostgresVideoRepository_ListFeed(t *testing.T) {
+func TestPostgresSessionStore_RotationAndFamilies(t *testing.T) {
 	ctx := context.Background()
 	resetDatabase(t)
 
 	userRepo := NewPostgresUserRepository(testPool)
-	friendRepo := NewPostgresFriendRepository(testPool)
-	videoRepo := NewPostgresVideoRepository(testPool)
+	user := createTestUser(t, userRepo, "family@example.com")
 
-	viewer := createTestUser(t, userRepo, "viewer@example.com")
-	acceptedFriend := createTestUser(t, userRepo, "accepted@example.com")
-	pendingFriend := createTestUser(t, userRepo, "pending@example.com")
-	stranger := createTestUser(t, userRepo, "stranger@example.com")
+	store := NewPostgresSessionStore(testPool)
+	familyID := uuid.NewString()
+	expires := time.Now().UTC().Add(time.Hour)
 
-	acceptedReq := models.FriendRequest{
-		ID:        uuid.NewString(),
-		Requester: viewer.ID,
-		Receiver:  acceptedFriend.ID,
-		Status:    "accepted",
-		CreatedAt: time.Now().UTC().Add(-2 * time.Hour),
-	}
-	if err := friendRepo.CreateRequest(ctx, acceptedReq); err != nil {
-		t.Fatalf("create accepted request: %v", err)
+	first := auth.Session{ID: familyID, RefreshToken: uuid.NewString(), UserID: user.ID, ExpiresAt: expires}
+	second := auth.Session{ID: familyID, RefreshToken: uuid.NewString(), UserID: user.ID, ExpiresAt: expires}
+	unrelated := auth.Session{ID: uuid.NewString(), RefreshToken: uuid.NewString(), UserID: user.ID, ExpiresAt: expires}
+
+	for _, session := range []auth.Session{first, second, unrelated} {
+		if err := store.Save(ctx, session); err != nil {
+			t.Fatalf("save session: %v", err)
+		}
 	}
-	if err := friendRepo.UpdateStatus(ctx, acceptedReq.ID, "accepted"); err != nil {
-		t.Fatalf("confirm accepted request status: %v", err)
+
+	if err := store.MarkRotated(ctx, first.RefreshToken, time.Now()); err != nil {
+		t.Fatalf("mark rotated: %v", err)
 	}
 
-	pendingReq := models.FriendRequest{
-		ID:        uuid.NewString(),
-		Requester: viewer.ID,
-		Receiver:  pendingFriend.ID,
-		Status:    "pending",
-		CreatedAt: time.Now().UTC().Add(-time.Hour),
+	loaded, err := store.Find(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("find rotated session: %v", err)
 	}
-	if err := friendRepo.CreateRequest(ctx, pendingReq); err != nil {
-		t.Fatalf("create pending request: %v", err)
+	if loaded.RotatedAt == nil {
+		t.Fatal("expected rotated_at to be set")
 	}
 
-	baseTime := time.Now().UTC().Add(-30 * time.Minute)
-	ownShare := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     viewer.ID,
-		URL:         "https://example.com/own",
-		Title:       "Viewer Share",
-		CreatedAt:   baseTime.Add(2 * time.Minute),
-		AssetStatus: models.AssetStatusPending,
+	if err := store.MarkRotated(ctx, first.RefreshToken, time.Now()); !errors.Is(err, auth.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused rotating twice, got %v", err)
 	}
-	acceptedShare := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     acceptedFriend.ID,
-		URL:         "https://example.com/accepted",
-		Title:       "Accepted Share",
-		CreatedAt:   baseTime.Add(5 * time.Minute),
-		AssetStatus: models.AssetStatusPending,
+	if err := store.MarkRotated(ctx, uuid.NewString(), time.Now()); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound rotating unknown token, got %v", err)
 	}
-	pendingShare := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     pendingFriend.ID,
-		URL:         "https://example.com/pending",
-		Title:       "Pending Share",
-		CreatedAt:   baseTime.Add(10 * time.Minute),
-		AssetStatus: models.AssetStatusPending,
+
+	if err := store.DeleteFamily(ctx, familyID); err != nil {
+		t.Fatalf("delete family: %v", err)
 	}
-	strangerShare := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     stranger.ID,
-		URL:         "https://example.com/stranger",
-		Title:       "Stranger Share",
-		CreatedAt:   baseTime.Add(15 * time.Minute),
-		AssetStatus: models.AssetStatusPending,
+
+	for _, token := range []string{first.RefreshToken, second.RefreshToken} {
+		if _, err := store.Find(ctx, token); !errors.Is(err, auth.ErrSessionNotFound) {
+			t.Fatalf("expected family token to be deleted, got %v", err)
+		}
+	}
+	if _, err := store.Find(ctx, unrelated.RefreshToken); err != nil {
+		t.Fatalf("expected unrelated session to survive: %v", err)
 	}
+}
 
-	for _, share := range []models.VideoShare{ownShare, acceptedShare, pendingShare, strangerShare} {
-		if err := videoRepo.Create(ctx, share); err != nil {
-			t.Fatalf("create share %s: %v", share.ID, err)
+func TestPostgresSessionStore_ListAndDeleteForUser(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	owner := createTestUser(t, userRepo, "devices@example.com")
+	other := createTestUser(t, userRepo, "elsewhere@example.com")
+
+	store := NewPostgresSessionStore(testPool)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	refreshedAt := now.Add(-time.Minute)
+
+	familyID := uuid.NewString()
+	rotated := auth.Session{ID: familyID, RefreshToken: uuid.NewString(), UserID: owner.ID, ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(-time.Hour)}
+	laptop := auth.Session{
+		ID:              familyID,
+		RefreshToken:    uuid.NewString(),
+		UserID:          owner.ID,
+		ExpiresAt:       now.Add(time.Hour),
+		CreatedAt:       now.Add(-time.Hour),
+		LastRefreshedAt: &refreshedAt,
+		UserAgent:       "Firefox",
+		ClientIP:        "203.0.113.10",
+	}
+	phone := auth.Session{ID: uuid.NewString(), RefreshToken: uuid.NewString(), UserID: owner.ID, ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(-30 * time.Minute), UserAgent: "VidFriends iOS"}
+	foreign := auth.Session{ID: uuid.NewString(), RefreshToken: uuid.NewString(), UserID: other.ID, ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+
+	for _, session := range []auth.Session{rotated, laptop, phone, foreign} {
+		if err := store.Save(ctx, session); err != nil {
+			t.Fatalf("save session: %v", err)
 		}
 	}
+	if err := store.MarkRotated(ctx, rotated.RefreshToken, refreshedAt); err != nil {
+		t.Fatalf("mark rotated: %v", err)
+	}
 
-	feed, err := videoRepo.ListFeed(ctx, viewer.ID)
+	sessions, err := store.ListForUser(ctx, owner.ID)
 	if err != nil {
-		t.Fatalf("list feed: %v", err)
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected one entry per active session, got %d", len(sessions))
 	}
 
-	if len(feed) != 2 {
-		t.Fatalf("expected 2 feed entries (viewer + accepted friend), got %d", len(feed))
+	first := sessions[0]
+	if first.RefreshToken != laptop.RefreshToken {
+		t.Fatalf("expected most recently used session first, got %+v", sessions)
+	}
+	if first.UserAgent != "Firefox" || first.ClientIP != "203.0.113.10" {
+		t.Fatalf("unexpected client details %+v", first)
+	}
+	if !first.CreatedAt.Equal(laptop.CreatedAt) || first.LastRefreshedAt == nil || !first.LastRefreshedAt.Equal(refreshedAt) {
+		t.Fatalf("unexpected session times %+v", first)
+	}
+	if sessions[1].LastRefreshedAt != nil {
+		t.Fatalf("expected never refreshed session, got %+v", sessions[1])
 	}
 
-	if feed[0].ID != acceptedShare.ID || feed[1].ID != ownShare.ID {
-		t.Fatalf("unexpected feed order: %+v", feed)
+	if err := store.DeleteForUser(ctx, owner.ID); err != nil {
+		t.Fatalf("delete for user: %v", err)
 	}
 
-	for _, share := range feed {
-		if share.OwnerID == pendingFriend.ID || share.OwnerID == stranger.ID {
-			t.Fatalf("unexpected share from owner %s in feed", share.OwnerID)
-		}
-		if share.AssetStatus == "" {
-			t.Fatalf("expected asset status to be populated for share %s", share.ID)
-		}
+	sessions, err = store.ListForUser(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("list sessions after delete: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("expected no sessions, got %d", len(sessions))
+	}
+	if _, err := store.Find(ctx, rotated.RefreshToken); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected rotated token to be deleted, got %v", err)
+	}
+	if _, err := store.Find(ctx, foreign.RefreshToken); err != nil {
+		t.Fatalf("expected other user's session to survive: %v", err)
 	}
 }
 
-func TestPostgresVideoRepository_ListFeedIncludesInboundAcceptedFriends(t *testing.T) {
+func TestPostgresUserTokenStore_ConsumeOnce(t *testing.T) {
 	ctx := context.Background()
 	resetDatabase(t)
 
 	userRepo := NewPostgresUserRepository(testPool)
-	friendRepo := NewPostgresFriendRepository(testPool)
-	videoRepo := NewPostgresVideoRepository(testPool)
+	user := createTestUser(t, userRepo, "reset@example.com")
 
-	viewer := createTestUser(t, userRepo, "viewer-inbound@example.com")
-	inboundFriend := createTestUser(t, userRepo, "inbound@example.com")
-	pendingFriend := createTestUser(t, userRepo, "pending-inbound@example.com")
+	store := NewPostgresUserTokenStore(testPool)
+	now := time.Now().UTC()
 
-	inboundReq := models.FriendRequest{
-		ID:        uuid.NewString(),
-		Requester: inboundFriend.ID,
-		Receiver:  viewer.ID,
-		Status:    "pending",
-		CreatedAt: time.Now().UTC().Add(-2 * time.Hour),
-	}
-	if err := friendRepo.CreateRequest(ctx, inboundReq); err != nil {
-		t.Fatalf("create inbound friend request: %v", err)
+	token := auth.UserToken{Hash: "hash-valid", UserID: user.ID, Purpose: auth.PurposePasswordReset, ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	expired := auth.UserToken{Hash: "hash-expired", UserID: user.ID, Purpose: auth.PurposePasswordReset, ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)}
+	for _, record := range []auth.UserToken{token, expired} {
+		if err := store.Save(ctx, record); err != nil {
+			t.Fatalf("save token: %v", err)
+		}
 	}
-	if err := friendRepo.UpdateStatus(ctx, inboundReq.ID, "accepted"); err != nil {
-		t.Fatalf("accept inbound friend request: %v", err)
+
+	if _, err := store.Consume(ctx, token.Hash, "email_verification", now); !errors.Is(err, auth.ErrUserTokenInvalid) {
+		t.Fatalf("expected purpose mismatch to be rejected, got %v", err)
 	}
 
-	pendingReq := models.FriendRequest{
-		ID:        uuid.NewString(),
-		Requester: viewer.ID,
-		Receiver:  pendingFriend.ID,
-		Status:    "pending",
-		CreatedAt: time.Now().UTC().Add(-time.Hour),
+	consumed, err := store.Consume(ctx, token.Hash, auth.PurposePasswordReset, now)
+	if err != nil {
+		t.Fatalf("consume token: %v", err)
 	}
-	if err := friendRepo.CreateRequest(ctx, pendingReq); err != nil {
-		t.Fatalf("create pending friend request: %v", err)
+	if consumed.UserID != user.ID || consumed.ConsumedAt == nil {
+		t.Fatalf("unexpected consumed token %+v", consumed)
 	}
 
-	baseTime := time.Now().UTC().Add(-45 * time.Minute)
-	inboundShare := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     inboundFriend.ID,
-		URL:         "https://example.com/inbound",
-		Title:       "Inbound Share",
-		CreatedAt:   baseTime.Add(10 * time.Minute),
-		AssetStatus: models.AssetStatusPending,
+	if _, err := store.Consume(ctx, token.Hash, auth.PurposePasswordReset, now); !errors.Is(err, auth.ErrUserTokenInvalid) {
+		t.Fatalf("expected second consume to fail, got %v", err)
 	}
-	viewerShare := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     viewer.ID,
-		URL:         "https://example.com/viewer",
-		Title:       "Viewer Share",
-		CreatedAt:   baseTime.Add(15 * time.Minute),
-		AssetStatus: models.AssetStatusPending,
+	if _, err := store.Consume(ctx, expired.Hash, auth.PurposePasswordReset, now); !errors.Is(err, auth.ErrUserTokenInvalid) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
 	}
-	pendingShare := models.VideoShare{
+
+	if err := store.DeleteForUser(ctx, user.ID, auth.PurposePasswordReset); err != nil {
+		t.Fatalf("delete tokens: %v", err)
+	}
+
+	change := auth.UserToken{Hash: "hash-change", UserID: user.ID, Purpose: auth.PurposeEmailChange, Payload: "new@example.com", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	if err := store.Save(ctx, change); err != nil {
+		t.Fatalf("save email change token: %v", err)
+	}
+	consumed, err = store.Consume(ctx, change.Hash, auth.PurposeEmailChange, now)
+	if err != nil {
+		t.Fatalf("consume email change token: %v", err)
+	}
+	if consumed.Payload != change.Payload {
+		t.Fatalf("expected payload %q, got %q", change.Payload, consumed.Payload)
+	}
+}
+
+func TestPostgresTwoFactorStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	user := createTestUser(t, userRepo, "totp@example.com")
+
+	store := NewPostgresTwoFactorStore(testPool)
+	now := time.Now().UTC()
+
+	if _, err := store.Find(ctx, user.ID); !errors.Is(err, auth.ErrTwoFactorNotEnrolled) {
+		t.Fatalf("expected missing enrollment, got %v", err)
+	}
+
+	if err := store.SavePending(ctx, auth.TwoFactorEnrollment{UserID: user.ID, Secret: "SECRET", CreatedAt: now}); err != nil {
+		t.Fatalf("save pending: %v", err)
+	}
+	if err := store.Enable(ctx, user.ID, now, 100, []string{"code-a", "code-b"}); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+
+	enrollment, err := store.Find(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("find enrollment: %v", err)
+	}
+	if enrollment.EnabledAt == nil || enrollment.LastUsedStep != 100 || enrollment.Secret != "SECRET" {
+		t.Fatalf("unexpected enrollment %+v", enrollment)
+	}
+
+	if err := store.SavePending(ctx, auth.TwoFactorEnrollment{UserID: user.ID, Secret: "OTHER", CreatedAt: now}); !errors.Is(err, auth.ErrTwoFactorAlreadyEnabled) {
+		t.Fatalf("expected enabled enrollment to be kept, got %v", err)
+	}
+
+	if err := store.RecordStep(ctx, user.ID, 100); !errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected used step to be rejected, got %v", err)
+	}
+	if err := store.RecordStep(ctx, user.ID, 101); err != nil {
+		t.Fatalf("record step: %v", err)
+	}
+
+	if err := store.UseRecoveryCode(ctx, user.ID, "code-a", now); err != nil {
+		t.Fatalf("use recovery code: %v", err)
+	}
+	if err := store.UseRecoveryCode(ctx, user.ID, "code-a", now); !errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected recovery code to be single use, got %v", err)
+	}
+
+	if err := store.ReplaceRecoveryCodes(ctx, user.ID, []string{"code-c"}); err != nil {
+		t.Fatalf("replace recovery codes: %v", err)
+	}
+	if err := store.UseRecoveryCode(ctx, user.ID, "code-b", now); !errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected replaced recovery code to be rejected, got %v", err)
+	}
+
+	if err := store.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Find(ctx, user.ID); !errors.Is(err, auth.ErrTwoFactorNotEnrolled) {
+		t.Fatalf("expected enrollment to be deleted, got %v", err)
+	}
+}
+
+func TestPostgresIdentityRepository_FindAndCreate(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	user := createTestUser(t, userRepo, "oidc@example.com")
+
+	repo := NewPostgresIdentityRepository(testPool)
+	if _, err := repo.Find(ctx, "google", "subject-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected missing identity, got %v", err)
+	}
+
+	identity := models.UserIdentity{Provider: "google", Subject: "subject-1", UserID: user.ID, Email: user.Email, CreatedAt: time.Now().UTC()}
+	if err := repo.Create(ctx, identity); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	if err := repo.Create(ctx, identity); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected duplicate identity to conflict, got %v", err)
+	}
+
+	found, err := repo.Find(ctx, "google", "subject-1")
+	if err != nil {
+		t.Fatalf("find identity: %v", err)
+	}
+	if found.UserID != user.ID || found.Email != user.Email {
+		t.Fatalf("unexpected identity %+v", found)
+	}
+}
+
+func TestPostgresLoginAttemptStore_CountsLocksAndResets(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	store := NewPostgresLoginAttemptStore(testPool)
+	email := "locked@example.com"
+
+	attempts, err := store.Find(ctx, email)
+	if err != nil {
+		t.Fatalf("find attempts: %v", err)
+	}
+	if attempts.FailedCount != 0 || attempts.LockedUntil != nil {
+		t.Fatalf("expected empty attempts, got %+v", attempts)
+	}
+
+	start := time.Now().UTC().Truncate(time.Second)
+	for i := 1; i <= 3; i++ {
+		at := start.Add(time.Duration(i) * time.Second)
+		attempts, err = store.RecordFailure(ctx, email, at, at.Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("record failure %d: %v", i, err)
+		}
+		if attempts.FailedCount != i {
+			t.Fatalf("expected count %d, got %d", i, attempts.FailedCount)
+		}
+	}
+
+	later := start.Add(2 * time.Hour)
+	attempts, err = store.RecordFailure(ctx, email, later, later.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("record failure after window: %v", err)
+	}
+	if attempts.FailedCount != 1 {
+		t.Fatalf("expected count to restart after window, got %d", attempts.FailedCount)
+	}
+
+	until := later.Add(15 * time.Minute)
+	if err := store.Lock(ctx, email, until); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	attempts, err = store.Find(ctx, email)
+	if err != nil {
+		t.Fatalf("find locked attempts: %v", err)
+	}
+	if attempts.LockedUntil == nil || !attempts.LockedUntil.Equal(until) {
+		t.Fatalf("expected lock until %v, got %+v", until, attempts.LockedUntil)
+	}
+
+	if err := store.Reset(ctx, email); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	attempts, err = store.Find(ctx, email)
+	if err != nil {
+		t.Fatalf("find reset attempts: %v", err)
+	}
+	if attempts.FailedCount != 0 || attempts.LockedUntil != nil {
+		t.Fatalf("expected attempts to be cleared, got %+v", attempts)
+	}
+}
+
+func TestPostgresAPITokenStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	other := createTestUser(t, userRepo, "other@example.com")
+
+	store := NewPostgresAPITokenStore(testPool)
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Microsecond)
+	token := auth.APIToken{
+		ID:        uuid.NewString(),
+		UserID:    owner.ID,
+		Name:      "CI bot",
+		Hash:      "hash-1",
+		Scopes:    []string{auth.ScopeFeedRead, auth.ScopeVideosWrite},
+		ExpiresAt: &expiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.Create(ctx, token); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	found, err := store.FindByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("find by hash: %v", err)
+	}
+	if found.ID != token.ID || found.Name != "CI bot" || len(found.Scopes) != 2 || found.ExpiresAt == nil || !found.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("unexpected token %+v", found)
+	}
+
+	if _, err := store.Find(ctx, other.ID, token.ID); !errors.Is(err, auth.ErrAPITokenNotFound) {
+		t.Fatalf("expected token to be hidden from other users, got %v", err)
+	}
+	if err := store.Delete(ctx, other.ID, token.ID); !errors.Is(err, auth.ErrAPITokenNotFound) {
+		t.Fatalf("expected other users not to delete the token, got %v", err)
+	}
+
+	usedAt := time.Now().UTC().Truncate(time.Microsecond)
+	if err := store.TouchLastUsed(ctx, token.ID, usedAt); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	if err := store.Rename(ctx, owner.ID, token.ID, "Deploy bot"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	found, err = store.Find(ctx, owner.ID, token.ID)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if found.Name != "Deploy bot" || found.LastUsedAt == nil || !found.LastUsedAt.Equal(usedAt) {
+		t.Fatalf("unexpected token after update %+v", found)
+	}
+
+	second := token
+	second.ID = uuid.NewString()
+	second.Hash = "hash-2"
+	second.ExpiresAt = nil
+	if err := store.Create(ctx, second); err != nil {
+		t.Fatalf("create second token: %v", err)
+	}
+	listed, err := store.ListForUser(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("expected 2 tokens, got %d", len(listed))
+	}
+
+	if err := store.DeleteForUser(ctx, owner.ID); err != nil {
+		t.Fatalf("delete for user: %v", err)
+	}
+	if _, err := store.FindByHash(ctx, "hash-2"); !errors.Is(err, auth.ErrAPITokenNotFound) {
+		t.Fatalf("expected tokens to be deleted, got %v", err)
+	}
+}
+
+func TestPostgresVideoRepository_ListFeed(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+
+	viewer := createTestUser(t, userRepo, "viewer@example.com")
+	acceptedFriend := createTestUser(t, userRepo, "accepted@example.com")
+	pendingFriend := createTestUser(t, userRepo, "pending@example.com")
+	stranger := createTestUser(t, userRepo, "stranger@example.com")
+
+	acceptedReq := models.FriendRequest{
+		ID:        uuid.NewString(),
+		Requester: viewer.ID,
+		Receiver:  acceptedFriend.ID,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC().Add(-2 * time.Hour),
+	}
+	if err := friendRepo.CreateRequest(ctx, acceptedReq); err != nil {
+		t.Fatalf("create accepted request: %v", err)
+	}
+	if _, err := friendRepo.Transition(ctx, models.FriendTransition{RequestID: acceptedReq.ID, From: "pending", To: "accepted", ActorID: acceptedFriend.ID, At: time.Now().UTC()}); err != nil {
+		t.Fatalf("accept request: %v", err)
+	}
+
+	pendingReq := models.FriendRequest{
+		ID:        uuid.NewString(),
+		Requester: viewer.ID,
+		Receiver:  pendingFriend.ID,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC().Add(-time.Hour),
+	}
+	if err := friendRepo.CreateRequest(ctx, pendingReq); err != nil {
+		t.Fatalf("create pending request: %v", err)
+	}
+
+	baseTime := time.Now().UTC().Add(-30 * time.Minute)
+	ownShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     viewer.ID,
+		URL:         "https://example.com/own",
+		Title:       "Viewer Share",
+		CreatedAt:   baseTime.Add(2 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+	acceptedShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     acceptedFriend.ID,
+		URL:         "https://example.com/accepted",
+		Title:       "Accepted Share",
+		CreatedAt:   baseTime.Add(5 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+	pendingShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     pendingFriend.ID,
+		URL:         "https://example.com/pending",
+		Title:       "Pending Share",
+		CreatedAt:   baseTime.Add(10 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+	strangerShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     stranger.ID,
+		URL:         "https://example.com/stranger",
+		Title:       "Stranger Share",
+		CreatedAt:   baseTime.Add(15 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+
+	for _, share := range []models.VideoShare{ownShare, acceptedShare, pendingShare, strangerShare} {
+		if err := videoRepo.Create(ctx, share); err != nil {
+			t.Fatalf("create share %s: %v", share.ID, err)
+		}
+	}
+
+	feed, err := videoRepo.ListFeed(ctx, viewer.ID, models.FeedFilter{}, nil, 100)
+	if err != nil {
+		t.Fatalf("list feed: %v", err)
+	}
+
+	if len(feed) != 2 {
+		t.Fatalf("expected 2 feed entries (viewer + accepted friend), got %d", len(feed))
+	}
+
+	if feed[0].ID != acceptedShare.ID || feed[1].ID != ownShare.ID {
+		t.Fatalf("unexpected feed order: %+v", feed)
+	}
+
+	for _, share := range feed {
+		if share.OwnerID == pendingFriend.ID || share.OwnerID == stranger.ID {
+			t.Fatalf("unexpected share from owner %s in feed", share.OwnerID)
+		}
+		if share.AssetStatus == "" {
+			t.Fatalf("expected asset status to be populated for share %s", share.ID)
+		}
+	}
+}
+
+func TestPostgresVideoRepository_ListFeedIncludesInboundAcceptedFriends(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+
+	viewer := createTestUser(t, userRepo, "viewer-inbound@example.com")
+	inboundFriend := createTestUser(t, userRepo, "inbound@example.com")
+	pendingFriend := createTestUser(t, userRepo, "pending-inbound@example.com")
+
+	inboundReq := models.FriendRequest{
+		ID:        uuid.NewString(),
+		Requester: inboundFriend.ID,
+		Receiver:  viewer.ID,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC().Add(-2 * time.Hour),
+	}
+	if err := friendRepo.CreateRequest(ctx, inboundReq); err != nil {
+		t.Fatalf("create inbound friend request: %v", err)
+	}
+	if _, err := friendRepo.Transition(ctx, models.FriendTransition{RequestID: inboundReq.ID, From: "pending", To: "accepted", ActorID: viewer.ID, At: time.Now().UTC()}); err != nil {
+		t.Fatalf("accept inbound friend request: %v", err)
+	}
+
+	pendingReq := models.FriendRequest{
+		ID:        uuid.NewString(),
+		Requester: viewer.ID,
+		Receiver:  pendingFriend.ID,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC().Add(-time.Hour),
+	}
+	if err := friendRepo.CreateRequest(ctx, pendingReq); err != nil {
+		t.Fatalf("create pending friend request: %v", err)
+	}
+
+	baseTime := time.Now().UTC().Add(-45 * time.Minute)
+	inboundShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     inboundFriend.ID,
+		URL:         "https://example.com/inbound",
+		Title:       "Inbound Share",
+		CreatedAt:   baseTime.Add(10 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+	viewerShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     viewer.ID,
+		URL:         "https://example.com/viewer",
+		Title:       "Viewer Share",
+		CreatedAt:   baseTime.Add(15 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+	pendingShare := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     pendingFriend.ID,
+		URL:         "https://example.com/pending",
+		Title:       "Pending Share",
+		CreatedAt:   baseTime.Add(20 * time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+
+	for _, share := range []models.VideoShare{inboundShare, viewerShare, pendingShare} {
+		if err := videoRepo.Create(ctx, share); err != nil {
+			t.Fatalf("create share %s: %v", share.ID, err)
+		}
+	}
+
+	feed, err := videoRepo.ListFeed(ctx, viewer.ID, models.FeedFilter{}, nil, 100)
+	if err != nil {
+		t.Fatalf("list feed with inbound friend: %v", err)
+	}
+
+	if len(feed) != 2 {
+		t.Fatalf("expected 2 feed entries (viewer + inbound friend), got %d", len(feed))
+	}
+
+	if feed[0].ID != viewerShare.ID || feed[1].ID != inboundShare.ID {
+		t.Fatalf("unexpected feed order: %+v", feed)
+	}
+
+	for _, share := range feed {
+		if share.OwnerID == pendingFriend.ID {
+			t.Fatalf("unexpected share from pending friend %s in feed", share.OwnerID)
+		}
+		if share.AssetStatus == "" {
+			t.Fatalf("expected asset status for share %s", share.ID)
+		}
+	}
+}
+
+func TestPostgresVideoRepository_UpdateAssetStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "asset-owner@example.com")
+	share := models.VideoShare{
+		ID:          uuid.NewString(),
+		OwnerID:     owner.ID,
+		URL:         "https://example.com/watch",
+		Title:       "Asset Lifecycle",
+		CreatedAt:   time.Now().UTC().Add(-time.Minute),
+		AssetStatus: models.AssetStatusPending,
+	}
+
+	if err := videoRepo.Create(ctx, share); err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	readyURL := "https://cdn.example.com/asset.mp4"
+	readySize := int64(2048)
+	if err := videoRepo.MarkAssetReady(ctx, share.ID, readyURL, readySize); err != nil {
+		t.Fatalf("mark asset ready: %v", err)
+	}
+
+	feed, err := videoRepo.ListFeed(ctx, owner.ID, models.FeedFilter{}, nil, 100)
+	if err != nil {
+		t.Fatalf("list feed after ready: %v", err)
+	}
+	if len(feed) != 1 {
+		t.Fatalf("expected 1 feed entry, got %d", len(feed))
+	}
+
+	updated := feed[0]
+	if updated.AssetStatus != models.AssetStatusReady {
+		t.Fatalf("expected asset status ready, got %s", updated.AssetStatus)
+	}
+	if updated.AssetURL != readyURL {
+		t.Fatalf("expected asset url %s, got %s", readyURL, updated.AssetURL)
+	}
+	if updated.AssetSize != readySize {
+		t.Fatalf("expected asset size %d, got %d", readySize, updated.AssetSize)
+	}
+
+	if err := videoRepo.MarkAssetFailed(ctx, share.ID); err != nil {
+		t.Fatalf("mark asset failed: %v", err)
+	}
+
+	feed, err = videoRepo.ListFeed(ctx, owner.ID, models.FeedFilter{}, nil, 100)
+	if err != nil {
+		t.Fatalf("list feed after failed: %v", err)
+	}
+	if len(feed) != 1 {
+		t.Fatalf("expected 1 feed entry after failure, got %d", len(feed))
+	}
+
+	failed := feed[0]
+	if failed.AssetStatus != models.AssetStatusFailed {
+		t.Fatalf("expected asset status failed, got %s", failed.AssetStatus)
+	}
+	if failed.AssetURL != "" {
+		t.Fatalf("expected asset url cleared, got %s", failed.AssetURL)
+	}
+	if failed.AssetSize != 0 {
+		t.Fatalf("expected asset size reset, got %d", failed.AssetSize)
+	}
+
+	if err := videoRepo.MarkAssetReady(ctx, uuid.NewString(), readyURL, readySize); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound when marking missing asset ready, got %v", err)
+	}
+	if err := videoRepo.MarkAssetFailed(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound when marking missing asset failed, got %v", err)
+	}
+}
+
+func TestPostgresUserRepository_DeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+	sessionStore := NewPostgresSessionStore(testPool)
+
+	leaving := createTestUser(t, userRepo, "leaving@example.com")
+	staying := createTestUser(t, userRepo, "staying@example.com")
+
+	if err := friendRepo.CreateRequest(ctx, models.FriendRequest{
+		ID:        uuid.NewString(),
+		Requester: leaving.ID,
+		Receiver:  staying.ID,
+		Status:    "accepted",
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("create friend request: %v", err)
+	}
+
+	older := models.VideoShare{ID: uuid.NewString(), OwnerID: leaving.ID, URL: "https://example.com/a", Title: "A", CreatedAt: time.Now().UTC().Add(-time.Hour)}
+	newer := models.VideoShare{ID: uuid.NewString(), OwnerID: leaving.ID, URL: "https://example.com/b", Title: "B", CreatedAt: time.Now().UTC()}
+	other := models.VideoShare{ID: uuid.NewString(), OwnerID: staying.ID, URL: "https://example.com/c", Title: "C", CreatedAt: time.Now().UTC()}
+	for _, share := range []models.VideoShare{older, newer, other} {
+		if err := videoRepo.Create(ctx, share); err != nil {
+			t.Fatalf("create share: %v", err)
+		}
+	}
+
+	owned, err := videoRepo.ListByOwner(ctx, leaving.ID)
+	if err != nil {
+		t.Fatalf("list owned shares: %v", err)
+	}
+	if len(owned) != 2 || owned[0].ID != newer.ID || owned[1].ID != older.ID {
+		t.Fatalf("expected own shares newest first, got %+v", owned)
+	}
+
+	if err := sessionStore.Save(ctx, auth.Session{
+		ID:           uuid.NewString(),
+		RefreshToken: "leaving-refresh",
+		UserID:       leaving.ID,
+		CreatedAt:    time.Now().UTC(),
+		ExpiresAt:    time.Now().UTC().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+
+	if err := userRepo.Delete(ctx, leaving.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := userRepo.FindByID(ctx, leaving.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected deleted user to be gone, got %v", err)
+	}
+	if err := userRepo.Delete(ctx, leaving.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	if owned, err := videoRepo.ListByOwner(ctx, leaving.ID); err != nil || len(owned) != 0 {
+		t.Fatalf("expected shares to cascade, got %d (err %v)", len(owned), err)
+	}
+	if requests, err := friendRepo.ListForUser(ctx, staying.ID); err != nil || len(requests) != 0 {
+		t.Fatalf("expected friend requests to cascade, got %d (err %v)", len(requests), err)
+	}
+	if _, err := sessionStore.Find(ctx, "leaving-refresh"); err == nil {
+		t.Fatal("expected sessions to cascade")
+	}
+	if feed, err := videoRepo.ListFeed(ctx, staying.ID, models.FeedFilter{}, nil, 100); err != nil || len(feed) != 1 || feed[0].ID != other.ID {
+		t.Fatalf("expected other user's shares to remain, got %+v (err %v)", feed, err)
+	}
+}
+
+func TestPostgresProfileRepository_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	profileRepo := NewPostgresProfileRepository(testPool)
+
+	alice := createTestUser(t, userRepo, "alice@example.com")
+	bob := createTestUser(t, userRepo, "bob@example.com")
+	created := time.Now().UTC().Truncate(time.Second)
+
+	if _, err := profileRepo.FindByUserID(ctx, alice.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before a profile exists, got %v", err)
+	}
+	if err := profileRepo.SetAvatar(ctx, alice.ID, models.Avatar{Key: "k"}, created); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound setting an avatar without a profile, got %v", err)
+	}
+
+	if err := profileRepo.Upsert(ctx, models.Profile{UserID: alice.ID, Handle: "alice", DisplayName: "Alice", Bio: "hi", CreatedAt: created, UpdatedAt: created}); err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	if err := profileRepo.Upsert(ctx, models.Profile{UserID: bob.ID, Handle: "alice", CreatedAt: created, UpdatedAt: created}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a taken handle, got %v", err)
+	}
+
+	avatar := models.Avatar{Key: "avatars/" + alice.ID + "/v1/", Small: "s", Medium: "m", Large: "l"}
+	if err := profileRepo.SetAvatar(ctx, alice.ID, avatar, created.Add(time.Minute)); err != nil {
+		t.Fatalf("set avatar: %v", err)
+	}
+
+	updated := created.Add(time.Hour)
+	if err := profileRepo.Upsert(ctx, models.Profile{UserID: alice.ID, Handle: "alice_a", DisplayName: "Alice A.", CreatedAt: updated, UpdatedAt: updated}); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+
+	profile, err := profileRepo.FindByHandle(ctx, "alice_a")
+	if err != nil {
+		t.Fatalf("find by handle: %v", err)
+	}
+	if profile.UserID != alice.ID || profile.DisplayName != "Alice A." || profile.Bio != "" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	if profile.Avatar != avatar {
+		t.Fatalf("expected avatar to survive profile updates, got %+v", profile.Avatar)
+	}
+	if !profile.CreatedAt.Equal(created) || !profile.UpdatedAt.Equal(updated) {
+		t.Fatalf("unexpected timestamps: created %v updated %v", profile.CreatedAt, profile.UpdatedAt)
+	}
+	if _, err := profileRepo.FindByHandle(ctx, "alice"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected old handle to be released, got %v", err)
+	}
+
+	summaries, err := profileRepo.Summaries(ctx, []string{alice.ID, bob.ID})
+	if err != nil {
+		t.Fatalf("summaries: %v", err)
+	}
+	if len(summaries) != 1 || summaries[alice.ID] != (models.ProfileSummary{UserID: alice.ID, Handle: "alice_a", DisplayName: "Alice A.", AvatarURL: "s"}) {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+
+	if err := userRepo.Delete(ctx, alice.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := profileRepo.FindByUserID(ctx, alice.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected profile to cascade, got %v", err)
+	}
+}
+
+func TestPostgresProfileRepository_SearchHandles(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	profileRepo := NewPostgresProfileRepository(testPool)
+	now := time.Now().UTC()
+
+	for _, handle := range []string{"bob", "bobby", "bo_x", "boax", "alice"} {
+		user := createTestUser(t, userRepo, handle+"@example.com")
+		if err := profileRepo.Upsert(ctx, models.Profile{UserID: user.ID, Handle: handle, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("create profile %s: %v", handle, err)
+		}
+	}
+
+	matches, err := profileRepo.SearchHandles(ctx, "bob", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Handle != "bob" || matches[1].Handle != "bobby" {
+		t.Fatalf("expected prefix matches ordered by handle, got %+v", matches)
+	}
+
+	matches, err = profileRepo.SearchHandles(ctx, "bo_", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Handle != "bo_x" {
+		t.Fatalf("expected underscore to match literally, got %+v", matches)
+	}
+
+	matches, err = profileRepo.SearchHandles(ctx, "bo", 2)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected limit to apply, got %+v", matches)
+	}
+}
+
+func TestPostgresInviteStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	store := NewPostgresInviteStore(testPool)
+
+	alice := createTestUser(t, userRepo, "alice@example.com")
+	bob := createTestUser(t, userRepo, "bob@example.com")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	link := invites.Invite{ID: uuid.NewString(), InviterID: alice.ID, Hash: "hash-link", MaxUses: 2, ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	mailed := invites.Invite{ID: uuid.NewString(), InviterID: alice.ID, Hash: "hash-mail", Email: "friend@example.com", MaxUses: 1, ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(time.Second)}
+	for _, invite := range []invites.Invite{link, mailed} {
+		if err := store.Create(ctx, invite); err != nil {
+			t.Fatalf("create invite: %v", err)
+		}
+	}
+
+	found, err := store.FindByHash(ctx, "hash-mail")
+	if err != nil {
+		t.Fatalf("find invite: %v", err)
+	}
+	if found.ID != mailed.ID || found.Email != "friend@example.com" || found.RevokedAt != nil || !found.ExpiresAt.Equal(mailed.ExpiresAt) {
+		t.Fatalf("unexpected invite: %+v", found)
+	}
+	if _, err := store.FindByHash(ctx, "unknown"); !errors.Is(err, invites.ErrInviteNotFound) {
+		t.Fatalf("expected ErrInviteNotFound, got %v", err)
+	}
+
+	listed, err := store.ListForInviter(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("list invites: %v", err)
+	}
+	if len(listed) != 2 || listed[0].ID != mailed.ID || listed[1].ID != link.ID {
+		t.Fatalf("expected invites newest first, got %+v", listed)
+	}
+
+	if _, err := store.Redeem(ctx, "hash-link", now.Add(2*time.Hour)); !errors.Is(err, invites.ErrInvalidCode) {
+		t.Fatalf("expected expired invite to be rejected, got %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		redeemed, err := store.Redeem(ctx, "hash-link", now)
+		if err != nil {
+			t.Fatalf("redeem %d: %v", i, err)
+		}
+		if redeemed.Uses != i || redeemed.InviterID != alice.ID {
+			t.Fatalf("unexpected redeemed invite: %+v", redeemed)
+		}
+	}
+	if _, err := store.Redeem(ctx, "hash-link", now); !errors.Is(err, invites.ErrInvalidCode) {
+		t.Fatalf("expected used up invite to be rejected, got %v", err)
+	}
+
+	if err := store.Revoke(ctx, bob.ID, mailed.ID, now); !errors.Is(err, invites.ErrInviteNotFound) {
+		t.Fatalf("expected other users to be unable to revoke, got %v", err)
+	}
+	if err := store.Revoke(ctx, alice.ID, mailed.ID, now); err != nil {
+		t.Fatalf("revoke invite: %v", err)
+	}
+	if err := store.Revoke(ctx, alice.ID, mailed.ID, now); !errors.Is(err, invites.ErrInviteNotFound) {
+		t.Fatalf("expected second revoke to report not found, got %v", err)
+	}
+	if _, err := store.Redeem(ctx, "hash-mail", now); !errors.Is(err, invites.ErrInvalidCode) {
+		t.Fatalf("expected revoked invite to be rejected, got %v", err)
+	}
+
+	if err := userRepo.Delete(ctx, alice.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := store.FindByHash(ctx, "hash-link"); !errors.Is(err, invites.ErrInviteNotFound) {
+		t.Fatalf("expected invites to be removed with the inviter, got %v", err)
+	}
+}
+
+func TestPostgresCircleRepository_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	repo := NewPostgresCircleRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	alice := createTestUser(t, userRepo, "alice@example.com")
+	bob := createTestUser(t, userRepo, "bob@example.com")
+	stranger := createTestUser(t, userRepo, "stranger@example.com")
+	createFriendship(t, friendRepo, owner.ID, alice.ID)
+	createFriendship(t, friendRepo, bob.ID, owner.ID)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	family := models.Circle{ID: uuid.NewString(), OwnerID: owner.ID, Name: "Family", MemberIDs: []string{alice.ID, bob.ID}, CreatedAt: now, UpdatedAt: now}
+	if err := repo.Create(ctx, family); err != nil {
+		t.Fatalf("create circle: %v", err)
+	}
+	if err := repo.Create(ctx, models.Circle{ID: uuid.NewString(), OwnerID: owner.ID, Name: "family", CreatedAt: now, UpdatedAt: now}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected names to be unique per owner, got %v", err)
+	}
+	strangers := models.Circle{ID: uuid.NewString(), OwnerID: owner.ID, Name: "Strangers", MemberIDs: []string{alice.ID, stranger.ID}, CreatedAt: now, UpdatedAt: now}
+	if err := repo.Create(ctx, strangers); !errors.Is(err, ErrNotFriends) {
+		t.Fatalf("expected non-friends to be rejected, got %v", err)
+	}
+	if _, err := repo.FindByID(ctx, owner.ID, strangers.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected rejected circle not to be stored, got %v", err)
+	}
+	empty := models.Circle{ID: uuid.NewString(), OwnerID: owner.ID, Name: "Book club", CreatedAt: now, UpdatedAt: now}
+	if err := repo.Create(ctx, empty); err != nil {
+		t.Fatalf("create empty circle: %v", err)
+	}
+
+	found, err := repo.FindByID(ctx, owner.ID, family.ID)
+	if err != nil {
+		t.Fatalf("find circle: %v", err)
+	}
+	if found.Name != "Family" || len(found.MemberIDs) != 2 || !found.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected circle: %+v", found)
+	}
+	if _, err := repo.FindByID(ctx, alice.ID, family.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other users' circles to be hidden, got %v", err)
+	}
+
+	listed, err := repo.ListForOwner(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("list circles: %v", err)
+	}
+	if len(listed) != 2 || listed[0].ID != empty.ID || listed[1].ID != family.ID || len(listed[0].MemberIDs) != 0 {
+		t.Fatalf("expected circles ordered by name, got %+v", listed)
+	}
+
+	family.Name = "Close family"
+	family.MemberIDs = []string{bob.ID}
+	family.UpdatedAt = now.Add(time.Minute)
+	if err := repo.Update(ctx, family); err != nil {
+		t.Fatalf("update circle: %v", err)
+	}
+	found, err = repo.FindByID(ctx, owner.ID, family.ID)
+	if err != nil {
+		t.Fatalf("find updated circle: %v", err)
+	}
+	if found.Name != "Close family" || len(found.MemberIDs) != 1 || found.MemberIDs[0] != bob.ID || !found.UpdatedAt.Equal(family.UpdatedAt) {
+		t.Fatalf("unexpected updated circle: %+v", found)
+	}
+	family.MemberIDs = []string{stranger.ID}
+	if err := repo.Update(ctx, family); !errors.Is(err, ErrNotFriends) {
+		t.Fatalf("expected non-friends to be rejected, got %v", err)
+	}
+	if found, err := repo.FindByID(ctx, owner.ID, family.ID); err != nil || len(found.MemberIDs) != 1 {
+		t.Fatalf("expected rejected update to keep members, got %+v (err %v)", found, err)
+	}
+
+	if err := repo.Delete(ctx, alice.ID, family.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other users to be unable to delete, got %v", err)
+	}
+	if err := repo.Delete(ctx, owner.ID, family.ID); err != nil {
+		t.Fatalf("delete circle: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, owner.ID, family.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected deleted circle to be gone, got %v", err)
+	}
+}
+
+func TestPostgresVideoRepository_ListFeedHonorsVisibility(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	circleRepo := NewPostgresCircleRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	insider := createTestUser(t, userRepo, "insider@example.com")
+	outsider := createTestUser(t, userRepo, "outsider@example.com")
+	stranger := createTestUser(t, userRepo, "stranger@example.com")
+	createFriendship(t, friendRepo, owner.ID, insider.ID)
+	outsiderFriendship := createFriendship(t, friendRepo, outsider.ID, owner.ID)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	circle := models.Circle{ID: uuid.NewString(), OwnerID: owner.ID, Name: "Insiders", MemberIDs: []string{insider.ID}, CreatedAt: now, UpdatedAt: now}
+	if err := circleRepo.Create(ctx, circle); err != nil {
+		t.Fatalf("create circle: %v", err)
+	}
+	strangerCircle := models.Circle{ID: uuid.NewString(), OwnerID: stranger.ID, Name: "Mine", CreatedAt: now, UpdatedAt: now}
+	if err := circleRepo.Create(ctx, strangerCircle); err != nil {
+		t.Fatalf("create stranger circle: %v", err)
+	}
+
+	share := func(name, visibility string, circleIDs, userIDs []string, at time.Duration) models.VideoShare {
+		return models.VideoShare{
+			ID:          uuid.NewString(),
+			OwnerID:     owner.ID,
+			URL:         "https://example.com/" + name,
+			Title:       name,
+			CreatedAt:   now.Add(at),
+			AssetStatus: models.AssetStatusPending,
+			Visibility:  visibility,
+			CircleIDs:   circleIDs,
+			UserIDs:     userIDs,
+		}
+	}
+	everyone := share("everyone", "", nil, nil, time.Minute)
+	circleOnly := share("circle", models.VisibilityCircles, []string{circle.ID}, nil, 2*time.Minute)
+	outsiderOnly := share("outsider", models.VisibilityUsers, nil, []string{outsider.ID}, 3*time.Minute)
+	for _, s := range []models.VideoShare{everyone, circleOnly, outsiderOnly} {
+		if err := videoRepo.Create(ctx, s); err != nil {
+			t.Fatalf("create share %s: %v", s.Title, err)
+		}
+	}
+
+	if err := videoRepo.Create(ctx, share("foreign", models.VisibilityCircles, []string{strangerCircle.ID}, nil, 0)); !errors.Is(err, ErrCircleNotFound) {
+		t.Fatalf("expected other users' circles to be rejected, got %v", err)
+	}
+	if err := videoRepo.Create(ctx, share("stranger", models.VisibilityUsers, nil, []string{stranger.ID}, 0)); !errors.Is(err, ErrNotFriends) {
+		t.Fatalf("expected non-friends to be rejected, got %v", err)
+	}
+
+	titles := func(userID string) []string {
+		t.Helper()
+		feed, err := videoRepo.ListFeed(ctx, userID, models.FeedFilter{}, nil, 100)
+		if err != nil {
+			t.Fatalf("list feed: %v", err)
+		}
+		var titles []string
+		for _, s := range feed {
+			titles = append(titles, s.Title)
+		}
+		return titles
+	}
+
+	for _, tc := range []struct {
+		name   string
+		userID string
+		want   []string
+	}{
+		{"owner", owner.ID, []string{"outsider", "circle", "everyone"}},
+		{"circleMember", insider.ID, []string{"circle", "everyone"}},
+		{"namedUser", outsider.ID, []string{"outsider", "everyone"}},
+		{"stranger", stranger.ID, nil},
+	} {
+		if got := titles(tc.userID); !slices.Equal(got, tc.want) {
+			t.Fatalf("%s: expected feed %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	feed, err := videoRepo.ListFeed(ctx, owner.ID, models.FeedFilter{}, nil, 100)
+	if err != nil {
+		t.Fatalf("list feed: %v", err)
+	}
+	if feed[1].Visibility != models.VisibilityCircles || feed[2].Visibility != models.VisibilityFriends {
+		t.Fatalf("expected visibility to be loaded, got %+v", feed)
+	}
+
+	if _, err := friendRepo.Transition(ctx, models.FriendTransition{RequestID: outsiderFriendship, From: "accepted", To: "removed", ActorID: owner.ID, At: now}); err != nil {
+		t.Fatalf("unfriend: %v", err)
+	}
+	if got := titles(outsider.ID); len(got) != 0 {
+		t.Fatalf("expected former friends to lose access, got %v", got)
+	}
+
+	if err := circleRepo.Delete(ctx, owner.ID, circle.ID); err != nil {
+		t.Fatalf("delete circle: %v", err)
+	}
+	if got := titles(insider.ID); !slices.Equal(got, []string{"everyone"}) {
+		t.Fatalf("expected deleted circle to hide its shares, got %v", got)
+	}
+}
+
+func TestPostgresVideoRepository_ListFeedPagesAndFilters(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+
+	viewer := createTestUser(t, userRepo, "viewer@example.com")
+	friend := createTestUser(t, userRepo, "friend@example.com")
+	createFriendship(t, friendRepo, viewer.ID, friend.ID)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	share := func(owner models.User, url string, at time.Duration) models.VideoShare {
+		s := models.VideoShare{
+			ID:          uuid.NewString(),
+			OwnerID:     owner.ID,
+			URL:         url,
+			Title:       url,
+			CreatedAt:   now.Add(at),
+			AssetStatus: models.AssetStatusPending,
+		}
+		if err := videoRepo.Create(ctx, s); err != nil {
+			t.Fatalf("create share %s: %v", url, err)
+		}
+		return s
+	}
+	long := share(friend, "https://www.youtube.com/watch?v=a", -time.Minute)
+	short := share(friend, "https://user@youtu.be:443/b", -time.Minute)
+	// Shares created at the same time are ordered by ID.
+	tied := []models.VideoShare{long, short}
+	if long.ID < short.ID {
+		tied = []models.VideoShare{short, long}
+	}
+	newest := share(viewer, "https://vimeo.com/c", 0)
+	oldest := share(friend, "https://notyoutube.com/d", -2*time.Minute)
+	if err := videoRepo.MarkAssetReady(ctx, newest.ID, "s3://bucket/c", 10); err != nil {
+		t.Fatalf("mark asset ready: %v", err)
+	}
+
+	ids := func(filter models.FeedFilter, after *models.FeedCursor, limit int) []string {
+		t.Helper()
+		feed, err := videoRepo.ListFeed(ctx, viewer.ID, filter, after, limit)
+		if err != nil {
+			t.Fatalf("list feed: %v", err)
+		}
+		var ids []string
+		for _, s := range feed {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	var pages []string
+	var after *models.FeedCursor
+	for {
+		feed, err := videoRepo.ListFeed(ctx, viewer.ID, models.FeedFilter{}, after, 1)
+		if err != nil {
+			t.Fatalf("list feed page: %v", err)
+		}
+		if len(feed) == 0 {
+			break
+		}
+		pages = append(pages, feed[0].ID)
+		after = &models.FeedCursor{CreatedAt: feed[0].CreatedAt, ShareID: feed[0].ID}
+	}
+	if want := []string{newest.ID, tied[0].ID, tied[1].ID, oldest.ID}; !slices.Equal(pages, want) {
+		t.Fatalf("expected pages %v, got %v", want, pages)
+	}
+
+	since, before := now.Add(-time.Minute), now
+	for _, tc := range []struct {
+		name   string
+		filter models.FeedFilter
+		want   []string
+	}{
+		{"owner", models.FeedFilter{OwnerID: viewer.ID}, []string{newest.ID}},
+		{"assetStatus", models.FeedFilter{AssetStatus: models.AssetStatusPending}, []string{tied[0].ID, tied[1].ID, oldest.ID}},
+		{"subdomain", models.FeedFilter{Domain: "youtube.com"}, []string{long.ID}},
+		{"host", models.FeedFilter{Domain: "YOUTU.BE"}, []string{short.ID}},
+		{"window", models.FeedFilter{Since: &since, Before: &before}, []string{tied[0].ID, tied[1].ID}},
+	} {
+		if got := ids(tc.filter, nil, 10); !slices.Equal(got, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestPostgresVideoRepository_FindUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	circleRepo := NewPostgresCircleRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+	filterRepo := NewPostgresFeedFilterRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	friend := createTestUser(t, userRepo, "friend@example.com")
+	stranger := createTestUser(t, userRepo, "stranger@example.com")
+	createFriendship(t, friendRepo, owner.ID, friend.ID)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	circle := models.Circle{ID: uuid.NewString(), OwnerID: owner.ID, Name: "Close", CreatedAt: now, UpdatedAt: now}
+	if err := circleRepo.Create(ctx, circle); err != nil {
+		t.Fatalf("create circle: %v", err)
+	}
+	share := models.VideoShare{
 		ID:          uuid.NewString(),
-		OwnerID:     pendingFriend.ID,
-		URL:         "https://example.com/pending",
-		Title:       "Pending Share",
-		CreatedAt:   baseTime.Add(20 * time.Minute),
+		OwnerID:     owner.ID,
+		URL:         "https://example.com/clip",
+		Title:       "Clip",
+		Note:        "Watch this",
+		CreatedAt:   now,
 		AssetStatus: models.AssetStatusPending,
+		Visibility:  models.VisibilityUsers,
+		UserIDs:     []string{friend.ID},
+	}
+	if err := videoRepo.Create(ctx, share); err != nil {
+		t.Fatalf("create share: %v", err)
 	}
 
-	for _, share := range []models.VideoShare{inboundShare, viewerShare, pendingShare} {
-		if err := videoRepo.Create(ctx, share); err != nil {
-			t.Fatalf("create share %s: %v", share.ID, err)
+	got, err := videoRepo.FindVisible(ctx, owner.ID, share.ID)
+	if err != nil {
+		t.Fatalf("find as owner: %v", err)
+	}
+	if got.Note != "Watch this" || !slices.Equal(got.UserIDs, []string{friend.ID}) || len(got.CircleIDs) != 0 {
+		t.Fatalf("unexpected share for owner: %+v", got)
+	}
+	got, err = videoRepo.FindVisible(ctx, friend.ID, share.ID)
+	if err != nil {
+		t.Fatalf("find as friend: %v", err)
+	}
+	if got.UserIDs != nil {
+		t.Fatalf("expected audience to be hidden from viewers, got %+v", got.UserIDs)
+	}
+	if _, err := videoRepo.FindVisible(ctx, stranger.ID, share.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected strangers not to find the share, got %v", err)
+	}
+
+	share.Note = "Edited"
+	share.Visibility = ""
+	if err := videoRepo.Update(ctx, share); err != nil {
+		t.Fatalf("update note: %v", err)
+	}
+	if got, err := videoRepo.FindVisible(ctx, friend.ID, share.ID); err != nil || got.Note != "Edited" || got.Visibility != models.VisibilityUsers {
+		t.Fatalf("expected note-only update to keep the audience, got %+v, %v", got, err)
+	}
+
+	share.Visibility, share.CircleIDs, share.UserIDs = models.VisibilityCircles, []string{circle.ID}, nil
+	if err := videoRepo.Update(ctx, share); err != nil {
+		t.Fatalf("update audience: %v", err)
+	}
+	if _, err := videoRepo.FindVisible(ctx, friend.ID, share.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected friend outside the circle to lose access, got %v", err)
+	}
+	if got, err := videoRepo.FindVisible(ctx, owner.ID, share.ID); err != nil || !slices.Equal(got.CircleIDs, []string{circle.ID}) || len(got.UserIDs) != 0 {
+		t.Fatalf("expected audience to be replaced, got %+v, %v", got, err)
+	}
+
+	share.UserIDs, share.CircleIDs, share.Visibility = []string{stranger.ID}, nil, models.VisibilityUsers
+	if err := videoRepo.Update(ctx, share); !errors.Is(err, ErrNotFriends) {
+		t.Fatalf("expected non-friends to be rejected, got %v", err)
+	}
+	if got, err := videoRepo.FindVisible(ctx, owner.ID, share.ID); err != nil || got.Visibility != models.VisibilityCircles {
+		t.Fatalf("expected failed update to be rolled back, got %+v, %v", got, err)
+	}
+
+	foreign := share
+	foreign.OwnerID, foreign.Visibility, foreign.UserIDs = friend.ID, "", nil
+	if err := videoRepo.Update(ctx, foreign); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other users' updates to fail, got %v", err)
+	}
+	foreign.Visibility, foreign.CircleIDs = models.VisibilityCircles, []string{circle.ID}
+	if err := videoRepo.Update(ctx, foreign); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other users' audience updates to fail as not found, got %v", err)
+	}
+	share.Visibility, share.CircleIDs, share.UserIDs = models.VisibilityCircles, []string{uuid.NewString()}, nil
+	if err := videoRepo.Update(ctx, share); !errors.Is(err, ErrCircleNotFound) {
+		t.Fatalf("expected unknown circles to be rejected, got %v", err)
+	}
+	if err := videoRepo.Delete(ctx, friend.ID, share.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other users' deletes to fail, got %v", err)
+	}
+
+	if err := filterRepo.HideShare(ctx, owner.ID, share.ID, now); err != nil {
+		t.Fatalf("hide share: %v", err)
+	}
+	if err := videoRepo.Delete(ctx, owner.ID, share.ID); err != nil {
+		t.Fatalf("delete share: %v", err)
+	}
+	if _, err := videoRepo.FindVisible(ctx, owner.ID, share.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected deleted share to be gone, got %v", err)
+	}
+	if hidden, err := filterRepo.ListHidden(ctx, owner.ID); err != nil || len(hidden) != 0 {
+		t.Fatalf("expected hides of the deleted share to be removed, got %+v, %v", hidden, err)
+	}
+	if err := videoRepo.Delete(ctx, owner.ID, share.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected second delete to fail, got %v", err)
+	}
+}
+
+func TestPostgresFeedFilterRepository_MutesAndHides(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	friendRepo := NewPostgresFriendRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+	filterRepo := NewPostgresFeedFilterRepository(testPool)
+
+	viewer := createTestUser(t, userRepo, "viewer@example.com")
+	loud := createTestUser(t, userRepo, "loud@example.com")
+	quiet := createTestUser(t, userRepo, "quiet@example.com")
+	stranger := createTestUser(t, userRepo, "stranger@example.com")
+	createFriendship(t, friendRepo, viewer.ID, loud.ID)
+	createFriendship(t, friendRepo, quiet.ID, viewer.ID)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	share := func(owner models.User, name string, at time.Duration) models.VideoShare {
+		s := models.VideoShare{
+			ID:          uuid.NewString(),
+			OwnerID:     owner.ID,
+			URL:         "https://example.com/" + name,
+			Title:       name,
+			CreatedAt:   now.Add(at),
+			AssetStatus: models.AssetStatusPending,
+		}
+		if err := videoRepo.Create(ctx, s); err != nil {
+			t.Fatalf("create share %s: %v", name, err)
+		}
+		return s
+	}
+	share(loud, "loud", time.Minute)
+	quietShare := share(quiet, "quiet", 2*time.Minute)
+	ownShare := share(viewer, "own", 3*time.Minute)
+	strangerShare := share(stranger, "stranger", 4*time.Minute)
+
+	titles := func() []string {
+		t.Helper()
+		feed, err := videoRepo.ListFeed(ctx, viewer.ID, models.FeedFilter{}, nil, 100)
+		if err != nil {
+			t.Fatalf("list feed: %v", err)
+		}
+		var titles []string
+		for _, s := range feed {
+			titles = append(titles, s.Title)
 		}
+		return titles
+	}
+
+	if err := filterRepo.Mute(ctx, models.FriendMute{UserID: viewer.ID, MutedID: stranger.ID, CreatedAt: now}); !errors.Is(err, ErrNotFriends) {
+		t.Fatalf("expected muting a non-friend to fail, got %v", err)
+	}
+	if err := filterRepo.Mute(ctx, models.FriendMute{UserID: viewer.ID, MutedID: loud.ID, CreatedAt: now}); err != nil {
+		t.Fatalf("mute: %v", err)
+	}
+	if got := titles(); !slices.Equal(got, []string{"own", "quiet"}) {
+		t.Fatalf("expected muted friend to be left out, got %v", got)
+	}
+
+	expired := now.Add(-time.Minute)
+	if err := filterRepo.Mute(ctx, models.FriendMute{UserID: viewer.ID, MutedID: loud.ID, Until: &expired, CreatedAt: now}); err != nil {
+		t.Fatalf("replace mute: %v", err)
+	}
+	if got := titles(); !slices.Equal(got, []string{"own", "quiet", "loud"}) {
+		t.Fatalf("expected expired mute to be ignored, got %v", got)
+	}
+	until := now.Add(time.Hour)
+	if err := filterRepo.Mute(ctx, models.FriendMute{UserID: viewer.ID, MutedID: loud.ID, Until: &until, CreatedAt: now}); err != nil {
+		t.Fatalf("replace mute: %v", err)
+	}
+	mutes, err := filterRepo.ListMutes(ctx, viewer.ID, now)
+	if err != nil {
+		t.Fatalf("list mutes: %v", err)
+	}
+	if len(mutes) != 1 || mutes[0].MutedID != loud.ID || mutes[0].Until == nil || !mutes[0].Until.Equal(until) {
+		t.Fatalf("unexpected mutes: %+v", mutes)
+	}
+	if mutes, err := filterRepo.ListMutes(ctx, viewer.ID, until.Add(time.Second)); err != nil || len(mutes) != 0 {
+		t.Fatalf("expected timed mute to lapse, got %+v, %v", mutes, err)
+	}
+	if got := titles(); !slices.Equal(got, []string{"own", "quiet"}) {
+		t.Fatalf("expected timed mute to apply, got %v", got)
+	}
+	if err := filterRepo.Unmute(ctx, viewer.ID, loud.ID); err != nil {
+		t.Fatalf("unmute: %v", err)
+	}
+	if err := filterRepo.Unmute(ctx, viewer.ID, loud.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected second unmute to fail, got %v", err)
 	}
 
-	feed, err := videoRepo.ListFeed(ctx, viewer.ID)
+	if err := filterRepo.HideShare(ctx, viewer.ID, strangerShare.ID, now); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected invisible share to be rejected, got %v", err)
+	}
+	for _, id := range []string{quietShare.ID, ownShare.ID} {
+		if err := filterRepo.HideShare(ctx, viewer.ID, id, now); err != nil {
+			t.Fatalf("hide share: %v", err)
+		}
+	}
+	if err := filterRepo.HideShare(ctx, viewer.ID, quietShare.ID, now.Add(time.Minute)); err != nil {
+		t.Fatalf("hide share again: %v", err)
+	}
+	if got := titles(); !slices.Equal(got, []string{"loud"}) {
+		t.Fatalf("expected hidden shares to be left out, got %v", got)
+	}
+	hidden, err := filterRepo.ListHidden(ctx, viewer.ID)
 	if err != nil {
-		t.Fatalf("list feed with inbound friend: %v", err)
+		t.Fatalf("list hidden: %v", err)
+	}
+	if len(hidden) != 2 || !hidden[0].HiddenAt.Equal(now) || !hidden[1].HiddenAt.Equal(now) {
+		t.Fatalf("expected hiding twice to keep the first time, got %+v", hidden)
 	}
 
-	if len(feed) != 2 {
-		t.Fatalf("expected 2 feed entries (viewer + inbound friend), got %d", len(feed))
+	if err := filterRepo.UnhideShare(ctx, viewer.ID, quietShare.ID); err != nil {
+		t.Fatalf("unhide share: %v", err)
+	}
+	if err := filterRepo.UnhideShare(ctx, viewer.ID, quietShare.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected second unhide to fail, got %v", err)
+	}
+	if got := titles(); !slices.Equal(got, []string{"quiet", "loud"}) {
+		t.Fatalf("expected unhidden share to return, got %v", got)
 	}
+}
 
-	if feed[0].ID != viewerShare.ID || feed[1].ID != inboundShare.ID {
-		t.Fatalf("unexpected feed order: %+v", feed)
+func TestPostgresCommentRepository_ThreadsRepliesAndTombstones(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+	commentRepo := NewPostgresCommentRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	friend := createTestUser(t, userRepo, "friend@example.com")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	share := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/v", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	if err := videoRepo.Create(ctx, share); err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	other := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/w", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	if err := videoRepo.Create(ctx, other); err != nil {
+		t.Fatalf("create other share: %v", err)
 	}
 
-	for _, share := range feed {
-		if share.OwnerID == pendingFriend.ID {
-			t.Fatalf("unexpected share from pending friend %s in feed", share.OwnerID)
+	comment := func(shareID string, author models.User, parentID string, at time.Duration) models.Comment {
+		t.Helper()
+		c := models.Comment{ID: uuid.NewString(), ShareID: shareID, AuthorID: author.ID, ParentID: parentID, Body: "hello", CreatedAt: now.Add(at)}
+		if err := commentRepo.Create(ctx, c); err != nil {
+			t.Fatalf("create comment: %v", err)
 		}
-		if share.AssetStatus == "" {
-			t.Fatalf("expected asset status for share %s", share.ID)
+		return c
+	}
+	first := comment(share.ID, friend, "", time.Minute)
+	second := comment(share.ID, owner, "", 2*time.Minute)
+	reply := comment(share.ID, owner, first.ID, 3*time.Minute)
+	comment(share.ID, friend, first.ID, 4*time.Minute)
+	comment(other.ID, friend, "", time.Minute)
+
+	for name, c := range map[string]models.Comment{
+		"replyToReply": {ShareID: share.ID, ParentID: reply.ID},
+		"otherShare":   {ShareID: other.ID, ParentID: first.ID},
+		"unknownShare": {ShareID: uuid.NewString()},
+	} {
+		c.ID, c.AuthorID, c.Body, c.CreatedAt = uuid.NewString(), friend.ID, "nope", now
+		if err := commentRepo.Create(ctx, c); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s: expected ErrNotFound, got %v", name, err)
 		}
 	}
+
+	threads, err := commentRepo.ListThreads(ctx, share.ID, nil, 1)
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != first.ID || threads[0].ReplyCount != 2 || !threads[0].CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("unexpected first page: %+v", threads)
+	}
+	threads, err = commentRepo.ListThreads(ctx, share.ID, &models.CommentCursor{CreatedAt: threads[0].CreatedAt, CommentID: threads[0].ID}, 10)
+	if err != nil {
+		t.Fatalf("list threads after cursor: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != second.ID || threads[0].ParentID != "" {
+		t.Fatalf("unexpected second page: %+v", threads)
+	}
+
+	replies, err := commentRepo.ListReplies(ctx, share.ID, first.ID, nil, 10)
+	if err != nil {
+		t.Fatalf("list replies: %v", err)
+	}
+	if len(replies) != 2 || replies[0].ID != reply.ID || replies[0].ParentID != first.ID {
+		t.Fatalf("unexpected replies: %+v", replies)
+	}
+
+	editedAt := now.Add(5 * time.Minute)
+	edit := first
+	edit.Body, edit.EditedAt = "edited", &editedAt
+	if err := commentRepo.Update(ctx, edit); err != nil {
+		t.Fatalf("update comment: %v", err)
+	}
+	edit.AuthorID = owner.ID
+	if err := commentRepo.Update(ctx, edit); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected only the author to edit, got %v", err)
+	}
+
+	if err := commentRepo.Delete(ctx, share.ID, first.ID, editedAt); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+	if err := commentRepo.Delete(ctx, share.ID, first.ID, editedAt); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected second delete to fail, got %v", err)
+	}
+	if err := commentRepo.Update(ctx, edit); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected deleted comment to stay deleted, got %v", err)
+	}
+
+	found, err := commentRepo.FindByID(ctx, share.ID, first.ID)
+	if err != nil {
+		t.Fatalf("find comment: %v", err)
+	}
+	if found.Body != "" || found.DeletedAt == nil || !found.DeletedAt.Equal(editedAt) || found.EditedAt == nil || found.ReplyCount != 2 {
+		t.Fatalf("expected a tombstone keeping its replies, got %+v", found)
+	}
+	if _, err := commentRepo.FindByID(ctx, other.ID, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected comment to belong to its share, got %v", err)
+	}
+
+	reply.ID, reply.ParentID = uuid.NewString(), first.ID
+	if err := commentRepo.Create(ctx, reply); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected reply to a deleted comment to fail, got %v", err)
+	}
+
+	if err := videoRepo.Delete(ctx, owner.ID, share.ID); err != nil {
+		t.Fatalf("delete share: %v", err)
+	}
+	if threads, err := commentRepo.ListThreads(ctx, share.ID, nil, 10); err != nil || len(threads) != 0 {
+		t.Fatalf("expected comments to go with the share, got %+v, %v", threads, err)
+	}
 }
 
-func TestPostgresVideoRepository_UpdateAssetStatusTransitions(t *testing.T) {
+func TestPostgresCommentRepository_DeletedAuthorsLeaveTombstones(t *testing.T) {
 	ctx := context.Background()
 	resetDatabase(t)
 
 	userRepo := NewPostgresUserRepository(testPool)
 	videoRepo := NewPostgresVideoRepository(testPool)
+	commentRepo := NewPostgresCommentRepository(testPool)
 
-	owner := createTestUser(t, userRepo, "asset-owner@example.com")
-	share := models.VideoShare{
-		ID:          uuid.NewString(),
-		OwnerID:     owner.ID,
-		URL:         "https://example.com/watch",
-		Title:       "Asset Lifecycle",
-		CreatedAt:   time.Now().UTC().Add(-time.Minute),
-		AssetStatus: models.AssetStatusPending,
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	leaving := createTestUser(t, userRepo, "leaving@example.com")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	share := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/v", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	if err := videoRepo.Create(ctx, share); err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	ownShare := models.VideoShare{ID: uuid.NewString(), OwnerID: leaving.ID, URL: "https://example.com/own", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	if err := videoRepo.Create(ctx, ownShare); err != nil {
+		t.Fatalf("create own share: %v", err)
+	}
+
+	offset := int64(5000)
+	parent := models.Comment{ID: uuid.NewString(), ShareID: share.ID, AuthorID: leaving.ID, Body: "Thread", OffsetMs: &offset, CreatedAt: now}
+	reply := models.Comment{ID: uuid.NewString(), ShareID: share.ID, AuthorID: owner.ID, ParentID: parent.ID, Body: "Reply", CreatedAt: now.Add(time.Minute)}
+	ownReply := models.Comment{ID: uuid.NewString(), ShareID: share.ID, AuthorID: leaving.ID, ParentID: parent.ID, Body: "Me again", CreatedAt: now.Add(2 * time.Minute)}
+	onOwnShare := models.Comment{ID: uuid.NewString(), ShareID: ownShare.ID, AuthorID: owner.ID, Body: "Nice", CreatedAt: now}
+	replyOnOwnShare := models.Comment{ID: uuid.NewString(), ShareID: ownShare.ID, AuthorID: leaving.ID, ParentID: onOwnShare.ID, Body: "Thanks", CreatedAt: now.Add(time.Minute)}
+	otherReplyOnOwnShare := models.Comment{ID: uuid.NewString(), ShareID: ownShare.ID, AuthorID: owner.ID, ParentID: onOwnShare.ID, Body: "Anytime", CreatedAt: now.Add(2 * time.Minute)}
+	for _, c := range []models.Comment{parent, reply, ownReply, onOwnShare, replyOnOwnShare, otherReplyOnOwnShare} {
+		if err := commentRepo.Create(ctx, c); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+	}
+
+	if err := userRepo.Delete(ctx, leaving.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	threads, err := commentRepo.ListThreads(ctx, share.ID, nil, 10)
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != parent.ID || threads[0].ReplyCount != 2 {
+		t.Fatalf("expected the thread to stay with its replies, got %+v", threads)
+	}
+	got := threads[0]
+	if got.AuthorID != "" || got.Body != "" || got.DeletedAt == nil || got.OffsetMs == nil || *got.OffsetMs != offset {
+		t.Fatalf("expected a tombstone without an author, got %+v", got)
+	}
+
+	replies, err := commentRepo.ListReplies(ctx, share.ID, parent.ID, nil, 10)
+	if err != nil {
+		t.Fatalf("list replies: %v", err)
+	}
+	if len(replies) != 2 || replies[0].ID != reply.ID || replies[0].Body != "Reply" || replies[0].AuthorID != owner.ID || replies[0].DeletedAt != nil {
+		t.Fatalf("expected the other user's reply to survive, got %+v", replies)
+	}
+	if replies[1].ID != ownReply.ID || replies[1].AuthorID != "" || replies[1].Body != "" || replies[1].DeletedAt == nil {
+		t.Fatalf("expected the deleted user's reply to become a tombstone, got %+v", replies[1])
+	}
+
+	markers, err := commentRepo.CountByOffset(ctx, share.ID, 10000)
+	if err != nil {
+		t.Fatalf("count by offset: %v", err)
 	}
+	if len(markers) != 0 {
+		t.Fatalf("expected tombstones not to be counted, got %+v", markers)
+	}
+
+	// Deleting the account deleted its share, and the share's threads with their replies.
+	for _, c := range []models.Comment{onOwnShare, replyOnOwnShare, otherReplyOnOwnShare} {
+		if _, err := commentRepo.FindByID(ctx, ownShare.ID, c.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected comments on the deleted user's shares to go with them, got %v", err)
+		}
+	}
+
+	if err := videoRepo.Delete(ctx, owner.ID, share.ID); err != nil {
+		t.Fatalf("delete share with threaded comments: %v", err)
+	}
+	for _, c := range []models.Comment{parent, reply, ownReply} {
+		if _, err := commentRepo.FindByID(ctx, share.ID, c.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected comments to go with the share, got %v", err)
+		}
+	}
+}
+
+func TestPostgresCommentRepository_OffsetsAndMarkers(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+	commentRepo := NewPostgresCommentRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	now := time.Now().UTC().Truncate(time.Second)
 
+	share := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/v", DurationMs: 222000, CreatedAt: now, AssetStatus: models.AssetStatusPending}
 	if err := videoRepo.Create(ctx, share); err != nil {
 		t.Fatalf("create share: %v", err)
 	}
+	found, err := videoRepo.FindVisible(ctx, owner.ID, share.ID)
+	if err != nil {
+		t.Fatalf("find share: %v", err)
+	}
+	if found.DurationMs != 222000 {
+		t.Fatalf("expected duration to be stored, got %d", found.DurationMs)
+	}
 
-	readyURL := "https://cdn.example.com/asset.mp4"
-	readySize := int64(2048)
-	if err := videoRepo.MarkAssetReady(ctx, share.ID, readyURL, readySize); err != nil {
-		t.Fatalf("mark asset ready: %v", err)
+	comment := func(offsetMs *int64, at time.Duration) models.Comment {
+		t.Helper()
+		c := models.Comment{ID: uuid.NewString(), ShareID: share.ID, AuthorID: owner.ID, Body: "look", OffsetMs: offsetMs, CreatedAt: now.Add(at)}
+		if err := commentRepo.Create(ctx, c); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+		return c
 	}
+	offset := func(ms int64) *int64 { return &ms }
+	late := comment(offset(60000), time.Minute)
+	early := comment(offset(5000), 2*time.Minute)
+	sameMoment := comment(offset(60000), 3*time.Minute)
+	unanchored := comment(nil, 4*time.Minute)
 
-	feed, err := videoRepo.ListFeed(ctx, owner.ID)
+	got, err := commentRepo.ListByOffset(ctx, share.ID, 0, math.MaxInt64, nil, 2)
 	if err != nil {
-		t.Fatalf("list feed after ready: %v", err)
+		t.Fatalf("list by offset: %v", err)
 	}
-	if len(feed) != 1 {
-		t.Fatalf("expected 1 feed entry, got %d", len(feed))
+	if len(got) != 2 || got[0].ID != early.ID || got[1].ID != late.ID || got[1].OffsetMs == nil || *got[1].OffsetMs != 60000 {
+		t.Fatalf("unexpected first page: %+v", got)
+	}
+	got, err = commentRepo.ListByOffset(ctx, share.ID, 0, math.MaxInt64, &models.CommentCursor{OffsetMs: 60000, CreatedAt: late.CreatedAt, CommentID: late.ID}, 10)
+	if err != nil {
+		t.Fatalf("list by offset after cursor: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != sameMoment.ID {
+		t.Fatalf("unexpected second page: %+v", got)
+	}
+	got, err = commentRepo.ListByOffset(ctx, share.ID, 0, 60000, nil, 10)
+	if err != nil {
+		t.Fatalf("list by offset range: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != early.ID {
+		t.Fatalf("expected the range to end before 60000, got %+v", got)
 	}
 
-	updated := feed[0]
-	if updated.AssetStatus != models.AssetStatusReady {
-		t.Fatalf("expected asset status ready, got %s", updated.AssetStatus)
+	threads, err := commentRepo.ListThreads(ctx, share.ID, nil, 10)
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
 	}
-	if updated.AssetURL != readyURL {
-		t.Fatalf("expected asset url %s, got %s", readyURL, updated.AssetURL)
+	if len(threads) != 4 || threads[3].ID != unanchored.ID || threads[3].OffsetMs != nil {
+		t.Fatalf("expected unanchored comment without an offset, got %+v", threads)
 	}
-	if updated.AssetSize != readySize {
-		t.Fatalf("expected asset size %d, got %d", readySize, updated.AssetSize)
+
+	if err := commentRepo.Delete(ctx, share.ID, early.ID, now); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+	markers, err := commentRepo.CountByOffset(ctx, share.ID, 10000)
+	if err != nil {
+		t.Fatalf("count by offset: %v", err)
+	}
+	if len(markers) != 1 || markers[0] != (models.CommentMarker{OffsetMs: 60000, Count: 2}) {
+		t.Fatalf("unexpected markers: %+v", markers)
+	}
+	comment(offset(69999), 5*time.Minute)
+	comment(offset(70000), 6*time.Minute)
+	markers, err = commentRepo.CountByOffset(ctx, share.ID, 10000)
+	if err != nil {
+		t.Fatalf("count by offset: %v", err)
+	}
+	want := []models.CommentMarker{{OffsetMs: 60000, Count: 3}, {OffsetMs: 70000, Count: 1}}
+	if !slices.Equal(markers, want) {
+		t.Fatalf("expected markers %+v, got %+v", want, markers)
 	}
+}
 
-	if err := videoRepo.MarkAssetFailed(ctx, share.ID); err != nil {
-		t.Fatalf("mark asset failed: %v", err)
+func TestPostgresReactionRepository_ToggleAndSummaries(t *testing.T) {
+	ctx := context.Background()
+	resetDatabase(t)
+
+	userRepo := NewPostgresUserRepository(testPool)
+	videoRepo := NewPostgresVideoRepository(testPool)
+	reactionRepo := NewPostgresReactionRepository(testPool)
+
+	owner := createTestUser(t, userRepo, "owner@example.com")
+	friend := createTestUser(t, userRepo, "friend@example.com")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	first := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/1", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	second := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/2", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	quiet := models.VideoShare{ID: uuid.NewString(), OwnerID: owner.ID, URL: "https://example.com/3", CreatedAt: now, AssetStatus: models.AssetStatusPending}
+	for _, share := range []models.VideoShare{first, second, quiet} {
+		if err := videoRepo.Create(ctx, share); err != nil {
+			t.Fatalf("create share: %v", err)
+		}
+	}
+
+	toggle := func(shareID, userID, kind string, want bool) {
+		t.Helper()
+		reacted, err := reactionRepo.Toggle(ctx, models.Reaction{ShareID: shareID, UserID: userID, Kind: kind, CreatedAt: now})
+		if err != nil {
+			t.Fatalf("toggle reaction: %v", err)
+		}
+		if reacted != want {
+			t.Fatalf("expected reacted=%v for %s on %s", want, kind, shareID)
+		}
 	}
+	toggle(first.ID, owner.ID, models.ReactionFire, true)
+	toggle(first.ID, friend.ID, models.ReactionFire, true)
+	toggle(first.ID, friend.ID, models.ReactionLike, true)
+	toggle(second.ID, friend.ID, models.ReactionSad, true)
+	toggle(second.ID, friend.ID, models.ReactionSad, false)
+	toggle(second.ID, friend.ID, models.ReactionWow, true)
 
-	feed, err = videoRepo.ListFeed(ctx, owner.ID)
+	summaries, err := reactionRepo.Summaries(ctx, friend.ID, []string{first.ID, second.ID, quiet.ID})
 	if err != nil {
-		t.Fatalf("list feed after failed: %v", err)
+		t.Fatalf("summaries: %v", err)
 	}
-	if len(feed) != 1 {
-		t.Fatalf("expected 1 feed entry after failure, got %d", len(feed))
+	if len(summaries) != 2 {
+		t.Fatalf("expected shares without reactions to be left out, got %+v", summaries)
+	}
+	got := summaries[first.ID]
+	if len(got.Counts) != 2 || got.Counts[models.ReactionFire] != 2 || got.Counts[models.ReactionLike] != 1 {
+		t.Fatalf("unexpected counts on first share: %+v", got.Counts)
+	}
+	if !slices.Equal(got.Mine, []string{models.ReactionFire, models.ReactionLike}) {
+		t.Fatalf("unexpected own reactions on first share: %v", got.Mine)
+	}
+	got = summaries[second.ID]
+	if len(got.Counts) != 1 || got.Counts[models.ReactionWow] != 1 || !slices.Equal(got.Mine, []string{models.ReactionWow}) {
+		t.Fatalf("unexpected reactions on second share: %+v", got)
 	}
 
-	failed := feed[0]
-	if failed.AssetStatus != models.AssetStatusFailed {
-		t.Fatalf("expected asset status failed, got %s", failed.AssetStatus)
+	summaries, err = reactionRepo.Summaries(ctx, owner.ID, []string{first.ID})
+	if err != nil {
+		t.Fatalf("summaries for owner: %v", err)
 	}
-	if failed.AssetURL != "" {
-		t.Fatalf("expected asset url cleared, got %s", failed.AssetURL)
+	if !slices.Equal(summaries[first.ID].Mine, []string{models.ReactionFire}) {
+		t.Fatalf("expected only the owner's reactions, got %v", summaries[first.ID].Mine)
 	}
-	if failed.AssetSize != 0 {
-		t.Fatalf("expected asset size reset, got %d", failed.AssetSize)
+
+	if _, err := reactionRepo.Toggle(ctx, models.Reaction{ShareID: uuid.NewString(), UserID: friend.ID, Kind: models.ReactionLike, CreatedAt: now}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing share, got %v", err)
 	}
 
-	if err := videoRepo.MarkAssetReady(ctx, uuid.NewString(), readyURL, readySize); !errors.Is(err, ErrNotFound) {
-		t.Fatalf("expected ErrNotFound when marking missing asset ready, got %v", err)
+	if err := videoRepo.Delete(ctx, owner.ID, first.ID); err != nil {
+		t.Fatalf("delete share: %v", err)
 	}
-	if err := videoRepo.MarkAssetFailed(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
-		t.Fatalf("expected ErrNotFound when marking missing asset failed, got %v", err)
+	summaries, err = reactionRepo.Summaries(ctx, friend.ID, []string{first.ID})
+	if err != nil {
+		t.Fatalf("summaries after delete: %v", err)
+	}
+	if len(summaries) != 0 {
+		t.Fatalf("expected reactions to go with the share, got %+v", summaries)
 	}
 }
 
@@ -558,7 +2418,7 @@ func resetDatabase(t *testing.T) {
 	}
 	defer conn.Release()
 
-	if _, err := conn.Exec(ctx, "TRUNCATE TABLE friend_requests, video_shares, sessions, users CASCADE"); err != nil {
+	if _, err := conn.Exec(ctx, "TRUNCATE TABLE share_reactions, share_comments, hidden_shares, friend_mutes, friend_suggestion_dismissals, video_share_users, video_share_circles, circle_members, circles, friend_request_events, friend_requests, video_shares, sessions, user_tokens, user_recovery_codes, user_two_factor, user_identities, login_attempts, api_tokens, friend_invites, user_profiles, users CASCADE"); err != nil {
 		t.Fatalf("truncate tables: %v", err)
 	}
 }
@@ -578,6 +2438,23 @@ func createTestUser(t *testing.T, repo *PostgresUserRepository, email string) mo
 	return user
 }
 
+// createFriendship stores an accepted friend request from requesterID to receiverID and returns
+// its ID.
+func createFriendship(t *testing.T, repo *PostgresFriendRepository, requesterID, receiverID string) string {
+	t.Helper()
+	request := models.FriendRequest{
+		ID:        uuid.NewString(),
+		Requester: requesterID,
+		Receiver:  receiverID,
+		Status:    "accepted",
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := repo.CreateRequest(context.Background(), request); err != nil {
+		t.Fatalf("create friendship: %v", err)
+	}
+	return request.ID
+}
+
 func timesClose(a, b time.Time, delta time.Duration) bool {
 	diff := a.Sub(b)
 	if diff < 0 {
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// PostgresProfileRepository persists user profiles.
+type PostgresProfileRepository struct {
+	pool db.Pool
+}
+
+// NewPostgresProfileRepository constructs a profile repository backed by PostgreSQL.
+func NewPostgresProfileRepository(pool db.Pool) *PostgresProfileRepository {
+	return &PostgresProfileRepository{pool: pool}
+}
+
+// Upsert creates the user's profile or replaces its handle, display name and bio. The avatar is
+// left untouched. It returns ErrConflict when another user holds the handle.
+func (r *PostgresProfileRepository) Upsert(ctx context.Context, profile models.Profile) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `
+        INSERT INTO user_profiles (user_id, handle, display_name, bio, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id) DO UPDATE
+        SET handle = excluded.handle,
+            display_name = excluded.display_name,
+            bio = excluded.bio,
+            updated_at = excluded.updated_at
+    `, profile.UserID, profile.Handle, profile.DisplayName, profile.Bio, profile.CreatedAt.UTC(), profile.UpdatedAt.UTC())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrConflict
+		}
+		return fmt.Errorf("upsert profile: %w", err)
+	}
+
+	return nil
+}
+
+// FindByUserID returns the user's profile.
+func (r *PostgresProfileRepository) FindByUserID(ctx context.Context, userID string) (models.Profile, error) {
+	return r.findOne(ctx, "user_id", userID)
+}
+
+// FindByHandle returns the profile with the lowercase handle.
+func (r *PostgresProfileRepository) FindByHandle(ctx context.Context, handle string) (models.Profile, error) {
+	return r.findOne(ctx, "handle", handle)
+}
+
+// findOne looks a profile up by column, which must be one of the literal names used above.
+func (r *PostgresProfileRepository) findOne(ctx context.Context, column, value string) (models.Profile, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	row := conn.QueryRow(ctx, `
+        SELECT user_id, handle, display_name, bio, avatar_key, avatar_small_url, avatar_medium_url, avatar_large_url, created_at, updated_at
+        FROM user_profiles
+        WHERE `+column+` = $1
+    `, value)
+
+	var profile models.Profile
+	if err := row.Scan(&profile.UserID, &profile.Handle, &profile.DisplayName, &profile.Bio,
+		&profile.Avatar.Key, &profile.Avatar.Small, &profile.Avatar.Medium, &profile.Avatar.Large,
+		&profile.CreatedAt, &profile.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Profile{}, ErrNotFound
+		}
+		return models.Profile{}, fmt.Errorf("select profile by %s: %w", column, err)
+	}
+
+	profile.CreatedAt = profile.CreatedAt.UTC()
+	profile.UpdatedAt = profile.UpdatedAt.UTC()
+	return profile, nil
+}
+
+// SetAvatar replaces the avatar locations of an existing profile. It returns ErrNotFound when
+// the user has no profile yet.
+func (r *PostgresProfileRepository) SetAvatar(ctx context.Context, userID string, avatar models.Avatar, at time.Time) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE user_profiles
+        SET avatar_key = $2,
+            avatar_small_url = $3,
+            avatar_medium_url = $4,
+            avatar_large_url = $5,
+            updated_at = $6
+        WHERE user_id = $1
+    `, userID, avatar.Key, avatar.Small, avatar.Medium, avatar.Large, at.UTC())
+	if err != nil {
+		return fmt.Errorf("update profile avatar: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Summaries returns the compact profiles of the users that have one, keyed by user ID.
+func (r *PostgresProfileRepository) Summaries(ctx context.Context, userIDs []string) (map[string]models.ProfileSummary, error) {
+	summaries := make(map[string]models.ProfileSummary, len(userIDs))
+	if len(userIDs) == 0 {
+		return summaries, nil
+	}
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT user_id, handle, display_name, avatar_small_url
+        FROM user_profiles
+        WHERE user_id = ANY($1::UUID[])
+    `, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query profile summaries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var summary models.ProfileSummary
+		if err := rows.Scan(&summary.UserID, &summary.Handle, &summary.DisplayName, &summary.AvatarURL); err != nil {
+			return nil, fmt.Errorf("scan profile summary: %w", err)
+		}
+		summaries[summary.UserID] = summary
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate profile summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// SearchHandles returns up to limit compact profiles whose handle starts with prefix, ordered by
+// handle.
+func (r *PostgresProfileRepository) SearchHandles(ctx context.Context, prefix string, limit int) ([]models.ProfileSummary, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Handles may contain underscores, which LIKE would otherwise treat as a wildcard.
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+
+	rows, err := conn.Query(ctx, `
+        SELECT user_id, handle, display_name, avatar_small_url
+        FROM user_profiles
+        WHERE handle LIKE $1 ESCAPE '\'
+        ORDER BY handle
+        LIMIT $2
+    `, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search profile handles: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []models.ProfileSummary
+	for rows.Next() {
+		var summary models.ProfileSummary
+		if err := rows.Scan(&summary.UserID, &summary.Handle, &summary.DisplayName, &summary.AvatarURL); err != nil {
+			return nil, fmt.Errorf("scan profile summary: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate profile handles: %w", err)
+	}
+
+	return summaries, nil
+}
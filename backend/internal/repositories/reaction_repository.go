@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/vidfriends/backend/internal/db"
+	"github.com/vidfriends/backend/internal/models"
+)
+
+// PostgresReactionRepository persists the emoji reactions users leave on video shares. It does
+// not check who may see a share; callers do that first.
+type PostgresReactionRepository struct {
+	pool db.Pool
+}
+
+// NewPostgresReactionRepository constructs a reaction repository backed by PostgreSQL.
+func NewPostgresReactionRepository(pool db.Pool) *PostgresReactionRepository {
+	return &PostgresReactionRepository{pool: pool}
+}
+
+// Toggle adds the reaction, or removes it when the user already reacted to the share with that
+// kind. It reports whether the reaction is in place afterwards, and returns ErrNotFound when the
+// share does not exist.
+func (r *PostgresReactionRepository) Toggle(ctx context.Context, reaction models.Reaction) (bool, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin reaction toggle: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        DELETE FROM share_reactions WHERE share_id = $1 AND user_id = $2 AND kind = $3
+    `, reaction.ShareID, reaction.UserID, reaction.Kind)
+	if err != nil {
+		return false, fmt.Errorf("delete reaction: %w", err)
+	}
+	added := tag.RowsAffected() == 0
+	if added {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO share_reactions (share_id, user_id, kind, created_at)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (share_id, user_id, kind) DO NOTHING
+        `, reaction.ShareID, reaction.UserID, reaction.Kind, reaction.CreatedAt.UTC()); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return false, ErrNotFound
+			}
+			return false, fmt.Errorf("insert reaction: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit reaction toggle: %w", err)
+	}
+	return added, nil
+}
+
+// Summaries returns the reaction counts of the shares, and the kinds the user reacted to each of
+// them with, in one query. Shares without reactions are left out of the map.
+func (r *PostgresReactionRepository) Summaries(ctx context.Context, userID string, shareIDs []string) (map[string]models.ReactionSummary, error) {
+	summaries := make(map[string]models.ReactionSummary)
+	if len(shareIDs) == 0 {
+		return summaries, nil
+	}
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT share_id, kind, COUNT(*), bool_or(user_id = $1)
+        FROM share_reactions
+        WHERE share_id = ANY($2::UUID[])
+        GROUP BY share_id, kind
+        ORDER BY share_id, kind
+    `, userID, shareIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query reaction summaries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shareID, kind string
+		var count int
+		var mine bool
+		if err := rows.Scan(&shareID, &kind, &count, &mine); err != nil {
+			return nil, fmt.Errorf("scan reaction summary: %w", err)
+		}
+		summary, ok := summaries[shareID]
+		if !ok {
+			summary.Counts = make(map[string]int)
+		}
+		summary.Counts[kind] = count
+		if mine {
+			summary.Mine = append(summary.Mine, kind)
+		}
+		summaries[shareID] = summary
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reaction summaries: %w", err)
+	}
+	return summaries, nil
+}
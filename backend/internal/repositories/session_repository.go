@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -29,12 +30,20 @@ func (s *PostgresSessionStore) Save(ctx context.Context, session auth.Session) e
 	}
 	defer conn.Release()
 
+	createdAt := session.CreatedAt.UTC()
+	if session.CreatedAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
 	_, err = conn.Exec(ctx, `
-        INSERT INTO sessions (refresh_token, user_id, expires_at)
-        VALUES ($1, $2, $3)
+        INSERT INTO sessions (refresh_token, session_id, user_id, expires_at, created_at, last_refreshed_at, user_agent, client_ip)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
         ON CONFLICT (refresh_token)
-        DO UPDATE SET user_id = EXCLUDED.user_id, expires_at = EXCLUDED.expires_at
-    `, session.RefreshToken, session.UserID, session.ExpiresAt.UTC())
+        DO UPDATE SET session_id = EXCLUDED.session_id, user_id = EXCLUDED.user_id, expires_at = EXCLUDED.expires_at,
+            created_at = EXCLUDED.created_at, last_refreshed_at = EXCLUDED.last_refreshed_at,
+            user_agent = EXCLUDED.user_agent, client_ip = EXCLUDED.client_ip
+    `, session.RefreshToken, session.ID, session.UserID, session.ExpiresAt.UTC(), createdAt,
+		nullableTime(session.LastRefreshedAt), session.UserAgent, session.ClientIP)
 	if err != nil {
 		return fmt.Errorf("upsert session: %w", err)
 	}
@@ -51,21 +60,19 @@ func (s *PostgresSessionStore) Find(ctx context.Context, refreshToken string) (a
 	defer conn.Release()
 
 	row := conn.QueryRow(ctx, `
-        SELECT refresh_token, user_id, expires_at
+        SELECT `+sessionColumns+`
         FROM sessions
         WHERE refresh_token = $1
     `, refreshToken)
 
-	var session auth.Session
-	var expiresAt time.Time
-	if err := row.Scan(&session.RefreshToken, &session.UserID, &expiresAt); err != nil {
+	session, err := scanSession(row)
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			return auth.Session{}, auth.ErrSessionNotFound
 		}
 		return auth.Session{}, fmt.Errorf("select session: %w", err)
 	}
 
-	session.ExpiresAt = expiresAt.UTC()
 	return session, nil
 }
 
@@ -91,3 +98,153 @@ func (s *PostgresSessionStore) Delete(ctx context.Context, refreshToken string)
 
 	return nil
 }
+
+// MarkRotated flags a refresh token as exchanged, failing with auth.ErrRefreshTokenReused when
+// another request already rotated it.
+func (s *PostgresSessionStore) MarkRotated(ctx context.Context, refreshToken string, at time.Time) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE sessions
+        SET rotated_at = $2
+        WHERE refresh_token = $1 AND rotated_at IS NULL
+    `, refreshToken, at.UTC())
+	if err != nil {
+		return fmt.Errorf("mark session rotated: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM sessions WHERE refresh_token = $1)
+    `, refreshToken).Scan(&exists); err != nil {
+		return fmt.Errorf("check rotated session: %w", err)
+	}
+
+	if exists {
+		return auth.ErrRefreshTokenReused
+	}
+	return auth.ErrSessionNotFound
+}
+
+// DeleteFamily removes every refresh token that belongs to the session.
+func (s *PostgresSessionStore) DeleteFamily(ctx context.Context, sessionID string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `
+        DELETE FROM sessions
+        WHERE session_id = $1
+    `, sessionID); err != nil {
+		return fmt.Errorf("delete session family: %w", err)
+	}
+
+	return nil
+}
+
+// ListForUser returns the current refresh token of each of the user's sessions, most recently
+// used first.
+func (s *PostgresSessionStore) ListForUser(ctx context.Context, userID string) ([]auth.Session, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `
+        SELECT `+sessionColumns+`
+        FROM sessions
+        WHERE user_id = $1 AND rotated_at IS NULL
+        ORDER BY COALESCE(last_refreshed_at, created_at) DESC
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]auth.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteForUser removes every refresh token issued to the user.
+func (s *PostgresSessionStore) DeleteForUser(ctx context.Context, userID string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `
+        DELETE FROM sessions
+        WHERE user_id = $1
+    `, userID); err != nil {
+		return fmt.Errorf("delete user sessions: %w", err)
+	}
+
+	return nil
+}
+
+const sessionColumns = `refresh_token, session_id, user_id, expires_at, rotated_at, created_at, last_refreshed_at, user_agent, client_ip`
+
+func scanSession(row pgx.Row) (auth.Session, error) {
+	var session auth.Session
+	var rotatedAt, lastRefreshedAt sql.NullTime
+	if err := row.Scan(
+		&session.RefreshToken,
+		&session.ID,
+		&session.UserID,
+		&session.ExpiresAt,
+		&rotatedAt,
+		&session.CreatedAt,
+		&lastRefreshedAt,
+		&session.UserAgent,
+		&session.ClientIP,
+	); err != nil {
+		return auth.Session{}, err
+	}
+
+	session.ExpiresAt = session.ExpiresAt.UTC()
+	session.CreatedAt = session.CreatedAt.UTC()
+	session.RotatedAt = timePtr(rotatedAt)
+	session.LastRefreshedAt = timePtr(lastRefreshedAt)
+	return session, nil
+}
+
+func timePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t := value.Time.UTC()
+	return &t
+}
+
+func nullableTime(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+	return value.UTC()
+}
+
+var _ auth.SessionStore = (*PostgresSessionStore)(nil)
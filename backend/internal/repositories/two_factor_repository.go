@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/db"
+)
+
+// PostgresTwoFactorStore persists TOTP enrollments and recovery codes to PostgreSQL.
+type PostgresTwoFactorStore struct {
+	pool db.Pool
+}
+
+// NewPostgresTwoFactorStore constructs a two-factor store backed by PostgreSQL.
+func NewPostgresTwoFactorStore(pool db.Pool) *PostgresTwoFactorStore {
+	return &PostgresTwoFactorStore{pool: pool}
+}
+
+// Find returns the user's enrollment.
+func (s *PostgresTwoFactorStore) Find(ctx context.Context, userID string) (auth.TwoFactorEnrollment, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return auth.TwoFactorEnrollment{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var enrollment auth.TwoFactorEnrollment
+	var enabledAt sql.NullTime
+	err = conn.QueryRow(ctx, `
+        SELECT user_id, secret, enabled_at, last_used_step, created_at
+        FROM user_two_factor
+        WHERE user_id = $1
+    `, userID).Scan(&enrollment.UserID, &enrollment.Secret, &enabledAt, &enrollment.LastUsedStep, &enrollment.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.TwoFactorEnrollment{}, auth.ErrTwoFactorNotEnrolled
+		}
+		return auth.TwoFactorEnrollment{}, fmt.Errorf("find two-factor enrollment: %w", err)
+	}
+
+	enrollment.EnabledAt = timePtr(enabledAt)
+	enrollment.CreatedAt = enrollment.CreatedAt.UTC()
+	return enrollment, nil
+}
+
+// SavePending stores a not yet enabled enrollment, replacing an earlier pending one.
+func (s *PostgresTwoFactorStore) SavePending(ctx context.Context, enrollment auth.TwoFactorEnrollment) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        INSERT INTO user_two_factor (user_id, secret, enabled_at, last_used_step, created_at)
+        VALUES ($1, $2, NULL, 0, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET secret = excluded.secret, last_used_step = 0, created_at = excluded.created_at
+        WHERE user_two_factor.enabled_at IS NULL
+    `, enrollment.UserID, enrollment.Secret, enrollment.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("save two-factor enrollment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTwoFactorAlreadyEnabled
+	}
+
+	return nil
+}
+
+// Enable activates the enrollment and stores the recovery code hashes in one transaction.
+func (s *PostgresTwoFactorStore) Enable(ctx context.Context, userID string, at time.Time, step int64, recoveryCodeHashes []string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE user_two_factor
+        SET enabled_at = $2, last_used_step = $3
+        WHERE user_id = $1 AND enabled_at IS NULL
+    `, userID, at.UTC(), step)
+	if err != nil {
+		return fmt.Errorf("enable two-factor: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTwoFactorNotEnrolled
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes, at); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RecordStep advances last_used_step only when the step is newer, so two requests presenting
+// the same code cannot both succeed.
+func (s *PostgresTwoFactorStore) RecordStep(ctx context.Context, userID string, step int64) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE user_two_factor
+        SET last_used_step = $2
+        WHERE user_id = $1 AND last_used_step < $2
+    `, userID, step)
+	if err != nil {
+		return fmt.Errorf("record two-factor step: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores new ones.
+func (s *PostgresTwoFactorStore) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, hashes, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode marks the recovery code as used in a single statement.
+func (s *PostgresTwoFactorStore) UseRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, `
+        UPDATE user_recovery_codes
+        SET used_at = $3
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+    `, userID, hash, at.UTC())
+	if err != nil {
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// Delete removes the user's enrollment and recovery codes.
+func (s *PostgresTwoFactorStore) Delete(ctx context.Context, userID string) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete two-factor enrollment: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, hashes []string, at time.Time) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO user_recovery_codes (user_id, code_hash, created_at)
+            VALUES ($1, $2, $3)
+        `, userID, hash, at.UTC()); err != nil {
+			return fmt.Errorf("insert recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+var _ auth.TwoFactorStore = (*PostgresTwoFactorStore)(nil)
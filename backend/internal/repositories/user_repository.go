@@ -10,5 +10,8 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user models.User) error
 	FindByEmail(ctx context.Context, email string) (models.User, error)
+	FindByID(ctx context.Context, id string) (models.User, error)
 	Update(ctx context.Context, user models.User) error
+	// Delete removes the user. Rows referencing the user are removed by ON DELETE CASCADE.
+	Delete(ctx context.Context, id string) error
 }
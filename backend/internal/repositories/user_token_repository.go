@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vidfriends/backend/internal/auth"
+	"github.com/vidfriends/backend/internal/db"
+)
+
+// PostgresUserTokenStore persists hashed single-use tokens to PostgreSQL.
+type PostgresUserTokenStore struct {
+	pool db.Pool
+}
+
+// NewPostgresUserTokenStore constructs a user token store backed by PostgreSQL.
+func NewPostgresUserTokenStore(pool db.Pool) *PostgresUserTokenStore {
+	return &PostgresUserTokenStore{pool: pool}
+}
+
+// Save stores a new token record.
+func (s *PostgresUserTokenStore) Save(ctx context.Context, token auth.UserToken) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `
+        INSERT INTO user_tokens (token_hash, user_id, purpose, payload, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, token.Hash, token.UserID, string(token.Purpose), token.Payload, token.ExpiresAt.UTC(), token.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("insert user token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marks the token as redeemed in a single statement so concurrent requests cannot both
+// succeed.
+func (s *PostgresUserTokenStore) Consume(ctx context.Context, hash string, purpose auth.TokenPurpose, at time.Time) (auth.UserToken, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return auth.UserToken{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	row := conn.QueryRow(ctx, `
+        UPDATE user_tokens
+        SET consumed_at = $3
+        WHERE token_hash = $1 AND purpose = $2 AND consumed_at IS NULL AND expires_at > $3
+        RETURNING token_hash, user_id, purpose, payload, expires_at, consumed_at, created_at
+    `, hash, string(purpose), at.UTC())
+
+	var token auth.UserToken
+	var tokenPurpose string
+	var consumedAt sql.NullTime
+	if err := row.Scan(&token.Hash, &token.UserID, &tokenPurpose, &token.Payload, &token.ExpiresAt, &consumedAt, &token.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.UserToken{}, auth.ErrUserTokenInvalid
+		}
+		return auth.UserToken{}, fmt.Errorf("consume user token: %w", err)
+	}
+
+	token.Purpose = auth.TokenPurpose(tokenPurpose)
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	token.CreatedAt = token.CreatedAt.UTC()
+	token.ConsumedAt = timePtr(consumedAt)
+	return token, nil
+}
+
+// DeleteForUser removes the user's tokens for the purpose.
+func (s *PostgresUserTokenStore) DeleteForUser(ctx context.Context, userID string, purpose auth.TokenPurpose) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `
+        DELETE FROM user_tokens
+        WHERE user_id = $1 AND purpose = $2
+    `, userID, string(purpose)); err != nil {
+		return fmt.Errorf("delete user tokens: %w", err)
+	}
+
+	return nil
+}
+
+var _ auth.UserTokenStore = (*PostgresUserTokenStore)(nil)
@@ -9,5 +9,9 @@ import (
 // VideoRepository exposes data access for shared videos.
 type VideoRepository interface {
 	Create(ctx context.Context, share models.VideoShare) error
-	ListFeed(ctx context.Context, userID string) ([]models.VideoShare, error)
+	ListFeed(ctx context.Context, userID string, filter models.FeedFilter, after *models.FeedCursor, limit int) ([]models.VideoShare, error)
+	FindVisible(ctx context.Context, userID, shareID string) (models.VideoShare, error)
+	Update(ctx context.Context, share models.VideoShare) error
+	Delete(ctx context.Context, ownerID, shareID string) error
+	ListByOwner(ctx context.Context, ownerID string) ([]models.VideoShare, error)
 }
@@ -17,6 +17,7 @@ import (
 
 // S3Storage implements videos.AssetStorage backed by an S3-compatible service.
 type S3Storage struct {
+	client   *s3.Client
 	uploader *manager.Uploader
 	bucket   string
 	baseURL  string
@@ -60,6 +61,7 @@ func NewS3Storage(ctx context.Context, cfg config.ObjectStoreConfig) (*S3Storage
 	})
 
 	return &S3Storage{
+		client:   client,
 		uploader: uploader,
 		bucket:   cfg.Bucket,
 		baseURL:  strings.TrimSuffix(cfg.PublicBaseURL, "/"),
@@ -89,3 +91,45 @@ func (s *S3Storage) Save(ctx context.Context, name string, r io.Reader) (string,
 
 	return fmt.Sprintf("%s/%s", s.baseURL, key), nil
 }
+
+// DeletePrefix removes every object whose key starts with prefix. Deleting a prefix that has no
+// objects is not an error.
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	prefix = strings.TrimLeft(prefix, "/")
+	if prefix == "" {
+		return fmt.Errorf("s3 storage: empty prefix")
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("s3 storage list %s: %w", prefix, err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := make([]s3types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, s3types.ObjectIdentifier{Key: object.Key})
+		}
+
+		out, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("s3 storage delete %s: %w", prefix, err)
+		}
+		if len(out.Errors) > 0 {
+			first := out.Errors[0]
+			return fmt.Errorf("s3 storage delete %s: %d objects failed, first %s: %s", prefix, len(out.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+	}
+
+	return nil
+}
@@ -33,15 +33,23 @@ type AssetIngestor struct {
 	updater  ShareAssetUpdater
 	logger   *slog.Logger
 
-	jobs   chan ingestJob
+	jobs   chan *ingestJob
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 	once   sync.Once
+
+	mu sync.Mutex
+	// pending holds the queued and running job of each share so it can be cancelled.
+	pending map[string]*ingestJob
 }
 
 type ingestJob struct {
-	share models.VideoShare
+	share   models.VideoShare
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running bool
+	done    chan struct{}
 }
 
 var errIngestorClosed = errors.New("asset ingestor closed")
@@ -65,9 +73,10 @@ func NewAssetIngestor(provider *YTDLPProvider, storage AssetStorage, updater Sha
 		storage:  storage,
 		updater:  updater,
 		logger:   logger,
-		jobs:     make(chan ingestJob, cfg.QueueSize),
+		jobs:     make(chan *ingestJob, cfg.QueueSize),
 		ctx:      ctx,
 		cancel:   cancel,
+		pending:  make(map[string]*ingestJob),
 	}
 
 	ing.wg.Add(cfg.Workers)
@@ -88,18 +97,49 @@ func (i *AssetIngestor) Enqueue(ctx context.Context, share models.VideoShare) er
 	default:
 	}
 
-	job := ingestJob{share: share}
+	// Jobs outlive the request and Shutdown lets running jobs finish, so only Cancel stops them.
+	jobCtx, cancel := context.WithCancel(context.Background())
+	job := &ingestJob{share: share, ctx: jobCtx, cancel: cancel, done: make(chan struct{})}
+	i.mu.Lock()
+	i.pending[share.ID] = job
+	i.mu.Unlock()
 
 	select {
 	case <-ctx.Done():
+		i.finish(job)
 		return ctx.Err()
 	case <-i.ctx.Done():
+		i.finish(job)
 		return errIngestorClosed
 	case i.jobs <- job:
 		return nil
 	}
 }
 
+// Cancel stops the queued or running ingestion of a share. When a job is running, Cancel waits
+// until it has stopped writing to storage, so the share's files can be removed afterwards.
+// Cancelling a share without a job does nothing.
+func (i *AssetIngestor) Cancel(ctx context.Context, shareID string) error {
+	i.mu.Lock()
+	job, ok := i.pending[shareID]
+	if ok {
+		delete(i.pending, shareID)
+		job.cancel()
+	}
+	running := ok && job.running
+	i.mu.Unlock()
+
+	if !running {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-job.done:
+		return nil
+	}
+}
+
 // Shutdown waits for the worker pool to drain outstanding jobs.
 func (i *AssetIngestor) Shutdown(ctx context.Context) error {
 	i.once.Do(func() {
@@ -132,22 +172,54 @@ func (i *AssetIngestor) worker() {
 			if !ok {
 				return
 			}
-			i.handleJob(job)
+			i.runJob(job)
 		}
 	}
 }
 
-func (i *AssetIngestor) handleJob(job ingestJob) {
+// runJob handles a job unless it was cancelled while queued.
+func (i *AssetIngestor) runJob(job *ingestJob) {
+	defer i.finish(job)
+
+	i.mu.Lock()
+	cancelled := job.ctx.Err() != nil
+	job.running = !cancelled
+	i.mu.Unlock()
+
+	if cancelled {
+		i.logger.Info("asset ingestion cancelled", "shareId", job.share.ID)
+		return
+	}
+	i.handleJob(job)
+}
+
+// finish forgets a job that has ended and releases anyone waiting on it in Cancel.
+func (i *AssetIngestor) finish(job *ingestJob) {
+	i.mu.Lock()
+	if i.pending[job.share.ID] == job {
+		delete(i.pending, job.share.ID)
+	}
+	i.mu.Unlock()
+	job.cancel()
+	close(job.done)
+}
+
+func (i *AssetIngestor) handleJob(job *ingestJob) {
 	if i.provider == nil || i.storage == nil || i.updater == nil {
 		i.logger.Error("asset ingestor missing dependencies", "hasProvider", i.provider != nil, "hasStorage", i.storage != nil, "hasUpdater", i.updater != nil)
 		return
 	}
 
-	fetchCtx, cancel := context.WithTimeout(context.Background(), maxDuration(2*i.provider.Timeout, 2*time.Minute))
+	fetchCtx, cancel := context.WithTimeout(job.ctx, maxDuration(2*i.provider.Timeout, 2*time.Minute))
 	defer cancel()
 
 	prefixed := &prefixedStorage{prefix: job.share.ID, base: i.storage}
 	_, assets, err := i.provider.Fetch(fetchCtx, job.share.URL, FetchOptions{DownloadVideo: true, Storage: prefixed})
+	if job.ctx.Err() != nil {
+		// The share was deleted; there is nothing left to record the outcome on.
+		i.logger.Info("asset ingestion cancelled", "shareId", job.share.ID)
+		return
+	}
 	if err != nil {
 		i.logger.Error("asset ingestion failed", "shareId", job.share.ID, "url", job.share.URL, "error", err)
 		i.recordFailure(job.share.ID)
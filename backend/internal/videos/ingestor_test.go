@@ -123,6 +123,65 @@ func TestAssetIngestorFailure(t *testing.T) {
 	}
 }
 
+func TestAssetIngestorCancel(t *testing.T) {
+	started := make(chan string, 2)
+	provider := &YTDLPProvider{Binary: "yt-dlp", Timeout: time.Second}
+	provider.Run = func(ctx context.Context, binary string, args ...string) ([]byte, error) {
+		started <- args[len(args)-1]
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	storage := &assetStorageStub{}
+	updater := &shareUpdaterStub{}
+	ingestor := NewAssetIngestor(provider, storage, updater, AssetIngestorConfig{QueueSize: 2, Workers: 1}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = ingestor.Shutdown(ctx)
+	}()
+
+	running := models.VideoShare{ID: "share-running", URL: "https://example.com/running"}
+	queued := models.VideoShare{ID: "share-queued", URL: "https://example.com/queued"}
+	for _, share := range []models.VideoShare{running, queued} {
+		if err := ingestor.Enqueue(context.Background(), share); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	select {
+	case url := <-started:
+		if url != running.URL {
+			t.Fatalf("expected %s to start first, got %s", running.URL, url)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ingestion did not start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := ingestor.Cancel(ctx, queued.ID); err != nil {
+		t.Fatalf("cancel queued: %v", err)
+	}
+	if err := ingestor.Cancel(ctx, running.ID); err != nil {
+		t.Fatalf("cancel running: %v", err)
+	}
+	if err := ingestor.Cancel(ctx, "share-unknown"); err != nil {
+		t.Fatalf("cancel unknown: %v", err)
+	}
+
+	// Give the worker a chance to pick up the cancelled queued job.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case url := <-started:
+		t.Fatalf("expected cancelled job to be skipped, but %s started", url)
+	default:
+	}
+	if len(updater.readyCalls) != 0 || len(updater.failedCalls) != 0 || len(storage.saved) != 0 {
+		t.Fatalf("expected cancelled jobs to record nothing, got ready %v failed %v", updater.readyCalls, updater.failedCalls)
+	}
+}
+
 func waitForCondition(t *testing.T, predicate func() bool, timeout time.Duration) {
 	t.Helper()
 	deadline := time.Now().Add(timeout)
@@ -1,12 +1,17 @@
 package videos
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Metadata captures the subset of video details used by VidFriends.
 type Metadata struct {
 	Title       string
 	Description string
 	Thumbnail   string
+	// Duration is the length of the video, or zero when the source does not report one.
+	Duration time.Duration
 }
 
 // Provider returns metadata for the supplied video URL.
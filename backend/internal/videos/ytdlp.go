@@ -92,9 +92,10 @@ func (p *YTDLPProvider) Lookup(ctx context.Context, url string) (Metadata, error
 	}
 
 	var payload struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Thumbnail   string `json:"thumbnail"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Thumbnail   string  `json:"thumbnail"`
+		Duration    float64 `json:"duration"`
 	}
 	if err := json.Unmarshal(out, &payload); err != nil {
 		return Metadata{}, fmt.Errorf("parse yt-dlp response: %w", err)
@@ -108,6 +109,7 @@ func (p *YTDLPProvider) Lookup(ctx context.Context, url string) (Metadata, error
 		Title:       payload.Title,
 		Description: payload.Description,
 		Thumbnail:   payload.Thumbnail,
+		Duration:    seconds(payload.Duration),
 	}, nil
 }
 
@@ -139,9 +141,10 @@ func (p *YTDLPProvider) Fetch(ctx context.Context, url string, opts FetchOptions
 	}
 
 	var payload struct {
-		Title              string `json:"title"`
-		Description        string `json:"description"`
-		Thumbnail          string `json:"thumbnail"`
+		Title              string  `json:"title"`
+		Description        string  `json:"description"`
+		Thumbnail          string  `json:"thumbnail"`
+		Duration           float64 `json:"duration"`
 		RequestedDownloads []struct {
 			Filepath string `json:"filepath"`
 			Filename string `json:"filename"`
@@ -160,6 +163,7 @@ func (p *YTDLPProvider) Fetch(ctx context.Context, url string, opts FetchOptions
 		Title:       payload.Title,
 		Description: payload.Description,
 		Thumbnail:   payload.Thumbnail,
+		Duration:    seconds(payload.Duration),
 	}
 
 	if !opts.DownloadVideo {
@@ -216,6 +220,15 @@ func (p *YTDLPProvider) Fetch(ctx context.Context, url string, opts FetchOptions
 	return metadata, assets, nil
 }
 
+// seconds converts the fractional seconds yt-dlp reports durations in. Live streams and some
+// sites report none, which yt-dlp omits or sends as zero.
+func seconds(value float64) time.Duration {
+	if value <= 0 {
+		return 0
+	}
+	return time.Duration(value * float64(time.Second)).Round(time.Millisecond)
+}
+
 func defaultCommandRunner(ctx context.Context, binary string, args ...string) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, binary, args...)
 	return cmd.Output()
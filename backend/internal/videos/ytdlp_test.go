@@ -23,14 +23,14 @@ func TestYTDLPProviderLookup(t *testing.T) {
 				t.Fatalf("unexpected arg at %d: got %q want %q", i, args[i], arg)
 			}
 		}
-		return []byte(`{"title":"Example","description":"Desc","thumbnail":"thumb.jpg"}`), nil
+		return []byte(`{"title":"Example","description":"Desc","thumbnail":"thumb.jpg","duration":212.5}`), nil
 	}
 
 	meta, err := provider.Lookup(context.Background(), "https://example.com")
 	if err != nil {
 		t.Fatalf("Lookup() error = %v", err)
 	}
-	if meta.Title != "Example" || meta.Description != "Desc" || meta.Thumbnail != "thumb.jpg" {
+	if meta.Title != "Example" || meta.Description != "Desc" || meta.Thumbnail != "thumb.jpg" || meta.Duration != 212500*time.Millisecond {
 		t.Fatalf("unexpected metadata: %+v", meta)
 	}
 }
@@ -0,0 +1,11 @@
+-- 0006_session_ids.sql
+-- Give every refresh token a stable session identifier that access tokens reference via the sid claim.
+
+BEGIN;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS session_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE INDEX IF NOT EXISTS sessions_session_id_idx ON sessions (session_id);
+
+COMMIT;
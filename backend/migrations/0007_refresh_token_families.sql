@@ -0,0 +1,9 @@
+-- 0007_refresh_token_families.sql
+-- Keep rotated refresh tokens so replaying one can revoke its whole session family.
+
+BEGIN;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+COMMIT;
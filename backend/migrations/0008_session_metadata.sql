@@ -0,0 +1,11 @@
+-- 0008_session_metadata.sql
+-- Record when a session started, when it was last refreshed and which client is using it.
+
+BEGIN;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS last_refreshed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS client_ip TEXT NOT NULL DEFAULT '';
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- 0010_email_verification.sql
+-- Track when a user proved they own their e-mail address.
+
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- 0013_login_attempts.sql
+-- Count failed logins per normalized e-mail address to throttle and lock out credential stuffing.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    email TEXT PRIMARY KEY,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+COMMIT;
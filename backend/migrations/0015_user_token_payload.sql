@@ -0,0 +1,9 @@
+-- 0015_user_token_payload.sql
+-- Let single-use tokens carry data for the flow they belong to, such as the pending address of an
+-- e-mail change.
+
+BEGIN;
+
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS payload TEXT NOT NULL DEFAULT '';
+
+COMMIT;
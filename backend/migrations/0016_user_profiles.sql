@@ -0,0 +1,19 @@
+-- 0016_user_profiles.sql
+-- Public profiles with a unique handle, display name, bio and the locations of resized avatars.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_profiles (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    handle TEXT NOT NULL UNIQUE,
+    display_name TEXT NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    avatar_key TEXT NOT NULL DEFAULT '',
+    avatar_small_url TEXT NOT NULL DEFAULT '',
+    avatar_medium_url TEXT NOT NULL DEFAULT '',
+    avatar_large_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
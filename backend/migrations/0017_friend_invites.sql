@@ -0,0 +1,21 @@
+-- 0017_friend_invites.sql
+-- Invite links and e-mail invites for people without an account. Signing up with an invite's code
+-- makes the new user a friend of the inviter. Only a SHA-256 hash of each code is stored.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS friend_invites (
+    id UUID PRIMARY KEY,
+    inviter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL DEFAULT '',
+    max_uses INT NOT NULL CHECK (max_uses > 0),
+    uses INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS friend_invites_inviter_idx ON friend_invites (inviter_id, created_at DESC);
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- 0018_friendship_lifecycle.sql
+-- Let friend requests be declined, cancelled, ended and unblocked, and record who made each change.
+
+BEGIN;
+
+ALTER TABLE friend_requests DROP CONSTRAINT IF EXISTS friend_requests_status_check;
+
+ALTER TABLE friend_requests
+    ADD CONSTRAINT friend_requests_status_valid
+        CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'removed', 'blocked'));
+
+-- actor_id is the user who made the latest change. Rows written before this migration leave it
+-- NULL; readers fall back to the requester for pending requests and the receiver otherwise.
+ALTER TABLE friend_requests
+    ADD COLUMN IF NOT EXISTS actor_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS friend_request_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID NOT NULL REFERENCES friend_requests(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL DEFAULT '',
+    to_status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS friend_request_events_request_idx ON friend_request_events (request_id, created_at);
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- 0019_friend_request_backfill.sql
+-- Fill in who last changed friend requests written before 0018 and when, and give every answered
+-- request a response time, so the friend views can index these columns directly.
+
+BEGIN;
+
+UPDATE friend_requests
+SET actor_id = COALESCE(actor_id, CASE WHEN status = 'pending' THEN requester_id ELSE receiver_id END),
+    updated_at = COALESCE(updated_at, responded_at, created_at),
+    responded_at = CASE WHEN status = 'pending' THEN responded_at ELSE COALESCE(responded_at, created_at) END
+WHERE actor_id IS NULL
+   OR updated_at IS NULL
+   OR (status <> 'pending' AND responded_at IS NULL);
+
+COMMIT;
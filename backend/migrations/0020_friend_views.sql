@@ -0,0 +1,21 @@
+-- 0020_friend_views.sql
+-- Index each friend view so paging through users with thousands of requests stays cheap. Every
+-- index matches the view's filter and its (time, id) keyset order.
+
+BEGIN;
+
+ALTER TABLE friend_requests ALTER COLUMN actor_id SET NOT NULL;
+ALTER TABLE friend_requests ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS friend_requests_accepted_requester_idx
+    ON friend_requests (requester_id, responded_at DESC, id DESC) WHERE status = 'accepted';
+CREATE INDEX IF NOT EXISTS friend_requests_accepted_receiver_idx
+    ON friend_requests (receiver_id, responded_at DESC, id DESC) WHERE status = 'accepted';
+CREATE INDEX IF NOT EXISTS friend_requests_pending_receiver_idx
+    ON friend_requests (receiver_id, created_at DESC, id DESC) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS friend_requests_pending_requester_idx
+    ON friend_requests (requester_id, created_at DESC, id DESC) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS friend_requests_blocked_actor_idx
+    ON friend_requests (actor_id, updated_at DESC, id DESC) WHERE status = 'blocked';
+
+COMMIT;
@@ -0,0 +1,43 @@
+-- 0021_friend_circles.sql
+-- Let users group friends into named circles and limit a share to some circles or users.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS circles (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS circles_owner_name_idx ON circles (owner_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS circle_members (
+    circle_id UUID NOT NULL REFERENCES circles(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (circle_id, member_id)
+);
+
+CREATE INDEX IF NOT EXISTS circle_members_member_idx ON circle_members (member_id);
+
+-- visibility is 'friends' for every accepted friend, 'circles' for members of the circles in
+-- video_share_circles and 'users' for the users in video_share_users. Audiences are checked
+-- against the current friendships whenever the feed is read.
+ALTER TABLE video_shares
+    ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'friends'
+        CHECK (visibility IN ('friends', 'circles', 'users'));
+
+CREATE TABLE IF NOT EXISTS video_share_circles (
+    share_id UUID NOT NULL REFERENCES video_shares(id) ON DELETE CASCADE,
+    circle_id UUID NOT NULL REFERENCES circles(id) ON DELETE CASCADE,
+    PRIMARY KEY (share_id, circle_id)
+);
+
+CREATE TABLE IF NOT EXISTS video_share_users (
+    share_id UUID NOT NULL REFERENCES video_shares(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (share_id, user_id)
+);
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- 0022_friend_suggestions.sql
+-- Remember the friend suggestions each user dismissed so they are not offered again.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS friend_suggestion_dismissals (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    candidate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dismissed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, candidate_id)
+);
+
+COMMIT;
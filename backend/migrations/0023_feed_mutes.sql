@@ -0,0 +1,22 @@
+-- 0023_feed_mutes.sql
+-- Let users mute friends, for good or until a given time, and hide single shares from their feed.
+
+BEGIN;
+
+-- muted_until is NULL for mutes that last until they are undone.
+CREATE TABLE IF NOT EXISTS friend_mutes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, muted_id)
+);
+
+CREATE TABLE IF NOT EXISTS hidden_shares (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    share_id UUID NOT NULL REFERENCES video_shares(id) ON DELETE CASCADE,
+    hidden_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, share_id)
+);
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- 0024_feed_keyset.sql
+-- Index shares by owner in the feed's (created_at, id) keyset order so paging through a busy feed
+-- does not sort every share of every friend.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS video_shares_owner_created_at_id_idx
+    ON video_shares (owner_id, created_at DESC, id DESC);
+
+COMMIT;
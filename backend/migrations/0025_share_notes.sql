@@ -0,0 +1,8 @@
+-- 0025_share_notes.sql
+-- Let owners caption their shares with a personal note shown alongside the video's own metadata.
+
+BEGIN;
+
+ALTER TABLE video_shares ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '';
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- 0026_share_comments.sql
+-- Let the people who can see a share talk about it in comments with one level of replies.
+
+BEGIN;
+
+-- parent_id is NULL for comments that start a thread. Deleted comments keep their row, with an
+-- empty body, so replies to them stay in place. Deleting an account turns its comments into such
+-- tombstones and leaves author_id NULL; only deleting the share removes comments.
+CREATE TABLE IF NOT EXISTS share_comments (
+    id UUID PRIMARY KEY,
+    share_id UUID NOT NULL REFERENCES video_shares(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    parent_id UUID REFERENCES share_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS share_comments_threads_idx
+    ON share_comments (share_id, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS share_comments_replies_idx
+    ON share_comments (parent_id, created_at, id);
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- 0027_comment_offsets.sql
+-- Record how long shared videos are and let comments point at a moment in them, so players can
+-- show markers on their timeline.
+
+BEGIN;
+
+-- duration_ms is 0 when the video's source does not report a length.
+ALTER TABLE video_shares ADD COLUMN IF NOT EXISTS duration_ms BIGINT NOT NULL DEFAULT 0;
+
+-- offset_ms is NULL for comments about the share as a whole.
+ALTER TABLE share_comments ADD COLUMN IF NOT EXISTS offset_ms BIGINT;
+
+COMMIT;
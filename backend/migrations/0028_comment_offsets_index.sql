@@ -0,0 +1,11 @@
+-- 0028_comment_offsets_index.sql
+-- Index the comments anchored to a moment in the video in timeline order. This runs apart from
+-- 0027 because CockroachDB cannot index a column added earlier in the same transaction.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS share_comments_offsets_idx
+    ON share_comments (share_id, offset_ms, created_at, id)
+    WHERE parent_id IS NULL AND offset_ms IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- 0029_share_reactions.sql
+-- Let the people who can see a share react to it with emoji, once per kind.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS share_reactions (
+    share_id UUID NOT NULL REFERENCES video_shares(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (share_id, user_id, kind)
+);
+
+-- The primary key serves the per-share counts of the feed; this index serves deleting a user's
+-- reactions along with their account.
+CREATE INDEX IF NOT EXISTS share_reactions_user_idx
+    ON share_reactions (user_id);
+
+COMMIT;